@@ -0,0 +1,127 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "stackplz/user/config"
+    "stackplz/user/event"
+    "stackplz/user/event_processor"
+    "stackplz/user/module"
+
+    "github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+    Use:               "replay <trace file>",
+    Short:             "解析通过 --record 保存的 trace 文件",
+    Long:              "离线解析 --record 保存的原始事件，输出相关的选项以本次命令行为准\n\t./stackplz replay tmp.trace --format json -o replay.log",
+    Args:              cobra.ExactArgs(1),
+    PersistentPreRunE: replayPreRunEFunc,
+    Run:               replayFunc,
+}
+
+func replayPreRunEFunc(command *cobra.Command, args []string) error {
+    // 回放不需要检查内核以及目标进程 只需要设置好 logger
+    dir, _ := os.Getwd()
+    NewLogger(dir + "/" + gconfig.LogFile)
+    if gconfig.Format != config.FORMAT_TEXT && gconfig.Format != config.FORMAT_JSON {
+        return errors.New(fmt.Sprintf("unsupported format %s, plz use text or json", gconfig.Format))
+    }
+    return nil
+}
+
+func replayFunc(command *cobra.Command, args []string) {
+    reader, err := module.OpenTraceReader(args[0])
+    if err != nil {
+        Logger.Fatalf("open trace file %s failed, err:%v", args[0], err)
+    }
+    defer reader.Close()
+
+    conf := reader.Conf
+    conf.SetLogger(Logger)
+    // 寄存器和 syscall 表以采集时的架构为准
+    err = config.SetArch(conf.Arch)
+    if err != nil {
+        Logger.Fatalf("%v", err)
+    }
+    // 数据布局相关的选项来自 trace 文件 输出相关的选项以本次命令行为准
+    conf.GetOff = gconfig.GetOff
+    conf.Color = gconfig.Color
+    conf.DumpHex = gconfig.DumpHex
+    conf.Format = gconfig.Format
+    conf.MergeSyscall = !gconfig.NoMerge
+    conf.Latency = gconfig.Latency
+    conf.SlowThreshold = uint64(gconfig.Slow)
+    conf.RetTimeout = uint64(gconfig.RetTimeout)
+    conf.Summary = gconfig.Summary
+    conf.Debug = gconfig.Debug
+    err = conf.SysCallConf.SetArgFilters(gconfig.Filter)
+    if err != nil {
+        Logger.Fatalf("parse filter failed, err:%v", err)
+    }
+    err = conf.SetRevRules(gconfig.RevRules)
+    if err != nil {
+        Logger.Fatalf("load rules failed, err:%v", err)
+    }
+
+    // 第一遍只加载 maps 快照 同一个进程的多个快照按时间作为不同的版本
+    event.EnableReplayMode()
+    for {
+        entry, err := reader.Next()
+        if err != nil {
+            if err != io.EOF {
+                Logger.Printf("read trace file failed, err:%v", err)
+            }
+            break
+        }
+        if entry.Kind == module.TRACE_ENTRY_MAPS {
+            event.AddMapsSnapshot(entry.Pid, entry.Ts, entry.Maps)
+        }
+    }
+
+    err = reader.Rewind()
+    if err != nil {
+        Logger.Fatalf("rewind trace file failed, err:%v", err)
+    }
+    processor := event_processor.NewEventProcessor(Logger, conf)
+    var count uint64
+    for {
+        entry, err := reader.Next()
+        if err != nil {
+            if err != io.EOF {
+                Logger.Printf("read trace file failed, err:%v", err)
+            }
+            break
+        }
+        if entry.Kind != module.TRACE_ENTRY_RECORD {
+            continue
+        }
+        var e event.IEventStruct = &event.CommonEvent{}
+        e.SetLogger(Logger)
+        e.SetConf(conf)
+        e.SetRecord(entry.Record)
+        processor.DispatchSync(e)
+        count++
+    }
+    // 等待 worker 输出完全部事件
+    err = processor.Close()
+    if err != nil {
+        Logger.Printf("%v", err)
+    }
+    Logger.Printf("replay %d records from %s", count, args[0])
+    if gconfig.Latency {
+        report := event_processor.LatencyReport()
+        if report != "" {
+            Logger.Print(report)
+        }
+    }
+    if gconfig.Summary {
+        Logger.Print(event_processor.SummaryReport())
+    }
+}
+
+func init() {
+    rootCmd.AddCommand(replayCmd)
+}
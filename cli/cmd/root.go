@@ -19,18 +19,23 @@ import (
     "stackplz/assets"
     "stackplz/user/config"
     "stackplz/user/event"
+    "stackplz/user/event_processor"
     "stackplz/user/module"
     "stackplz/user/util"
     "strconv"
     "strings"
     "sync"
     "syscall"
+    "time"
 
     "github.com/spf13/cobra"
 )
 
 var Logger *log.Logger
 
+// --spawn 之后等待新进程出现的时长
+const SPAWN_WAIT_TIMEOUT = 10 * time.Second
+
 func NewLogger(log_path string) *log.Logger {
     if Logger != nil {
         return Logger
@@ -116,6 +121,10 @@ func persistentPreRunEFunc(command *cobra.Command, args []string) error {
         mconfig.ExternalBTF = ""
     } else {
         if !util.HasEnableBTF {
+            // 内置的 btf 文件都是 arm64 的
+            if config.GetArch().Name != config.ARCH_ARM64 {
+                return errors.New(fmt.Sprintf("kernel BTF is required on %s", config.GetArch().Name))
+            }
             // 检查平台 判断是不是开发板
             mconfig.ExternalBTF = findBTFAssets()
         } else {
@@ -132,88 +141,127 @@ func persistentPreRunEFunc(command *cobra.Command, args []string) error {
         logger.Fatalf("not support for this machine, has no bpf_probe_read_user")
     }
 
-    // 第一步先释放用于获取堆栈信息的外部库
-    exec_path, err := os.Executable()
-    if err != nil {
-        return fmt.Errorf("please build as executable binary, %v", err)
-    }
-    if gconfig.Debug {
-        logger.Printf("Executable:%s", exec_path)
-    }
-    // 获取一次 后面用得到 免去重复获取
-    exec_path = path.Dir(exec_path)
-    _, err = os.Stat(exec_path + "/" + "preload_libs")
-    var has_restore bool = false
+    // 第一步先释放用于获取堆栈信息的外部库 只有使用 libunwindstack 回溯时才需要
+    // auto 要等确定了目标是不是 32 位之后才知道用哪一种
+    err = event.SetUnwinder(gconfig.Unwinder)
     if err != nil {
-        if os.IsNotExist(err) {
-            // 路径不存在就自动释放
-            err = assets.RestoreAssets(exec_path, "preload_libs")
-            if err != nil {
-                return fmt.Errorf("RestoreAssets preload_libs failed, %v", err)
-            }
-            has_restore = true
-        } else {
-            // 未知异常 比如权限问题 那么直接结束
-            return err
-        }
+        return err
     }
     if gconfig.Prepare {
-        // 认为是需要重新释放一次
-        if !has_restore {
-            err = assets.RestoreAssets(exec_path, "preload_libs")
-            if err != nil {
-                return fmt.Errorf("RestoreAssets preload_libs failed, %v", err)
-            }
+        err = restorePreloadLibs(logger, true)
+        if err != nil {
+            return err
         }
         fmt.Println("RestoreAssets preload_libs success")
         os.Exit(0)
     }
 
     // 第二步 通过包名获取uid和库路径 先通过pm命令获取安装位置
-    // 支持设置单独的pid，但是要排除程序本身的pid
-    if gconfig.Name != "" {
-        err = parseByPackage(gconfig.Name)
+    // 包名 uid pid 都可以指定多个 满足其中任意一个即追踪 但是要排除程序本身的pid
+    if gconfig.Spawn {
+        // 进程是启动之后才有的 只能按 uid 过滤
+        if len(gconfig.Names) == 0 && len(gconfig.Uids) == 0 {
+            return errors.New("--spawn needs --name or --uid")
+        }
+        if len(gconfig.Names) == 0 && gconfig.SpawnCmd == "" {
+            return errors.New("--spawn with --uid needs --spawn-cmd")
+        }
+        if gconfig.SpawnCmd != "" {
+            spawnLauncher = shellLauncher(gconfig.SpawnCmd)
+        }
+    }
+    var uids []uint32
+    var pids []uint32
+    for _, name := range gconfig.Names {
+        gconfig.Uid = config.MAGIC_UID
+        err = parseByPackage(name)
         if err != nil {
             return err
         }
         if gconfig.Uid == config.MAGIC_UID {
-            logger.Fatalf("get uid by package name:%s failed", gconfig.Name)
+            logger.Fatalf("get uid by package name:%s failed", name)
         }
-        // 如果说是系统APP 那么这里解析出来的uid是2000 应该用 PID_MODE
+        // 如果说是系统APP 那么这里解析出来的uid是2000 应该用 --pid
         if gconfig.Uid == 2000 {
             // 这里现在还有一种情况没有继续适配
             // 如果系统APP这个时候还没有运行 那么实际上没有pid...
-            mconfig.FilterMode = util.PID_MODE
             panic("watch system app by --name not supported yet, plz use --pid")
-        } else {
-            mconfig.FilterMode = util.UID_MODE
         }
-    } else if gconfig.Uid != config.MAGIC_UID {
-        err = parseByUid(gconfig.Uid)
+        uids = append(uids, gconfig.Uid)
+    }
+    for _, uid := range gconfig.Uids {
+        err = parseByUid(uint32(uid))
         if err != nil {
             return err
         }
-        mconfig.FilterMode = util.UID_MODE
-    } else if gconfig.Pid != 0 {
-        if gconfig.Tid != config.MAGIC_TID {
-            mconfig.FilterMode = util.PID_TID_MODE
-            logger.Printf("watch for pid:%d + tid:%d", gconfig.Pid, gconfig.Tid)
-        } else {
-            if gconfig.Pid == config.MAGIC_PID {
-                logger.Fatalf("please set one of them --pid/--uid/--name")
-            }
-            mconfig.FilterMode = util.PID_MODE
-            logger.Printf("watch for pid:%d", gconfig.Pid)
+        uids = append(uids, uint32(uid))
+    }
+    for _, pid := range gconfig.Pids {
+        err = parseByPid(uint32(pid))
+        if err != nil {
+            return err
         }
-        err = parseByPid(gconfig.Pid)
+        pids = append(pids, uint32(pid))
+    }
+    // 普通 Linux 或容器中没有 pm/dumpsys 按可执行文件 comm cgroup 容器 ID 直接从 /proc 中选择进程
+    selector, err := util.NewTargetSelector(gconfig.Exes, gconfig.Comms, gconfig.Cgroups, gconfig.Containers)
+    if err != nil {
+        return err
+    }
+    if len(uids)+len(pids) > config.MAX_WATCH_PROC_COUNT {
+        return fmt.Errorf("max uid + pid count is %d", config.MAX_WATCH_PROC_COUNT)
+    }
+    if !selector.IsEmpty() {
+        // 之后启动的进程由 watchTargets 定期重新扫描加入 超出上限的部分等有进程退出后再加入
+        targetWatch = newSelectorWatch(selector.Resolve, pids, config.MAX_WATCH_PROC_COUNT-len(uids)-len(pids))
+        procs, err := targetWatch.scan()
         if err != nil {
             return err
         }
+        if len(procs) == 0 {
+            return errors.New("no process matched by --exe/--comm/--cgroup/--container")
+        }
+        for _, proc := range procs {
+            err = parseByPid(proc.Pid)
+            if err != nil {
+                return err
+            }
+            logger.Printf("matched pid:%d uid:%d comm:%s exe:%s", proc.Pid, proc.Uid, proc.Comm, proc.Exe)
+            pids = append(pids, proc.Pid)
+        }
+    }
+    if gconfig.Tid != config.MAGIC_TID {
+        if len(pids) == 0 {
+            return errors.New("--tid should be used with --pid")
+        }
+        mconfig.Tids = append(mconfig.Tids, gconfig.Tid)
+    }
+    err = mconfig.SetTids(gconfig.Tids)
+    if err != nil {
+        return err
+    }
+    if len(uids) > 0 {
+        mconfig.FilterMode = util.UID_MODE
+    } else if len(pids) > 0 && len(mconfig.Tids) > 0 {
+        mconfig.FilterMode = util.PID_TID_MODE
+    } else if len(pids) > 0 {
+        mconfig.FilterMode = util.PID_MODE
     } else {
-        return errors.New("please set --uid/--name/--pid/--pid + --tid")
+        return errors.New("please set --uid/--name/--pid/--pid + --tid or --exe/--comm/--cgroup/--container")
     }
+    logger.Printf("watch for uids:%v pids:%v tids:%v", uids, pids, mconfig.Tids)
 
-    // 转换命令行的选项 并且进行检查
+    // 转换命令行的选项 并且进行检查 Uid/Pid 为第一个指定的值
+    gconfig.Uid = config.MAGIC_UID
+    if len(uids) > 0 {
+        gconfig.Uid = uids[0]
+    }
+    gconfig.Pid = config.MAGIC_PID
+    if len(pids) > 0 {
+        gconfig.Pid = pids[0]
+    }
+    mconfig.Uids = uids
+    mconfig.Pids = pids
     mconfig.Uid = gconfig.Uid
     mconfig.Pid = gconfig.Pid
     mconfig.Tid = gconfig.Tid
@@ -227,43 +275,53 @@ func persistentPreRunEFunc(command *cobra.Command, args []string) error {
         mconfig.UprobeSignal = signal
     }
     mconfig.Buffer = gconfig.Buffer
-    var brk_base uint64 = 0x0
-    if gconfig.BrkLib != "" {
-        if gconfig.Pid == config.MAGIC_PID {
-            return errors.New("plz set pid when use breakpoint")
-        }
-        lib_info, err := event.FindLibInMaps(gconfig.Pid, gconfig.BrkLib)
+    // ringbuf 的大小需要是页大小的 2 的幂次倍
+    if gconfig.RingBufSize == 0 || gconfig.RingBufSize&(gconfig.RingBufSize-1) != 0 {
+        return fmt.Errorf("ringbuf size %dM should be a power of 2", gconfig.RingBufSize)
+    }
+    mconfig.RingBufSize = gconfig.RingBufSize
+    if len(gconfig.BrkAddr) > config.MAX_BRK_COUNT {
+        return fmt.Errorf("max brk count is %d", config.MAX_BRK_COUNT)
+    }
+    for i, value := range gconfig.BrkAddr {
+        brk, err := config.ParseBrkConfig(uint32(i), value)
         if err != nil {
             return err
         }
-        brk_base = lib_info.BaseAddr
-    }
-
-    if gconfig.BrkAddr != "" && strings.HasPrefix(gconfig.BrkAddr, "0x") {
-        infos := strings.Split(gconfig.BrkAddr, ":")
-        if len(infos) > 2 {
-            return errors.New(fmt.Sprintf("parse for %s failed, format invaild", gconfig.BrkAddr))
+        // 没有单独指定库的 使用 --brk-lib 作为基址
+        if brk.Lib == "" {
+            brk.Lib = gconfig.BrkLib
         }
-        if len(infos) == 2 {
-            if infos[1] == "r" {
-                mconfig.BrkType = util.HW_BREAKPOINT_R
-            } else if infos[1] == "w" {
-                mconfig.BrkType = util.HW_BREAKPOINT_W
-            } else if infos[1] == "x" {
-                mconfig.BrkType = util.HW_BREAKPOINT_X
-            } else if infos[1] == "rw" {
-                mconfig.BrkType = util.HW_BREAKPOINT_RW
-            } else {
-                return errors.New(fmt.Sprintf("parse BrkType for %s failed", infos[1]))
+        if brk.Symbol != "" && brk.Lib == "" {
+            return fmt.Errorf("brk symbol %s should be used with library, e.g. libc.so!%s", brk.Symbol, brk.Symbol)
+        }
+        var brk_base uint64 = 0x0
+        if brk.Lib != "" {
+            if gconfig.Pid == config.MAGIC_PID {
+                return errors.New("plz set pid when use breakpoint")
+            }
+            lib_info, err := event.FindLibInMaps(gconfig.Pid, brk.Lib)
+            if err != nil {
+                return err
+            }
+            if lib_info.LibPath == "" {
+                return fmt.Errorf("can not find %s in maps of pid %d", brk.Lib, gconfig.Pid)
+            }
+            brk_base = lib_info.BaseAddr
+            err = brk.ResolveSymbol(lib_info.LibPath)
+            if err != nil {
+                return err
             }
-        } else {
-            mconfig.BrkType = util.HW_BREAKPOINT_X
         }
-        addr, err := strconv.ParseUint(strings.TrimPrefix(infos[0], "0x"), 16, 64)
+        err = brk.SetBase(brk_base)
         if err != nil {
-            return errors.New(fmt.Sprintf("parse for %s failed, err:%v", gconfig.BrkAddr, err))
+            return err
         }
-        mconfig.BrkAddr = brk_base + addr
+        mconfig.Brks = append(mconfig.Brks, brk)
+    }
+    if gconfig.Spawn && len(mconfig.Brks) != 0 {
+        // 断点需要目标进程的 pid 无法在进程启动前设置
+        return errors.New("--spawn can not be used with --brk")
     }
 
     mconfig.UnwindStack = gconfig.UnwindStack
@@ -272,11 +330,37 @@ func persistentPreRunEFunc(command *cobra.Command, args []string) error {
     }
     mconfig.StackSize = gconfig.StackSize
     mconfig.ShowRegs = gconfig.ShowRegs
+    for _, brk := range mconfig.Brks {
+        // 断点的参数从采集到的寄存器中读取 没有 --stack 时至少需要寄存器数据
+        if len(brk.Args) > 0 && !mconfig.UnwindStack {
+            mconfig.ShowRegs = true
+        }
+    }
     mconfig.GetOff = gconfig.GetOff
     mconfig.Debug = gconfig.Debug
     mconfig.Is32Bit = gconfig.Is32Bit
+    unwinder, err := event.ResolveUnwinder(mconfig.Is32Bit)
+    if err != nil && mconfig.UnwindStack {
+        return err
+    }
+    if unwinder == event.UNWINDER_LIBUNWINDSTACK && mconfig.UnwindStack {
+        err = restorePreloadLibs(logger, false)
+        if err != nil {
+            return err
+        }
+    }
+    mconfig.Arch = config.GetArch().Name
     mconfig.Color = gconfig.Color
     mconfig.DumpHex = gconfig.DumpHex
+    if gconfig.Format != config.FORMAT_TEXT && gconfig.Format != config.FORMAT_JSON {
+        return errors.New(fmt.Sprintf("unsupported format %s, plz use text or json", gconfig.Format))
+    }
+    mconfig.Format = gconfig.Format
+    mconfig.MergeSyscall = !gconfig.NoMerge
+    mconfig.Latency = gconfig.Latency
+    mconfig.SlowThreshold = uint64(gconfig.Slow)
+    mconfig.RetTimeout = uint64(gconfig.RetTimeout)
+    mconfig.Summary = gconfig.Summary
     err = mconfig.SetTidsBlacklist(gconfig.TidsBlacklist)
     if err != nil {
         return err
@@ -293,11 +377,41 @@ func persistentPreRunEFunc(command *cobra.Command, args []string) error {
     if err != nil {
         return err
     }
+    // 没有指定 --lib 时优先使用目标进程自身 maps 中的 libc 普通 Linux 和容器中的路径与 Android 不同
+    if !command.Flags().Changed("lib") && gconfig.Pid != config.MAGIC_PID {
+        libc_path, err := util.FindLibcInMaps(gconfig.Pid)
+        if err != nil {
+            if gconfig.Debug {
+                logger.Printf("use default lib %s, %v", gconfig.Library, err)
+            }
+        } else {
+            gconfig.Library = libc_path
+        }
+    }
     // 这里暂时是针对 stack 命令 后续整合 syscall 要进行区分
+    mconfig.StackUprobeConf.Lazy = gconfig.Lazy
     mconfig.StackUprobeConf.LibPath, err = util.FindLib(gconfig.Library, gconfig.LibraryDirs)
+    lib_deferred := false
     if err != nil {
-        logger.Fatal(err)
-        os.Exit(1)
+        if !gconfig.Lazy {
+            logger.Fatal(err)
+            os.Exit(1)
+        }
+        // 库可能在启动后才被释放出来 等到加载时再 hook
+        lib_deferred = true
+    }
+    // 除了 APP 的库目录 也在 --lib 所在的目录中查找
+    var library_dirs []string
+    library_dirs = append(library_dirs, gconfig.LibraryDirs...)
+    if !lib_deferred {
+        library_dirs = append(library_dirs, path.Dir(mconfig.StackUprobeConf.LibPath))
+    }
+    if gconfig.ListSymbols != "" {
+        err = listSymbols(gconfig.ListSymbols, library_dirs)
+        if err != nil {
+            return err
+        }
+        os.Exit(0)
     }
 
     // 处理 syscall 的命令
@@ -314,16 +428,33 @@ func persistentPreRunEFunc(command *cobra.Command, args []string) error {
                 return err
             }
         }
-    } else if len(gconfig.HookPoint) != 0 {
-        if len(gconfig.HookPoint) > 8 {
-            logger.Fatal("max uprobe hook point count is 8")
+        err = mconfig.SysCallConf.SetArgFilters(gconfig.Filter)
+        if err != nil {
+            return err
         }
-        err = mconfig.StackUprobeConf.ParseConfig(gconfig.HookPoint)
+        err = mconfig.SetRevRules(gconfig.RevRules)
         if err != nil {
             return err
         }
-    } else if mconfig.BrkAddr != 0 {
-        logger.Printf("set breakpoint addr:0x%x", mconfig.BrkAddr)
+        // 没有 CONFIG_BPF_KPROBE_OVERRIDE 时 kprobe 程序无法加载 errno 规则退化为只记录
+        if len(mconfig.GetRevErrnoFuncs()) > 0 && !util.HasKprobeOverride() {
+            count := mconfig.DegradeRevErrnoRules()
+            logger.Printf("kernel does not support bpf_override_return (CONFIG_BPF_KPROBE_OVERRIDE), %d errno rules will only be logged", count)
+        }
+    } else if len(gconfig.HookPoint) != 0 {
+        err = mconfig.StackUprobeConf.ParseConfig(gconfig.HookPoint, library_dirs)
+        if err != nil {
+            return err
+        }
+        for _, point := range mconfig.StackUprobeConf.Points {
+            if point.Deferred {
+                logger.Printf("%s not found, hook %s after it is loaded", point.LibPath, point.PointName)
+            }
+        }
+    } else if len(mconfig.Brks) != 0 {
+        for _, brk := range mconfig.Brks {
+            logger.Printf("set %s", brk.String())
+        }
     } else {
         logger.Fatal("hook nothing, plz set -w/--point or -s/--syscall")
     }
@@ -331,6 +462,54 @@ func persistentPreRunEFunc(command *cobra.Command, args []string) error {
     return nil
 }
 
+// 释放 libunwindstack 所需的外部库 路径不存在时自动释放 force 表示总是重新释放
+func restorePreloadLibs(logger *log.Logger, force bool) error {
+    exec_path, err := os.Executable()
+    if err != nil {
+        return fmt.Errorf("please build as executable binary, %v", err)
+    }
+    if gconfig.Debug {
+        logger.Printf("Executable:%s", exec_path)
+    }
+    exec_path = path.Dir(exec_path)
+    _, err = os.Stat(exec_path + "/" + "preload_libs")
+    if err != nil && !os.IsNotExist(err) {
+        // 未知异常 比如权限问题 那么直接结束
+        return err
+    }
+    if err == nil && !force {
+        return nil
+    }
+    err = assets.RestoreAssets(exec_path, "preload_libs")
+    if err != nil {
+        return fmt.Errorf("RestoreAssets preload_libs failed, %v", err)
+    }
+    return nil
+}
+
+// 列出库中的函数符号 可以带上 glob 或正则 用于确认 --point 会展开成哪些 hook 点
+func listSymbols(value string, library_dirs []string) error {
+    library := value
+    pattern := ""
+    if index := strings.Index(value, ":"); index > 0 {
+        library = value[:index]
+        pattern = value[index+1:]
+    }
+    lib_path, err := util.FindLib(library, library_dirs)
+    if err != nil {
+        return err
+    }
+    syms, err := util.MatchFuncSymbols(lib_path, pattern)
+    if err != nil {
+        return err
+    }
+    for _, sym := range syms {
+        fmt.Printf("0x%08x %6d %s\n", sym.Value, sym.Size, sym.Name)
+    }
+    fmt.Printf("%d symbols in %s\n", len(syms), lib_path)
+    return nil
+}
+
 func runFunc(command *cobra.Command, args []string) {
     stopper := make(chan os.Signal, 1)
     signal.Notify(stopper, os.Interrupt, syscall.SIGTERM)
@@ -340,8 +519,16 @@ func runFunc(command *cobra.Command, args []string) {
     var runModules = make(map[string]module.IModule)
     var wg sync.WaitGroup
 
+    if gconfig.Record != "" {
+        err := module.StartRecord(gconfig.Record, mconfig)
+        if err != nil {
+            Logger.Fatalf("start record to %s failed, err:%v", gconfig.Record, err)
+        }
+        Logger.Printf("record raw events to %s", gconfig.Record)
+    }
+
     var modNames []string
-    if mconfig.BrkAddr != 0 {
+    if len(mconfig.Brks) != 0 {
         modNames = []string{module.MODULE_NAME_BRK}
     } else {
         modNames = []string{module.MODULE_NAME_PERF, module.MODULE_NAME_STACK}
@@ -364,8 +551,23 @@ func runFunc(command *cobra.Command, args []string) {
         runMods++
 
     }
+    if gconfig.Latency && gconfig.ReportInterval > 0 {
+        event_processor.StartLatencyReport(Logger, gconfig.ReportInterval)
+    }
     if runMods > 0 {
         Logger.Printf("start %d modules", runMods)
+        // 过滤规则和 hook 都已经生效 此时再拉起进程 不会错过启动阶段的代码
+        if targetWatch != nil && len(mconfig.Brks) == 0 {
+            tracker := module.GetModuleByName(module.MODULE_NAME_STACK).(*module.MStack)
+            go watchTargets(ctx, targetWatch, tracker)
+        }
+        if gconfig.Spawn {
+            tracker := module.GetModuleByName(module.MODULE_NAME_STACK).(*module.MStack)
+            err := spawnTarget(gconfig.Names, mconfig.Uids, tracker)
+            if err != nil {
+                Logger.Fatalf("spawn %v failed, err:%v", gconfig.Names, err)
+            }
+        }
         <-stopper
     } else {
         Logger.Println("No runnable modules, Exit(1)")
@@ -382,6 +584,19 @@ func runFunc(command *cobra.Command, args []string) {
         }
     }
     wg.Wait()
+    err := module.StopRecord()
+    if err != nil {
+        Logger.Printf("stop record failed, err:%v", err)
+    }
+    if gconfig.Latency {
+        report := event_processor.LatencyReport()
+        if report != "" {
+            Logger.Print(report)
+        }
+    }
+    if gconfig.Summary {
+        Logger.Print(event_processor.SummaryReport())
+    }
     os.Exit(0)
 }
 
@@ -411,6 +626,11 @@ func parseByUid(uid uint32) error {
         gconfig.Is32Bit = false
         return nil
     }
+    // 普通 Linux 下没有包信息 直接按 uid 追踪
+    if !hasPackageManager() {
+        gconfig.Is32Bit = false
+        return nil
+    }
 
     lines, err := runCommand("pm", "list", "package", "--uid", strconv.FormatUint(uint64(uid), 10))
     if err != nil {
@@ -447,65 +667,36 @@ func findBTFAssets() string {
 }
 
 func parseByPid(pid uint32) error {
-
-    pid_str := strconv.FormatUint(uint64(pid), 10)
-    maps_path := "/proc/" + pid_str + "/maps"
-
-    // uid=$(ps -o user= -p 22812) && id -u $uid
-    // 先通过这样的命令获取到进程的 uid 判断是不是APP进程
-    lines, err := runCommand("sh", "-c", fmt.Sprintf("uid=$(ps -o user= -p %s ) && id -u $uid", pid_str))
+    // 直接读取 /proc/<pid>/status 获取进程的 uid 判断是不是APP进程
+    uid, err := util.ReadProcUid(pid)
     if err != nil {
         return err
     }
     if gconfig.Debug {
-        Logger.Printf("[parseByPid] get uid by pid=%d result:\n\t%s", pid, lines)
+        Logger.Printf("[parseByPid] get uid by pid=%d result:%d", pid, uid)
     }
-    value, _ := strconv.ParseUint(lines, 10, 32)
-    uid := uint32(value)
-    // 这个范围内的是常规的 APP 进程
-    if uid > 10000 && uid < 20000 {
+    // 这个范围内的是常规的 APP 进程 普通 Linux 下可能是普通用户 交给 parseByUid 判断
+    if uid > 10000 && uid < 20000 && hasPackageManager() {
         return parseByUid(uid)
     }
-    // 特殊的 uid
-    // root 0
-    // system 1000
-    // shell 2000
-    if uid == 1000 {
-        // 考虑到 system app 进程的 uid 都是 1000
-        // 那么这种尝试通过检查 maps 的 app_process 来确定架构以及库文件路径
-        lines, err = runCommand("sh", "-c", fmt.Sprintf("cat %s | grep -m1 bin/app_process", maps_path))
-        if err != nil {
-            return err
-        }
-        if gconfig.Debug {
-            Logger.Printf("[parseByPid] check app_process by pid=%d result:\n\t%s", pid, lines)
-        }
-        if strings.HasSuffix(lines, "/app_process64") {
-            gconfig.Is32Bit = false
-        } else if strings.HasSuffix(lines, "/app_process") {
-            gconfig.Is32Bit = true
-        } else {
-            return fmt.Errorf("[parseByPid] can not find detect process arch by pid=%d", pid)
-        }
+    // 其他情况包括 root system shell 以及普通 Linux 进程
+    // 通过可执行文件的位数确定是 32 还是 64 位 Android 上即 app_process/app_process64
+    is_32bit, err := util.IsProc32Bit(pid)
+    if err != nil {
+        return fmt.Errorf("[parseByPid] can not detect process arch by pid=%d, err:%v", pid, err)
     }
+    gconfig.Is32Bit = is_32bit
+    return nil
+}
 
-    // 通过检查 进程 maps 中 linker 的名字确定是 32 还是 64
-    // cat /proc/22812/maps | grep -m1 bin/linker
-    lines, err = runCommand("sh", "-c", fmt.Sprintf("cat %s | grep -m1 bin/linker", maps_path))
+// 开发板 + Docker 以及普通 Linux 上没有 Android 的包管理命令
+func hasPackageManager() bool {
+    _, err := exec.LookPath("dumpsys")
     if err != nil {
-        return err
-    }
-    if lines == "" {
-        return fmt.Errorf("[parseByPid] can not find detect process arch by pid=%d", pid)
+        return false
     }
-    if strings.HasSuffix(lines, "/linker64") {
-        gconfig.Is32Bit = false
-    } else if strings.HasSuffix(lines, "/linker") {
-        gconfig.Is32Bit = true
-    } else {
-        return fmt.Errorf("[parseByPid] can not find detect process arch by pid=%d", pid)
-    }
-    return nil
+    _, err = exec.LookPath("pm")
+    return err == nil
 }
 
 func findKallsymsSymbol(symbol string) (bool, error) {
@@ -530,6 +721,9 @@ func findKallsymsSymbol(symbol string) (bool, error) {
 
 func parseByPackage(name string) error {
     // 先设置默认值
+    if !hasPackageManager() {
+        return fmt.Errorf("can not find package %s without pm/dumpsys, plz use --exe/--comm/--cgroup/--container", name)
+    }
     gconfig.Is32Bit = true
     gconfig.Name = name
     cmd := exec.Command("dumpsys", "package", name)
@@ -547,6 +741,8 @@ func parseByPackage(name string) error {
 
     // 使用带缓冲的读取器
     outputBuf := bufio.NewReader(stdout)
+    // 可能指定了多个包名 只修正当前包的库目录
+    lib_dir_index := -1
 
     for {
         // 按行读
@@ -571,22 +767,31 @@ func parseByPackage(name string) error {
                 gconfig.Uid = uint32(value)
             case "legacyNativeLibraryDir":
                 // 考虑到后面会通过其他方式增加搜索路径 所以是数组
+                lib_dir_index = len(gconfig.LibraryDirs)
                 gconfig.LibraryDirs = append(gconfig.LibraryDirs, value)
             case "dataDir":
                 gconfig.DataDir = value
             case "primaryCpuAbi":
-                // 只支持 arm64 否则直接返回错误
-                // 不过对于syscall则是支持 32 位的 后面优化逻辑
+                // 32 位的 APP 库目录是 lib/arm syscall 会按进程自身的位数使用对应的调用号表
+                var abi_dir string
                 if value == "arm64-v8a" {
                     gconfig.Is32Bit = false
-                    if len(gconfig.LibraryDirs) != 1 {
-                        // 一般是不会进入这个分支 万一呢
-                        return fmt.Errorf("can not find legacyNativeLibraryDir, cmd:%s", strings.Join(cmd.Args, " "))
-                    }
-                    gconfig.LibraryDirs[0] = gconfig.LibraryDirs[0] + "/" + "arm64"
+                    abi_dir = "arm64"
+                } else if value == "armeabi-v7a" || value == "armeabi" {
+                    gconfig.Is32Bit = true
+                    abi_dir = "arm"
+                } else if value == "x86_64" && config.GetArch().Name == config.ARCH_X86_64 {
+                    // x86 模拟器上的 APP 32 位的暂不支持
+                    gconfig.Is32Bit = false
+                    abi_dir = "x86_64"
                 } else {
                     return fmt.Errorf("not support package=%s primaryCpuAbi=%s", name, value)
                 }
+                if lib_dir_index < 0 {
+                    // 一般是不会进入这个分支 万一呢
+                    return fmt.Errorf("can not find legacyNativeLibraryDir, cmd:%s", strings.Join(cmd.Args, " "))
+                }
+                gconfig.LibraryDirs[lib_dir_index] = gconfig.LibraryDirs[lib_dir_index] + "/" + abi_dir
             }
         }
     }
@@ -600,6 +805,275 @@ func parseByPackage(name string) error {
     return nil
 }
 
+// 登记会 fork 出目标进程的父进程 新进程从 fork 开始追踪 由 MStack 实现
+type spawnTracker interface {
+    TrackSpawnParent(pid uint32, mode uint32) error
+    UntrackSpawnParent(pid uint32) error
+}
+
+// 拉起目标进程的方式 默认通过 am 启动 APP 的入口 Activity
+// 指定 --spawn-cmd 时替换为执行该命令 便于在普通 Linux 上用桩程序代替
+var spawnLauncher func(name string, tracker spawnTracker) error = launchPackage
+
+func launchPackage(name string, tracker spawnTracker) error {
+    // 先结束已经在运行的进程 保证是重新启动
+    _, err := runCommand("am", "force-stop", name)
+    if err != nil {
+        return err
+    }
+    // 输出的最后一行为 包名/Activity
+    lines, err := runCommand("cmd", "package", "resolve-activity", "--brief", "-c", "android.intent.category.LAUNCHER", name)
+    if err != nil {
+        return err
+    }
+    parts := strings.Split(lines, "\n")
+    component := strings.TrimSpace(parts[len(parts)-1])
+    if !strings.Contains(component, "/") {
+        return fmt.Errorf("can not find launcher activity of %s, result:%s", name, lines)
+    }
+    // APP 进程由 zygote fork 而来 从 fork 开始追踪 不用等到切换 uid 之后
+    // 启用了 USAP 时进程是预先 fork 好的 这种情况仍然在切换 uid 之后开始追踪
+    zygote_pids := findZygotePids()
+    if len(zygote_pids) == 0 {
+        Logger.Printf("can not find zygote, trace %s after it switches uid", name)
+    }
+    for _, pid := range zygote_pids {
+        err = tracker.TrackSpawnParent(pid, config.SPAWN_CHILD_UID)
+        if err != nil {
+            return err
+        }
+    }
+    _, err = runCommand("am", "start", "-n", component)
+    if err != nil {
+        return err
+    }
+    Logger.Printf("start %s", component)
+    return nil
+}
+
+// 命令中的 {pkg} 替换为包名 不等待命令结束 桩程序本身可能就是要追踪的进程
+func shellLauncher(command string) func(name string, tracker spawnTracker) error {
+    return func(name string, tracker spawnTracker) error {
+        cmd := exec.Command("sh", "-c", strings.ReplaceAll(command, "{pkg}", name))
+        cmd.Stdout = os.Stdout
+        cmd.Stderr = os.Stderr
+        // 命令由本进程直接 fork 只在启动期间登记自身 命令及其子进程从 fork 开始追踪
+        self_pid := uint32(os.Getpid())
+        err := tracker.TrackSpawnParent(self_pid, config.SPAWN_CHILD_ANY)
+        if err != nil {
+            return err
+        }
+        err = cmd.Start()
+        tracker.UntrackSpawnParent(self_pid)
+        if err != nil {
+            return err
+        }
+        Logger.Printf("start %s", cmd.String())
+        go cmd.Wait()
+        return nil
+    }
+}
+
+// 通过 cmdline 找到 zygote 和 zygote64
+func findZygotePids() []uint32 {
+    var pids []uint32
+    entries, err := os.ReadDir("/proc")
+    if err != nil {
+        return pids
+    }
+    for _, entry := range entries {
+        pid, err := strconv.ParseUint(entry.Name(), 10, 32)
+        if err != nil {
+            continue
+        }
+        cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+        if err != nil {
+            continue
+        }
+        name := strings.SplitN(string(cmdline), "\x00", 2)[0]
+        if name == "zygote" || name == "zygote64" {
+            pids = append(pids, uint32(pid))
+        }
+    }
+    return pids
+}
+
+// 记录拉起期间登记的父进程 等待结束时统一取消
+type spawnParents struct {
+    sync.Mutex
+    tracker spawnTracker
+    pids    []uint32
+}
+
+func (this *spawnParents) TrackSpawnParent(pid uint32, mode uint32) error {
+    this.Lock()
+    defer this.Unlock()
+    err := this.tracker.TrackSpawnParent(pid, mode)
+    if err != nil {
+        return err
+    }
+    this.pids = append(this.pids, pid)
+    return nil
+}
+
+func (this *spawnParents) UntrackSpawnParent(pid uint32) error {
+    this.Lock()
+    defer this.Unlock()
+    for i, v := range this.pids {
+        if v == pid {
+            this.pids = append(this.pids[:i], this.pids[i+1:]...)
+            return this.tracker.UntrackSpawnParent(pid)
+        }
+    }
+    return nil
+}
+
+func (this *spawnParents) untrackAll() {
+    this.Lock()
+    defer this.Unlock()
+    for _, pid := range this.pids {
+        this.tracker.UntrackSpawnParent(pid)
+    }
+    this.pids = nil
+}
+
+// 拉起目标 期间登记的父进程 fork 出的新进程从 fork 开始追踪 不会错过切换 uid 之前的代码
+// 目标出现或者超时之后取消登记 避免追踪 zygote 之后 fork 的其他 APP
+func spawnTarget(names []string, uids []uint32, tracker spawnTracker) error {
+    old_pids := util.ListPidsByUids(uids)
+    parents := &spawnParents{tracker: tracker}
+    if len(names) == 0 {
+        // 只有 --spawn-cmd 的情况
+        names = []string{""}
+    }
+    for _, name := range names {
+        err := spawnLauncher(name, parents)
+        if err != nil {
+            parents.untrackAll()
+            return err
+        }
+    }
+    go func() {
+        defer parents.untrackAll()
+        deadline := time.Now().Add(SPAWN_WAIT_TIMEOUT)
+        spawned_uids := make(map[uint32]bool)
+        for time.Now().Before(deadline) {
+            for pid, uid := range util.ListPidsByUids(uids) {
+                if _, ok := old_pids[pid]; !ok {
+                    old_pids[pid] = uid
+                    spawned_uids[uid] = true
+                    Logger.Printf("spawned pid:%d uid:%d", pid, uid)
+                }
+            }
+            // 每个 uid 都有新进程出现了
+            if len(spawned_uids) == len(uids) {
+                parents.untrackAll()
+            }
+            time.Sleep(50 * time.Millisecond)
+        }
+    }()
+    return nil
+}
+
+const TARGET_RESCAN_INTERVAL = time.Second
+
+type pidWatcher interface {
+    WatchPid(pid uint32) error
+    UnwatchPid(pid uint32) error
+}
+
+// --exe/--comm/--cgroup/--container 匹配到的 pid 集合 与 --pid 指定的分开维护
+type selectorWatch struct {
+    resolve func() ([]*util.ProcInfo, error)
+    // --pid 指定的 不会被取消
+    fixed  map[uint32]bool
+    pids   map[uint32]bool
+    limit  int
+    capped bool
+}
+
+var targetWatch *selectorWatch
+
+func newSelectorWatch(resolve func() ([]*util.ProcInfo, error), fixed []uint32, limit int) *selectorWatch {
+    watch := &selectorWatch{resolve: resolve, limit: limit}
+    watch.fixed = make(map[uint32]bool)
+    for _, pid := range fixed {
+        watch.fixed[pid] = true
+    }
+    watch.pids = make(map[uint32]bool)
+    return watch
+}
+
+// 重新匹配 返回新加入的进程和已经不再匹配的 pid 超出上限时只提示一次
+func (this *selectorWatch) rescan() (added []*util.ProcInfo, removed []uint32, err error) {
+    procs, err := this.resolve()
+    if err != nil {
+        return nil, nil, err
+    }
+    matched := make(map[uint32]bool)
+    for _, proc := range procs {
+        matched[proc.Pid] = true
+    }
+    for pid := range this.pids {
+        if !matched[pid] {
+            delete(this.pids, pid)
+            removed = append(removed, pid)
+        }
+    }
+    capped := false
+    for _, proc := range procs {
+        if this.fixed[proc.Pid] || this.pids[proc.Pid] {
+            continue
+        }
+        if len(this.pids) >= this.limit {
+            capped = true
+            continue
+        }
+        this.pids[proc.Pid] = true
+        added = append(added, proc)
+    }
+    if capped && !this.capped {
+        Logger.Printf("matched process count exceeds %d, only the first %d are traced", config.MAX_WATCH_PROC_COUNT, this.limit)
+    }
+    this.capped = capped
+    return added, removed, nil
+}
+
+// 启动时的匹配结果
+func (this *selectorWatch) scan() ([]*util.ProcInfo, error) {
+    added, _, err := this.rescan()
+    return added, err
+}
+
+// 定期重新匹配 之后启动的进程即使不是已匹配进程的子进程也能追踪到
+func watchTargets(ctx context.Context, watch *selectorWatch, watcher pidWatcher) {
+    ticker := time.NewTicker(TARGET_RESCAN_INTERVAL)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+        added, removed, err := watch.rescan()
+        if err != nil {
+            Logger.Printf("rescan targets failed, err:%v", err)
+            continue
+        }
+        for _, pid := range removed {
+            watcher.UnwatchPid(pid)
+        }
+        for _, proc := range added {
+            err = watcher.WatchPid(proc.Pid)
+            if err != nil {
+                Logger.Printf("watch pid:%d failed, err:%v", proc.Pid, err)
+                continue
+            }
+            Logger.Printf("matched pid:%d uid:%d comm:%s exe:%s", proc.Pid, proc.Uid, proc.Comm, proc.Exe)
+        }
+    }
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -617,24 +1091,34 @@ func init() {
     // 考虑到外部库更新 每个版本首次运行前 都应该执行一次
     rootCmd.PersistentFlags().BoolVar(&gconfig.Prepare, "prepare", false, "prepare libs")
     // 过滤设定
-    rootCmd.PersistentFlags().StringVarP(&gconfig.Name, "name", "n", "", "must set uid or package name")
-    rootCmd.PersistentFlags().Uint32VarP(&gconfig.Uid, "uid", "u", config.MAGIC_UID, "must set uid or package name")
-    rootCmd.PersistentFlags().Uint32VarP(&gconfig.Pid, "pid", "p", config.MAGIC_PID, "add pid to filter")
-    rootCmd.PersistentFlags().Uint32VarP(&gconfig.Tid, "tid", "t", config.MAGIC_TID, "add tid to filter")
+    rootCmd.PersistentFlags().StringArrayVarP(&gconfig.Names, "name", "n", []string{}, "package name, can be set multiple times")
+    rootCmd.PersistentFlags().BoolVar(&gconfig.Spawn, "spawn", false, "launch the package after hooks are installed, force-stop it first if running")
+    rootCmd.PersistentFlags().StringVar(&gconfig.SpawnCmd, "spawn-cmd", "", "command used by --spawn instead of am start, {pkg} is replaced by package name")
+    rootCmd.PersistentFlags().UintSliceVarP(&gconfig.Uids, "uid", "u", []uint{}, "uid to filter, can be set multiple times")
+    rootCmd.PersistentFlags().UintSliceVarP(&gconfig.Pids, "pid", "p", []uint{}, "pid to filter, can be set multiple times")
+    rootCmd.PersistentFlags().StringArrayVar(&gconfig.Exes, "exe", []string{}, "select processes by executable path or file name, can be set multiple times")
+    rootCmd.PersistentFlags().StringArrayVar(&gconfig.Comms, "comm", []string{}, "select processes by comm regex, can be set multiple times")
+    rootCmd.PersistentFlags().StringArrayVar(&gconfig.Cgroups, "cgroup", []string{}, "select processes in cgroup path and its children, e.g. /system.slice/nginx.service")
+    rootCmd.PersistentFlags().StringArrayVar(&gconfig.Containers, "container", []string{}, "select processes by docker/containerd/podman container id or its prefix")
+    rootCmd.PersistentFlags().Uint32VarP(&gconfig.Tid, "tid", "t", config.MAGIC_TID, "add tid to filter, use with --pid")
+    rootCmd.PersistentFlags().StringVar(&gconfig.Tids, "tids", "", "tid white list, max 256, e.g. 1234,1235")
     rootCmd.PersistentFlags().StringVar(&gconfig.TidsBlacklist, "no-tids", "", "tid black list, max 20")
     rootCmd.PersistentFlags().StringVar(&gconfig.PidsBlacklist, "no-pids", "", "pid black list, max 20")
     rootCmd.PersistentFlags().StringVar(&gconfig.TNamesWhitelist, "tnames", "", "thread name white list, max 20")
     rootCmd.PersistentFlags().StringVar(&gconfig.TNamesBlacklist, "no-tnames", "", "thread name black list, max 20")
     rootCmd.PersistentFlags().BoolVar(&gconfig.TraceIsolated, "iso", false, "watch isolated process")
-    rootCmd.PersistentFlags().BoolVar(&gconfig.HideRoot, "hide-root", false, "hide some root feature")
+    rootCmd.PersistentFlags().BoolVar(&gconfig.HideRoot, "hide-root", false, "hide some root feature with built-in rules")
+    rootCmd.PersistentFlags().StringVar(&gconfig.RevRules, "rules", "", "rules file for hiding root features, match path prefix/glob or arg string, action errno/rewrite/log")
     rootCmd.PersistentFlags().StringVar(&gconfig.UprobeSignal, "kill", "", "send signal when hit uprobe hook, e.g. SIGSTOP/SIGABRT/SIGTRAP/...")
     // 硬件断点设定
-    rootCmd.PersistentFlags().StringVarP(&gconfig.BrkAddr, "brk", "", "", "set hardware breakpoint address")
-    rootCmd.PersistentFlags().StringVarP(&gconfig.BrkLib, "brk-lib", "", "", "as library base address")
+    rootCmd.PersistentFlags().StringArrayVar(&gconfig.BrkAddr, "brk", []string{}, "set hardware breakpoint/watchpoint, can be set multiple times, e.g. 0x70ddfd63f0:x libnative-lib.so!0xf3a4 0x7fc0001000:w:8")
+    rootCmd.PersistentFlags().StringVarP(&gconfig.BrkLib, "brk-lib", "", "", "as library base address of --brk without its own library")
     // 缓冲区大小设定 单位M
-    rootCmd.PersistentFlags().Uint32VarP(&gconfig.Buffer, "buffer", "b", 8, "perf cache buffer size, default 8M")
+    rootCmd.PersistentFlags().Uint32VarP(&gconfig.Buffer, "buffer", "b", 8, "perf cache buffer size of each cpu, default 8M, used with --stack/--regs")
+    rootCmd.PersistentFlags().Uint32Var(&gconfig.RingBufSize, "ringbuf-size", 32, "ringbuf size shared by all cpus, default 32M, power of 2, used without --stack/--regs")
     // 堆栈输出设定
     rootCmd.PersistentFlags().BoolVar(&gconfig.UnwindStack, "stack", false, "enable unwindstack")
+    rootCmd.PersistentFlags().StringVar(&gconfig.Unwinder, "unwinder", event.UNWINDER_AUTO, "stack unwinder, auto (go for 64-bit, libunwindstack for 32-bit), go (eh_frame/debug_frame CFI with fp fallback) or libunwindstack (needs cgo and preload_libs)")
     rootCmd.PersistentFlags().Uint32VarP(&gconfig.StackSize, "stack-size", "", 8192, "stack dump size, default 8192 bytes, max 65528 bytes")
     rootCmd.PersistentFlags().BoolVar(&gconfig.ShowRegs, "regs", false, "show regs")
     rootCmd.PersistentFlags().BoolVar(&gconfig.GetOff, "getoff", false, "try get pc and lr offset")
@@ -643,14 +1127,25 @@ func init() {
     rootCmd.PersistentFlags().BoolVarP(&gconfig.Quiet, "quiet", "q", false, "wont logging to terminal when used")
     rootCmd.PersistentFlags().BoolVarP(&gconfig.Color, "color", "c", false, "enable color for log file")
     rootCmd.PersistentFlags().StringVarP(&gconfig.LogFile, "out", "o", "stackplz_tmp.log", "save the log to file")
+    rootCmd.Flags().StringVar(&gconfig.Record, "record", "", "save raw events to trace file, use replay command to parse it later")
     // 常规ELF库hook设定
-    rootCmd.PersistentFlags().StringVarP(&gconfig.Library, "lib", "l", "/apex/com.android.runtime/lib64/bionic/libc.so", "full lib path")
-    rootCmd.PersistentFlags().StringArrayVarP(&gconfig.HookPoint, "point", "w", []string{}, "hook point config, e.g. strstr+0x0[str,str] write[int,buf:128,int]")
+    rootCmd.PersistentFlags().StringVarP(&gconfig.Library, "lib", "l", "/apex/com.android.runtime/lib64/bionic/libc.so", "full lib path, default to libc in maps of target process when --pid/--exe/... is set")
+    rootCmd.PersistentFlags().BoolVar(&gconfig.Lazy, "lazy", false, "hook the library after it is loaded if it does not exist at start, e.g. unpacked or dlopen-ed later")
+    rootCmd.PersistentFlags().StringArrayVarP(&gconfig.HookPoint, "point", "w", []string{}, fmt.Sprintf("hook point config, e.g. strstr+0x0[str,str] write[int,buf:128,int], at most %d points after expanding symbol patterns", config.MAX_UPROBE_POINT_COUNT))
+    rootCmd.PersistentFlags().StringVar(&gconfig.ListSymbols, "list-symbols", "", "list func symbols of lib and exit, e.g. libnative-lib.so or 'libnative-lib.so:Java_*'")
     rootCmd.PersistentFlags().StringVar(&gconfig.RegName, "reg", "", "get the offset of reg")
     rootCmd.PersistentFlags().BoolVarP(&gconfig.DumpHex, "dumphex", "", false, "dump buffer as hex")
+    rootCmd.PersistentFlags().StringVar(&gconfig.Format, "format", config.FORMAT_TEXT, "output format of events, text or json")
+    rootCmd.PersistentFlags().BoolVar(&gconfig.Latency, "latency", false, "collect syscall latency and print count/min/avg/p50/p99/max and histogram on exit")
+    rootCmd.PersistentFlags().DurationVar(&gconfig.Slow, "slow", 0, "mark syscalls slower than the threshold, e.g. 10ms")
+    rootCmd.PersistentFlags().DurationVar(&gconfig.ReportInterval, "report-interval", 0, "print latency report at interval, e.g. 10s")
+    rootCmd.PersistentFlags().DurationVar(&gconfig.RetTimeout, "ret-timeout", 5*time.Second, "max time to wait for the return of a :ret hook point before printing it unfinished")
+    rootCmd.PersistentFlags().BoolVar(&gconfig.Summary, "summary", false, "only count syscalls, errors, threads and pids, print the summary on exit")
+    rootCmd.PersistentFlags().BoolVar(&gconfig.NoMerge, "no-merge", false, "output sys_enter and sys_exit separately instead of merging them into one line")
     rootCmd.PersistentFlags().BoolVarP(&gconfig.NoCheck, "nocheck", "", false, "disable check for bpf")
     rootCmd.PersistentFlags().BoolVarP(&gconfig.Btf, "btf", "", false, "declare BTF enabled")
     // syscall hook
     rootCmd.PersistentFlags().StringVarP(&gconfig.SysCall, "syscall", "s", "", "filter syscalls")
     rootCmd.PersistentFlags().StringVar(&gconfig.SysCallBlacklist, "no-syscall", "", "syscall black list, max 20")
+    rootCmd.PersistentFlags().StringArrayVar(&gconfig.Filter, "filter", []string{}, "filter syscalls by args or return value, e.g. openat.pathname~\"/proc/*/maps\" ret<0 connect.addr.port==443")
 }
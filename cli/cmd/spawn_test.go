@@ -0,0 +1,128 @@
+package cmd
+
+import (
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "log"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "stackplz/user/config"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+type fakeTracker struct {
+    sync.Mutex
+    calls []string
+}
+
+func (this *fakeTracker) TrackSpawnParent(pid uint32, mode uint32) error {
+    this.Lock()
+    defer this.Unlock()
+    this.calls = append(this.calls, fmt.Sprintf("track %d %d", pid, mode))
+    return nil
+}
+
+func (this *fakeTracker) UntrackSpawnParent(pid uint32) error {
+    this.Lock()
+    defer this.Unlock()
+    this.calls = append(this.calls, fmt.Sprintf("untrack %d", pid))
+    return nil
+}
+
+func (this *fakeTracker) Calls() string {
+    this.Lock()
+    defer this.Unlock()
+    return strings.Join(this.calls, ", ")
+}
+
+// spawnTarget 等待新进程的 goroutine 在测试结束之后仍可能输出日志 所以不恢复 Logger
+func setupSpawnTest(t *testing.T) {
+    Logger = log.New(ioutil.Discard, "", 0)
+    old_launcher := spawnLauncher
+    t.Cleanup(func() {
+        spawnLauncher = old_launcher
+    })
+}
+
+func TestShellLauncher(t *testing.T) {
+    setupSpawnTest(t)
+    out := filepath.Join(t.TempDir(), "out")
+    tracker := &fakeTracker{}
+    launch := shellLauncher("echo {pkg} > " + out)
+    if err := launch("com.example.app", tracker); err != nil {
+        t.Fatal(err)
+    }
+    // 只在 fork 期间登记自身
+    self_pid := os.Getpid()
+    want := fmt.Sprintf("track %d %d, untrack %d", self_pid, config.SPAWN_CHILD_ANY, self_pid)
+    if got := tracker.Calls(); got != want {
+        t.Fatalf("calls = %s, want %s", got, want)
+    }
+    deadline := time.Now().Add(5 * time.Second)
+    for time.Now().Before(deadline) {
+        content, _ := ioutil.ReadFile(out)
+        if strings.TrimSpace(string(content)) == "com.example.app" {
+            return
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    t.Fatal("stub command did not run with the package name")
+}
+
+// 桩程序代替 am start 登记一个假的 zygote 然后拉起当前 uid 的新进程
+func TestSpawnTarget(t *testing.T) {
+    setupSpawnTest(t)
+    const zygote_pid = 1234
+    var stub *exec.Cmd
+    spawnLauncher = func(name string, tracker spawnTracker) error {
+        if name != "com.example.app" {
+            return fmt.Errorf("unexpected name %s", name)
+        }
+        if err := tracker.TrackSpawnParent(zygote_pid, config.SPAWN_CHILD_UID); err != nil {
+            return err
+        }
+        stub = exec.Command("sleep", "5")
+        return stub.Start()
+    }
+    tracker := &fakeTracker{}
+    err := spawnTarget([]string{"com.example.app"}, []uint32{uint32(os.Getuid())}, tracker)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer func() {
+        stub.Process.Kill()
+        stub.Wait()
+    }()
+    // 新进程出现之后取消登记
+    want := fmt.Sprintf("track %d %d, untrack %d", zygote_pid, config.SPAWN_CHILD_UID, zygote_pid)
+    deadline := time.Now().Add(5 * time.Second)
+    for time.Now().Before(deadline) {
+        if tracker.Calls() == want {
+            return
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    t.Fatalf("calls = %s, want %s", tracker.Calls(), want)
+}
+
+func TestSpawnTargetLaunchFailed(t *testing.T) {
+    setupSpawnTest(t)
+    spawnLauncher = func(name string, tracker spawnTracker) error {
+        tracker.TrackSpawnParent(1, config.SPAWN_CHILD_UID)
+        tracker.TrackSpawnParent(2, config.SPAWN_CHILD_UID)
+        return errors.New("launch failed")
+    }
+    tracker := &fakeTracker{}
+    if err := spawnTarget(nil, []uint32{uint32(os.Getuid())}, tracker); err == nil {
+        t.Fatal("spawnTarget should return the launcher error")
+    }
+    want := fmt.Sprintf("track 1 %d, track 2 %d, untrack 1, untrack 2", config.SPAWN_CHILD_UID, config.SPAWN_CHILD_UID)
+    if got := tracker.Calls(); got != want {
+        t.Fatalf("calls = %s, want %s", got, want)
+    }
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+    "io/ioutil"
+    "log"
+    "reflect"
+    "sort"
+    "stackplz/user/util"
+    "testing"
+)
+
+type fakeProcs struct {
+    pids []uint32
+}
+
+func (this *fakeProcs) resolve() ([]*util.ProcInfo, error) {
+    var procs []*util.ProcInfo
+    for _, pid := range this.pids {
+        procs = append(procs, &util.ProcInfo{Pid: pid})
+    }
+    return procs, nil
+}
+
+func procPids(procs []*util.ProcInfo) []uint32 {
+    var pids []uint32
+    for _, proc := range procs {
+        pids = append(pids, proc.Pid)
+    }
+    return pids
+}
+
+func sortedPids(pids []uint32) []uint32 {
+    sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+    return pids
+}
+
+func TestSelectorWatchRescan(t *testing.T) {
+    Logger = log.New(ioutil.Discard, "", 0)
+    procs := &fakeProcs{pids: []uint32{100, 200, 300, 400}}
+    // 200 由 --pid 指定 最多再追踪 2 个
+    watch := newSelectorWatch(procs.resolve, []uint32{200}, 2)
+    added, err := watch.scan()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := procPids(added); !reflect.DeepEqual(got, []uint32{100, 300}) {
+        t.Fatalf("initial = %v", got)
+    }
+    if !watch.capped {
+        t.Fatal("400 should be over the limit")
+    }
+
+    // 之后启动的进程在有空位时加入 退出的进程被移除 --pid 指定的不会移除
+    procs.pids = []uint32{300, 400, 500}
+    added, removed, err := watch.rescan()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := procPids(added); !reflect.DeepEqual(got, []uint32{400}) {
+        t.Fatalf("added = %v", got)
+    }
+    if !reflect.DeepEqual(removed, []uint32{100}) {
+        t.Fatalf("removed = %v", removed)
+    }
+    if !watch.capped {
+        t.Fatal("500 should be over the limit")
+    }
+
+    procs.pids = []uint32{500, 600}
+    added, removed, err = watch.rescan()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := procPids(added); !reflect.DeepEqual(got, []uint32{500, 600}) {
+        t.Fatalf("added = %v", got)
+    }
+    if got := sortedPids(removed); !reflect.DeepEqual(got, []uint32{300, 400}) {
+        t.Fatalf("removed = %v", got)
+    }
+    if watch.capped {
+        t.Fatal("all matched processes fit in the limit")
+    }
+}
@@ -1,9 +1,11 @@
 #ifndef __STACKPLZ_ARCH_H__
 #define __STACKPLZ_ARCH_H__
 
+#include "vmlinux.h"
 #include "bpf_helpers.h"
 #include "bpf_tracing.h"
 #include "common/common.h"
+#include "types.h"
 
 #if defined(__TARGET_ARCH_x86)
     #define PT_REGS_PARM6(ctx) ((ctx)->r9)
@@ -11,4 +13,159 @@
     #define PT_REGS_PARM6(x) ((x)->regs[5])
 #endif
 
-#endif
\ No newline at end of file
+// 寄存器相关的读取都集中在这里 寄存器编号与用户态的 REG_* 一致
+// READ_KERN 读 pt_regs 好像有问题 统一用 bpf_probe_read_kernel
+#define READ_REG(ptr)                                                                          \
+    ({                                                                                         \
+        u64 _val = 0;                                                                          \
+        bpf_probe_read_kernel((void *) &_val, sizeof(_val), &ptr);                             \
+        _val;                                                                                  \
+    })
+
+#if defined(__TARGET_ARCH_x86)
+
+// uprobe 和 syscall 拿到的都是 pt_regs
+typedef struct pt_regs arch_regs_t;
+
+// 通过寄存器传递的参数个数
+#define ARG_REG_COUNT 6
+#define REG_INDEX_MAX REG_X86_64_MAX
+// 作为长度的寄存器 编号需要小于这个值
+#define COUNT_INDEX_MAX REG_X86_64_MAX
+
+static __always_inline u64 read_reg(arch_regs_t* regs, u32 index) {
+    switch (index) {
+        case REG_X86_64_AX: return READ_REG(regs->ax);
+        case REG_X86_64_BX: return READ_REG(regs->bx);
+        case REG_X86_64_CX: return READ_REG(regs->cx);
+        case REG_X86_64_DX: return READ_REG(regs->dx);
+        case REG_X86_64_SI: return READ_REG(regs->si);
+        case REG_X86_64_DI: return READ_REG(regs->di);
+        case REG_X86_64_BP: return READ_REG(regs->bp);
+        case REG_X86_64_SP: return READ_REG(regs->sp);
+        case REG_X86_64_IP: return READ_REG(regs->ip);
+        case REG_X86_64_FLAGS: return READ_REG(regs->flags);
+        case REG_X86_64_CS: return READ_REG(regs->cs);
+        case REG_X86_64_SS: return READ_REG(regs->ss);
+        case REG_X86_64_R8: return READ_REG(regs->r8);
+        case REG_X86_64_R9: return READ_REG(regs->r9);
+        case REG_X86_64_R10: return READ_REG(regs->r10);
+        case REG_X86_64_R11: return READ_REG(regs->r11);
+        case REG_X86_64_R12: return READ_REG(regs->r12);
+        case REG_X86_64_R13: return READ_REG(regs->r13);
+        case REG_X86_64_R14: return READ_REG(regs->r14);
+        case REG_X86_64_R15: return READ_REG(regs->r15);
+    }
+    return 0;
+}
+
+// System V 调用约定 rdi rsi rdx rcx r8 r9
+static __always_inline u64 read_func_arg(arch_regs_t* regs, u32 index) {
+    switch (index) {
+        case 0: return READ_REG(regs->di);
+        case 1: return READ_REG(regs->si);
+        case 2: return READ_REG(regs->dx);
+        case 3: return READ_REG(regs->cx);
+        case 4: return READ_REG(regs->r8);
+        case 5: return READ_REG(regs->r9);
+    }
+    return 0;
+}
+
+// syscall 的第四个参数在 r10 中 rcx 被 syscall 指令占用了
+static __always_inline u64 read_syscall_arg(arch_regs_t* regs, u32 index) {
+    if (index == 3) {
+        return READ_REG(regs->r10);
+    }
+    return read_func_arg(regs, index);
+}
+
+static __always_inline u64 read_syscall_nr(struct pt_regs* regs) {
+    return READ_REG(regs->orig_ax);
+}
+
+static __always_inline u64 read_ret(arch_regs_t* regs) {
+    return READ_REG(regs->ax);
+}
+
+static __always_inline u64 read_pc(arch_regs_t* regs) {
+    return READ_REG(regs->ip);
+}
+
+static __always_inline u64 read_sp(arch_regs_t* regs, bool is_compat) {
+    return READ_REG(regs->sp);
+}
+
+// 没有 lr 取栈顶的返回地址 在函数入口处是准确的
+// syscall 时取决于封装函数有没有用到栈 仅供参考
+static __always_inline u64 read_lr(arch_regs_t* regs, bool is_compat) {
+    u64 sp = READ_REG(regs->sp);
+    u64 lr = 0;
+    bpf_probe_read_user(&lr, sizeof(lr), (void *) sp);
+    return lr;
+}
+
+#else
+
+// arm64 的 pt_regs 开头就是 user_pt_regs
+typedef struct user_pt_regs arch_regs_t;
+
+// 除了 sp pc 之外的寄存器都可以按顺序作为参数读取
+#define ARG_REG_COUNT (REG_ARM64_LR + 1)
+#define REG_INDEX_MAX REG_ARM64_MAX
+// 以寄存器值作为长度 只包含 x0-x28 x29 是 fp 寄存器 所以不包含在内
+#define COUNT_INDEX_MAX REG_ARM64_X29
+
+static __always_inline u64 read_reg(arch_regs_t* regs, u32 index) {
+    if (index <= REG_ARM64_LR) {
+        return READ_REG(regs->regs[index]);
+    } else if (index == REG_ARM64_SP) {
+        return READ_REG(regs->sp);
+    } else if (index == REG_ARM64_PC) {
+        return READ_REG(regs->pc);
+    }
+    return 0;
+}
+
+static __always_inline u64 read_func_arg(arch_regs_t* regs, u32 index) {
+    if (index < ARG_REG_COUNT) {
+        return READ_REG(regs->regs[index]);
+    }
+    return 0;
+}
+
+static __always_inline u64 read_syscall_arg(arch_regs_t* regs, u32 index) {
+    return read_func_arg(regs, index);
+}
+
+// syscallno 是 s32
+static __always_inline u64 read_syscall_nr(struct pt_regs* regs) {
+    return READ_KERN(regs->syscallno);
+}
+
+static __always_inline u64 read_ret(arch_regs_t* regs) {
+    return READ_REG(regs->regs[0]);
+}
+
+static __always_inline u64 read_pc(arch_regs_t* regs) {
+    return READ_REG(regs->pc);
+}
+
+// 32 位进程的 lr sp 分别是 r14 r13
+static __always_inline u64 read_sp(arch_regs_t* regs, bool is_compat) {
+    if (is_compat) {
+        return READ_REG(regs->regs[13]);
+    }
+    return READ_REG(regs->sp);
+}
+
+static __always_inline u64 read_lr(arch_regs_t* regs, bool is_compat) {
+    if (is_compat) {
+        return READ_REG(regs->regs[14]);
+    }
+    return READ_REG(regs->regs[30]);
+}
+
+#endif
+
+#endif
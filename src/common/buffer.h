@@ -83,7 +83,7 @@ static __always_inline int save_bytes_to_buf(event_data_t *event, void *ptr, u32
 #define MAX_STR_ARR_ELEM      128
 #define __user
 
-static __always_inline int save_str_arr_to_buf(event_data_t *event, const char __user *const __user *ptr, u8 index) {
+static __always_inline int save_str_arr_to_buf(event_data_t *event, const char __user *const __user *ptr, u8 index, bool is_compat) {
     // Data saved to submit buf: [index][string count][str1 size][str1][str2 size][str2]...
 
     u8 elem_num = 0;
@@ -101,7 +101,14 @@ static __always_inline int save_str_arr_to_buf(event_data_t *event, const char _
 #pragma unroll
     for (int i = 0; i < MAX_STR_ARR_ELEM; i++) {
         const char *argp = NULL;
-        bpf_probe_read_user(&argp, sizeof(argp), &ptr[i]);
+        if (is_compat) {
+            // 32 位进程的指针数组 元素只有 4 字节
+            u32 argp32 = 0;
+            bpf_probe_read_user(&argp32, sizeof(argp32), (void *)ptr + i * sizeof(u32));
+            argp = (const char *)(u64)argp32;
+        } else {
+            bpf_probe_read_user(&argp, sizeof(argp), &ptr[i]);
+        }
         if (!argp)
             goto out;
 
@@ -198,5 +205,17 @@ static __always_inline int events_perf_submit(program_data_t *p, u32 id)
                  :
                  : [size] "r"(size), [max_size] "i"(MAX_EVENT_SIZE));
 
+    // 堆栈和寄存器只能由内核在 perf 采样时附带 不需要时通过 ringbuf 提交 所有 CPU 共用一个缓冲区
+    if (p->config->use_ringbuf) {
+        long ret = bpf_ringbuf_output(&ring_events, p->event, size, 0);
+        if (ret < 0) {
+            u32 zero = 0;
+            u64 *lost = bpf_map_lookup_elem(&ringbuf_lost, &zero);
+            if (lost != NULL) {
+                *lost += 1;
+            }
+        }
+        return ret;
+    }
     return bpf_perf_event_output(p->ctx, &events, BPF_F_CURRENT_CPU, p->event, size);
 }
\ No newline at end of file
@@ -4,7 +4,7 @@
 #include "bpf_helpers.h"
 #include "maps.h"
 
-#include <vmlinux_510.h>
+#include "vmlinux.h"
 
 // helper macros for branch prediction
 #ifndef likely
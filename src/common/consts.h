@@ -9,7 +9,13 @@
 #define TASK_COMM_LEN 16
 #define MAX_COUNT 20
 #define MAX_WATCH_PROC_COUNT 256
+#define MAX_UPROBE_POINT_COUNT 512 // hook 点数量上限 即 uprobe_point_args_map 的大小
 #define MAX_PATH_COMPONENTS   48
+// 32 位进程的 syscall 调用号加上这个标志 和 64 位的调用号区分开
+#define COMPAT_NR_FLAG 0x80000000
+// --spawn 期间 spawn_parent_map 中的进程 fork 出新进程时的处理方式
+#define SPAWN_CHILD_ANY 1   // 子进程直接追踪
+#define SPAWN_CHILD_UID 2   // zygote 的子进程 切换到要追踪的 uid 之前同样追踪 切换到其他 uid 就不再追踪
 
 // clang-format off
 #define MAX_PERCPU_BUFSIZE (1 << 15)  // set by the kernel as an upper bound
@@ -1,7 +1,7 @@
 #ifndef __EVENT_INIT_H__
 #define __EVENT_INIT_H__
 
-#include "vmlinux_510.h"
+#include "vmlinux.h"
 
 #include "bpf_helpers.h"
 #include "common/common.h"
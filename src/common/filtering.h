@@ -1,10 +1,31 @@
 #ifndef __FILTERING_H__
 #define __FILTERING_H__
 
-#include "vmlinux_510.h"
+#include "vmlinux.h"
 #include "maps.h"
 #include "types.h"
 
+// --spawn 拉起目标时 在 fork 时就把新进程加入追踪范围 不用等到它切换 uid
+static __always_inline void track_spawn_child(struct task_struct *parent, struct task_struct *child)
+{
+    // 只关心新进程 线程创建同样会走到 sched_process_fork
+    u32 child_ns_tgid = get_task_ns_tgid(child);
+    if (get_task_ns_pid(child) != child_ns_tgid) {
+        return;
+    }
+    u32 parent_ns_tgid = get_task_ns_tgid(parent);
+    u32* mode = bpf_map_lookup_elem(&spawn_parent_map, &parent_ns_tgid);
+    if (mode == NULL) {
+        return;
+    }
+    if (*mode == SPAWN_CHILD_ANY) {
+        bpf_map_update_elem(&child_parent_map, &child_ns_tgid, &child_ns_tgid, BPF_ANY);
+    } else if (*mode == SPAWN_CHILD_UID) {
+        u32 uid = bpf_get_current_uid_gid() & 0xffffffff;
+        bpf_map_update_elem(&spawn_child_map, &child_ns_tgid, &uid, BPF_ANY);
+    }
+}
+
 static __always_inline u64 should_trace(program_data_t *p)
 {
 
@@ -37,22 +58,10 @@ static __always_inline u64 should_trace(program_data_t *p)
     // 4. 在ebpf程序中维护有数量上限的 uid/pid/tid 列表 即它们不被限制为单个
     // 5. tid 不具有唯一性 必须搭配 pid
 
-    // 仔细想了下上面的逻辑要同时实现不太可能 还是按这样来？
-    // 1. 只追踪uid所属进程；同时支持
-    //     1. pid黑名单列表
-    //     2. tid黑名单列表（其实不同pid下存在相同tid的概率很小，加上这里是黑名单，所以黑名单同时生效，不需要组合pid+tid）
-    //     3. 线程名黑名单（这是为了忽略掉某些实际上不关心且过于活跃的线程）
-    // 2. 只追踪指定pid进程，以及产生的子进程（可不选）；同时支持
-    //     1. tid黑名单列表
-    //     2. 线程名黑名单
-    // 3. 只追踪pid+tid限定的线程
-    
-    // 额外的支持
-    // 1. 可指定一个或者多个pid
-    // 2. 可指定一个pid+多个tid
-
-    // 上面的情况，都应该只能以其中一种模式进行过滤
-    // 考虑到指明了过滤模式 那么就不需要使用 MAGIC_PID 去判断了 因为对应的模式下对应参数必须有值
+    // 现在的做法
+    // 1. uid pid 集合通过 hash map 维护 命中其中任意一个即追踪 pid 的子进程也追踪
+    // 2. 再依次判断 tid 白名单 pid/tid 黑名单 线程名黑白名单
+    // 3. filter_mode 只用于区分是否进行了设置
 
     u32 filter_key = 0;
     common_filter_t* filter = bpf_map_lookup_elem(&common_filter, &filter_key);
@@ -63,79 +72,52 @@ static __always_inline u64 should_trace(program_data_t *p)
         return 1;
     }
 
-    if (config->filter_mode == UID_MODE) {
-        if (filter->uid == context->uid) {
-            for (int i = 0; i < MAX_COUNT; i++) {
-                // 因为列表肯定是挨着填充的 所以遇到 MAGIC 就可以直接结束循环了
-                if (filter->blacklist_pids[i] == MAGIC_PID) break;
-                if (filter->blacklist_pids[i] == context->pid) {
-                    return 0;
-                };
-            }
-            for (int i = 0; i < MAX_COUNT; i++) {
-                if (filter->blacklist_tids[i] == MAGIC_TID) break;
-                if (filter->blacklist_tids[i] == context->tid) {
-                    return 0;
-                };
-            }
-            // 这样过滤很方便 思路打开 简而言之就是不要自己维护列表 直接把 map 当列表用最方便
-            // 即直接用 黑/白名单 作为key 然后 value 作为 flag 后面也可以改进 uid pid 
-            u32 *flag = bpf_map_lookup_elem(&thread_filter, &p->event->context.comm);
-            if (filter->thread_name_whitelist == 1) {
-                if (flag != NULL && *flag == 2) {
-                    return 1;
-                }
-                return 0;
-            }
-            if (flag != NULL && *flag == 1) {
-                return 0;
-            }
+    if (config->filter_mode == UNKNOWN_MODE) {
+        return 0;
+    }
 
-            return 1;
-        }
+    // zygote 的子进程在切换 uid 之前同样追踪 切换之后按 uid 判断
+    u32* spawn_uid = bpf_map_lookup_elem(&spawn_child_map, &context->pid);
+    if (spawn_uid != NULL && *spawn_uid != context->uid) {
+        bpf_map_delete_elem(&spawn_child_map, &context->pid);
+        spawn_uid = NULL;
+    }
+
+    // uid pid 都可以指定多个 满足其中任意一个即可 pid 产生的子进程同样追踪
+    // 例如同时追踪 APP 本身 :remote 之类的子进程 以及与其交互的系统进程
+    if (spawn_uid == NULL &&
+        bpf_map_lookup_elem(&watch_uid_map, &context->uid) == NULL &&
+        bpf_map_lookup_elem(&watch_proc_map, &context->pid) == NULL &&
+        bpf_map_lookup_elem(&child_parent_map, &context->pid) == NULL) {
         return 0;
-    } else if (config->filter_mode == PID_MODE) {
-        if (filter->pid == context->pid) {
-            for (int i = 0; i < MAX_COUNT; i++) {
-                if (filter->blacklist_tids[i] == MAGIC_TID) break;
-                if (filter->blacklist_tids[i] == context->tid) {
-                    return 0;
-                };
-            }
-            u32 *flag = bpf_map_lookup_elem(&thread_filter, &p->event->context.comm);
-            if (filter->thread_name_whitelist == 1) {
-                if (flag != NULL && *flag == 2) {
-                    return 1;
-                }
-                return 0;
-            }
-            if (flag != NULL && *flag == 1) {
-                return 0;
-            }
-            return 1;
-        }
-        // fork 产生的情况
-        u32* pid = bpf_map_lookup_elem(&child_parent_map, &context->pid);
-        if (pid != NULL) {
-            return 1;
-        }
+    }
+    // 设置了 tid 白名单的 只追踪名单中的线程
+    if (filter->tid_whitelist == 1 && bpf_map_lookup_elem(&watch_tid_map, &context->tid) == NULL) {
         return 0;
-    } else if (config->filter_mode == PID_TID_MODE) {
-        if (filter->pid == context->pid && filter->tid == context->tid) {
-            u32 *flag = bpf_map_lookup_elem(&thread_filter, &p->event->context.comm);
-            if (filter->thread_name_whitelist == 1) {
-                if (flag != NULL && *flag == 2) {
-                    return 1;
-                }
-                return 0;
-            }
-            if (flag != NULL && *flag == 1) {
-                return 0;
-            }
+    }
+    for (int i = 0; i < MAX_COUNT; i++) {
+        // 因为列表肯定是挨着填充的 所以遇到 MAGIC 就可以直接结束循环了
+        if (filter->blacklist_pids[i] == MAGIC_PID) break;
+        if (filter->blacklist_pids[i] == context->pid) {
+            return 0;
+        };
+    }
+    for (int i = 0; i < MAX_COUNT; i++) {
+        if (filter->blacklist_tids[i] == MAGIC_TID) break;
+        if (filter->blacklist_tids[i] == context->tid) {
+            return 0;
+        };
+    }
+    // 这样过滤很方便 思路打开 简而言之就是不要自己维护列表 直接把 map 当列表用最方便
+    // 即直接用 黑/白名单 作为key 然后 value 作为 flag
+    u32 *flag = bpf_map_lookup_elem(&thread_filter, &p->event->context.comm);
+    if (filter->thread_name_whitelist == 1) {
+        if (flag != NULL && *flag == 2) {
             return 1;
         }
         return 0;
-    } else {
+    }
+    if (flag != NULL && *flag == 1) {
         return 0;
     }
 
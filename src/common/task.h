@@ -1,7 +1,7 @@
 #ifndef __STACKPLZ_TASK_H__
 #define __STACKPLZ_TASK_H__
 
-#include "vmlinux_510.h"
+#include "vmlinux.h"
 
 #include "bpf_core_read.h"
 #include "bpf_helpers.h"
@@ -27,4 +27,18 @@ static __always_inline u32 get_task_ns_tgid(struct task_struct *task)
     return get_task_pid_vnr(group_leader);
 }
 
+// arm64 内核中 32 位进程的 thread_info.flags 会设置 TIF_32BIT
+#define TIF_32BIT 22
+
+// x86_64 下暂不支持 32 位进程
+static __always_inline bool is_compat_task(struct task_struct *task)
+{
+#if defined(__TARGET_ARCH_x86)
+    return false;
+#else
+    u64 flags = READ_KERN(task->thread_info.flags);
+    return (flags & (1UL << TIF_32BIT)) != 0;
+#endif
+}
+
 #endif
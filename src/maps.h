@@ -1,7 +1,7 @@
 #ifndef __MAPS_H__
 #define __MAPS_H__
 
-#include "vmlinux_510.h"
+#include "vmlinux.h"
 #include "bpf/bpf_helpers.h"
 #include "types.h"
 
@@ -28,15 +28,31 @@
 #define BPF_ARRAY(_name, _value_type, _max_entries)                                                \
     BPF_MAP(_name, BPF_MAP_TYPE_ARRAY, u32, _value_type, _max_entries)
 
+// ringbuf 的 max_entries 即缓冲区大小 需要是页大小的 2 的幂次倍
+#define BPF_RINGBUF(_name, _max_entries)                                                           \
+    struct {                                                                                       \
+        __uint(type, BPF_MAP_TYPE_RINGBUF);                                                        \
+        __uint(max_entries, _max_entries);                                                         \
+    } _name SEC(".maps");
+
 BPF_PERCPU_ARRAY(bufs, buf_t, MAX_BUFFERS);                        // percpu global buffer variables
 BPF_PERF_OUTPUT(events, 1024);      // events submission
+BPF_RINGBUF(ring_events, 32 * 1024 * 1024);                        // 不需要堆栈和寄存器时的事件提交 大小在加载前修改
+BPF_PERCPU_ARRAY(ringbuf_lost, u64, 1);                            // ringbuf 满了提交失败的事件数
 BPF_HASH(args_map, u64, args_t, 1024);                             // persist args between function entry and return
 BPF_HASH(child_parent_map, u32, u32, 512);
 BPF_HASH(common_filter, u32, common_filter_t, 1);
 BPF_HASH(sys_whitelist, u32, u32, 40);
 BPF_HASH(sys_blacklist, u32, u32, 40);
 BPF_HASH(thread_filter, thread_name_t, u32, 40);
+BPF_HASH(watch_uid_map, u32, u32, MAX_WATCH_PROC_COUNT);           // 要追踪的 uid 集合
+BPF_HASH(watch_proc_map, u32, u32, MAX_WATCH_PROC_COUNT);          // 要追踪的 pid 集合
+BPF_HASH(watch_tid_map, u32, u32, MAX_WATCH_PROC_COUNT);           // tid 白名单
+BPF_HASH(spawn_parent_map, u32, u32, 8);                           // --spawn 期间 这些进程 fork 出的新进程从 fork 开始追踪
+BPF_HASH(spawn_child_map, u32, u32, 64);                           // 还没有切换 uid 的 zygote 子进程 value 为 fork 时的 uid
 BPF_HASH(rev_filter, rev_string_t, u32, 40);
+BPF_ARRAY(rev_action, rev_action_t, 40);
+BPF_HASH(rev_errno_map, u64, u32, 1024);                           // 需要修改返回值的线程
 BPF_PERCPU_ARRAY(event_data_map, event_data_t, 1);
 BPF_ARRAY(config_map, config_entry_t, 1);
 
@@ -1,6 +1,6 @@
 #include "utils.h"
 
-#include "vmlinux_510.h"
+#include "vmlinux.h"
 
 #include "bpf_helpers.h"
 #include "bpf_tracing.h"
@@ -19,14 +19,8 @@ struct {
 // https://arthurchiao.art/blog/bpf-ringbuf-zh/
 // https://mozillazg.com/2022/05/ebpf-libbpfgo-use-ringbuf-map.html
 // https://github.com/mozillazg/hello-libbpfgo
-// 根据文章，以及 cilium/ebpf 中关于读取 ringbuf 的部分
-// 可以知道 BPF_MAP_TYPE_RINGBUF 的 max_entries 就是环形缓冲区的大小
-// 调整大小可以考虑加载前编辑常量实现
-// 后续考虑迁移到 ringbuf 方式读取
-// struct {
-//     __uint(type, BPF_MAP_TYPE_RINGBUF);
-//     __uint(max_entries, 32 * 1024 * 1024 /* 32 MB */);
-// } fake_events SEC(".maps");
+// syscall/uprobe 事件在不需要堆栈和寄存器时已经通过 maps.h 中的 ring_events 提交
+// 上面两个 map 依赖内核产生的 mmap 记录和硬件断点采样 只能继续使用 perf event array
 
 // SEC("perf_event")
 // int perf_event_handler(void *ctx) {
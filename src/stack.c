@@ -8,15 +8,9 @@
 #include "common/context.h"
 #include "common/filtering.h"
 
-struct {
-    __uint(type, BPF_MAP_TYPE_HASH);
-    __type(key, u32);
-    __type(value, u32);
-    __uint(max_entries, MAX_WATCH_PROC_COUNT);
-} watch_proc_map SEC(".maps");
-
 typedef struct uprobe_point_args_t {
     u32 count;
+    u32 ret_mode;
     point_arg point_args[MAX_POINT_ARG_COUNT];
 } uprobe_point_args;
 
@@ -24,9 +18,25 @@ struct {
     __uint(type, BPF_MAP_TYPE_HASH);
     __type(key, u32);
     __type(value, struct uprobe_point_args_t);
-    __uint(max_entries, 512);
+    __uint(max_entries, MAX_UPROBE_POINT_COUNT);
 } uprobe_point_args_map SEC(".maps");
 
+// :ret 模式下进入时保存寄存器 返回时据此读取输出参数
+// key 为 point_index << 32 | tid 递归调用时只保留最内层的
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, u64);
+    __type(value, arch_regs_t);
+    __uint(max_entries, 1024);
+} uprobe_regs_map SEC(".maps");
+
+struct {
+    __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+    __type(key, u32);
+    __type(value, arch_regs_t);
+    __uint(max_entries, 1);
+} uprobe_regs_buf SEC(".maps");
+
 
 SEC("raw_tracepoint/sched_process_fork")
 int tracepoint__sched__sched_process_fork(struct bpf_raw_tracepoint_args *ctx)
@@ -44,6 +54,8 @@ int tracepoint__sched__sched_process_fork(struct bpf_raw_tracepoint_args *ctx)
     u32 child_ns_pid = get_task_ns_pid(child);
     u32 child_ns_tgid = get_task_ns_tgid(child);
 
+    track_spawn_child(parent, child);
+
     u32* pid = bpf_map_lookup_elem(&child_parent_map, &parent_ns_pid);
     if (pid == NULL) {
         return 0;
@@ -57,49 +69,19 @@ int tracepoint__sched__sched_process_fork(struct bpf_raw_tracepoint_args *ctx)
     return 0;
 }
 
-static __always_inline u32 probe_stack_warp(struct pt_regs* ctx, u32 args_key) {
-
-    program_data_t p = {};
-    if (!init_program_data(&p, ctx))
-        return 0;
-
-    if (!should_trace(&p))
-        return 0;
-
-    struct uprobe_point_args_t* uprobe_point_args = bpf_map_lookup_elem(&uprobe_point_args_map, &args_key);
-    if (uprobe_point_args == NULL) {
-        return 0;
-    }
-
-    u32 filter_key = 0;
-    common_filter_t* filter = bpf_map_lookup_elem(&common_filter, &filter_key);
-    if (filter == NULL) {
-        return 0;
-    }
-
-    save_to_submit_buf(p.event, (void *) &args_key, sizeof(u32), 0);
-    u64 lr = 0;
-    if(filter->is_32bit) {
-        bpf_probe_read_kernel(&lr, sizeof(lr), &ctx->regs[14]);
-        save_to_submit_buf(p.event, (void *) &lr, sizeof(u64), 1);
-    }
-    else {
-        bpf_probe_read_kernel(&lr, sizeof(lr), &ctx->regs[30]);
-        save_to_submit_buf(p.event, (void *) &lr, sizeof(u64), 1);
-    }
-    u64 pc = 0;
-    u64 sp = 0;
-    bpf_probe_read_kernel(&pc, sizeof(pc), &ctx->pc);
-    bpf_probe_read_kernel(&sp, sizeof(sp), &ctx->sp);
-    save_to_submit_buf(p.event, (void *) &pc, sizeof(u64), 2);
-    save_to_submit_buf(p.event, (void *) &sp, sizeof(u64), 3);
+static __always_inline u64 get_regs_key(u32 args_key) {
+    u64 regs_key = args_key;
+    u32 tid = bpf_get_current_pid_tgid();
+    return regs_key << 32 | tid;
+}
 
+// 按配置从寄存器中取参数 read_flag 与当前阶段一致的才进一步读取内容
+static __always_inline u32 read_point_args(program_data_t p, struct uprobe_point_args_t* uprobe_point_args, arch_regs_t* regs, u32 read_flag, u32 next_arg_index) {
     u32 point_arg_count = MAX_POINT_ARG_COUNT;
     if (uprobe_point_args->count <= point_arg_count) {
         point_arg_count = uprobe_point_args->count;
     }
 
-    int next_arg_index = 4;
     for (int i = 0; i < point_arg_count; i++) {
         struct point_arg_t* point_arg = (struct point_arg_t*) &uprobe_point_args->point_args[i];
 
@@ -109,16 +91,12 @@ static __always_inline u32 probe_stack_warp(struct pt_regs* ctx, u32 args_key) {
         }
         if (point_arg->read_index == READ_INDEX_REG) {
             // 未来可能允许读取很多个参数...
-            if (i > REG_ARM64_LR) {
+            if (i >= ARG_REG_COUNT) {
                 continue;
             }
-            arg_ptr = READ_KERN(ctx->regs[i]);
-        } else if (point_arg->read_index == REG_ARM64_SP) {
-            arg_ptr = READ_KERN(ctx->sp);
-        } else if (point_arg->read_index == REG_ARM64_PC) {
-            arg_ptr = READ_KERN(ctx->pc);
-        } else if (point_arg->read_index <= REG_ARM64_LR) {
-            arg_ptr = READ_KERN(ctx->regs[point_arg->read_index]);
+            arg_ptr = read_func_arg(regs, i);
+        } else if (point_arg->read_index < REG_INDEX_MAX) {
+            arg_ptr = read_reg(regs, point_arg->read_index);
         } else {
             continue;
         }
@@ -127,7 +105,7 @@ static __always_inline u32 probe_stack_warp(struct pt_regs* ctx, u32 args_key) {
         save_to_submit_buf(p.event, (void *)&arg_ptr, sizeof(u64), next_arg_index);
         next_arg_index += 1;
 
-        if (point_arg->read_flag != UPROBE_ENTER_READ) {
+        if (point_arg->read_flag != read_flag) {
             continue;
         }
         if (arg_ptr == 0) {
@@ -136,10 +114,9 @@ static __always_inline u32 probe_stack_warp(struct pt_regs* ctx, u32 args_key) {
         u32 read_count = MAX_BUF_READ_SIZE;
         if (point_arg->item_countindex != READ_INDEX_SKIP) {
             u32 item_count = 0;
-            // 以寄存器值作为索引 只包含 x0-x28
-            // x29 是fp寄存器 所以不包含在内
-            if (point_arg->item_countindex < REG_ARM64_X29) {
-                item_count = READ_KERN(ctx->regs[point_arg->item_countindex]);
+            // 以寄存器值作为索引 范围见 COUNT_INDEX_MAX
+            if (point_arg->item_countindex < COUNT_INDEX_MAX) {
+                item_count = read_reg(regs, point_arg->item_countindex);
             }
             if (item_count != 0 && item_count <= read_count) {
                 read_count = item_count;
@@ -149,6 +126,50 @@ static __always_inline u32 probe_stack_warp(struct pt_regs* ctx, u32 args_key) {
         }
         next_arg_index = read_arg(p, point_arg, arg_ptr, read_count, next_arg_index);
     }
+    return next_arg_index;
+}
+
+static __always_inline u32 probe_stack_warp(struct pt_regs* ctx, u32 args_key) {
+
+    program_data_t p = {};
+    if (!init_program_data(&p, ctx))
+        return 0;
+
+    if (!should_trace(&p))
+        return 0;
+
+    struct uprobe_point_args_t* uprobe_point_args = bpf_map_lookup_elem(&uprobe_point_args_map, &args_key);
+    if (uprobe_point_args == NULL) {
+        return 0;
+    }
+
+    u32 filter_key = 0;
+    common_filter_t* filter = bpf_map_lookup_elem(&common_filter, &filter_key);
+    if (filter == NULL) {
+        return 0;
+    }
+
+    u32 regs_buf_key = 0;
+    arch_regs_t* regs = bpf_map_lookup_elem(&uprobe_regs_buf, &regs_buf_key);
+    if (regs == NULL) {
+        return 0;
+    }
+    bpf_probe_read_kernel(regs, sizeof(arch_regs_t), ctx);
+
+    save_to_submit_buf(p.event, (void *) &args_key, sizeof(u32), 0);
+    bool is_compat = is_compat_task(p.event->task);
+    u64 lr = read_lr(regs, is_compat);
+    u64 sp = read_sp(regs, is_compat);
+    save_to_submit_buf(p.event, (void *) &lr, sizeof(u64), 1);
+    u64 pc = read_pc(regs);
+    save_to_submit_buf(p.event, (void *) &pc, sizeof(u64), 2);
+    save_to_submit_buf(p.event, (void *) &sp, sizeof(u64), 3);
+
+    u32 next_arg_index = read_point_args(p, uprobe_point_args, regs, UPROBE_ENTER_READ, 4);
+    if (uprobe_point_args->ret_mode) {
+        u64 regs_key = get_regs_key(args_key);
+        bpf_map_update_elem(&uprobe_regs_map, &regs_key, regs, BPF_ANY);
+    }
     // stackplz 的一个重要动作就是要取寄存器信息之类的
     // 所以除了 PERF_SAMPLE_RAW 还可能会有 PERF_SAMPLE_REGS_USER PERF_SAMPLE_STACK_USER
     // 经过实际测试 接收到的数据是结构体对齐的 但是最终对齐补了几位是无法预测的
@@ -162,37 +183,67 @@ static __always_inline u32 probe_stack_warp(struct pt_regs* ctx, u32 args_key) {
     return 0;
 }
 
-SEC("uprobe/stack_0")
-int probe_stack_0(struct pt_regs* ctx) {
-    u32 args_key = 0;
-    return probe_stack_warp(ctx, args_key);
-}
+// 函数返回时用进入时保存的寄存器读取输出参数 再带上返回值
+static __always_inline u32 probe_stack_ret_warp(struct pt_regs* ctx, u32 args_key) {
+
+    program_data_t p = {};
+    if (!init_program_data(&p, ctx))
+        return 0;
+
+    if (!should_trace(&p))
+        return 0;
+
+    struct uprobe_point_args_t* uprobe_point_args = bpf_map_lookup_elem(&uprobe_point_args_map, &args_key);
+    if (uprobe_point_args == NULL) {
+        return 0;
+    }
 
-#define PROBE_STACK(name)                          \
-    SEC("uprobe/stack_##name")                     \
-    int probe_stack_##name(struct pt_regs* ctx)    \
-    {                                              \
-        u32 args_key = name;                       \
-        return probe_stack_warp(ctx, args_key);    \
+    u64 regs_key = get_regs_key(args_key);
+    arch_regs_t* regs = bpf_map_lookup_elem(&uprobe_regs_map, &regs_key);
+    if (regs == NULL) {
+        // 开始追踪前就已经进入的调用
+        return 0;
     }
 
-// PROBE_STACK(0);
-PROBE_STACK(1);
-PROBE_STACK(2);
-PROBE_STACK(3);
-PROBE_STACK(4);
-PROBE_STACK(5);
-PROBE_STACK(6);
-PROBE_STACK(7);
-// PROBE_STACK(8);
-// PROBE_STACK(9);
-// PROBE_STACK(10);
-// PROBE_STACK(11);
-// PROBE_STACK(12);
-// PROBE_STACK(13);
-// PROBE_STACK(14);
-// PROBE_STACK(15);
-// PROBE_STACK(16);
-// PROBE_STACK(17);
-// PROBE_STACK(18);
-// PROBE_STACK(19);
\ No newline at end of file
+    save_to_submit_buf(p.event, (void *) &args_key, sizeof(u32), 0);
+    u32 next_arg_index = read_point_args(p, uprobe_point_args, regs, UPROBE_EXIT_READ, 1);
+    bpf_map_delete_elem(&uprobe_regs_map, &regs_key);
+
+    u64 ret = read_ret((arch_regs_t*) ctx);
+    save_to_submit_buf(p.event, (void *) &ret, sizeof(u64), next_arg_index);
+    next_arg_index += 1;
+
+    u32 out_size = sizeof(event_context_t) + p.event->buf_off;
+    save_to_submit_buf(p.event, (void *) &out_size, sizeof(u32), next_arg_index);
+    events_perf_submit(&p, UPROBE_EXIT);
+    return 0;
+}
+
+// 由用户态加载时改写 不改写时为 0
+#define LOAD_CONSTANT(param, var) asm("%0 = " param " ll" : "=r"(var))
+
+// 内核不支持 bpf_get_attach_cookie 时 用户态为每个 hook 点复制一份并改写 point_index
+SEC("uprobe/stack")
+int probe_stack(struct pt_regs* ctx) {
+    u64 point_index = 0;
+    LOAD_CONSTANT("point_index", point_index);
+    return probe_stack_warp(ctx, (u32) point_index);
+}
+
+SEC("uretprobe/stack")
+int probe_stack_ret(struct pt_regs* ctx) {
+    u64 point_index = 0;
+    LOAD_CONSTANT("point_index", point_index);
+    return probe_stack_ret_warp(ctx, (u32) point_index);
+}
+
+// 5.15+ 所有 hook 点挂载同一个程序 point_index 作为 attach cookie 传入
+SEC("uprobe/stack_cookie")
+int probe_stack_cookie(struct pt_regs* ctx) {
+    return probe_stack_warp(ctx, (u32) bpf_get_attach_cookie(ctx));
+}
+
+SEC("uretprobe/stack_cookie")
+int probe_stack_ret_cookie(struct pt_regs* ctx) {
+    return probe_stack_ret_warp(ctx, (u32) bpf_get_attach_cookie(ctx));
+}
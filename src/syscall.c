@@ -10,10 +10,64 @@
 
 // syscall过滤配置
 struct syscall_filter_t {
-    u32 is_32bit;
     u32 syscall_all;
     u32 whitelist_mode;
     u32 blacklist_mode;
+    u32 rev_mode;
+};
+
+// 规则文件中的匹配方式以及动作
+#define MAX_REV_STR_LEN 32
+#define REV_ANY_NR 0xffffffff
+#define REV_ANY_ARG 0xff
+
+enum rev_match_e
+{
+    REV_MATCH_PREFIX = 1,
+    REV_MATCH_EXACT,
+};
+
+enum rev_action_e
+{
+    REV_ACTION_LOG = 1,
+    REV_ACTION_ERRNO,
+    REV_ACTION_REWRITE,
+};
+
+// --filter 中可以在内核中完成的简单比较
+#define MAX_ARG_FILTER_COUNT 4
+#define MAX_ARG_FILTER_STR_LEN 32
+// arg_index 为该值时表示比较返回值
+#define ARG_FILTER_RET 0xff
+// 不区分 syscall 的条件 比如 ret<0
+#define ARG_FILTER_ANY_NR 0xffffffff
+
+enum arg_filter_op_e
+{
+    ARG_FILTER_OP_EQ = 1,
+    ARG_FILTER_OP_NE,
+    ARG_FILTER_OP_LT,
+    ARG_FILTER_OP_LE,
+    ARG_FILTER_OP_GT,
+    ARG_FILTER_OP_GE,
+    ARG_FILTER_OP_PREFIX,
+};
+
+struct arg_filter_t {
+    u32 arg_index;
+    u32 op;
+    // int 类型的参数只取低 32 位并做符号扩展
+    u32 is_int32;
+    u32 str_len;
+    s64 value;
+    char str[MAX_ARG_FILTER_STR_LEN];
+};
+
+// 同一个 syscall 的多个条件之间是 与 的关系
+struct syscall_arg_filter_t {
+    u32 count;
+    u32 pad;
+    struct arg_filter_t filters[MAX_ARG_FILTER_COUNT];
 };
 
 typedef struct syscall_point_args_t {
@@ -23,19 +77,12 @@ typedef struct syscall_point_args_t {
     point_arg point_arg_ret;
 } syscall_point_args;
 
-struct {
-    __uint(type, BPF_MAP_TYPE_HASH);
-    __type(key, u32);
-    __type(value, u32);
-    __uint(max_entries, MAX_WATCH_PROC_COUNT);
-} watch_proc_map SEC(".maps");
-
-// syscall_point_args_map 的 key 就是 nr
+// syscall_point_args_map 的 key 就是 nr 32 位进程的 nr 带有 COMPAT_NR_FLAG
 struct {
     __uint(type, BPF_MAP_TYPE_HASH);
     __type(key, u32);
     __type(value, struct syscall_point_args_t);
-    __uint(max_entries, 512);
+    __uint(max_entries, 1024);
 } syscall_point_args_map SEC(".maps");
 
 struct {
@@ -45,6 +92,156 @@ struct {
     __uint(max_entries, 1);
 } syscall_filter SEC(".maps");
 
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, u32);
+    __type(value, struct syscall_arg_filter_t);
+    __uint(max_entries, 1024);
+} sys_arg_filter SEC(".maps");
+
+static __always_inline bool match_arg_filter(struct arg_filter_t* filter, u64 arg) {
+    if (filter->op == ARG_FILTER_OP_PREFIX) {
+        // 字符串类型的参数 比较前缀 完整的匹配交给前端
+        char buf[MAX_ARG_FILTER_STR_LEN] = {};
+        if (arg == 0) {
+            return false;
+        }
+        if (bpf_probe_read_user_str(buf, sizeof(buf), (void*) arg) < 0) {
+            return false;
+        }
+        #pragma unroll
+        for (int i = 0; i < MAX_ARG_FILTER_STR_LEN; i++) {
+            if (i >= filter->str_len) {
+                break;
+            }
+            if (buf[i] != filter->str[i]) {
+                return false;
+            }
+        }
+        return true;
+    }
+    s64 value = (s64) arg;
+    if (filter->is_int32) {
+        value = (s64)(s32) arg;
+    }
+    switch (filter->op) {
+        case ARG_FILTER_OP_EQ:
+            return value == filter->value;
+        case ARG_FILTER_OP_NE:
+            return value != filter->value;
+        case ARG_FILTER_OP_LT:
+            return value < filter->value;
+        case ARG_FILTER_OP_LE:
+            return value <= filter->value;
+        case ARG_FILTER_OP_GT:
+            return value > filter->value;
+        case ARG_FILTER_OP_GE:
+            return value >= filter->value;
+    }
+    return true;
+}
+
+// sys_enter 时只检查参数 sys_exit 时只检查返回值
+static __always_inline bool match_arg_filters(u32 key, args_t* args, u64 ret, u32 read_flag) {
+    struct syscall_arg_filter_t* arg_filter = bpf_map_lookup_elem(&sys_arg_filter, &key);
+    if (arg_filter == NULL) {
+        return true;
+    }
+    #pragma unroll
+    for (int i = 0; i < MAX_ARG_FILTER_COUNT; i++) {
+        if (i >= arg_filter->count) {
+            break;
+        }
+        struct arg_filter_t* filter = &arg_filter->filters[i];
+        u64 arg = 0;
+        if (filter->arg_index == ARG_FILTER_RET) {
+            if (read_flag != SYS_EXIT) {
+                continue;
+            }
+            arg = ret;
+        } else {
+            if (read_flag != SYS_ENTER) {
+                continue;
+            }
+            u32 arg_index = filter->arg_index;
+            if (arg_index >= 6) {
+                continue;
+            }
+            arg = args->args[arg_index];
+        }
+        if (!match_arg_filter(filter, arg)) {
+            return false;
+        }
+    }
+    return true;
+}
+
+// 逐个字符构造 key 去查 rev_filter 这样前缀和完整匹配都可以通过 hash 查找完成
+static __always_inline void apply_rev_rule(u32 sysno, u32 arg_index, u64 ptr) {
+    char buf[MAX_REV_STR_LEN] = {};
+    long len = bpf_probe_read_user_str(buf, sizeof(buf), (void*) ptr);
+    if (len <= 1) {
+        return;
+    }
+    rev_string_t key = {};
+    #pragma unroll
+    for (int i = 0; i < MAX_REV_STR_LEN - 1; i++) {
+        if (buf[i] == 0) {
+            break;
+        }
+        key.name[i] = buf[i];
+        u32* index = bpf_map_lookup_elem(&rev_filter, &key);
+        if (index == NULL) {
+            continue;
+        }
+        rev_action_t* action = bpf_map_lookup_elem(&rev_action, index);
+        if (action == NULL) {
+            continue;
+        }
+        // 32 位进程的 sysno 带有 COMPAT_NR_FLAG 参数位置按 compat 的配置
+        u32 want_arg = action->arg_index;
+        if (action->nr != REV_ANY_NR) {
+            if (sysno == action->compat_nr) {
+                want_arg = action->compat_arg_index;
+            } else if (sysno != action->nr) {
+                continue;
+            }
+        }
+        if (want_arg != REV_ANY_ARG && want_arg != arg_index) {
+            continue;
+        }
+        if (action->match_mode == REV_MATCH_EXACT && buf[i + 1] != 0) {
+            continue;
+        }
+        if (action->action == REV_ACTION_REWRITE) {
+            // 只能在原字符串的位置上覆盖 新的路径不能比原来的长
+            u32 target_len = action->target_len;
+            if (target_len < len && target_len < MAX_REV_STR_LEN) {
+                bpf_probe_write_user((void*) ptr, action->target, target_len + 1);
+            }
+        } else if (action->action == REV_ACTION_ERRNO) {
+            // 由挂在对应 syscall 上的 kprobe 修改返回值
+            u64 id = bpf_get_current_pid_tgid();
+            u32 err_no = action->err_no;
+            bpf_map_update_elem(&rev_errno_map, &id, &err_no, BPF_ANY);
+        }
+        return;
+    }
+}
+
+SEC("kprobe/rev_override_return")
+int rev_override_return(struct pt_regs* ctx) {
+    u64 id = bpf_get_current_pid_tgid();
+    u32* err_no = bpf_map_lookup_elem(&rev_errno_map, &id);
+    if (err_no == NULL) {
+        return 0;
+    }
+    s64 ret = -(s64) *err_no;
+    bpf_map_delete_elem(&rev_errno_map, &id);
+    bpf_override_return(ctx, ret);
+    return 0;
+}
+
 SEC("raw_tracepoint/sched_process_fork")
 int tracepoint__sched__sched_process_fork(struct bpf_raw_tracepoint_args *ctx)
 {
@@ -76,6 +273,8 @@ int tracepoint__sched__sched_process_fork(struct bpf_raw_tracepoint_args *ctx)
     u32 child_ns_pid = get_task_ns_pid(child);
     u32 child_ns_tgid = get_task_ns_tgid(child);
 
+    track_spawn_child(parent, child);
+
     // bpf_printk("[syscall] parent_ns_pid:%d child_ns_pid:%d\n", parent_ns_pid, child_ns_pid);
     u32* pid = bpf_map_lookup_elem(&child_parent_map, &parent_ns_pid);
     if (pid == NULL) {
@@ -110,8 +309,14 @@ int raw_syscalls_sys_enter(struct bpf_raw_tracepoint_args* ctx) {
         return 0;
 
     struct pt_regs *regs = (struct pt_regs *)(ctx->args[0]);
-    u64 syscallno = READ_KERN(regs->syscallno);
+    arch_regs_t *aregs = (arch_regs_t *) regs;
+    u64 syscallno = read_syscall_nr(regs);
     u32 sysno = (u32)syscallno;
+    // 32 位进程的调用号是另外一套 按进程自身的情况区分
+    bool is_compat = is_compat_task(p.event->task);
+    if (is_compat) {
+        sysno |= COMPAT_NR_FLAG;
+    }
     // 先根据调用号确定有没有对应的参数获取方案 没有直接结束
     struct syscall_point_args_t* syscall_point_args = bpf_map_lookup_elem(&syscall_point_args_map, &sysno);
     if (syscall_point_args == NULL) {
@@ -143,33 +348,34 @@ int raw_syscalls_sys_enter(struct bpf_raw_tracepoint_args* ctx) {
         }
     }
 
-    // 保存寄存器应该放到所有过滤完成之后
     args_t args = {};
-    args.args[0] = READ_KERN(regs->regs[0]);
-    args.args[1] = READ_KERN(regs->regs[1]);
-    args.args[2] = READ_KERN(regs->regs[2]);
-    args.args[3] = READ_KERN(regs->regs[3]);
-    args.args[4] = READ_KERN(regs->regs[4]);
+    args.args[0] = read_syscall_arg(aregs, 0);
+    args.args[1] = read_syscall_arg(aregs, 1);
+    args.args[2] = read_syscall_arg(aregs, 2);
+    args.args[3] = read_syscall_arg(aregs, 3);
+    args.args[4] = read_syscall_arg(aregs, 4);
+    args.args[5] = read_syscall_arg(aregs, 5);
+
+    // 参数过滤 不满足的话不保存寄存器 这样 sys_exit 也就不会输出了
+    if (!match_arg_filters(sysno, &args, 0, SYS_ENTER)) {
+        return 0;
+    }
+    u32 any_nr = ARG_FILTER_ANY_NR;
+    if (!match_arg_filters(any_nr, &args, 0, SYS_ENTER)) {
+        return 0;
+    }
+
+    // 保存寄存器应该放到所有过滤完成之后
     save_args(&args, SYSCALL_ENTER);
 
     // event->context 已经有进程的信息了
     save_to_submit_buf(p.event, (void *) &sysno, sizeof(u32), 0);
 
     // 先获取 lr sp pc 并发送 这样可以尽早计算调用来源情况
-    // READ_KERN 好像有问题
-    u64 lr = 0;
-    if(filter->is_32bit) {
-        bpf_probe_read_kernel(&lr, sizeof(lr), &regs->regs[14]);
-        save_to_submit_buf(p.event, (void *) &lr, sizeof(u64), 1);
-    }
-    else {
-        bpf_probe_read_kernel(&lr, sizeof(lr), &regs->regs[30]);
-        save_to_submit_buf(p.event, (void *) &lr, sizeof(u64), 1);
-    }
-    u64 pc = 0;
-    u64 sp = 0;
-    bpf_probe_read_kernel(&pc, sizeof(pc), &regs->pc);
-    bpf_probe_read_kernel(&sp, sizeof(sp), &regs->sp);
+    u64 lr = read_lr(aregs, is_compat);
+    u64 pc = read_pc(aregs);
+    u64 sp = read_sp(aregs, is_compat);
+    save_to_submit_buf(p.event, (void *) &lr, sizeof(u64), 1);
     save_to_submit_buf(p.event, (void *) &pc, sizeof(u64), 2);
     save_to_submit_buf(p.event, (void *) &sp, sizeof(u64), 3);
 
@@ -189,17 +395,13 @@ int raw_syscalls_sys_enter(struct bpf_raw_tracepoint_args* ctx) {
         }
         if (point_arg->read_index == READ_INDEX_REG) {
             // 未来可能允许读取很多个参数...
-            if (i > REG_ARM64_PC) {
+            if (i >= ARG_REG_COUNT) {
                 continue;
             }
-            arg_ptr = READ_KERN(regs->regs[i]);
+            arg_ptr = read_syscall_arg(aregs, i);
         } else if (point_arg->read_index > READ_INDEX_SKIP) {
-            if (point_arg->read_index <= REG_ARM64_LR) {
-                arg_ptr = READ_KERN(regs->regs[point_arg->read_index]);
-            } else if (point_arg->read_index == REG_ARM64_SP) {
-                arg_ptr = READ_KERN(regs->sp);
-            } else if (point_arg->read_index == REG_ARM64_PC) {
-                arg_ptr = READ_KERN(regs->pc);
+            if (point_arg->read_index < REG_INDEX_MAX) {
+                arg_ptr = read_reg(aregs, point_arg->read_index);
             } else {
                 continue;
             }
@@ -220,10 +422,9 @@ int raw_syscalls_sys_enter(struct bpf_raw_tracepoint_args* ctx) {
         u32 read_count = MAX_BUF_READ_SIZE;
         if (point_arg->item_countindex != READ_INDEX_SKIP) {
             u32 item_count = 0;
-            // 以寄存器值作为索引 只包含 x0-x28
-            // x29 是fp寄存器 所以不包含在内
-            if (point_arg->item_countindex < REG_ARM64_X29) {
-                item_count = READ_KERN(regs->regs[point_arg->item_countindex]);
+            // syscall 的长度参数按参数序号取 范围见 COUNT_INDEX_MAX
+            if (point_arg->item_countindex < COUNT_INDEX_MAX) {
+                item_count = read_syscall_arg(aregs, point_arg->item_countindex);
             }
             if (item_count != 0 && item_count <= read_count) {
                 read_count = item_count;
@@ -232,6 +433,10 @@ int raw_syscalls_sys_enter(struct bpf_raw_tracepoint_args* ctx) {
             read_count = point_arg->size;
         }
         next_arg_index = read_arg(p, point_arg, arg_ptr, read_count, next_arg_index);
+        // 先读取原始内容再按规则处理
+        if (filter->rev_mode == 1 && point_arg->alias_type == TYPE_STRING) {
+            apply_rev_rule(sysno, i, arg_ptr);
+        }
     }
 
     u32 out_size = sizeof(event_context_t) + p.event->buf_off;
@@ -258,8 +463,13 @@ int raw_syscalls_sys_exit(struct bpf_raw_tracepoint_args* ctx) {
         return 0;
 
     struct pt_regs *regs = (struct pt_regs *)(ctx->args[0]);
-    u64 syscallno = READ_KERN(regs->syscallno);
+    arch_regs_t *aregs = (arch_regs_t *) regs;
+    u64 syscallno = read_syscall_nr(regs);
     u32 sysno = (u32)syscallno;
+    bool is_compat = is_compat_task(p.event->task);
+    if (is_compat) {
+        sysno |= COMPAT_NR_FLAG;
+    }
 
     struct syscall_point_args_t* syscall_point_args = bpf_map_lookup_elem(&syscall_point_args_map, &sysno);
     if (syscall_point_args == NULL) {
@@ -278,6 +488,12 @@ int raw_syscalls_sys_exit(struct bpf_raw_tracepoint_args* ctx) {
     }
     del_args(SYSCALL_ENTER);
 
+    // kprobe 没有生效的话 这里把残留的记录清理掉
+    if (filter->rev_mode == 1) {
+        u64 id = bpf_get_current_pid_tgid();
+        bpf_map_delete_elem(&rev_errno_map, &id);
+    }
+
     if (filter->syscall_all == 0) {
         // 非 追踪全部syscall模式
         if (filter->whitelist_mode == 1) {
@@ -296,6 +512,20 @@ int raw_syscalls_sys_exit(struct bpf_raw_tracepoint_args* ctx) {
         }
     }
 
+    // 返回值过滤
+    u64 ret = read_ret(aregs);
+    if (is_compat) {
+        // 32 位进程的返回值只有低 32 位 符号扩展之后前端才能正确识别错误码
+        ret = (u64)(s64)(s32)ret;
+    }
+    if (!match_arg_filters(sysno, &saved_args, ret, SYS_EXIT)) {
+        return 0;
+    }
+    u32 any_nr = ARG_FILTER_ANY_NR;
+    if (!match_arg_filters(any_nr, &saved_args, ret, SYS_EXIT)) {
+        return 0;
+    }
+
     int next_arg_index = 0;
     save_to_submit_buf(p.event, (void *) &sysno, sizeof(u32), next_arg_index);
     next_arg_index += 1;
@@ -314,21 +544,17 @@ int raw_syscalls_sys_exit(struct bpf_raw_tracepoint_args* ctx) {
         }
         if (point_arg->read_index == READ_INDEX_REG) {
             // 未来可能允许读取很多个参数...
-            if (i > REG_ARM64_LR) {
+            if (i >= ARG_REG_COUNT) {
                 continue;
             }
             // 考虑到这里是syscall执行结束 尽可能优先采用之前保存的寄存器的值
-            if (i < REG_ARM64_X6) {
+            if (i < 6) {
                 arg_ptr = saved_args.args[i];
             } else {
-                arg_ptr = READ_KERN(regs->regs[i]);
+                arg_ptr = read_syscall_arg(aregs, i);
             }
-        } else if (point_arg->read_index == REG_ARM64_SP) {
-            arg_ptr = READ_KERN(regs->sp);
-        } else if (point_arg->read_index == REG_ARM64_PC) {
-            arg_ptr = READ_KERN(regs->pc);
-        } else if (point_arg->read_index <= REG_ARM64_LR) {
-            arg_ptr = READ_KERN(regs->regs[point_arg->read_index]);
+        } else if (point_arg->read_index < REG_INDEX_MAX) {
+            arg_ptr = read_reg(aregs, point_arg->read_index);
         } else {
             continue;
         }
@@ -346,10 +572,9 @@ int raw_syscalls_sys_exit(struct bpf_raw_tracepoint_args* ctx) {
         u32 read_count = MAX_BUF_READ_SIZE;
         if (point_arg->item_countindex != READ_INDEX_SKIP) {
             u32 item_count = 0;
-            // 以寄存器值作为索引 只包含 x0-x28
-            // x29 是fp寄存器 所以不包含在内
-            if (point_arg->item_countindex < REG_ARM64_X29) {
-                item_count = READ_KERN(regs->regs[point_arg->item_countindex]);
+            // syscall 的长度参数按参数序号取 范围见 COUNT_INDEX_MAX
+            if (point_arg->item_countindex < COUNT_INDEX_MAX) {
+                item_count = read_syscall_arg(aregs, point_arg->item_countindex);
             }
             if (item_count != 0 && item_count <= read_count) {
                 read_count = item_count;
@@ -360,9 +585,7 @@ int raw_syscalls_sys_exit(struct bpf_raw_tracepoint_args* ctx) {
         next_arg_index = read_arg(p, point_arg, arg_ptr, read_count, next_arg_index);
     }
 
-    // 读取返回值
-    u64 ret = READ_KERN(regs->regs[0]);
-    // 保存之
+    // 保存返回值
     save_to_submit_buf(p.event, (void *) &ret, sizeof(ret), next_arg_index);
     next_arg_index += 1;
     // 取返回值的参数配置 并尝试进一步读取
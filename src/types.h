@@ -4,13 +4,9 @@
 #include "common/consts.h"
 
 typedef struct common_filter {
-    u32 is_32bit;
-    u32 uid;
-    u32 pid;
-    u32 tid;
-    u32 pid_list[MAX_WATCH_PROC_COUNT];
     u32 blacklist_pids[MAX_COUNT];
     u32 blacklist_tids[MAX_COUNT];
+    u32 tid_whitelist;
     u32 thread_name_whitelist;
     u32 trace_isolated;
     u32 signal;
@@ -28,10 +24,24 @@ typedef struct rev_string {
     char name[32];
 } rev_string_t;
 
+// rev_filter 命中之后的处理方式 以规则序号为索引
+typedef struct rev_action {
+    u32 action;
+    u32 err_no;
+    u32 match_mode;
+    u32 nr;
+    u32 arg_index;
+    u32 compat_nr;
+    u32 compat_arg_index;
+    u32 target_len;
+    char target[32];
+} rev_action_t;
+
 
 typedef struct config_entry {
     u32 filter_mode;
     u32 stackplz_pid;
+    u32 use_ringbuf;
 } config_entry_t;
 
 enum filter_mode_e
@@ -46,7 +56,8 @@ enum event_id_e
 {
     SYSCALL_ENTER = 456,
     SYSCALL_EXIT,
-    UPROBE_ENTER
+    UPROBE_ENTER,
+    UPROBE_EXIT
 };
 
 enum arm64_reg_e
@@ -87,6 +98,32 @@ enum arm64_reg_e
     REG_ARM64_MAX
 };
 
+// 与 perf 采样寄存器的顺序一致 64 位下没有 ds es fs gs
+enum x86_64_reg_e
+{
+    REG_X86_64_AX = 0,
+    REG_X86_64_BX,
+    REG_X86_64_CX,
+    REG_X86_64_DX,
+    REG_X86_64_SI,
+    REG_X86_64_DI,
+    REG_X86_64_BP,
+    REG_X86_64_SP,
+    REG_X86_64_IP,
+    REG_X86_64_FLAGS,
+    REG_X86_64_CS,
+    REG_X86_64_SS,
+    REG_X86_64_R8,
+    REG_X86_64_R9,
+    REG_X86_64_R10,
+    REG_X86_64_R11,
+    REG_X86_64_R12,
+    REG_X86_64_R13,
+    REG_X86_64_R14,
+    REG_X86_64_R15,
+    REG_X86_64_MAX
+};
+
 enum arg_type_e
 {
 	TYPE_NONE = 0,
@@ -120,6 +157,20 @@ enum arg_type_e
 	TYPE_TIMEZONE,
 	TYPE_PTHREAD_ATTR,
 	TYPE_BUFFER_T,
+	TYPE_PIPEFD,
+	TYPE_TIMESPEC32,
+	TYPE_TIMEVAL32,
+	TYPE_STAT64,
+	TYPE_STATFS32,
+	TYPE_STATFS64,
+	TYPE_SIGACTION32,
+	TYPE_RUSAGE32,
+	TYPE_SYSINFO32,
+	TYPE_MSGHDR32,
+	TYPE_ITIMERSPEC32,
+	TYPE_STACK_T32,
+	TYPE_STAT_X86_64,
+	TYPE_EPOLLEVENT_X86_64,
 };
 
 enum read_type_e
@@ -128,7 +179,8 @@ enum read_type_e
 	SYS_ENTER_EXIT,
 	SYS_ENTER,
 	SYS_EXIT,
-	UPROBE_ENTER_READ
+	UPROBE_ENTER_READ,
+	UPROBE_EXIT_READ
 };
 
 typedef struct event_context {
@@ -1,7 +1,7 @@
 #ifndef STACKPLZ_UTILS_H
 #define STACKPLZ_UTILS_H
 
-#include "vmlinux_510.h"
+#include "vmlinux.h"
 
 #include "bpf_helpers.h"
 #include "bpf_core_read.h"
@@ -9,6 +9,7 @@
 
 #include "common/consts.h"
 #include "common/buffer.h"
+#include "common/task.h"
 
 #define MAX_POINT_ARG_COUNT 10
 #define READ_INDEX_SKIP 100
@@ -68,7 +69,16 @@ static __always_inline u32 read_ptr_arg(program_data_t p, struct point_arg_t* po
     // 比较复杂的 指针 + 结构体
     if (point_arg->alias_type == TYPE_IOVEC) {
         struct iovec iovec_ptr;
-        int errno = bpf_probe_read_user(&iovec_ptr, sizeof(iovec_ptr), (void*) ptr);
+        int errno = 0;
+        if (is_compat_task(p.event->task)) {
+            // 32 位进程的 iovec 是两个 u32 转换成 64 位的布局 前端统一解析
+            u32 iovec32[2] = {};
+            errno = bpf_probe_read_user(&iovec32, sizeof(iovec32), (void*) ptr);
+            iovec_ptr.iov_base = (void*)(u64)iovec32[0];
+            iovec_ptr.iov_len = iovec32[1];
+        } else {
+            errno = bpf_probe_read_user(&iovec_ptr, sizeof(iovec_ptr), (void*) ptr);
+        }
         if (errno == 0) {
             save_to_submit_buf(p.event, (void *)&iovec_ptr, sizeof(iovec_ptr), next_arg_index);
             next_arg_index += 1;
@@ -116,7 +126,7 @@ static __always_inline u32 read_arg(program_data_t p, struct point_arg_t* point_
         return next_arg_index;
     }
     if (point_arg->type == TYPE_STRING_ARR) {
-        save_str_arr_to_buf(p.event, (const char *const *) ptr /*ptr*/, next_arg_index);
+        save_str_arr_to_buf(p.event, (const char *const *) ptr /*ptr*/, next_arg_index, is_compat_task(p.event->task));
         next_arg_index += 1;
         return next_arg_index;
     }
@@ -161,10 +171,14 @@ static __always_inline u32 read_arg(program_data_t p, struct point_arg_t* point_
         return next_arg_index;
     }
     if (point_arg->type == TYPE_POINTER) {
-        // 指针类型 通常读一下对应指针的数据即可 后续记得考虑兼容下32位
+        // 指针类型 通常读一下对应指针的数据即可 32 位进程的指针只有 4 字节
         // 读取指针所指向位置的值 并且保存
         u64 addr = 0;
-        bpf_probe_read_user(&addr, sizeof(addr), (void*) ptr);
+        if (is_compat_task(p.event->task)) {
+            bpf_probe_read_user(&addr, sizeof(u32), (void*) ptr);
+        } else {
+            bpf_probe_read_user(&addr, sizeof(addr), (void*) ptr);
+        }
         save_to_submit_buf(p.event, (void *) &addr, sizeof(u64), next_arg_index);
         next_arg_index += 1;
         // 如果指向的是一个结构体 那么我们就再进一步把结构体数据读取出来
@@ -0,0 +1,11 @@
+#ifndef __STACKPLZ_VMLINUX_H__
+#define __STACKPLZ_VMLINUX_H__
+
+// x86_64 的头文件通过 make vmlinux_x86 从开发机的内核 BTF 生成
+#if defined(__TARGET_ARCH_x86)
+    #include "vmlinux_x86.h"
+#else
+    #include "vmlinux_510.h"
+#endif
+
+#endif
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+const (
+	ARCH_ARM64  = "arm64"
+	ARCH_X86_64 = "x86_64"
+)
+
+// 不存在的寄存器 比如 x86_64 没有 lr
+const REG_NONE uint32 = 0xffffffff
+
+// 架构相关的信息 寄存器编号和 perf 采样数据中的顺序一致 eBPF 中的 read_index 也使用这套编号
+type Arch struct {
+	Name string
+	// 下标为寄存器编号
+	RegNames []string
+	// 寄存器名到编号 包含别名
+	Regs  map[string]uint32
+	RegPC uint32
+	RegSP uint32
+	RegFP uint32
+	RegLR uint32
+	// 作为长度使用的寄存器编号需要小于这个值 与 eBPF 中的 COUNT_INDEX_MAX 一致
+	CountRegMax uint32
+	// 函数调用约定中依次用于传参的寄存器
+	ArgRegs []uint32
+	// 下标为 DWARF 寄存器编号 值为对应的寄存器编号 返回地址列也在其中
+	DwarfRegs []uint32
+	// 回溯时返回地址减去这个值 得到调用指令所在的位置
+	PcAdjust uint64
+	// syscall 在内核中的函数名前缀 kprobe 挂载时使用
+	SysCallPrefix string
+	// 32 位进程的 syscall 有单独的 compat 实现时使用这个前缀 没有则与 64 位共用
+	CompatSysCallPrefix string
+	// perf 采样的寄存器数量 32 位进程的单独给出
+	SampleRegCount   int
+	SampleRegCount32 int
+	points           *WatchPointTable
+	// 取值与架构相关的 flag 回放时同样按 trace 文件的架构解析
+	flags *decodeFlags
+}
+
+func (this *Arch) ArgReg(index uint32) (uint32, bool) {
+	if index >= uint32(len(this.ArgRegs)) {
+		return 0, false
+	}
+	return this.ArgRegs[index], true
+}
+
+func (this *Arch) RegName(index uint32) string {
+	if index >= uint32(len(this.RegNames)) {
+		return fmt.Sprintf("reg%d", index)
+	}
+	return this.RegNames[index]
+}
+
+func (this *Arch) GetSampleRegCount(is_32bit bool) int {
+	if is_32bit && this.SampleRegCount32 > 0 {
+		return this.SampleRegCount32
+	}
+	return this.SampleRegCount
+}
+
+func newArm64Arch() *Arch {
+	arch := &Arch{
+		Name:                ARCH_ARM64,
+		RegPC:               REG_ARM64_PC,
+		RegSP:               REG_ARM64_SP,
+		RegFP:               REG_ARM64_X29,
+		RegLR:               REG_ARM64_LR,
+		CountRegMax:         REG_ARM64_X29,
+		PcAdjust:            4,
+		SysCallPrefix:       "__arm64_sys_",
+		CompatSysCallPrefix: "__arm64_compat_sys_",
+		SampleRegCount:      int(REG_ARM64_MAX),
+		SampleRegCount32:    16,
+		points:              NewWatchPointTable(),
+		flags:               newArm64DecodeFlags(),
+	}
+	arch.Regs = make(map[string]uint32)
+	for i := REG_ARM64_X0; i <= REG_ARM64_X29; i++ {
+		arch.RegNames = append(arch.RegNames, fmt.Sprintf("x%d", i))
+	}
+	arch.RegNames = append(arch.RegNames, "lr", "sp", "pc")
+	for i, name := range arch.RegNames {
+		arch.Regs[name] = uint32(i)
+	}
+	// x0-x30 sp 的 DWARF 编号与寄存器编号一致
+	for i := REG_ARM64_X0; i <= REG_ARM64_SP; i++ {
+		arch.DwarfRegs = append(arch.DwarfRegs, i)
+	}
+	// 除 sp pc 之外的寄存器都可以用来传参 多出来的部分实际是从栈上传递的
+	for i := REG_ARM64_X0; i <= REG_ARM64_LR; i++ {
+		arch.ArgRegs = append(arch.ArgRegs, i)
+	}
+	return arch
+}
+
+func newX86_64Arch() *Arch {
+	arch := &Arch{
+		Name:           ARCH_X86_64,
+		RegPC:          REG_X86_64_IP,
+		RegSP:          REG_X86_64_SP,
+		RegFP:          REG_X86_64_BP,
+		RegLR:          REG_NONE,
+		CountRegMax:    REG_X86_64_MAX,
+		PcAdjust:       1,
+		SysCallPrefix:  "__x64_sys_",
+		SampleRegCount: int(REG_X86_64_MAX),
+		points:         NewWatchPointTable(),
+		flags:          newX86_64DecodeFlags(),
+	}
+	arch.RegNames = []string{"rax", "rbx", "rcx", "rdx", "rsi", "rdi", "rbp", "rsp", "rip", "eflags", "cs", "ss"}
+	for i := 8; i <= 15; i++ {
+		arch.RegNames = append(arch.RegNames, fmt.Sprintf("r%d", i))
+	}
+	arch.Regs = make(map[string]uint32)
+	for i, name := range arch.RegNames {
+		arch.Regs[name] = uint32(i)
+	}
+	// 和 arm64 的写法保持兼容
+	arch.Regs["sp"] = REG_X86_64_SP
+	arch.Regs["pc"] = REG_X86_64_IP
+	// rax rdx rcx rbx rsi rdi rbp rsp r8-r15 最后一列是返回地址
+	arch.DwarfRegs = []uint32{
+		REG_X86_64_AX, REG_X86_64_DX, REG_X86_64_CX, REG_X86_64_BX,
+		REG_X86_64_SI, REG_X86_64_DI, REG_X86_64_BP, REG_X86_64_SP,
+	}
+	for i := REG_X86_64_R8; i <= REG_X86_64_R15; i++ {
+		arch.DwarfRegs = append(arch.DwarfRegs, i)
+	}
+	arch.DwarfRegs = append(arch.DwarfRegs, REG_X86_64_IP)
+	// System V 调用约定 syscall 的第四个参数是 r10 由 eBPF 单独处理
+	arch.ArgRegs = []uint32{REG_X86_64_DI, REG_X86_64_SI, REG_X86_64_DX, REG_X86_64_CX, REG_X86_64_R8, REG_X86_64_R9}
+	return arch
+}
+
+var archs = map[string]*Arch{
+	ARCH_ARM64:  newArm64Arch(),
+	ARCH_X86_64: newX86_64Arch(),
+}
+
+var cur_arch = archs[defaultArchName()]
+
+func defaultArchName() string {
+	if runtime.GOARCH == "amd64" {
+		return ARCH_X86_64
+	}
+	return ARCH_ARM64
+}
+
+func GetArch() *Arch {
+	return cur_arch
+}
+
+// 回放时以 trace 文件中记录的架构为准 老版本的 trace 文件没有记录 只可能是 arm64
+func SetArch(name string) error {
+	if name == "" {
+		name = ARCH_ARM64
+	}
+	arch, ok := archs[name]
+	if !ok {
+		return errors.New(fmt.Sprintf("unsupported arch %s, should be arm64 or x86_64", name))
+	}
+	cur_arch = arch
+	return nil
+}
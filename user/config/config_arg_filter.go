@@ -0,0 +1,405 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+)
+
+// --filter 表达式 形如 openat.pathname~"/proc/*/maps" ret<0 connect.addr.port==443
+// 简单的比较会下发到 eBPF 程序中完成 其他的在前端根据解析结果过滤
+
+const MAX_ARG_FILTER_COUNT = 4
+const MAX_ARG_FILTER_STR_LEN = 32
+const ARG_FILTER_RET = 0xff
+const ARG_FILTER_ANY_NR = 0xffffffff
+
+const (
+	ARG_FILTER_OP_EQ uint32 = iota + 1
+	ARG_FILTER_OP_NE
+	ARG_FILTER_OP_LT
+	ARG_FILTER_OP_LE
+	ARG_FILTER_OP_GT
+	ARG_FILTER_OP_GE
+	ARG_FILTER_OP_PREFIX
+)
+
+// 注意顺序 先匹配两个字符的
+var arg_filter_ops = []string{"==", "!=", "<=", ">=", "<", ">", "~"}
+
+type ArgFilterItem struct {
+	arg_index uint32
+	op        uint32
+	is_int32  uint32
+	str_len   uint32
+	value     int64
+	str       [MAX_ARG_FILTER_STR_LEN]byte
+}
+
+type SyscallArgFilter struct {
+	count   uint32
+	pad     uint32
+	filters [MAX_ARG_FILTER_COUNT]ArgFilterItem
+}
+
+type ArgFilter struct {
+	Expr string
+	// 为空表示对所有 syscall 生效
+	SysCall string
+	// ret 表示返回值
+	ArgName string
+	// 结构体成员 比如 addr.port
+	Fields   []string
+	Op       string
+	IsString bool
+	StrValue string
+	IntValue int64
+}
+
+func (this *ArgFilter) IsRet() bool {
+	return this.ArgName == "ret"
+}
+
+func (this *ArgFilter) MatchSysCall(name string) bool {
+	return this.SysCall == "" || this.SysCall == name
+}
+
+func ParseArgFilter(expr string) (*ArgFilter, error) {
+	filter := &ArgFilter{Expr: expr}
+	var left, right string
+	// 取最先出现的操作符 避免匹配到字符串里面的内容
+	op_index := -1
+	for _, op := range arg_filter_ops {
+		index := strings.Index(expr, op)
+		if index > 0 && (op_index == -1 || index < op_index) {
+			op_index = index
+			filter.Op = op
+		}
+	}
+	if op_index > 0 {
+		left = strings.TrimSpace(expr[:op_index])
+		right = strings.TrimSpace(expr[op_index+len(filter.Op):])
+	}
+	if filter.Op == "" || left == "" || right == "" {
+		return nil, errors.New(fmt.Sprintf("invalid filter %s, e.g. openat.pathname~\"/proc/*/maps\" ret<0", expr))
+	}
+	if strings.HasPrefix(right, "\"") {
+		value, err := strconv.Unquote(right)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid string %s in filter %s", right, expr))
+		}
+		filter.IsString = true
+		filter.StrValue = value
+	} else {
+		value, err := strconv.ParseInt(right, 0, 64)
+		if err != nil {
+			// 允许直接写 0xffffffffffffffff 这样的值
+			uvalue, uerr := strconv.ParseUint(right, 0, 64)
+			if uerr != nil {
+				return nil, errors.New(fmt.Sprintf("invalid value %s in filter %s", right, expr))
+			}
+			value = int64(uvalue)
+		}
+		filter.IntValue = value
+	}
+	if filter.Op == "~" && !filter.IsString {
+		return nil, errors.New(fmt.Sprintf("operator ~ need a string pattern, filter:%s", expr))
+	}
+	if filter.IsString && filter.Op != "==" && filter.Op != "!=" && filter.Op != "~" {
+		return nil, errors.New(fmt.Sprintf("string only support ==, != and ~, filter:%s", expr))
+	}
+
+	items := strings.Split(left, ".")
+	if items[0] == "ret" && len(items) == 1 {
+		filter.ArgName = "ret"
+	} else if len(GetSysCallPointsByName(items[0])) > 0 && len(items) > 1 {
+		filter.SysCall = items[0]
+		filter.ArgName = items[1]
+		filter.Fields = items[2:]
+	} else {
+		filter.ArgName = items[0]
+		filter.Fields = items[1:]
+	}
+	if filter.SysCall != "" && !filter.IsRet() {
+		nr_points := GetSysCallPointsByName(filter.SysCall)
+		if len(nr_points) == 0 {
+			return nil, errors.New(fmt.Sprintf("%s is not a syscall, filter:%s", filter.SysCall, expr))
+		}
+		// 64 位和 32 位的参数名可能不同 有一个存在即可
+		found := false
+		for _, nr_point := range nr_points {
+			if nr_point.GetArgIndex(filter.ArgName) >= 0 {
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New(fmt.Sprintf("syscall %s has no arg named %s", filter.SysCall, filter.ArgName))
+		}
+	}
+	return filter, nil
+}
+
+func (this *SysCallArgs) GetArgIndex(arg_name string) int {
+	for index, point_arg := range this.Args {
+		if point_arg.ArgName == arg_name {
+			return index
+		}
+	}
+	return -1
+}
+
+// 尝试转换为 eBPF 中的过滤条件 不支持的返回 false
+func (this *ArgFilter) ToBpfItem(nr_point *SysCallArgs) (ArgFilterItem, bool) {
+	item := ArgFilterItem{}
+	if len(this.Fields) > 0 {
+		return item, false
+	}
+	if this.IsRet() {
+		if this.IsString {
+			return item, false
+		}
+		item.arg_index = ARG_FILTER_RET
+	} else {
+		index := nr_point.GetArgIndex(this.ArgName)
+		if index < 0 || index >= 6 {
+			return item, false
+		}
+		item.arg_index = uint32(index)
+		point_arg := nr_point.Args[index]
+		if this.IsString {
+			// 字符串只能在 sys_enter 时读取
+			if point_arg.AliasType != TYPE_STRING || point_arg.ReadFlag != SYS_ENTER {
+				return item, false
+			}
+			prefix := this.StrValue
+			if this.Op == "~" {
+				// 通配符之前的部分作为前缀
+				if index := strings.IndexAny(prefix, "*?[\\"); index >= 0 {
+					prefix = prefix[:index]
+				}
+			} else if this.Op == "==" {
+				// 带上结尾的 0 就是完整匹配
+				prefix += "\x00"
+			} else {
+				return item, false
+			}
+			if prefix == "" {
+				return item, false
+			}
+			if len(prefix) > MAX_ARG_FILTER_STR_LEN {
+				prefix = prefix[:MAX_ARG_FILTER_STR_LEN]
+			}
+			item.op = ARG_FILTER_OP_PREFIX
+			item.str_len = uint32(len(prefix))
+			copy(item.str[:], prefix)
+			return item, true
+		}
+		if point_arg.Type != TYPE_NUM {
+			return item, false
+		}
+		if point_arg.AliasType == TYPE_INT {
+			item.is_int32 = 1
+		}
+	}
+	switch this.Op {
+	case "==":
+		item.op = ARG_FILTER_OP_EQ
+	case "!=":
+		item.op = ARG_FILTER_OP_NE
+	case "<":
+		item.op = ARG_FILTER_OP_LT
+	case "<=":
+		item.op = ARG_FILTER_OP_LE
+	case ">":
+		item.op = ARG_FILTER_OP_GT
+	case ">=":
+		item.op = ARG_FILTER_OP_GE
+	default:
+		return item, false
+	}
+	item.value = this.IntValue
+	return item, true
+}
+
+// 前端的过滤 value 为解析得到的参数值 返回值是 int64
+func (this *ArgFilter) MatchValue(value interface{}) bool {
+	if len(this.Fields) > 0 {
+		var ok bool
+		value, ok = GetFieldValue(value, this.Fields)
+		if !ok {
+			return false
+		}
+	}
+	if this.IsString {
+		str, ok := value.(string)
+		if !ok {
+			return false
+		}
+		switch this.Op {
+		case "==":
+			return str == this.StrValue
+		case "!=":
+			return str != this.StrValue
+		case "~":
+			matched, _ := filepath.Match(this.StrValue, str)
+			return matched
+		}
+		return false
+	}
+	var num int64
+	switch v := value.(type) {
+	case int64:
+		num = v
+	case uint64:
+		num = int64(v)
+	case int32:
+		num = int64(v)
+	case uint32:
+		num = int64(v)
+	case uint16:
+		num = int64(v)
+	case float64:
+		num = int64(v)
+	default:
+		return false
+	}
+	switch this.Op {
+	case "==":
+		return num == this.IntValue
+	case "!=":
+		return num != this.IntValue
+	case "<":
+		return num < this.IntValue
+	case "<=":
+		return num <= this.IntValue
+	case ">":
+		return num > this.IntValue
+	case ">=":
+		return num >= this.IntValue
+	}
+	return false
+}
+
+// 通过 json 转换取结构体成员 成员名即 json 中的字段名
+func GetFieldValue(value interface{}, fields []string) (interface{}, bool) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var result interface{}
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, false
+	}
+	for _, field := range fields {
+		m, ok := result.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		result, ok = m[field]
+		if !ok {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+func (this *SyscallConfig) SetArgFilters(exprs []string) error {
+	this.ArgFilters = nil
+	for _, expr := range exprs {
+		filter, err := ParseArgFilter(expr)
+		if err != nil {
+			return err
+		}
+		this.ArgFilters = append(this.ArgFilters, filter)
+	}
+	return nil
+}
+
+func (this *SyscallArgFilter) Add(item ArgFilterItem) bool {
+	if this.count >= MAX_ARG_FILTER_COUNT {
+		return false
+	}
+	this.filters[this.count] = item
+	this.count++
+	return true
+}
+
+// 按调用号整理能在 eBPF 中完成的条件 超出数量的只在前端过滤
+// truncated 为每个调用号因为超出数量而没有下发的条件数
+func (this *SyscallConfig) GetBpfArgFilters() (result map[uint32]*SyscallArgFilter, truncated map[uint32]int) {
+	result = make(map[uint32]*SyscallArgFilter)
+	truncated = make(map[uint32]int)
+	add := func(nr uint32, item ArgFilterItem) {
+		arg_filter, ok := result[nr]
+		if !ok {
+			arg_filter = &SyscallArgFilter{}
+			result[nr] = arg_filter
+		}
+		if !arg_filter.Add(item) {
+			truncated[nr]++
+		}
+	}
+	for _, filter := range this.ArgFilters {
+		if filter.SysCall == "" && filter.IsRet() {
+			if item, ok := filter.ToBpfItem(nil); ok {
+				add(ARG_FILTER_ANY_NR, item)
+			}
+			continue
+		}
+		for _, points := range []map[string]IWatchPoint{GetAllWatchPoints(), GetAllCompatWatchPoints()} {
+			for _, point := range points {
+				nr_point, ok := (point).(*SysCallArgs)
+				if !ok || !filter.MatchSysCall(nr_point.PointName) {
+					continue
+				}
+				if !filter.IsRet() && nr_point.GetArgIndex(filter.ArgName) < 0 {
+					continue
+				}
+				if item, ok := filter.ToBpfItem(nr_point); ok {
+					add(nr_point.NR, item)
+				}
+			}
+		}
+	}
+	return result, truncated
+}
+
+func argFilterNRName(nr uint32) string {
+	if nr == ARG_FILTER_ANY_NR {
+		return "*"
+	}
+	if nr_point, ok := (GetWatchPointByNR(nr)).(*SysCallArgs); ok {
+		return nr_point.PointName
+	}
+	return fmt.Sprintf("nr:%d", nr)
+}
+
+func (this *SyscallConfig) UpdateArgFilterMap(arg_filter_map *ebpf.Map) error {
+	arg_filters, truncated := this.GetBpfArgFilters()
+	for nr, count := range truncated {
+		this.logger.Printf("%s has more than %d filter conditions, %d of them are only checked in userspace", argFilterNRName(nr), MAX_ARG_FILTER_COUNT, count)
+	}
+	for _, filter := range this.ArgFilters {
+		// sys_enter 时还没有返回值 eBPF 中无法丢弃 等合并之后才在前端过滤
+		if filter.IsRet() {
+			this.logger.Printf("filter %s can not drop sys_enter events in eBPF, they still reach userspace", filter.Expr)
+		}
+	}
+	for nr, arg_filter := range arg_filters {
+		key := nr
+		err := arg_filter_map.Update(unsafe.Pointer(&key), unsafe.Pointer(arg_filter), ebpf.UpdateAny)
+		if err != nil {
+			return err
+		}
+	}
+	if this.Debug && len(this.ArgFilters) > 0 {
+		this.logger.Printf("update sys_arg_filter success")
+	}
+	return nil
+}
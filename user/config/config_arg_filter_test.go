@@ -0,0 +1,134 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseArgFilter(t *testing.T) {
+	defer SetArch(GetArch().Name)
+	if err := SetArch(ARCH_ARM64); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr    string
+		syscall string
+		arg     string
+		fields  []string
+		op      string
+		str     string
+		value   int64
+	}{
+		{`openat.pathname~"/proc/*/maps"`, "openat", "pathname", nil, "~", "/proc/*/maps", 0},
+		{`ret<0`, "", "ret", nil, "<", "", 0},
+		{`openat.ret==-2`, "openat", "ret", nil, "==", "", -2},
+		{`connect.addr.port==443`, "connect", "addr", []string{"port"}, "==", "", 443},
+		{`flags!=0x80000`, "", "flags", nil, "!=", "", 0x80000},
+		{`fd>=3`, "", "fd", nil, ">=", "", 3},
+		{`value==0xffffffffffffffff`, "", "value", nil, "==", "", -1},
+		// 字符串中的操作符不影响解析
+		{`pathname=="a<b"`, "", "pathname", nil, "==", "a<b", 0},
+	}
+	for _, test := range tests {
+		filter, err := ParseArgFilter(test.expr)
+		if err != nil {
+			t.Errorf("%s: %v", test.expr, err)
+			continue
+		}
+		if filter.SysCall != test.syscall || filter.ArgName != test.arg || filter.Op != test.op {
+			t.Errorf("%s: got %s.%s %s, want %s.%s %s", test.expr, filter.SysCall, filter.ArgName, filter.Op, test.syscall, test.arg, test.op)
+		}
+		if len(filter.Fields) != len(test.fields) || (len(test.fields) > 0 && filter.Fields[0] != test.fields[0]) {
+			t.Errorf("%s: fields %v, want %v", test.expr, filter.Fields, test.fields)
+		}
+		if filter.IsString != (test.str != "") || filter.StrValue != test.str || filter.IntValue != test.value {
+			t.Errorf("%s: value %q %d, want %q %d", test.expr, filter.StrValue, filter.IntValue, test.str, test.value)
+		}
+	}
+
+	for _, expr := range []string{
+		"openat",
+		"ret<",
+		`pathname~123`,
+		`pathname<"a"`,
+		`pathname=="a`,
+		"fd==abc",
+		"openat.nosuch==1",
+	} {
+		if _, err := ParseArgFilter(expr); err == nil {
+			t.Errorf("%s should be rejected", expr)
+		}
+	}
+}
+
+func TestArgFilterMatchValue(t *testing.T) {
+	type sockaddr struct {
+		Port uint16 `json:"port"`
+	}
+	tests := []struct {
+		expr  string
+		value interface{}
+		want  bool
+	}{
+		{`ret<0`, int64(-1), true},
+		{`ret<0`, int64(0), false},
+		{`fd>=3`, uint32(3), true},
+		{`fd>3`, int32(3), false},
+		{`flags!=0`, uint64(0), false},
+		{`pathname=="/proc/self/maps"`, "/proc/self/maps", true},
+		{`pathname!="/proc/self/maps"`, "/proc/self/maps", false},
+		{`pathname~"/proc/*/maps"`, "/proc/123/maps", true},
+		{`pathname~"/proc/*/maps"`, "/proc/123/task/1/maps", false},
+		{`addr.port==443`, sockaddr{Port: 443}, true},
+		{`addr.port==443`, sockaddr{Port: 80}, false},
+		{`addr.nosuch==443`, sockaddr{Port: 443}, false},
+		// 类型不符时视为不满足
+		{`fd==3`, "3", false},
+		{`pathname=="3"`, int64(3), false},
+	}
+	for _, test := range tests {
+		filter, err := ParseArgFilter(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filter.MatchValue(test.value); got != test.want {
+			t.Errorf("%s on %v = %v, want %v", test.expr, test.value, got, test.want)
+		}
+	}
+}
+
+func TestBpfArgFilters(t *testing.T) {
+	defer SetArch(GetArch().Name)
+	if err := SetArch(ARCH_ARM64); err != nil {
+		t.Fatal(err)
+	}
+	conf := &SyscallConfig{}
+	err := conf.SetArgFilters([]string{
+		`openat.pathname~"/data/*"`,
+		`openat.flags!=1`,
+		`openat.flags!=2`,
+		`openat.flags!=3`,
+		`openat.flags!=4`,
+		`openat.flags!=5`,
+		`openat.pathname~"*.so"`,
+		`ret<0`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	arg_filters, truncated := conf.GetBpfArgFilters()
+	// openat 为 56 前缀为空的通配符无法下发
+	arg_filter, ok := arg_filters[56]
+	if !ok || arg_filter.count != MAX_ARG_FILTER_COUNT {
+		t.Fatalf("openat filters = %v, want %d conditions", arg_filter, MAX_ARG_FILTER_COUNT)
+	}
+	if item := arg_filter.filters[0]; item.op != ARG_FILTER_OP_PREFIX || string(item.str[:item.str_len]) != "/data/" {
+		t.Errorf("first item %+v, want prefix /data/", item)
+	}
+	if truncated[56] != 2 {
+		t.Errorf("truncated %d conditions, want 2", truncated[56])
+	}
+	ret_filter, ok := arg_filters[ARG_FILTER_ANY_NR]
+	if !ok || ret_filter.count != 1 || ret_filter.filters[0].arg_index != ARG_FILTER_RET || ret_filter.filters[0].op != ARG_FILTER_OP_LT {
+		t.Errorf("ret filter %+v", ret_filter)
+	}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"stackplz/user/util"
+	"strconv"
+	"strings"
+)
+
+// 硬件断点/观察点的数量受限于 CPU 的调试寄存器 一般为 6 个断点 4 个观察点
+const MAX_BRK_COUNT = 16
+
+type BrkConfig struct {
+	Index uint32
+	// 为空表示 Offset 就是绝对地址
+	Lib    string
+	Offset uint64
+	// 按符号设置时 Offset 由符号地址加上 SymOffset 得到
+	Symbol    string
+	SymOffset uint64
+	// 加上库基址之后的实际地址
+	Addr uint64
+	Type uint32
+	Len  uint64
+	// 命中时从寄存器和栈数据中读取的参数 格式与 --point 一致
+	ArgsStr string
+	Args    []PointArg
+}
+
+var brk_type_names = map[string]uint32{
+	"r":  util.HW_BREAKPOINT_R,
+	"w":  util.HW_BREAKPOINT_W,
+	"rw": util.HW_BREAKPOINT_RW,
+	"x":  util.HW_BREAKPOINT_X,
+}
+
+var brk_reg = regexp.MustCompile(`^(0x[[:xdigit:]]+|[^+\[:]+)(\+0x[[:xdigit:]]+)?(\[.+?\])?((?::\w+){0,2})$`)
+
+// 格式为 [lib!](0xaddr|symbol[+0xoff])[args][:type[:len]] type 为 r/w/rw/x 默认 x len 为 1/2/4/8 默认 4
+// libnative-lib.so!_Z7decryptPKc+0x10[str:x0,buf:32:sp+0x10]:x 命中时将 x0 读取为字符串 读取 sp+0x10 处32字节数据
+func ParseBrkConfig(index uint32, value string) (*BrkConfig, error) {
+	brk := &BrkConfig{Index: index, Type: util.HW_BREAKPOINT_X, Len: 4}
+	if parts := strings.SplitN(value, "!", 2); len(parts) == 2 {
+		brk.Lib = parts[0]
+		value = parts[1]
+	}
+	match := brk_reg.FindStringSubmatch(value)
+	if match == nil {
+		return nil, fmt.Errorf("parse brk %s failed, format invalid", value)
+	}
+	if strings.HasPrefix(match[1], "0x") {
+		if match[2] != "" {
+			return nil, fmt.Errorf("parse brk %s failed, offset is only supported by symbol", value)
+		}
+		offset, err := strconv.ParseUint(strings.TrimPrefix(match[1], "0x"), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse brk %s failed, err:%v", value, err)
+		}
+		brk.Offset = offset
+	} else {
+		brk.Symbol = match[1]
+		if match[2] != "" {
+			offset, err := strconv.ParseUint(strings.TrimPrefix(match[2], "+0x"), 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse brk %s failed, err:%v", value, err)
+			}
+			brk.SymOffset = offset
+		}
+	}
+	if match[3] != "" {
+		brk.ArgsStr = match[3][1 : len(match[3])-1]
+		for arg_index, arg_str := range strings.Split(brk.ArgsStr, ",") {
+			if strings.HasPrefix(arg_str, "out:") {
+				return nil, fmt.Errorf("parse brk arg %s failed, out arg is not supported by brk", arg_str)
+			}
+			arg_type, err := ParseArgType(arg_str)
+			if err != nil {
+				return nil, err
+			}
+			brk.Args = append(brk.Args, PointArg{fmt.Sprintf("arg_%d", arg_index), UPROBE_ENTER_READ, arg_type, "???"})
+		}
+		if len(brk.Args) > MAX_POINT_ARG_COUNT {
+			return nil, fmt.Errorf("max brk arg count is %d", MAX_POINT_ARG_COUNT)
+		}
+	}
+	var infos []string
+	if match[4] != "" {
+		infos = strings.Split(match[4][1:], ":")
+	}
+	if len(infos) > 0 {
+		brk_type, ok := brk_type_names[infos[0]]
+		if !ok {
+			return nil, fmt.Errorf("parse brk type %s failed, should be r/w/rw/x", infos[0])
+		}
+		brk.Type = brk_type
+	}
+	if len(infos) > 1 {
+		if brk.Type == util.HW_BREAKPOINT_X {
+			return nil, errors.New("len is only supported by watchpoint, breakpoint always uses 4")
+		}
+		brk_len, err := strconv.ParseUint(infos[1], 0, 64)
+		if err != nil || (brk_len != 1 && brk_len != 2 && brk_len != 4 && brk_len != 8) {
+			return nil, fmt.Errorf("parse brk len %s failed, should be 1/2/4/8", infos[1])
+		}
+		brk.Len = brk_len
+	}
+	return brk, nil
+}
+
+// 通过库的 ELF 符号表得到符号的地址
+func (this *BrkConfig) ResolveSymbol(lib_path string) error {
+	if this.Symbol == "" {
+		return nil
+	}
+	sym, err := util.FindFuncSymbol(lib_path, this.Symbol)
+	if err != nil {
+		return err
+	}
+	this.Offset = sym.Value + this.SymOffset
+	return nil
+}
+
+func (this *BrkConfig) SetBase(base uint64) error {
+	this.Addr = base + this.Offset
+	if this.Type == util.HW_BREAKPOINT_X && this.Addr%4 != 0 {
+		return fmt.Errorf("breakpoint addr 0x%x should be 4 bytes aligned", this.Addr)
+	}
+	return nil
+}
+
+func (this *BrkConfig) TypeName() string {
+	for name, brk_type := range brk_type_names {
+		if brk_type == this.Type {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func (this *BrkConfig) IsWatchpoint() bool {
+	return this.Type != util.HW_BREAKPOINT_X
+}
+
+func (this *BrkConfig) String() string {
+	s := fmt.Sprintf("brk#%d 0x%x:%s", this.Index, this.Addr, this.TypeName())
+	if this.IsWatchpoint() {
+		s += fmt.Sprintf(":%d", this.Len)
+	}
+	if this.Symbol != "" {
+		s += fmt.Sprintf("(%s!%s+0x%x)", this.Lib, this.Symbol, this.SymOffset)
+	} else if this.Lib != "" {
+		s += fmt.Sprintf("(%s+0x%x)", this.Lib, this.Offset)
+	}
+	return s
+}
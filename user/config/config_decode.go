@@ -0,0 +1,597 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// 把 syscall 的数值参数转换为可读的符号 比如 openat 的 flags 转换为 O_RDONLY|O_CLOEXEC
+// 按 syscall 名 + 参数名 注册 syscall 名为 * 表示对所有 syscall 的同名参数生效
+
+type ArgDecoder func(value uint64) string
+
+type FlagName struct {
+	Value uint64
+	Name  string
+}
+
+var arg_decoders = make(map[string]ArgDecoder)
+
+func RegisterArgDecoder(syscall_name string, arg_name string, decoder ArgDecoder) {
+	key := syscall_name + "|" + arg_name
+	if _, ok := arg_decoders[key]; ok {
+		panic(fmt.Sprintf("arg decoder for %s %s already registered", syscall_name, arg_name))
+	}
+	arg_decoders[key] = decoder
+}
+
+func GetArgDecoder(syscall_name string, arg_name string) ArgDecoder {
+	if decoder, ok := arg_decoders[syscall_name+"|"+arg_name]; ok {
+		return decoder
+	}
+	if decoder, ok := arg_decoders["*|"+arg_name]; ok {
+		return decoder
+	}
+	return nil
+}
+
+// int 类型的参数实际只用到寄存器的低 32 位
+// 比如 AT_FDCWD 对应的寄存器值是 0xffffff9c 而不是 0xffffffffffffff9c
+func IntValue(value uint64) int64 {
+	if value>>32 == 0 {
+		return int64(int32(uint32(value)))
+	}
+	return int64(value)
+}
+
+// 有注册的按注册的转换 int 输出为有符号十进制 其他的保持 hex
+func DecodeNumArg(syscall_name string, point_arg *PointArg, value uint64) string {
+	decoder := GetArgDecoder(syscall_name, point_arg.ArgName)
+	if decoder != nil {
+		return decoder(value)
+	}
+	if point_arg.AliasType == TYPE_INT {
+		return fmt.Sprintf("%d", IntValue(value))
+	}
+	return fmt.Sprintf("0x%x", value)
+}
+
+// 返回值在 [-4095, -1] 之间视为错误
+func GetErrno(ret uint64) (syscall.Errno, bool) {
+	value := int64(ret)
+	if value < 0 && value >= -4095 {
+		return syscall.Errno(-value), true
+	}
+	return 0, false
+}
+
+func GetErrnoName(errno syscall.Errno) string {
+	for _, item := range errno_names {
+		if item.Value == uint64(errno) {
+			return item.Name
+		}
+	}
+	return fmt.Sprintf("errno_%d", errno)
+}
+
+// ENOENT 这样的名字或者数字 转换为 errno
+func GetErrnoByName(name string) (syscall.Errno, bool) {
+	if value, err := strconv.ParseUint(name, 0, 32); err == nil {
+		return syscall.Errno(value), value > 0 && value < 4096
+	}
+	for _, item := range errno_names {
+		if item.Name == name {
+			return syscall.Errno(item.Value), true
+		}
+	}
+	return 0, false
+}
+
+// 返回地址的 syscall 以 hex 输出
+var address_ret_syscalls = []string{"mmap", "mremap", "brk", "shmat"}
+
+// 错误输出为 -ENOENT (No such file or directory) 其他输出为有符号十进制
+func DecodeRet(syscall_name string, ret uint64) string {
+	if errno, ok := GetErrno(ret); ok {
+		desc := errno.Error()
+		if desc != "" {
+			desc = strings.ToUpper(desc[:1]) + desc[1:]
+		}
+		return fmt.Sprintf("-%s (%s)", GetErrnoName(errno), desc)
+	}
+	for _, name := range address_ret_syscalls {
+		if name == syscall_name {
+			return fmt.Sprintf("0x%x", ret)
+		}
+	}
+	return fmt.Sprintf("%d", int64(ret))
+}
+
+// 多个 flag 组合 无法识别的部分以 hex 附在最后
+// 多 bit 的 flag 要放在其子集之前 比如 O_SYNC 要在 O_DSYNC 之前
+func FlagsDecoder(flags []FlagName) ArgDecoder {
+	return func(value uint64) string {
+		var names []string
+		for _, flag := range flags {
+			if flag.Value != 0 && value&flag.Value == flag.Value {
+				names = append(names, flag.Name)
+				value &^= flag.Value
+			}
+		}
+		if value != 0 || len(names) == 0 {
+			names = append(names, fmt.Sprintf("0x%x", value))
+		}
+		return strings.Join(names, "|")
+	}
+}
+
+func EnumDecoder(values []FlagName) ArgDecoder {
+	return func(value uint64) string {
+		for _, item := range values {
+			if item.Value == value {
+				return item.Name
+			}
+		}
+		return fmt.Sprintf("%d", IntValue(value))
+	}
+}
+
+func OctalDecoder(value uint64) string {
+	return fmt.Sprintf("0%o", value)
+}
+
+func DirfdDecoder(value uint64) string {
+	if IntValue(value) == AT_FDCWD {
+		return "AT_FDCWD"
+	}
+	return fmt.Sprintf("%d", IntValue(value))
+}
+
+func SignalDecoder(value uint64) string {
+	return EnumDecoder(signal_names)(value)
+}
+
+// open 的 flags 低两位是访问模式 单独处理
+func OpenFlagsDecoder(value uint64) string {
+	var mode string
+	switch value & O_ACCMODE {
+	case O_RDONLY:
+		mode = "O_RDONLY"
+	case O_WRONLY:
+		mode = "O_WRONLY"
+	case O_RDWR:
+		mode = "O_RDWR"
+	default:
+		mode = fmt.Sprintf("0x%x", value&O_ACCMODE)
+	}
+	value &^= O_ACCMODE
+	if value == 0 {
+		return mode
+	}
+	return mode + "|" + FlagsDecoder(GetArch().flags.open)(value)
+}
+
+// 低 8 位是子进程退出时发给父进程的信号
+func CloneFlagsDecoder(value uint64) string {
+	signal := value & 0xff
+	value &^= 0xff
+	var parts []string
+	if value != 0 {
+		parts = append(parts, FlagsDecoder(GetArch().flags.clone)(value))
+	}
+	if signal != 0 {
+		parts = append(parts, SignalDecoder(signal))
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, "|")
+}
+
+// mmap 的 flags 低两位是映射类型
+func MmapFlagsDecoder(value uint64) string {
+	var map_type string
+	switch value & 0x3 {
+	case MAP_SHARED:
+		map_type = "MAP_SHARED"
+	case MAP_PRIVATE:
+		map_type = "MAP_PRIVATE"
+	case MAP_SHARED_VALIDATE:
+		map_type = "MAP_SHARED_VALIDATE"
+	default:
+		map_type = "0"
+	}
+	value &^= 0x3
+	if value == 0 {
+		return map_type
+	}
+	return map_type + "|" + FlagsDecoder(GetArch().flags.mmap)(value)
+}
+
+func ProtDecoder(value uint64) string {
+	if value == 0 {
+		return "PROT_NONE"
+	}
+	return FlagsDecoder(GetArch().flags.prot)(value)
+}
+
+// ioctl 的 request 能识别的直接输出名字 否则按 _IOC 的格式拆开
+func IoctlRequestDecoder(value uint64) string {
+	request := uint32(value)
+	for _, item := range ioctl_requests {
+		if uint64(request) == item.Value {
+			return item.Name
+		}
+	}
+	dir := (request >> 30) & 0x3
+	size := (request >> 16) & 0x3fff
+	req_type := (request >> 8) & 0xff
+	nr := request & 0xff
+	if dir == 0 && size == 0 {
+		return fmt.Sprintf("0x%x", value)
+	}
+	var dir_str string
+	switch dir {
+	case 0:
+		dir_str = "_IOC_NONE"
+	case 1:
+		dir_str = "_IOC_WRITE"
+	case 2:
+		dir_str = "_IOC_READ"
+	case 3:
+		dir_str = "_IOC_READ|_IOC_WRITE"
+	}
+	var type_str string
+	if req_type >= 0x20 && req_type < 0x7f {
+		type_str = fmt.Sprintf("'%c'", req_type)
+	} else {
+		type_str = fmt.Sprintf("0x%x", req_type)
+	}
+	return fmt.Sprintf("_IOC(%s, %s, 0x%x, 0x%x)", dir_str, type_str, nr, size)
+}
+
+// 以下取值来自内核的 uapi 头文件 不使用 x/sys/unix 中的常量
+// 后者是编译时所在架构的值 分析另一个架构的 trace 文件时会出错
+const (
+	O_ACCMODE = 0x3
+	O_RDONLY  = 0x0
+	O_WRONLY  = 0x1
+	O_RDWR    = 0x2
+
+	MAP_SHARED          = 0x1
+	MAP_PRIVATE         = 0x2
+	MAP_SHARED_VALIDATE = 0x3
+
+	AT_FDCWD = -100
+
+	SOCK_NONBLOCK = 0x800
+	SOCK_CLOEXEC  = 0x80000
+)
+
+// 按架构区分的 flag 表 fcntl prctl 的选项是枚举值
+type decodeFlags struct {
+	open  []FlagName
+	mmap  []FlagName
+	prot  []FlagName
+	clone []FlagName
+	fcntl []FlagName
+	prctl []FlagName
+}
+
+func (this *decodeFlags) openFlag(name string) uint64 {
+	for _, flag := range this.open {
+		if flag.Name == name {
+			return flag.Value
+		}
+	}
+	return 0
+}
+
+// O_DIRECT O_LARGEFILE O_DIRECTORY O_NOFOLLOW 在 arm/arm64 上与 asm-generic 不同
+// O_TMPFILE 包含 O_DIRECTORY 也随之不同
+func openFlags(direct, largefile, directory, nofollow uint64) []FlagName {
+	return []FlagName{
+		{0x40, "O_CREAT"},
+		{0x80, "O_EXCL"},
+		{0x100, "O_NOCTTY"},
+		{0x200, "O_TRUNC"},
+		{0x400, "O_APPEND"},
+		{0x800, "O_NONBLOCK"},
+		{0x101000, "O_SYNC"},
+		{0x1000, "O_DSYNC"},
+		{0x2000, "O_ASYNC"},
+		{direct, "O_DIRECT"},
+		{largefile, "O_LARGEFILE"},
+		{0x400000 | directory, "O_TMPFILE"},
+		{directory, "O_DIRECTORY"},
+		{nofollow, "O_NOFOLLOW"},
+		{0x40000, "O_NOATIME"},
+		{0x80000, "O_CLOEXEC"},
+		{0x200000, "O_PATH"},
+	}
+}
+
+var generic_mmap_flags = []FlagName{
+	{0x10, "MAP_FIXED"},
+	{0x20, "MAP_ANONYMOUS"},
+	{0x100, "MAP_GROWSDOWN"},
+	{0x800, "MAP_DENYWRITE"},
+	{0x1000, "MAP_EXECUTABLE"},
+	{0x2000, "MAP_LOCKED"},
+	{0x4000, "MAP_NORESERVE"},
+	{0x8000, "MAP_POPULATE"},
+	{0x10000, "MAP_NONBLOCK"},
+	{0x20000, "MAP_STACK"},
+	{0x40000, "MAP_HUGETLB"},
+	{0x80000, "MAP_SYNC"},
+	{0x100000, "MAP_FIXED_NOREPLACE"},
+}
+
+var generic_prot_flags = []FlagName{
+	{0x1, "PROT_READ"},
+	{0x2, "PROT_WRITE"},
+	{0x4, "PROT_EXEC"},
+	{0x8, "PROT_SEM"},
+	{0x1000000, "PROT_GROWSDOWN"},
+	{0x2000000, "PROT_GROWSUP"},
+}
+
+var generic_clone_flags = []FlagName{
+	{0x100, "CLONE_VM"},
+	{0x200, "CLONE_FS"},
+	{0x400, "CLONE_FILES"},
+	{0x800, "CLONE_SIGHAND"},
+	{0x1000, "CLONE_PIDFD"},
+	{0x2000, "CLONE_PTRACE"},
+	{0x4000, "CLONE_VFORK"},
+	{0x8000, "CLONE_PARENT"},
+	{0x10000, "CLONE_THREAD"},
+	{0x20000, "CLONE_NEWNS"},
+	{0x40000, "CLONE_SYSVSEM"},
+	{0x80000, "CLONE_SETTLS"},
+	{0x100000, "CLONE_PARENT_SETTID"},
+	{0x200000, "CLONE_CHILD_CLEARTID"},
+	{0x400000, "CLONE_DETACHED"},
+	{0x800000, "CLONE_UNTRACED"},
+	{0x1000000, "CLONE_CHILD_SETTID"},
+	{0x2000000, "CLONE_NEWCGROUP"},
+	{0x4000000, "CLONE_NEWUTS"},
+	{0x8000000, "CLONE_NEWIPC"},
+	{0x10000000, "CLONE_NEWUSER"},
+	{0x20000000, "CLONE_NEWPID"},
+	{0x40000000, "CLONE_NEWNET"},
+	{0x80000000, "CLONE_IO"},
+}
+
+// 64 位下 F_GETLK 等不需要区分 64 版本
+var generic_fcntl_cmds = []FlagName{
+	{0, "F_DUPFD"},
+	{1, "F_GETFD"},
+	{2, "F_SETFD"},
+	{3, "F_GETFL"},
+	{4, "F_SETFL"},
+	{5, "F_GETLK"},
+	{6, "F_SETLK"},
+	{7, "F_SETLKW"},
+	{8, "F_SETOWN"},
+	{9, "F_GETOWN"},
+	{10, "F_SETSIG"},
+	{11, "F_GETSIG"},
+	{15, "F_SETOWN_EX"},
+	{16, "F_GETOWN_EX"},
+	{36, "F_OFD_GETLK"},
+	{37, "F_OFD_SETLK"},
+	{38, "F_OFD_SETLKW"},
+	{1024, "F_SETLEASE"},
+	{1025, "F_GETLEASE"},
+	{1026, "F_NOTIFY"},
+	{1030, "F_DUPFD_CLOEXEC"},
+	{1031, "F_SETPIPE_SZ"},
+	{1032, "F_GETPIPE_SZ"},
+	{1033, "F_ADD_SEALS"},
+	{1034, "F_GET_SEALS"},
+}
+
+var generic_prctl_options = []FlagName{
+	{1, "PR_SET_PDEATHSIG"},
+	{2, "PR_GET_PDEATHSIG"},
+	{3, "PR_GET_DUMPABLE"},
+	{4, "PR_SET_DUMPABLE"},
+	{7, "PR_GET_KEEPCAPS"},
+	{8, "PR_SET_KEEPCAPS"},
+	{15, "PR_SET_NAME"},
+	{16, "PR_GET_NAME"},
+	{21, "PR_GET_SECCOMP"},
+	{22, "PR_SET_SECCOMP"},
+	{23, "PR_CAPBSET_READ"},
+	{24, "PR_CAPBSET_DROP"},
+	{29, "PR_SET_TIMERSLACK"},
+	{30, "PR_GET_TIMERSLACK"},
+	{35, "PR_SET_MM"},
+	{36, "PR_SET_CHILD_SUBREAPER"},
+	{37, "PR_GET_CHILD_SUBREAPER"},
+	{38, "PR_SET_NO_NEW_PRIVS"},
+	{39, "PR_GET_NO_NEW_PRIVS"},
+	{40, "PR_GET_TID_ADDRESS"},
+	{41, "PR_SET_THP_DISABLE"},
+	{42, "PR_GET_THP_DISABLE"},
+	{47, "PR_CAP_AMBIENT"},
+	{0x59616d61, "PR_SET_PTRACER"},
+	{0x53564d41, "PR_SET_VMA"},
+}
+
+func newArm64DecodeFlags() *decodeFlags {
+	flags := &decodeFlags{}
+	flags.open = openFlags(0x10000, 0x20000, 0x4000, 0x8000)
+	flags.mmap = generic_mmap_flags
+	// arm64 特有的 prot
+	flags.prot = append([]FlagName{{0x10, "PROT_BTI"}, {0x20, "PROT_MTE"}}, generic_prot_flags...)
+	flags.clone = generic_clone_flags
+	flags.fcntl = generic_fcntl_cmds
+	flags.prctl = append([]FlagName{
+		{50, "PR_SVE_SET_VL"},
+		{51, "PR_SVE_GET_VL"},
+		{54, "PR_PAC_RESET_KEYS"},
+		{55, "PR_SET_TAGGED_ADDR_CTRL"},
+		{56, "PR_GET_TAGGED_ADDR_CTRL"},
+	}, generic_prctl_options...)
+	return flags
+}
+
+func newX86_64DecodeFlags() *decodeFlags {
+	flags := &decodeFlags{}
+	flags.open = openFlags(0x4000, 0x8000, 0x10000, 0x20000)
+	flags.mmap = append([]FlagName{{0x40, "MAP_32BIT"}}, generic_mmap_flags...)
+	flags.prot = generic_prot_flags
+	flags.clone = generic_clone_flags
+	flags.fcntl = generic_fcntl_cmds
+	flags.prctl = generic_prctl_options
+	return flags
+}
+
+// 注册时架构还没有确定 解析时才按当前架构取表
+func archEnumDecoder(table func(flags *decodeFlags) []FlagName) ArgDecoder {
+	return func(value uint64) string {
+		return EnumDecoder(table(GetArch().flags))(value)
+	}
+}
+
+func Pipe2FlagsDecoder(value uint64) string {
+	flags := GetArch().flags
+	return FlagsDecoder([]FlagName{
+		{flags.openFlag("O_CLOEXEC"), "O_CLOEXEC"},
+		{flags.openFlag("O_NONBLOCK"), "O_NONBLOCK"},
+		{flags.openFlag("O_DIRECT"), "O_DIRECT"},
+	})(value)
+}
+
+var ioctl_requests = []FlagName{
+	{0x5401, "TCGETS"},
+	{0x5402, "TCSETS"},
+	{0x5413, "TIOCGWINSZ"},
+	{0x5414, "TIOCSWINSZ"},
+	{0x541b, "FIONREAD"},
+	{0x5421, "FIONBIO"},
+	{0x5451, "FIOCLEX"},
+	{0x5450, "FIONCLEX"},
+	{0x5452, "FIOASYNC"},
+	{0x8912, "SIOCGIFCONF"},
+	{0x8913, "SIOCGIFFLAGS"},
+	{0x8933, "SIOCGIFINDEX"},
+	{0x8927, "SIOCGIFHWADDR"},
+	{0x8915, "SIOCGIFADDR"},
+	// binder 以及 ashmem 是 Android 上最常见的
+	{0xc0306201, "BINDER_WRITE_READ"},
+	{0x40046205, "BINDER_SET_MAX_THREADS"},
+	{0x40046207, "BINDER_SET_CONTEXT_MGR"},
+	{0x40046208, "BINDER_THREAD_EXIT"},
+	{0xc0046209, "BINDER_VERSION"},
+	{0x41007701, "ASHMEM_SET_NAME"},
+	{0x81007702, "ASHMEM_GET_NAME"},
+	{0x40087703, "ASHMEM_SET_SIZE"},
+	{0x7704, "ASHMEM_GET_SIZE"},
+	{0x40087705, "ASHMEM_SET_PROT_MASK"},
+	{0x7706, "ASHMEM_GET_PROT_MASK"},
+}
+
+func init() {
+	RegisterArgDecoder("*", "dirfd", DirfdDecoder)
+	RegisterArgDecoder("*", "olddirfd", DirfdDecoder)
+	RegisterArgDecoder("*", "newdirfd", DirfdDecoder)
+	RegisterArgDecoder("*", "dfd", DirfdDecoder)
+
+	RegisterArgDecoder("openat", "flags", OpenFlagsDecoder)
+	RegisterArgDecoder("openat", "mode", OctalDecoder)
+	RegisterArgDecoder("mkdirat", "mode", OctalDecoder)
+	RegisterArgDecoder("mknodat", "mode", OctalDecoder)
+	RegisterArgDecoder("fchmod", "mode", OctalDecoder)
+	RegisterArgDecoder("fchmodat", "mode", OctalDecoder)
+	RegisterArgDecoder("dup3", "flags", FlagsDecoder([]FlagName{{0x80000, "O_CLOEXEC"}}))
+	RegisterArgDecoder("pipe2", "flags", Pipe2FlagsDecoder)
+	RegisterArgDecoder("faccessat", "flags", FlagsDecoder([]FlagName{{4, "R_OK"}, {2, "W_OK"}, {1, "X_OK"}}))
+	RegisterArgDecoder("unlinkat", "flags", FlagsDecoder([]FlagName{{0x200, "AT_REMOVEDIR"}}))
+
+	RegisterArgDecoder("mmap", "prot", ProtDecoder)
+	RegisterArgDecoder("mmap", "flags", MmapFlagsDecoder)
+	RegisterArgDecoder("mprotect", "prot", ProtDecoder)
+	RegisterArgDecoder("madvise", "advice", EnumDecoder([]FlagName{
+		{0, "MADV_NORMAL"},
+		{1, "MADV_RANDOM"},
+		{2, "MADV_SEQUENTIAL"},
+		{3, "MADV_WILLNEED"},
+		{4, "MADV_DONTNEED"},
+		{8, "MADV_FREE"},
+		{9, "MADV_REMOVE"},
+		{10, "MADV_DONTFORK"},
+		{11, "MADV_DOFORK"},
+		{12, "MADV_MERGEABLE"},
+		{13, "MADV_UNMERGEABLE"},
+		{14, "MADV_HUGEPAGE"},
+		{15, "MADV_NOHUGEPAGE"},
+		{16, "MADV_DONTDUMP"},
+		{17, "MADV_DODUMP"},
+		{18, "MADV_WIPEONFORK"},
+		{19, "MADV_KEEPONFORK"},
+		{20, "MADV_COLD"},
+		{21, "MADV_PAGEOUT"},
+	}))
+
+	RegisterArgDecoder("prctl", "option", archEnumDecoder(func(flags *decodeFlags) []FlagName {
+		return flags.prctl
+	}))
+	RegisterArgDecoder("fcntl", "cmd", archEnumDecoder(func(flags *decodeFlags) []FlagName {
+		return flags.fcntl
+	}))
+	RegisterArgDecoder("ioctl", "request", IoctlRequestDecoder)
+	RegisterArgDecoder("clone", "flags", CloneFlagsDecoder)
+
+	RegisterArgDecoder("kill", "sig", SignalDecoder)
+	RegisterArgDecoder("tkill", "sig", SignalDecoder)
+	RegisterArgDecoder("tgkill", "sig", SignalDecoder)
+	RegisterArgDecoder("rt_tgsigqueueinfo", "sig", SignalDecoder)
+	RegisterArgDecoder("pidfd_send_signal", "sig", SignalDecoder)
+	RegisterArgDecoder("rt_sigaction", "signum", SignalDecoder)
+	RegisterArgDecoder("rt_sigprocmask", "how", EnumDecoder([]FlagName{
+		{0, "SIG_BLOCK"},
+		{1, "SIG_UNBLOCK"},
+		{2, "SIG_SETMASK"},
+	}))
+
+	RegisterArgDecoder("lseek", "whence", EnumDecoder([]FlagName{
+		{0, "SEEK_SET"},
+		{1, "SEEK_CUR"},
+		{2, "SEEK_END"},
+		{3, "SEEK_DATA"},
+		{4, "SEEK_HOLE"},
+	}))
+	RegisterArgDecoder("epoll_ctl", "op", EnumDecoder([]FlagName{
+		{1, "EPOLL_CTL_ADD"},
+		{2, "EPOLL_CTL_DEL"},
+		{3, "EPOLL_CTL_MOD"},
+	}))
+	RegisterArgDecoder("socket", "domain", EnumDecoder([]FlagName{
+		{1, "AF_UNIX"},
+		{2, "AF_INET"},
+		{10, "AF_INET6"},
+		{16, "AF_NETLINK"},
+		{17, "AF_PACKET"},
+	}))
+	RegisterArgDecoder("socket", "type", SocketTypeDecoder)
+}
+
+// 低 4 位是类型 其余是 SOCK_NONBLOCK SOCK_CLOEXEC
+func SocketTypeDecoder(value uint64) string {
+	socket_type := EnumDecoder([]FlagName{
+		{1, "SOCK_STREAM"},
+		{2, "SOCK_DGRAM"},
+		{3, "SOCK_RAW"},
+		{5, "SOCK_SEQPACKET"},
+	})(value & 0xf)
+	value &^= 0xf
+	if value == 0 {
+		return socket_type
+	}
+	return socket_type + "|" + FlagsDecoder([]FlagName{{SOCK_NONBLOCK, "SOCK_NONBLOCK"}, {SOCK_CLOEXEC, "SOCK_CLOEXEC"}})(value)
+}
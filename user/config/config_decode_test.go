@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestArchDecoders(t *testing.T) {
+	defer SetArch(GetArch().Name)
+	tests := []struct {
+		arch    string
+		syscall string
+		arg     string
+		value   uint64
+		want    string
+	}{
+		{ARCH_ARM64, "openat", "flags", 0x84000, "O_RDONLY|O_DIRECTORY|O_CLOEXEC"},
+		{ARCH_X86_64, "openat", "flags", 0x84000, "O_RDONLY|O_DIRECT|O_CLOEXEC"},
+		{ARCH_ARM64, "openat", "flags", 0x28041, "O_WRONLY|O_CREAT|O_LARGEFILE|O_NOFOLLOW"},
+		{ARCH_X86_64, "openat", "flags", 0x28041, "O_WRONLY|O_CREAT|O_LARGEFILE|O_NOFOLLOW"},
+		{ARCH_ARM64, "openat", "flags", 0x404002, "O_RDWR|O_TMPFILE"},
+		{ARCH_X86_64, "openat", "flags", 0x410002, "O_RDWR|O_TMPFILE"},
+		{ARCH_X86_64, "mmap", "flags", 0x62, "MAP_PRIVATE|MAP_32BIT|MAP_ANONYMOUS"},
+		{ARCH_ARM64, "mmap", "flags", 0x62, "MAP_PRIVATE|MAP_ANONYMOUS|0x40"},
+		{ARCH_ARM64, "mprotect", "prot", 0x15, "PROT_BTI|PROT_READ|PROT_EXEC"},
+		{ARCH_X86_64, "mprotect", "prot", 0x15, "PROT_READ|PROT_EXEC|0x10"},
+		{ARCH_ARM64, "prctl", "option", 54, "PR_PAC_RESET_KEYS"},
+		{ARCH_X86_64, "prctl", "option", 54, "54"},
+		{ARCH_X86_64, "fcntl", "cmd", 1030, "F_DUPFD_CLOEXEC"},
+		{ARCH_ARM64, "clone", "flags", 0x1200011, "CLONE_CHILD_CLEARTID|CLONE_CHILD_SETTID|SIGCHLD"},
+		{ARCH_ARM64, "pipe2", "flags", 0x10800, "O_NONBLOCK|O_DIRECT"},
+		{ARCH_X86_64, "pipe2", "flags", 0x4800, "O_NONBLOCK|O_DIRECT"},
+		// 以下取值在两个架构上相同
+		{ARCH_ARM64, "ioctl", "request", 0x5413, "TIOCGWINSZ"},
+		{ARCH_X86_64, "ioctl", "request", 0x8933, "SIOCGIFINDEX"},
+		{ARCH_ARM64, "madvise", "advice", 8, "MADV_FREE"},
+		{ARCH_X86_64, "madvise", "advice", 21, "MADV_PAGEOUT"},
+		{ARCH_ARM64, "socket", "domain", 10, "AF_INET6"},
+		{ARCH_ARM64, "socket", "type", 0x80801, "SOCK_STREAM|SOCK_NONBLOCK|SOCK_CLOEXEC"},
+		{ARCH_X86_64, "lseek", "whence", 4, "SEEK_HOLE"},
+		{ARCH_ARM64, "epoll_ctl", "op", 3, "EPOLL_CTL_MOD"},
+		{ARCH_ARM64, "rt_sigprocmask", "how", 2, "SIG_SETMASK"},
+		{ARCH_X86_64, "kill", "sig", 9, "SIGKILL"},
+		{ARCH_ARM64, "openat", "dirfd", 0xffffff9c, "AT_FDCWD"},
+	}
+	for _, tt := range tests {
+		if err := SetArch(tt.arch); err != nil {
+			t.Fatal(err)
+		}
+		decoder := GetArgDecoder(tt.syscall, tt.arg)
+		if decoder == nil {
+			t.Fatalf("no decoder for %s %s", tt.syscall, tt.arg)
+		}
+		if got := decoder(tt.value); got != tt.want {
+			t.Errorf("%s %s %s 0x%x = %s, want %s", tt.arch, tt.syscall, tt.arg, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestErrnoNames(t *testing.T) {
+	ret := int64(-2)
+	if got := DecodeRet("openat", uint64(ret)); got != "-ENOENT (No such file or directory)" {
+		t.Errorf("DecodeRet = %s", got)
+	}
+	if errno, ok := GetErrnoByName("EAGAIN"); !ok || errno != 11 {
+		t.Errorf("EAGAIN = %d, %v", errno, ok)
+	}
+	if got := GetErrnoName(4000); got != "errno_4000" {
+		t.Errorf("GetErrnoName(4000) = %s", got)
+	}
+}
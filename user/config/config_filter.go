@@ -3,16 +3,13 @@ package config
 type ConfigMap struct {
 	filter_mode  uint32
 	stackplz_pid uint32
+	use_ringbuf  uint32
 }
 
 type CommonFilter struct {
-	is_32bit              uint32
-	uid                   uint32
-	pid                   uint32
-	tid                   uint32
-	pid_list              [MAX_WATCH_PROC_COUNT]uint32
 	blacklist_pids        [MAX_COUNT]uint32
 	blacklist_tids        [MAX_COUNT]uint32
+	tid_whitelist         uint32
 	thread_name_whitelist uint32
 	trace_isolated        uint32
 	signal                uint32
@@ -27,18 +24,10 @@ type RevFilter struct {
 }
 
 type SyscallFilter struct {
-	is_32bit       uint32
 	syscall_all    uint32
 	whitelist_mode uint32
 	blacklist_mode uint32
-}
-
-func (this *SyscallFilter) SetArch(is_32bit bool) {
-	if is_32bit {
-		this.is_32bit = 1
-	} else {
-		this.is_32bit = 0
-	}
+	rev_mode       uint32
 }
 
 func (this *SyscallFilter) SetHookALL(all bool) {
@@ -57,6 +46,14 @@ func (this *SyscallFilter) SetWhitelistMode(flag bool) {
 	}
 }
 
+func (this *SyscallFilter) SetRevMode(flag bool) {
+	if flag {
+		this.rev_mode = 1
+	} else {
+		this.rev_mode = 0
+	}
+}
+
 func (this *SyscallFilter) SetBlacklistMode(flag bool) {
 	if flag {
 		this.blacklist_mode = 1
@@ -1,13 +1,26 @@
 package config
 
+import "time"
+
 type GlobalConfig struct {
     Prepare          bool
     Name             string
+    Names            []string
+    Spawn            bool
+    SpawnCmd         string
     Uid              uint32
+    Uids             []uint
     Pid              uint32
+    Pids             []uint
+    Exes             []string
+    Comms            []string
+    Cgroups          []string
+    Containers       []string
     Tid              uint32
+    Tids             string
     Color            bool
     UnwindStack      bool
+    Unwinder         string
     StackSize        uint32
     ShowRegs         bool
     GetOff           bool
@@ -17,20 +30,33 @@ type GlobalConfig struct {
     TNamesBlacklist  string
     TraceIsolated    bool
     HideRoot         bool
+    RevRules         string
     UprobeSignal     string
     Debug            bool
     Quiet            bool
     Is32Bit          bool
     Buffer           uint32
-    BrkAddr          string
+    RingBufSize      uint32
+    BrkAddr          []string
     BrkLib           string
     LogFile          string
     DataDir          string
     LibraryDirs      []string
     HookPoint        []string
     Library          string
+    Lazy             bool
+    ListSymbols      string
     RegName          string
     DumpHex          bool
+    Format           string
+    Record           string
+    NoMerge          bool
+    Latency          bool
+    Slow             time.Duration
+    ReportInterval   time.Duration
+    RetTimeout       time.Duration
+    Summary          bool
+    Filter           []string
     NoCheck          bool
     Btf              bool
     SysCall          string
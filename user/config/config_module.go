@@ -8,6 +8,7 @@ import (
     "stackplz/user/util"
     "strconv"
     "strings"
+    "sync"
     "unsafe"
 
     "github.com/cilium/ebpf"
@@ -16,7 +17,11 @@ import (
 type StackUprobeConfig struct {
     LibName string
     LibPath string
-    Points  []UprobeArgs
+    // 允许 hook 启动时还不存在的库
+    Lazy   bool
+    Points []UprobeArgs
+    // 延迟 hook 的点挂载后整体替换 Points 已经取出的旧切片不会再被修改
+    points_lock sync.RWMutex
 }
 
 func ParseStrAsNum(v string) (uint64, error) {
@@ -114,9 +119,10 @@ func ParseArgType(arg_str string) (ArgType, error) {
     }
     if arg_index != "" {
         read_offset := ""
-        if len(arg_index) > 2 {
-            read_offset = arg_index[2:]
-            arg_index = arg_index[:2]
+        // 寄存器名之后是偏移 寄存器名不一定是两个字符 比如 x12 rdi
+        if i := strings.IndexAny(arg_index, "+-"); i > 0 {
+            read_offset = arg_index[i:]
+            arg_index = arg_index[:i]
         }
         read_index, err := ParseAsReg(arg_index)
         if err != nil {
@@ -183,26 +189,71 @@ func ParseArgType(arg_str string) (ArgType, error) {
     return arg_type, err
 }
 
+func (this *StackUprobeConfig) GetPoints() []UprobeArgs {
+    this.points_lock.RLock()
+    defer this.points_lock.RUnlock()
+    return this.Points
+}
+
+func (this *StackUprobeConfig) GetPoint(index uint32) (*UprobeArgs, error) {
+    points := this.GetPoints()
+    if index >= uint32(len(points)) {
+        return nil, fmt.Errorf("probe_index %d bigger than points", index)
+    }
+    return &points[index], nil
+}
+
+// 复制一份再修改 解析事件的一方持有的旧切片保持不变
+func (this *StackUprobeConfig) UpdatePoint(index int, point UprobeArgs) {
+    this.points_lock.Lock()
+    defer this.points_lock.Unlock()
+    points := make([]UprobeArgs, len(this.Points))
+    copy(points, this.Points)
+    points[index] = point
+    this.Points = points
+}
+
 func (this *StackUprobeConfig) IsEnable() bool {
-    return len(this.Points) > 0
+    return len(this.GetPoints()) > 0
 }
 
-func (this *StackUprobeConfig) ParseConfig(configs []string) (err error) {
+func (this *StackUprobeConfig) ParseConfig(configs []string, library_dirs []string) (err error) {
     // strstr+0x0[str,str] 命中 strstr + 0x0 时将x0和x1读取为字符串
     // write[int,buf:128,int] 命中 write 时将x0读取为int、x1读取为字节数组、x2读取为int
     // 0x89ab[buf:64,int] 命中hook点时读取 x0 处64字节数据 读取 x1 值
     // 0x89ab[buf:64:sp+0x20-0x8] 命中hook点时读取 sp+0x20-0x8 处64字节数据
     // 0x89ab[buf:x1:sp+0x20-0x8] 命中hook点时读取 sp+0x20-0x8 处x1寄存器大小字节数据
-    for point_index, config_str := range configs {
-        reg := regexp.MustCompile(`(\w+)(\+0x[[:xdigit:]]+)?(\[.+?\])?`)
+    // libnative-lib.so:_Z7decryptPKc[str] 指定 hook 点所在的库 不指定则为 --lib 设置的库
+    // decrypt[ptr,out:buf:64,int]:ret 函数返回时读取 out: 标记的参数以及 x0 和进入时的参数合并输出
+    // Java_com_foo_*[ptr,ptr] 或 /.*[Cc]rypt.*/[str] 按 glob 或正则匹配库中的函数符号 展开为多个 hook 点
+    for _, config_str := range configs {
+        ret_mode := false
+        if strings.HasSuffix(config_str, ":ret") && len(config_str) > len(":ret") {
+            ret_mode = true
+            config_str = strings.TrimSuffix(config_str, ":ret")
+        }
+        reg := regexp.MustCompile(`^(?:([^:\[]+):)?(/[^/]+/|[\w*?]+)(\+0x[[:xdigit:]]+)?(\[.+?\])?`)
         match := reg.FindStringSubmatch(config_str)
 
         if len(match) > 0 {
             hook_point := UprobeArgs{}
-            hook_point.Index = uint32(point_index)
+            hook_point.RetMode = ret_mode
             hook_point.Offset = 0x0
             hook_point.LibPath = this.LibPath
-            sym_or_off := match[1]
+            if match[1] != "" {
+                // 每个库单独按 --lib 的规则查找
+                hook_point.LibPath, err = util.FindLib(match[1], library_dirs)
+                if err != nil && !this.Lazy {
+                    return err
+                }
+            }
+            if this.Lazy {
+                // 找不到的库等到 mmap 时再挂载
+                if _, err := os.Stat(hook_point.LibPath); err != nil {
+                    hook_point.Deferred = true
+                }
+            }
+            sym_or_off := match[2]
             hook_point.PointName = sym_or_off
             if strings.HasPrefix(sym_or_off, "0x") {
                 offset, err := strconv.ParseUint(strings.TrimPrefix(sym_or_off, "0x"), 16, 64)
@@ -214,7 +265,7 @@ func (this *StackUprobeConfig) ParseConfig(configs []string) (err error) {
             } else {
                 hook_point.Symbol = sym_or_off
             }
-            off := match[2]
+            off := match[3]
             if off != "" {
                 if strings.HasPrefix(off, "+0x") {
                     offset, err := strconv.ParseUint(strings.TrimPrefix(off, "+0x"), 16, 64)
@@ -224,12 +275,19 @@ func (this *StackUprobeConfig) ParseConfig(configs []string) (err error) {
                     hook_point.Offset = offset
                 }
             }
-            if match[3] != "" {
-                hook_point.ArgsStr = match[3][1 : len(match[3])-1]
+            if match[4] != "" {
+                hook_point.ArgsStr = match[4][1 : len(match[4])-1]
                 args := strings.Split(hook_point.ArgsStr, ",")
                 for arg_index, arg_str := range args {
                     arg_name := fmt.Sprintf("arg_%d", arg_index)
                     arg := PointArg{arg_name, UPROBE_ENTER_READ, INT, "???"}
+                    if strings.HasPrefix(arg_str, "out:") {
+                        if !ret_mode {
+                            return errors.New(fmt.Sprintf("out arg %s need :ret, point:%s", arg_str, config_str))
+                        }
+                        arg.ReadFlag = UPROBE_EXIT_READ
+                        arg_str = strings.TrimPrefix(arg_str, "out:")
+                    }
                     arg_type, err := ParseArgType(arg_str)
                     if err != nil {
                         return err
@@ -238,11 +296,36 @@ func (this *StackUprobeConfig) ParseConfig(configs []string) (err error) {
                     hook_point.Args = append(hook_point.Args, arg)
                 }
             }
-            this.Points = append(this.Points, hook_point)
+            if !util.IsSymbolPattern(hook_point.Symbol) {
+                hook_point.Index = uint32(len(this.Points))
+                this.Points = append(this.Points, hook_point)
+                continue
+            }
+            if hook_point.Deferred {
+                return errors.New(fmt.Sprintf("symbol pattern %s needs %s exists before start", hook_point.Symbol, hook_point.LibPath))
+            }
+            // 每个匹配到的符号作为单独的 hook 点 输出时以实际的符号命名
+            syms, err := util.MatchFuncSymbols(hook_point.LibPath, hook_point.Symbol)
+            if err != nil {
+                return err
+            }
+            if len(syms) == 0 {
+                return errors.New(fmt.Sprintf("no func symbol in %s matches %s", hook_point.LibPath, hook_point.Symbol))
+            }
+            for _, sym := range syms {
+                sym_point := hook_point
+                sym_point.Index = uint32(len(this.Points))
+                sym_point.Symbol = sym.Name
+                sym_point.PointName = sym.Name
+                this.Points = append(this.Points, sym_point)
+            }
         } else {
             return errors.New(fmt.Sprintf("parse for %s failed", config_str))
         }
     }
+    if len(this.Points) > MAX_UPROBE_POINT_COUNT {
+        return errors.New(fmt.Sprintf("max uprobe hook point count is %d, got %d after expanding symbol patterns", MAX_UPROBE_POINT_COUNT, len(this.Points)))
+    }
     return nil
 }
 
@@ -261,7 +344,7 @@ func (this *StackUprobeConfig) Check() error {
         return fmt.Errorf("need hook point count is 0 :(")
     }
     _, err := os.Stat(this.LibPath)
-    if err != nil {
+    if err != nil && !this.Lazy {
         return err
     }
     parts := strings.Split(this.LibPath, "/")
@@ -275,6 +358,7 @@ type SyscallConfig struct {
     Enable            bool
     syscall_whitelist []uint32
     syscall_blacklist []uint32
+    ArgFilters        []*ArgFilter
 }
 
 func NewSyscallConfig() *SyscallConfig {
@@ -285,7 +369,6 @@ func NewSyscallConfig() *SyscallConfig {
 
 func (this *SyscallConfig) GetSyscallFilter() SyscallFilter {
     filter := SyscallFilter{}
-    filter.SetArch(this.Is32Bit)
     filter.SetHookALL(this.HookALL)
     filter.SetWhitelistMode(len(this.syscall_whitelist) > 0)
     filter.SetBlacklistMode(len(this.syscall_blacklist) > 0)
@@ -314,16 +397,18 @@ func (this *SyscallConfig) UpdateBlackList(blacklist *ebpf.Map) error {
 
 func (this *SyscallConfig) UpdatePointArgsMap(SyscallPointArgsMap *ebpf.Map) error {
     // 取 syscall 参数配置 syscall_point_args_map
-    points := GetAllWatchPoints()
-    for nr_name, point := range points {
-        nr_point, ok := (point).(*SysCallArgs)
-        if !ok {
-            panic(fmt.Sprintf("cast [%s] point to SysCallArgs failed", nr_name))
-        }
-        // 这里可以改成只更新追踪的syscall以加快速度
-        err := SyscallPointArgsMap.Update(unsafe.Pointer(&nr_point.NR), unsafe.Pointer(nr_point.GetConfig()), ebpf.UpdateAny)
-        if err != nil {
-            return err
+    // 32 位进程的调用号带有 COMPAT_NR_FLAG 所以可以放在同一个 map 中
+    for _, points := range []map[string]IWatchPoint{GetAllWatchPoints(), GetAllCompatWatchPoints()} {
+        for nr_name, point := range points {
+            nr_point, ok := (point).(*SysCallArgs)
+            if !ok {
+                panic(fmt.Sprintf("cast [%s] point to SysCallArgs failed", nr_name))
+            }
+            // 这里可以改成只更新追踪的syscall以加快速度
+            err := SyscallPointArgsMap.Update(unsafe.Pointer(&nr_point.NR), unsafe.Pointer(nr_point.GetConfig()), ebpf.UpdateAny)
+            if err != nil {
+                return err
+            }
         }
     }
     if this.Debug {
@@ -349,12 +434,14 @@ func (this *SyscallConfig) SetSysCall(syscall string) error {
         return fmt.Errorf("max syscall whitelist count is %d, provided count:%d", MAX_COUNT, len(items))
     }
     for _, v := range items {
-        point := GetWatchPointByName(v)
-        nr_point, ok := (point).(*SysCallArgs)
-        if !ok {
+        // 同名的 64 位和 32 位 syscall 都加入 这样不用关心目标进程是多少位的
+        nr_points := GetSysCallPointsByName(v)
+        if len(nr_points) == 0 {
             return errors.New(fmt.Sprintf("cast [%s] watchpoint to SysCallArgs failed", v))
         }
-        this.syscall_whitelist = append(this.syscall_whitelist, uint32(nr_point.NR))
+        for _, nr_point := range nr_points {
+            this.syscall_whitelist = append(this.syscall_whitelist, uint32(nr_point.NR))
+        }
     }
     return nil
 }
@@ -365,16 +452,34 @@ func (this *SyscallConfig) SetSysCallBlacklist(syscall_blacklist string) error {
         return fmt.Errorf("max syscall blacklist count is %d, provided count:%d", MAX_COUNT, len(items))
     }
     for _, v := range items {
-        point := GetWatchPointByName(v)
-        nr_point, ok := (point).(*SysCallArgs)
-        if !ok {
+        nr_points := GetSysCallPointsByName(v)
+        if len(nr_points) == 0 {
             panic(fmt.Sprintf("cast [%s] watchpoint to SysCallArgs failed", v))
         }
-        this.syscall_blacklist = append(this.syscall_blacklist, uint32(nr_point.NR))
+        for _, nr_point := range nr_points {
+            this.syscall_blacklist = append(this.syscall_blacklist, uint32(nr_point.NR))
+        }
     }
     return nil
 }
 
+func (this *SyscallConfig) IsTraced(nr uint32) bool {
+    for _, v := range this.syscall_blacklist {
+        if v == nr {
+            return false
+        }
+    }
+    if this.HookALL {
+        return true
+    }
+    for _, v := range this.syscall_whitelist {
+        if v == nr {
+            return true
+        }
+    }
+    return false
+}
+
 func (this *SyscallConfig) IsEnable() bool {
     return this.Enable
 }
@@ -386,12 +491,18 @@ func (this *SyscallConfig) Check() error {
 
 func (this *SyscallConfig) Info() string {
     var watchlist []string
+    names := make(map[string]bool)
     for _, v := range this.syscall_whitelist {
         point := GetWatchPointByNR(v)
         nr_point, ok := (point).(*SysCallArgs)
         if !ok {
             panic(fmt.Sprintf("cast [%d] watchpoint to SysCallArgs failed", v))
         }
+        // 64 位和 32 位的同名 syscall 只显示一次
+        if names[nr_point.Name()] {
+            continue
+        }
+        names[nr_point.Name()] = true
         watchlist = append(watchlist, nr_point.Name())
     }
     return fmt.Sprintf("watch:%s", strings.Join(watchlist, ","))
@@ -408,6 +519,7 @@ type ModuleConfig struct {
     Name              string
     StackUprobeConf   StackUprobeConfig
     SysCallConf       SyscallConfig
+    RevRules          []*RevRule `json:"-"`
 }
 
 func NewModuleConfig() *ModuleConfig {
@@ -456,6 +568,24 @@ func (this *ModuleConfig) SetTidsBlacklist(tids_blacklist string) error {
     return nil
 }
 
+func (this *ModuleConfig) SetTids(tids string) error {
+    if tids == "" {
+        return nil
+    }
+    items := strings.Split(tids, ",")
+    if len(this.Tids)+len(items) > MAX_WATCH_PROC_COUNT {
+        return fmt.Errorf("max tid whitelist count is %d, provided count:%d", MAX_WATCH_PROC_COUNT, len(this.Tids)+len(items))
+    }
+    for _, v := range items {
+        value, err := strconv.ParseUint(v, 10, 32)
+        if err != nil {
+            return fmt.Errorf("parse tid %s failed, err:%v", v, err)
+        }
+        this.Tids = append(this.Tids, uint32(value))
+    }
+    return nil
+}
+
 func (this *ModuleConfig) SetPidsBlacklist(pids_blacklist string) error {
     if pids_blacklist == "" {
         return nil
@@ -499,32 +629,28 @@ func (this *ModuleConfig) SetTNamesWhitelist(t_names_blacklist string) error {
     return nil
 }
 
-func (this *ModuleConfig) UpdateRevFilter(rev_filter *ebpf.Map) (err error) {
+func (this *ModuleConfig) UpdateRevFilter(rev_filter *ebpf.Map, rev_action *ebpf.Map) (err error) {
     // ./stackplz -n com.starbucks.cn --iso -s newfstatat,openat,faccessat --hide-root -o tmp.log -q
-    var rev_list []string = []string{
-        "/sbin/su",
-        "/sbin/.magisk/",
-        "/dev/.magisk",
-        "/system/bin/magisk",
-        "/system/bin/su",
-        "/system/xbin/su",
-        // "ro.debuggable",
-        "/proc/mounts",
-        "which su",
-        "mount",
-    }
-
-    for _, v := range rev_list {
-        if len(v) > 32 {
-            panic(fmt.Sprintf("[%s] rev string max len is 32", v))
-        }
-        key_value := 1
+    // 规则序号作为 rev_action 的索引
+    for i, rule := range this.RevRules {
+        if rule.bpf_key == "" {
+            continue
+        }
+        index := uint32(i)
+        action := rule.GetAction()
+        err = rev_action.Update(unsafe.Pointer(&index), unsafe.Pointer(&action), ebpf.UpdateAny)
+        if err != nil {
+            return err
+        }
         filter := RevFilter{}
-        copy(filter.RevString[:], v)
-        err = rev_filter.Update(unsafe.Pointer(&filter), unsafe.Pointer(&key_value), ebpf.UpdateAny)
+        copy(filter.RevString[:], rule.bpf_key)
+        err = rev_filter.Update(unsafe.Pointer(&filter), unsafe.Pointer(&index), ebpf.UpdateAny)
         if err != nil {
             return err
         }
+        if this.Debug {
+            this.logger.Printf("update rev rule[%d] %s", i, rule.String())
+        }
     }
     return err
 }
@@ -581,22 +707,42 @@ func (this *ModuleConfig) UpdateThreadFilter(thread_filter *ebpf.Map) (err error
     }
     return err
 }
+// uid pid tid 的集合直接作为 map 的 key
+func (this *ModuleConfig) UpdateWatchFilter(watch_uid_map, watch_proc_map, watch_tid_map *ebpf.Map) (err error) {
+    var flag uint32 = 1
+    for _, uid := range this.Uids {
+        err = watch_uid_map.Update(unsafe.Pointer(&uid), unsafe.Pointer(&flag), ebpf.UpdateAny)
+        if err != nil {
+            return err
+        }
+    }
+    for _, pid := range this.Pids {
+        err = watch_proc_map.Update(unsafe.Pointer(&pid), unsafe.Pointer(&flag), ebpf.UpdateAny)
+        if err != nil {
+            return err
+        }
+    }
+    for _, tid := range this.Tids {
+        err = watch_tid_map.Update(unsafe.Pointer(&tid), unsafe.Pointer(&flag), ebpf.UpdateAny)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
 func (this *ModuleConfig) GetCommonFilter() unsafe.Pointer {
     filter := CommonFilter{}
-    filter.is_32bit = 0
-    filter.uid = this.Uid
-    filter.pid = this.Pid
-    filter.tid = this.Tid
-    // 这些暂时硬编码
-    for i := 0; i < MAX_COUNT; i++ {
-        filter.pid_list[i] = MAGIC_PID
-    }
     for i := 0; i < MAX_COUNT; i++ {
         filter.blacklist_pids[i] = this.PidsBlacklist[i]
     }
     for i := 0; i < MAX_COUNT; i++ {
         filter.blacklist_tids[i] = this.TidsBlacklist[i]
     }
+    filter.tid_whitelist = 0
+    if len(this.Tids) > 0 {
+        filter.tid_whitelist = 1
+    }
     filter.thread_name_whitelist = 0
     if len(this.TNamesWhitelist) > 0 {
         filter.thread_name_whitelist = 1
@@ -607,7 +753,7 @@ func (this *ModuleConfig) GetCommonFilter() unsafe.Pointer {
     }
     filter.signal = this.UprobeSignal
     if this.Debug {
-        this.logger.Printf("CommonFilter{uid=%d, pid=%d, tid=%d, is_32bit=%d, whitelist:%d}", filter.uid, filter.pid, filter.tid, filter.is_32bit, filter.thread_name_whitelist)
+        this.logger.Printf("CommonFilter{uids=%v, pids=%v, tids=%v, whitelist:%d}", this.Uids, this.Pids, this.Tids, filter.thread_name_whitelist)
     }
     return unsafe.Pointer(&filter)
 }
@@ -616,6 +762,9 @@ func (this *ModuleConfig) GetConfigMap() ConfigMap {
     config := ConfigMap{}
     config.stackplz_pid = this.SelfPid
     config.filter_mode = this.FilterMode
+    if this.UseRingBuf() {
+        config.use_ringbuf = 1
+    }
     if this.Debug {
         this.logger.Printf("ConfigMap{stackplz_pid=%d}", config.stackplz_pid)
     }
@@ -0,0 +1,400 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"stackplz/user/util"
+	"strings"
+	"syscall"
+)
+
+// --hide-root 以及 --rules 的规则 格式为 json 数组
+// [
+//   {"match": "prefix", "pattern": "/sbin/su", "action": "errno", "errno": "ENOENT"},
+//   {"match": "glob", "pattern": "/data/local/tmp/*frida*", "action": "log"},
+//   {"match": "string", "syscall": "execve", "arg": "filename", "pattern": "/system/bin/su", "action": "rewrite", "target": "/system/bin/sh"}
+// ]
+// 前缀和完整匹配在 eBPF 中完成 glob 只有结尾为 * 时可以转换为前缀 其他的只能用于 log
+// rev_filter 只以字符串为 key 下发到 eBPF 的 pattern 即使 syscall 不同也不能重复
+
+const MAX_REV_RULE_COUNT = 40
+const MAX_REV_STR_LEN = 32
+const REV_ANY_NR = 0xffffffff
+const REV_ANY_ARG = 0xff
+
+// 规则指定的 syscall 在 64 位或 32 位中不存在时 对应的调用号不会命中任何 syscall
+const REV_NO_NR = 0xfffffffe
+
+const (
+	REV_MATCH_PREFIX uint32 = iota + 1
+	REV_MATCH_EXACT
+)
+
+const (
+	REV_ACTION_LOG uint32 = iota + 1
+	REV_ACTION_ERRNO
+	REV_ACTION_REWRITE
+)
+
+type RevAction struct {
+	action           uint32
+	err_no           uint32
+	match_mode       uint32
+	nr               uint32
+	arg_index        uint32
+	compat_nr        uint32
+	compat_arg_index uint32
+	target_len       uint32
+	target           [MAX_REV_STR_LEN]byte
+}
+
+type RevRule struct {
+	Match   string `json:"match"`
+	Pattern string `json:"pattern"`
+	SysCall string `json:"syscall,omitempty"`
+	Arg     string `json:"arg,omitempty"`
+	Action  string `json:"action"`
+	Errno   string `json:"errno,omitempty"`
+	Target  string `json:"target,omitempty"`
+
+	nr        uint32
+	arg_index uint32
+	// 32 位进程的调用号带有 COMPAT_NR_FLAG 参数位置也可能不同
+	compat_nr        uint32
+	compat_arg_index uint32
+	err_no           syscall.Errno
+	// 没有同名字符串参数而不生效的 64 位或 32 位 syscall
+	skipped []string
+	// eBPF 中用于查找的字符串 为空表示只在前端处理
+	bpf_key    string
+	match_mode uint32
+}
+
+// 原先写死的 rev_list 全部按前缀匹配
+func DefaultRevRules() []*RevRule {
+	var rules []*RevRule
+	for _, path := range []string{
+		"/sbin/su",
+		"/sbin/.magisk/",
+		"/dev/.magisk",
+		"/system/bin/magisk",
+		"/system/bin/su",
+		"/system/xbin/su",
+		"/proc/mounts",
+		"which su",
+		"mount",
+	} {
+		rules = append(rules, &RevRule{Match: "prefix", Pattern: path, Action: "errno", Errno: "ENOENT"})
+	}
+	return rules
+}
+
+func LoadRevRules(path string) ([]*RevRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []*RevRule
+	if err = json.Unmarshal(data, &rules); err != nil {
+		return nil, errors.New(fmt.Sprintf("parse rules file %s failed, err:%v", path, err))
+	}
+	return rules, nil
+}
+
+func (this *RevRule) String() string {
+	var scope string
+	if this.SysCall != "" {
+		scope = this.SysCall
+		if this.Arg != "" {
+			scope += "." + this.Arg
+		}
+		scope += " "
+	}
+	switch this.Action {
+	case "errno":
+		return fmt.Sprintf("%s%s %s => %s", scope, this.Match, this.Pattern, this.Errno)
+	case "rewrite":
+		return fmt.Sprintf("%s%s %s => %s", scope, this.Match, this.Pattern, this.Target)
+	}
+	return fmt.Sprintf("%s%s %s", scope, this.Match, this.Pattern)
+}
+
+// 输出在参数后面的标记
+func (this *RevRule) Mark() string {
+	switch this.Action {
+	case "errno":
+		return "rev:" + this.Errno
+	case "rewrite":
+		return "rev:" + this.Target
+	}
+	return "rev:log"
+}
+
+func (this *RevRule) Check() error {
+	if this.Pattern == "" {
+		return errors.New("empty pattern in rule")
+	}
+	this.nr = REV_ANY_NR
+	this.arg_index = REV_ANY_ARG
+	this.compat_nr = REV_ANY_NR
+	this.compat_arg_index = REV_ANY_ARG
+	if this.SysCall != "" {
+		nr_points := GetSysCallPointsByName(this.SysCall)
+		if len(nr_points) == 0 {
+			return errors.New(fmt.Sprintf("unknown syscall %s in rule %s", this.SysCall, this.String()))
+		}
+		this.nr = REV_NO_NR
+		this.compat_nr = REV_NO_NR
+		this.skipped = nil
+		for _, nr_point := range nr_points {
+			arg_index := uint32(REV_ANY_ARG)
+			if this.Arg != "" {
+				index := nr_point.GetArgIndex(this.Arg)
+				if index < 0 || nr_point.Args[index].AliasType != TYPE_STRING {
+					this.skipped = append(this.skipped, revNRName(nr_point))
+					continue
+				}
+				arg_index = uint32(index)
+			}
+			if nr_point.NR&COMPAT_NR_FLAG != 0 {
+				this.compat_nr = nr_point.NR
+				this.compat_arg_index = arg_index
+			} else {
+				this.nr = nr_point.NR
+				this.arg_index = arg_index
+			}
+		}
+		if this.nr == REV_NO_NR && this.compat_nr == REV_NO_NR {
+			return errors.New(fmt.Sprintf("syscall %s has no string arg named %s", this.SysCall, this.Arg))
+		}
+	} else if this.Arg != "" {
+		return errors.New(fmt.Sprintf("arg %s need a syscall, rule %s", this.Arg, this.String()))
+	}
+
+	switch this.Action {
+	case "log":
+	case "errno":
+		errno, ok := GetErrnoByName(this.Errno)
+		if !ok {
+			return errors.New(fmt.Sprintf("invalid errno %s in rule %s", this.Errno, this.String()))
+		}
+		this.err_no = errno
+	case "rewrite":
+		if this.Target == "" || len(this.Target) >= MAX_REV_STR_LEN {
+			return errors.New(fmt.Sprintf("rewrite target max len is %d, rule %s", MAX_REV_STR_LEN-1, this.String()))
+		}
+	default:
+		return errors.New(fmt.Sprintf("unknown action %s, plz use errno, rewrite or log", this.Action))
+	}
+
+	switch this.Match {
+	case "prefix":
+		this.bpf_key = this.Pattern
+		this.match_mode = REV_MATCH_PREFIX
+	case "string":
+		this.bpf_key = this.Pattern
+		this.match_mode = REV_MATCH_EXACT
+	case "glob":
+		if _, err := filepath.Match(this.Pattern, ""); err != nil {
+			return errors.New(fmt.Sprintf("invalid glob %s, err:%v", this.Pattern, err))
+		}
+		index := strings.IndexAny(this.Pattern, "*?[\\")
+		if index < 0 {
+			this.bpf_key = this.Pattern
+			this.match_mode = REV_MATCH_EXACT
+		} else if index == len(this.Pattern)-1 && this.Pattern[index] == '*' {
+			// 只有结尾的 * 等价于前缀匹配
+			this.bpf_key = this.Pattern[:index]
+			this.match_mode = REV_MATCH_PREFIX
+		} else if this.Action != "log" {
+			return errors.New(fmt.Sprintf("glob %s is too complex for action %s, only trailing * is supported", this.Pattern, this.Action))
+		}
+	default:
+		return errors.New(fmt.Sprintf("unknown match %s, plz use prefix, glob or string", this.Match))
+	}
+	if this.bpf_key == "" && this.Action != "log" {
+		return errors.New(fmt.Sprintf("pattern %s can not be used for action %s", this.Pattern, this.Action))
+	}
+	if len(this.bpf_key) >= MAX_REV_STR_LEN {
+		return errors.New(fmt.Sprintf("pattern max len is %d, rule %s", MAX_REV_STR_LEN-1, this.String()))
+	}
+	// 只记录的规则不需要下发
+	if this.Action == "log" {
+		this.bpf_key = ""
+	}
+	return nil
+}
+
+func (this *RevRule) GetAction() RevAction {
+	action := RevAction{
+		err_no:     uint32(this.err_no),
+		match_mode: this.match_mode,
+		nr:         this.nr,
+		arg_index:  this.arg_index,
+		compat_nr:  this.compat_nr,
+		// 与 64 位的 syscall 同名时 参数位置可能不同
+		compat_arg_index: this.compat_arg_index,
+	}
+	switch this.Action {
+	case "errno":
+		action.action = REV_ACTION_ERRNO
+	case "rewrite":
+		action.action = REV_ACTION_REWRITE
+		action.target_len = uint32(len(this.Target))
+		copy(action.target[:], this.Target)
+	default:
+		action.action = REV_ACTION_LOG
+	}
+	return action
+}
+
+// nr 为事件中的调用号 32 位进程的带有 COMPAT_NR_FLAG
+func (this *RevRule) MatchArg(nr uint32, arg_index int, value string) bool {
+	want_arg := this.arg_index
+	if this.nr != REV_ANY_NR {
+		if nr == this.compat_nr {
+			want_arg = this.compat_arg_index
+		} else if nr != this.nr {
+			return false
+		}
+	}
+	if want_arg != REV_ANY_ARG && want_arg != uint32(arg_index) {
+		return false
+	}
+	switch this.Match {
+	case "prefix":
+		return strings.HasPrefix(value, this.Pattern)
+	case "string":
+		return value == this.Pattern
+	case "glob":
+		matched, _ := filepath.Match(this.Pattern, value)
+		return matched
+	}
+	return false
+}
+
+func (this *ModuleConfig) SetRevRules(rules_path string) error {
+	var rules []*RevRule
+	var err error
+	if rules_path != "" {
+		rules, err = LoadRevRules(rules_path)
+		if err != nil {
+			return err
+		}
+	} else if this.HideRoot {
+		rules = DefaultRevRules()
+	}
+	if len(rules) > MAX_REV_RULE_COUNT {
+		return errors.New(fmt.Sprintf("max rule count is %d, provided count:%d", MAX_REV_RULE_COUNT, len(rules)))
+	}
+	keys := make(map[string]bool)
+	for _, rule := range rules {
+		if err = rule.Check(); err != nil {
+			return err
+		}
+		for _, name := range rule.skipped {
+			this.logger.Printf("rule %s does not apply to %s, it has no string arg named %s", rule.String(), name, rule.Arg)
+		}
+		if rule.bpf_key == "" {
+			continue
+		}
+		// rev_filter 以字符串为 key 不能重复
+		if keys[rule.bpf_key] {
+			return errors.New(fmt.Sprintf("duplicate pattern %s in rules, patterns must be unique even for different syscalls", rule.bpf_key))
+		}
+		keys[rule.bpf_key] = true
+	}
+	this.RevRules = rules
+	return nil
+}
+
+func revNRName(nr_point *SysCallArgs) string {
+	if nr_point.NR&COMPAT_NR_FLAG != 0 {
+		return "32-bit " + nr_point.PointName
+	}
+	return "64-bit " + nr_point.PointName
+}
+
+// 返回第一条命中的规则
+func (this *ModuleConfig) MatchRevRule(nr uint32, arg_index int, value string) *RevRule {
+	for _, rule := range this.RevRules {
+		if rule.MatchArg(nr, arg_index, value) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// 内核不支持 bpf_override_return 时 errno 规则只能记录 返回降级的规则数量
+func (this *ModuleConfig) DegradeRevErrnoRules() int {
+	count := 0
+	for _, rule := range this.RevRules {
+		if rule.Action != "errno" {
+			continue
+		}
+		rule.Action = "log"
+		rule.Errno = ""
+		rule.bpf_key = ""
+		count++
+	}
+	return count
+}
+
+// syscall 在内核中的入口函数 32 位进程的 syscall 有 compat 实现时挂载到 compat 的入口
+func revErrnoFuncName(nr_point *SysCallArgs) string {
+	arch := GetArch()
+	if nr_point.NR&COMPAT_NR_FLAG != 0 && arch.CompatSysCallPrefix != "" {
+		name := arch.CompatSysCallPrefix + nr_point.PointName
+		if util.HasKallsymsSymbol(name) {
+			return name
+		}
+	}
+	return arch.SysCallPrefix + nr_point.PointName
+}
+
+// 需要修改返回值的 syscall 对应的 kprobe 挂载点 即内核中的函数名
+func (this *ModuleConfig) GetRevErrnoFuncs() []string {
+	names := make(map[string]bool)
+	var results []string
+	add := func(nr_point *SysCallArgs) {
+		name := revErrnoFuncName(nr_point)
+		if !names[name] {
+			names[name] = true
+			results = append(results, name)
+		}
+	}
+	for _, rule := range this.RevRules {
+		if rule.Action != "errno" {
+			continue
+		}
+		if rule.SysCall != "" {
+			for _, nr_point := range GetSysCallPointsByName(rule.SysCall) {
+				if nr_point.NR == rule.nr || nr_point.NR == rule.compat_nr {
+					add(nr_point)
+				}
+			}
+			continue
+		}
+		// 没有指定 syscall 的 对所有追踪的带字符串参数的 syscall 生效
+		for _, points := range []map[string]IWatchPoint{GetAllWatchPoints(), GetAllCompatWatchPoints()} {
+			for _, point := range points {
+				nr_point, ok := (point).(*SysCallArgs)
+				if !ok || !this.SysCallConf.IsTraced(nr_point.NR) {
+					continue
+				}
+				for _, point_arg := range nr_point.Args {
+					if point_arg.AliasType == TYPE_STRING {
+						add(nr_point)
+						break
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(results)
+	return results
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultRevRules(t *testing.T) {
+	conf := NewModuleConfig()
+	conf.HideRoot = true
+	if err := conf.SetRevRules(""); err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{"/proc/mounts", "which su", "mount"} {
+		rule := conf.MatchRevRule(56, 1, pattern)
+		if rule == nil || rule.Action != "errno" {
+			t.Errorf("default rules should return errno for %s, got %v", pattern, rule)
+		}
+	}
+	if count := conf.DegradeRevErrnoRules(); count != len(conf.RevRules) {
+		t.Errorf("degraded %d rules, want %d", count, len(conf.RevRules))
+	}
+	for _, rule := range conf.RevRules {
+		if rule.Action != "log" || rule.GetAction().action != REV_ACTION_LOG {
+			t.Errorf("rule %s not degraded to log", rule.String())
+		}
+	}
+}
+
+func TestRevRuleCompat(t *testing.T) {
+	defer SetArch(GetArch().Name)
+	if err := SetArch(ARCH_ARM64); err != nil {
+		t.Fatal(err)
+	}
+	rule := &RevRule{Match: "prefix", SysCall: "openat", Arg: "pathname", Pattern: "/system/bin/su", Action: "errno", Errno: "ENOENT"}
+	if err := rule.Check(); err != nil {
+		t.Fatal(err)
+	}
+	action := rule.GetAction()
+	if action.nr != 56 || action.arg_index != 1 {
+		t.Errorf("nr %d arg %d, want 56 1", action.nr, action.arg_index)
+	}
+	if action.compat_nr != 322|COMPAT_NR_FLAG || action.compat_arg_index != 1 {
+		t.Errorf("compat nr 0x%x arg %d, want 0x%x 1", action.compat_nr, action.compat_arg_index, 322|COMPAT_NR_FLAG)
+	}
+	tests := []struct {
+		nr    uint32
+		index int
+		value string
+		want  bool
+	}{
+		{56, 1, "/system/bin/su", true},
+		{322 | COMPAT_NR_FLAG, 1, "/system/bin/su", true},
+		{322 | COMPAT_NR_FLAG, 0, "/system/bin/su", false},
+		{48, 1, "/system/bin/su", false},
+		{56, 1, "/system/bin/sh", false},
+	}
+	for _, tt := range tests {
+		if got := rule.MatchArg(tt.nr, tt.index, tt.value); got != tt.want {
+			t.Errorf("MatchArg(0x%x, %d, %s) = %v, want %v", tt.nr, tt.index, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRevRulesUniquePattern(t *testing.T) {
+	defer SetArch(GetArch().Name)
+	if err := SetArch(ARCH_ARM64); err != nil {
+		t.Fatal(err)
+	}
+	rules_path := filepath.Join(t.TempDir(), "rules.json")
+	// rev_filter 只以字符串为 key 不同 syscall 的同一 pattern 也会冲突
+	data := `[
+		{"match": "string", "syscall": "openat", "arg": "pathname", "pattern": "/system/bin/su", "action": "errno", "errno": "ENOENT"},
+		{"match": "string", "syscall": "faccessat", "arg": "pathname", "pattern": "/system/bin/su", "action": "errno", "errno": "EACCES"}
+	]`
+	if err := os.WriteFile(rules_path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf := NewModuleConfig()
+	err := conf.SetRevRules(rules_path)
+	if err == nil || !strings.Contains(err.Error(), "duplicate pattern /system/bin/su") {
+		t.Fatalf("err = %v, want duplicate pattern", err)
+	}
+}
@@ -86,6 +86,33 @@ type TimeZone_t struct {
 	Tz_dsttime     int32
 }
 
+// 下面两个结构体的布局和架构有关 不直接用 syscall 包中的定义
+// 这样在 x86_64 上编译的 stackplz 也能正确回放 arm64 的 trace 文件
+type Stat_t struct {
+	Dev               uint64
+	Ino               uint64
+	Mode              uint32
+	Nlink             uint32
+	Uid               uint32
+	Gid               uint32
+	Rdev              uint64
+	X__pad1           uint64
+	Size              int64
+	Blksize           int32
+	X__pad2           int32
+	Blocks            int64
+	Atim              syscall.Timespec
+	Mtim              syscall.Timespec
+	Ctim              syscall.Timespec
+	X__glibc_reserved [2]int32
+}
+type EpollEvent struct {
+	Events uint32
+	_      int32
+	Fd     int32
+	Pad    int32
+}
+
 // GO 中结构体这个 padding 用 unsafe.Sizeof 会直接给你算上
 // 用 binary.Size 则直接就是对应的大小
 // 如果用 unsafe.Sizeof 这个大小去解析对应的二进制
@@ -149,6 +176,22 @@ const (
 	TYPE_TIMEZONE
 	TYPE_PTHREAD_ATTR
 	TYPE_BUFFER_T
+	TYPE_PIPEFD
+	// 以下是 arm32 下布局不同的结构体
+	TYPE_TIMESPEC32
+	TYPE_TIMEVAL32
+	TYPE_STAT64
+	TYPE_STATFS32
+	TYPE_STATFS64
+	TYPE_SIGACTION32
+	TYPE_RUSAGE32
+	TYPE_SYSINFO32
+	TYPE_MSGHDR32
+	TYPE_ITIMERSPEC32
+	TYPE_STACK_T32
+	// 以下是 x86_64 下布局不同的结构体
+	TYPE_STAT_X86_64
+	TYPE_EPOLLEVENT_X86_64
 )
 
 func A(arg_name string, arg_type ArgType) PArg {
@@ -168,14 +211,14 @@ var STRING = AT(TYPE_STRING, TYPE_STRING, uint32(unsafe.Sizeof(uint64(0))))
 var STRING_ARR = AT(TYPE_STRING_ARR, TYPE_STRING_ARR, uint32(unsafe.Sizeof(uint64(0))))
 var POINTER = AT(TYPE_POINTER, TYPE_POINTER, uint32(unsafe.Sizeof(uint64(0))))
 var TIMESPEC = AT(TYPE_TIMESPEC, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.Timespec{})))
-var STAT = AT(TYPE_STAT, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.Stat_t{})))
+var STAT = AT(TYPE_STAT, TYPE_STRUCT, uint32(unsafe.Sizeof(Stat_t{})))
 var STATFS = AT(TYPE_STATFS, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.Statfs_t{})))
 var SIGACTION = AT(TYPE_SIGACTION, TYPE_STRUCT, uint32(unsafe.Sizeof(Sigaction{})))
 var UTSNAME = AT(TYPE_UTSNAME, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.Utsname{})))
 var SOCKADDR = AT(TYPE_SOCKADDR, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.RawSockaddrUnix{})))
 var RUSAGE = AT(TYPE_RUSAGE, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.Rusage{})))
 var IOVEC = AT(TYPE_IOVEC, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.Iovec{})))
-var EPOLLEVENT = AT(TYPE_EPOLLEVENT, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.EpollEvent{})))
+var EPOLLEVENT = AT(TYPE_EPOLLEVENT, TYPE_STRUCT, uint32(unsafe.Sizeof(EpollEvent{})))
 var SYSINFO = AT(TYPE_SYSINFO, TYPE_STRUCT, uint32(unsafe.Sizeof(syscall.Sysinfo_t{})))
 var SIGINFO = AT(TYPE_SIGINFO, TYPE_STRUCT, uint32(unsafe.Sizeof(SigInfo{})))
 var MSGHDR = AT(TYPE_MSGHDR, TYPE_STRUCT, uint32(unsafe.Sizeof(Msghdr{})))
@@ -197,6 +240,7 @@ var IOVEC_T_PTR = IOVEC.NewType(TYPE_POINTER)
 // #define _NSIG_WORDS (_NSIG / _NSIG_BPW)
 // unsigned long -> 4
 var SIGSET = AT(TYPE_SIGSET, TYPE_STRUCT, 4*8)
+var PIPEFD = AT(TYPE_PIPEFD, TYPE_STRUCT, 2*4)
 var POLLFD = AT(TYPE_POLLFD, TYPE_STRUCT, uint32(unsafe.Sizeof(Pollfd{})))
 
 // 这是一种比较特殊的类型 即某个指针类型的参数 要在执行之后才有实际的值
@@ -236,7 +280,7 @@ func init() {
 	Register(&SArgs{21, PA("epoll_ctl", []PArg{A("epfd", INT), A("op", INT), A("fd", INT), A("event", EPOLLEVENT)})})
 	Register(&SArgs{22, PA("epoll_pwait", []PArg{A("epfd", INT), A("events", POINTER), A("maxevents", INT), A("timeout", INT), A("sigmask", SIGSET)})})
 	Register(&SArgs{23, PA("dup", []PArg{A("oldfd", INT)})})
-	Register(&SArgs{24, PA("dup3", []PArg{A("oldfd", INT), A("newfd", UINT64), A("flags", INT)})})
+	Register(&SArgs{24, PA("dup3", []PArg{A("oldfd", INT), A("newfd", INT), A("flags", INT)})})
 	Register(&SArgs{25, PA("fcntl", []PArg{A("fd", INT), A("cmd", INT), A("arg", INT)})})
 	Register(&SArgs{26, PA("inotify_init1", []PArg{A("flags", INT)})})
 	Register(&SArgs{27, PA("inotify_add_watch", []PArg{A("fd", INT), A("pathname", STRING), A("mask", INT)})})
@@ -271,7 +315,7 @@ func init() {
 	Register(&SArgs{56, PA("openat", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
 	Register(&SArgs{57, PA("close", []PArg{A("fd", INT)})})
 	Register(&SArgs{58, PA("vhangup", []PArg{})})
-	Register(&SArgs{59, PA("pipe2", []PArg{B("pipefd", POINTER), A("flags", INT)})})
+	Register(&SArgs{59, PA("pipe2", []PArg{B("pipefd", PIPEFD), A("flags", INT)})})
 	Register(&SArgs{60, PA("quotactl", []PArg{A("cmd", INT), A("special", STRING), A("id", INT), A("addr", INT)})})
 	Register(&SArgs{61, PA("getdents64", []PArg{A("fd", INT), B("dirp", POINTER), A("count", INT)})})
 	Register(&SArgs{62, PA("lseek", []PArg{A("fd", INT), A("offset", INT), A("whence", INT)})})
@@ -433,7 +477,7 @@ func init() {
 	Register(&SArgs{217, PA("add_key", []PArg{A("_type", STRING), A("_description", STRING), A("_payload", POINTER), A("plen", INT), A("ringid", INT)})})
 	Register(&SArgs{218, PA("request_key", []PArg{A("_type", STRING), A("_description", STRING), A("_callout_info", STRING), A("destringid", INT)})})
 	Register(&SArgs{219, PA("keyctl", []PArg{A("option", INT), A("arg2", INT), A("arg3", INT), A("arg4", INT), A("arg5", INT)})})
-	Register(&SArgs{220, PA("clone", []PArg{A("fn", POINTER), A("stack", POINTER), A("flags", INT), A("arg0", INT), A("arg1", INT), A("arg2", INT)})})
+	Register(&SArgs{220, PA("clone", []PArg{A("flags", UINT64), A("stack", POINTER), A("parent_tid", POINTER), A("tls", POINTER), A("child_tid", POINTER)})})
 	Register(&SArgs{221, PA("execve", []PArg{A("pathname", STRING), A("argv", STRING_ARR), A("envp", STRING_ARR)})})
 	Register(&SArgs{222, PA("mmap", []PArg{B("addr", POINTER), A("length", INT), A("prot", INT), A("flags", INT)})})
 	Register(&SArgs{223, PA("fadvise64", []PArg{A("fd", INT), A("offset", INT), A("len", INT), A("advice", INT)})})
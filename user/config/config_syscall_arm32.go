@@ -0,0 +1,554 @@
+package config
+
+import (
+	"encoding/binary"
+)
+
+// arm32 (EABI) 进程的 syscall 调用号和 arm64 不同 部分结构体的布局也不同
+// 32 位进程中 long 和指针都是 4 字节 64 位的参数会占用一对对齐的寄存器
+// eBPF 中根据进程的 compat 标志 给调用号加上 COMPAT_NR_FLAG 再查询对应的配置
+
+type Timespec32 struct {
+	Sec  int32
+	Nsec int32
+}
+
+type Timeval32 struct {
+	Sec  int32
+	Usec int32
+}
+
+// struct stat64 EABI 下 long long 按 8 字节对齐
+type Stat64_32 struct {
+	Dev       uint64
+	_         [4]byte
+	X__st_ino uint32
+	Mode      uint32
+	Nlink     uint32
+	Uid       uint32
+	Gid       uint32
+	Rdev      uint64
+	_         [8]byte
+	Size      int64
+	Blksize   uint32
+	_         [4]byte
+	Blocks    uint64
+	Atim      Timespec32
+	Mtim      Timespec32
+	Ctim      Timespec32
+	Ino       uint64
+}
+
+type Statfs32 struct {
+	Type    uint32
+	Bsize   uint32
+	Blocks  uint32
+	Bfree   uint32
+	Bavail  uint32
+	Files   uint32
+	Ffree   uint32
+	Fsid    [2]int32
+	Namelen uint32
+	Frsize  uint32
+	Flags   uint32
+	Spare   [4]uint32
+}
+
+// struct statfs64 是 packed 的 大小为 84
+type Statfs64_32 struct {
+	Type    uint32
+	Bsize   uint32
+	Blocks  uint64
+	Bfree   uint64
+	Bavail  uint64
+	Files   uint64
+	Ffree   uint64
+	Fsid    [2]int32
+	Namelen uint32
+	Frsize  uint32
+	Flags   uint32
+	Spare   [4]uint32
+}
+
+// rt_sigaction 使用的 struct sigaction 注意 mask 在最后
+type Sigaction32 struct {
+	Sa_handler  uint32
+	Sa_flags    uint32
+	Sa_restorer uint32
+	Sa_mask     [2]uint32
+}
+
+type Rusage32 struct {
+	Utime    Timeval32
+	Stime    Timeval32
+	Maxrss   int32
+	Ixrss    int32
+	Idrss    int32
+	Isrss    int32
+	Minflt   int32
+	Majflt   int32
+	Nswap    int32
+	Inblock  int32
+	Oublock  int32
+	Msgsnd   int32
+	Msgrcv   int32
+	Nsignals int32
+	Nvcsw    int32
+	Nivcsw   int32
+}
+
+type Sysinfo32 struct {
+	Uptime    int32
+	Loads     [3]uint32
+	Totalram  uint32
+	Freeram   uint32
+	Sharedram uint32
+	Bufferram uint32
+	Totalswap uint32
+	Freeswap  uint32
+	Procs     uint16
+	Pad       uint16
+	Totalhigh uint32
+	Freehigh  uint32
+	Unit      uint32
+	X_f       [8]byte
+}
+
+type Msghdr32 struct {
+	Name       uint32
+	Namelen    uint32
+	Iov        uint32
+	Iovlen     uint32
+	Control    uint32
+	Controllen uint32
+	Flags      int32
+}
+
+type ItTmerspec32 struct {
+	It_interval Timespec32
+	It_value    Timespec32
+}
+
+type Stack_t32 struct {
+	Ss_sp    uint32
+	Ss_flags int32
+	Ss_size  uint32
+}
+
+// 这些结构体存在 padding 或者 packed 统一用 binary.Size 计算大小
+var TIMESPEC32 = AT(TYPE_TIMESPEC32, TYPE_STRUCT, uint32(binary.Size(Timespec32{})))
+var TIMEVAL32 = AT(TYPE_TIMEVAL32, TYPE_STRUCT, uint32(binary.Size(Timeval32{})))
+var STAT64 = AT(TYPE_STAT64, TYPE_STRUCT, uint32(binary.Size(Stat64_32{})))
+var STATFS32 = AT(TYPE_STATFS32, TYPE_STRUCT, uint32(binary.Size(Statfs32{})))
+var STATFS64 = AT(TYPE_STATFS64, TYPE_STRUCT, uint32(binary.Size(Statfs64_32{})))
+var SIGACTION32 = AT(TYPE_SIGACTION32, TYPE_STRUCT, uint32(binary.Size(Sigaction32{})))
+var RUSAGE32 = AT(TYPE_RUSAGE32, TYPE_STRUCT, uint32(binary.Size(Rusage32{})))
+var SYSINFO32 = AT(TYPE_SYSINFO32, TYPE_STRUCT, uint32(binary.Size(Sysinfo32{})))
+var MSGHDR32 = AT(TYPE_MSGHDR32, TYPE_STRUCT, uint32(binary.Size(Msghdr32{})))
+var ITIMERSPEC32 = AT(TYPE_ITIMERSPEC32, TYPE_STRUCT, uint32(binary.Size(ItTmerspec32{})))
+var STACK_T32 = AT(TYPE_STACK_T32, TYPE_STRUCT, uint32(binary.Size(Stack_t32{})))
+
+func init() {
+	// arch/arm/tools/syscall.tbl 中 EABI 可用的部分
+	// 带 64 位参数的 syscall 参数按实际占用的寄存器展开 比如 pread64 的 pos 在 r4 r5
+	// *_time64 这类 syscall 使用 64 位的 time_t 结构体和 arm64 一致
+	RegisterCompat(&SArgs{0, PA("restart_syscall", []PArg{})})
+	RegisterCompat(&SArgs{1, PA("exit", []PArg{A("status", INT)})})
+	RegisterCompat(&SArgs{2, PA("fork", []PArg{})})
+	RegisterCompat(&SArgs{3, PA("read", []PArg{A("fd", INT), B("buf", READ_BUFFER_T), A("count", INT)})})
+	RegisterCompat(&SArgs{4, PA("write", []PArg{A("fd", INT), A("buf", WRITE_BUFFER_T), A("count", INT)})})
+	RegisterCompat(&SArgs{5, PA("open", []PArg{A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterCompat(&SArgs{6, PA("close", []PArg{A("fd", INT)})})
+	RegisterCompat(&SArgs{8, PA("creat", []PArg{A("pathname", STRING), A("mode", UINT32)})})
+	RegisterCompat(&SArgs{9, PA("link", []PArg{A("oldpath", STRING), A("newpath", STRING)})})
+	RegisterCompat(&SArgs{10, PA("unlink", []PArg{A("pathname", STRING)})})
+	RegisterCompat(&SArgs{11, PA("execve", []PArg{A("pathname", STRING), A("argv", STRING_ARR), A("envp", STRING_ARR)})})
+	RegisterCompat(&SArgs{12, PA("chdir", []PArg{A("path", STRING)})})
+	RegisterCompat(&SArgs{14, PA("mknod", []PArg{A("pathname", STRING), A("mode", UINT32), A("dev", INT)})})
+	RegisterCompat(&SArgs{15, PA("chmod", []PArg{A("pathname", STRING), A("mode", UINT32)})})
+	RegisterCompat(&SArgs{16, PA("lchown", []PArg{A("pathname", STRING), A("owner", INT), A("group", INT)})})
+	RegisterCompat(&SArgs{19, PA("lseek", []PArg{A("fd", INT), A("offset", INT), A("whence", INT)})})
+	RegisterCompat(&SArgs{20, PA("getpid", []PArg{})})
+	RegisterCompat(&SArgs{21, PA("mount", []PArg{A("source", INT), A("target", STRING), A("filesystemtype", STRING), A("mountflags", INT), A("data", POINTER)})})
+	RegisterCompat(&SArgs{23, PA("setuid", []PArg{A("uid", INT)})})
+	RegisterCompat(&SArgs{24, PA("getuid", []PArg{})})
+	RegisterCompat(&SArgs{26, PA("ptrace", []PArg{A("request", INT), A("pid", INT), A("addr", POINTER), A("data", POINTER)})})
+	RegisterCompat(&SArgs{29, PA("pause", []PArg{})})
+	RegisterCompat(&SArgs{33, PA("access", []PArg{A("pathname", STRING), A("mode", INT)})})
+	RegisterCompat(&SArgs{34, PA("nice", []PArg{A("inc", INT)})})
+	RegisterCompat(&SArgs{36, PA("sync", []PArg{})})
+	RegisterCompat(&SArgs{37, PA("kill", []PArg{A("pid", INT), A("sig", INT)})})
+	RegisterCompat(&SArgs{38, PA("rename", []PArg{A("oldpath", STRING), A("newpath", STRING)})})
+	RegisterCompat(&SArgs{39, PA("mkdir", []PArg{A("pathname", STRING), A("mode", UINT32)})})
+	RegisterCompat(&SArgs{40, PA("rmdir", []PArg{A("pathname", STRING)})})
+	RegisterCompat(&SArgs{41, PA("dup", []PArg{A("oldfd", INT)})})
+	RegisterCompat(&SArgs{42, PA("pipe", []PArg{B("pipefd", PIPEFD)})})
+	RegisterCompat(&SArgs{43, PA("times", []PArg{A("tbuf", POINTER)})})
+	RegisterCompat(&SArgs{45, PA("brk", []PArg{A("brk", INT)})})
+	RegisterCompat(&SArgs{46, PA("setgid", []PArg{A("gid", INT)})})
+	RegisterCompat(&SArgs{47, PA("getgid", []PArg{})})
+	RegisterCompat(&SArgs{49, PA("geteuid", []PArg{})})
+	RegisterCompat(&SArgs{50, PA("getegid", []PArg{})})
+	RegisterCompat(&SArgs{51, PA("acct", []PArg{A("name", STRING)})})
+	RegisterCompat(&SArgs{52, PA("umount2", []PArg{A("target", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{54, PA("ioctl", []PArg{A("fd", INT), A("request", UINT64), A("arg0", INT), A("arg1", INT), A("arg2", INT), A("arg3", INT)})})
+	RegisterCompat(&SArgs{55, PA("fcntl", []PArg{A("fd", INT), A("cmd", INT), A("arg", INT)})})
+	RegisterCompat(&SArgs{57, PA("setpgid", []PArg{A("pid", INT), A("pgid", INT)})})
+	RegisterCompat(&SArgs{60, PA("umask", []PArg{A("mode", INT)})})
+	RegisterCompat(&SArgs{61, PA("chroot", []PArg{A("path", STRING)})})
+	RegisterCompat(&SArgs{63, PA("dup2", []PArg{A("oldfd", INT), A("newfd", INT)})})
+	RegisterCompat(&SArgs{64, PA("getppid", []PArg{})})
+	RegisterCompat(&SArgs{65, PA("getpgrp", []PArg{})})
+	RegisterCompat(&SArgs{66, PA("setsid", []PArg{})})
+	RegisterCompat(&SArgs{67, PA("sigaction", []PArg{A("signum", INT), A("act", POINTER), A("oldact", POINTER)})})
+	RegisterCompat(&SArgs{70, PA("setreuid", []PArg{A("ruid", INT), A("euid", INT)})})
+	RegisterCompat(&SArgs{71, PA("setregid", []PArg{A("rgid", INT), A("egid", INT)})})
+	RegisterCompat(&SArgs{72, PA("sigsuspend", []PArg{A("restart", INT), A("oldmask", UINT32), A("mask", UINT32)})})
+	RegisterCompat(&SArgs{73, PA("sigpending", []PArg{B("set", POINTER)})})
+	RegisterCompat(&SArgs{74, PA("sethostname", []PArg{A("name", STRING), A("len", INT)})})
+	RegisterCompat(&SArgs{75, PA("setrlimit", []PArg{A("resource", INT), A("rlim", POINTER)})})
+	RegisterCompat(&SArgs{77, PA("getrusage", []PArg{A("who", INT), B("usage", RUSAGE32)})})
+	RegisterCompat(&SArgs{78, PA("gettimeofday", []PArg{B("tv", TIMEVAL32), B("tz", TIMEZONE)})})
+	RegisterCompat(&SArgs{79, PA("settimeofday", []PArg{A("tv", TIMEVAL32), A("tz", TIMEZONE)})})
+	RegisterCompat(&SArgs{80, PA("getgroups", []PArg{A("gidsetsize", INT), A("grouplist", INT)})})
+	RegisterCompat(&SArgs{81, PA("setgroups", []PArg{A("gidsetsize", INT), A("grouplist", INT)})})
+	RegisterCompat(&SArgs{83, PA("symlink", []PArg{A("target", STRING), A("linkpath", STRING)})})
+	RegisterCompat(&SArgs{85, PA("readlink", []PArg{A("pathname", STRING), B("buf", STRING), A("bufsiz", INT)})})
+	RegisterCompat(&SArgs{86, PA("uselib", []PArg{A("library", STRING)})})
+	RegisterCompat(&SArgs{87, PA("swapon", []PArg{A("specialfile", STRING), A("swap_flags", INT)})})
+	RegisterCompat(&SArgs{88, PA("reboot", []PArg{A("magic1", INT), A("magic2", INT), A("cmd", INT), A("arg", POINTER)})})
+	RegisterCompat(&SArgs{91, PA("munmap", []PArg{A("addr", INT), A("length", INT)})})
+	RegisterCompat(&SArgs{92, PA("truncate", []PArg{A("path", STRING), A("length", INT)})})
+	RegisterCompat(&SArgs{93, PA("ftruncate", []PArg{A("fd", INT), A("length", INT)})})
+	RegisterCompat(&SArgs{94, PA("fchmod", []PArg{A("fd", INT), A("mode", INT)})})
+	RegisterCompat(&SArgs{95, PA("fchown", []PArg{A("fd", INT), A("owner", INT), A("group", INT)})})
+	RegisterCompat(&SArgs{96, PA("getpriority", []PArg{A("which", INT), A("who", INT)})})
+	RegisterCompat(&SArgs{97, PA("setpriority", []PArg{A("which", INT), A("who", INT), A("prio", INT)})})
+	RegisterCompat(&SArgs{99, PA("statfs", []PArg{A("path", STRING), B("buf", STATFS32)})})
+	RegisterCompat(&SArgs{100, PA("fstatfs", []PArg{A("fd", INT), B("buf", STATFS32)})})
+	RegisterCompat(&SArgs{103, PA("syslog", []PArg{A("type", INT), A("bufp", STRING), A("len", INT)})})
+	RegisterCompat(&SArgs{104, PA("setitimer", []PArg{A("which", INT), A("value", POINTER), A("ovalue", POINTER)})})
+	RegisterCompat(&SArgs{105, PA("getitimer", []PArg{A("which", INT), A("value", POINTER)})})
+	RegisterCompat(&SArgs{106, PA("stat", []PArg{A("pathname", STRING), B("statbuf", POINTER)})})
+	RegisterCompat(&SArgs{107, PA("lstat", []PArg{A("pathname", STRING), B("statbuf", POINTER)})})
+	RegisterCompat(&SArgs{108, PA("fstat", []PArg{A("fd", INT), B("statbuf", POINTER)})})
+	RegisterCompat(&SArgs{111, PA("vhangup", []PArg{})})
+	RegisterCompat(&SArgs{114, PA("wait4", []PArg{A("pid", INT), A("wstatus", POINTER), A("options", INT), B("rusage", RUSAGE32)})})
+	RegisterCompat(&SArgs{115, PA("swapoff", []PArg{A("specialfile", STRING)})})
+	RegisterCompat(&SArgs{116, PA("sysinfo", []PArg{B("info", SYSINFO32)})})
+	RegisterCompat(&SArgs{118, PA("fsync", []PArg{A("fd", INT)})})
+	RegisterCompat(&SArgs{119, PA("sigreturn", []PArg{})})
+	RegisterCompat(&SArgs{120, PA("clone", []PArg{A("flags", UINT64), A("stack", POINTER), A("parent_tid", POINTER), A("tls", POINTER), A("child_tid", POINTER)})})
+	RegisterCompat(&SArgs{121, PA("setdomainname", []PArg{A("name", STRING), A("len", INT)})})
+	RegisterCompat(&SArgs{122, PA("uname", []PArg{B("buf", UTSNAME)})})
+	RegisterCompat(&SArgs{124, PA("adjtimex", []PArg{A("txc_p", POINTER)})})
+	RegisterCompat(&SArgs{125, PA("mprotect", []PArg{A("addr", POINTER), A("length", INT), A("prot", INT)})})
+	RegisterCompat(&SArgs{126, PA("sigprocmask", []PArg{A("how", INT), A("set", POINTER), B("oldset", POINTER)})})
+	RegisterCompat(&SArgs{128, PA("init_module", []PArg{A("umod", POINTER), A("len", INT), A("uargs", STRING)})})
+	RegisterCompat(&SArgs{129, PA("delete_module", []PArg{A("name_user", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{131, PA("quotactl", []PArg{A("cmd", INT), A("special", STRING), A("id", INT), A("addr", INT)})})
+	RegisterCompat(&SArgs{132, PA("getpgid", []PArg{A("pid", INT)})})
+	RegisterCompat(&SArgs{133, PA("fchdir", []PArg{A("fd", INT)})})
+	RegisterCompat(&SArgs{135, PA("sysfs", []PArg{A("option", INT), A("arg1", INT), A("arg2", INT)})})
+	RegisterCompat(&SArgs{136, PA("personality", []PArg{A("personality", INT)})})
+	RegisterCompat(&SArgs{138, PA("setfsuid", []PArg{A("uid", INT)})})
+	RegisterCompat(&SArgs{139, PA("setfsgid", []PArg{A("gid", INT)})})
+	RegisterCompat(&SArgs{140, PA("_llseek", []PArg{A("fd", INT), A("offset_high", UINT32), A("offset_low", UINT32), B("result", POINTER), A("whence", INT)})})
+	RegisterCompat(&SArgs{141, PA("getdents", []PArg{A("fd", INT), B("dirp", POINTER), A("count", INT)})})
+	RegisterCompat(&SArgs{142, PA("_newselect", []PArg{A("nfds", INT), A("readfds", POINTER), A("writefds", POINTER), A("exceptfds", POINTER), A("timeout", TIMEVAL32)})})
+	RegisterCompat(&SArgs{143, PA("flock", []PArg{A("fd", INT), A("operation", INT)})})
+	RegisterCompat(&SArgs{144, PA("msync", []PArg{A("addr", POINTER), A("length", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{145, PA("readv", []PArg{A("fd", INT), B("iov", POINTER), A("iovcnt", INT)})})
+	RegisterCompat(&SArgs{146, PA("writev", []PArg{A("fd", INT), A("iov", POINTER), A("iovcnt", INT)})})
+	RegisterCompat(&SArgs{147, PA("getsid", []PArg{A("pid", INT)})})
+	RegisterCompat(&SArgs{148, PA("fdatasync", []PArg{A("fd", INT)})})
+	RegisterCompat(&SArgs{150, PA("mlock", []PArg{A("start", INT), A("len", INT)})})
+	RegisterCompat(&SArgs{151, PA("munlock", []PArg{A("start", INT), A("len", INT)})})
+	RegisterCompat(&SArgs{152, PA("mlockall", []PArg{A("flags", INT)})})
+	RegisterCompat(&SArgs{153, PA("munlockall", []PArg{})})
+	RegisterCompat(&SArgs{154, PA("sched_setparam", []PArg{A("pid", INT), A("param", POINTER)})})
+	RegisterCompat(&SArgs{155, PA("sched_getparam", []PArg{A("pid", INT), B("param", POINTER)})})
+	RegisterCompat(&SArgs{156, PA("sched_setscheduler", []PArg{A("pid", INT), A("policy", INT), A("param", POINTER)})})
+	RegisterCompat(&SArgs{157, PA("sched_getscheduler", []PArg{A("pid", INT)})})
+	RegisterCompat(&SArgs{158, PA("sched_yield", []PArg{})})
+	RegisterCompat(&SArgs{159, PA("sched_get_priority_max", []PArg{A("policy", INT)})})
+	RegisterCompat(&SArgs{160, PA("sched_get_priority_min", []PArg{A("policy", INT)})})
+	RegisterCompat(&SArgs{161, PA("sched_rr_get_interval", []PArg{A("pid", INT), A("interval", TIMESPEC32)})})
+	RegisterCompat(&SArgs{162, PA("nanosleep", []PArg{A("req", TIMESPEC32), A("rem", TIMESPEC32)})})
+	RegisterCompat(&SArgs{163, PA("mremap", []PArg{A("old_address", POINTER), A("old_size", INT), A("new_size", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{164, PA("setresuid", []PArg{A("ruid", INT), A("euid", INT), A("suid", INT)})})
+	RegisterCompat(&SArgs{165, PA("getresuid", []PArg{A("ruidp", INT), A("euidp", INT), A("suidp", INT)})})
+	RegisterCompat(&SArgs{168, PA("poll", []PArg{A("fds", POLLFD), A("nfds", INT), A("timeout", INT)})})
+	RegisterCompat(&SArgs{169, PA("nfsservctl", []PArg{A("cmd", INT), A("argp", POINTER), A("resp", POINTER)})})
+	RegisterCompat(&SArgs{170, PA("setresgid", []PArg{A("rgid", INT), A("egid", INT), A("sgid", INT)})})
+	RegisterCompat(&SArgs{171, PA("getresgid", []PArg{A("rgidp", INT), A("egidp", INT), A("sgidp", INT)})})
+	RegisterCompat(&SArgs{172, PA("prctl", []PArg{A("option", INT), A("arg2", UINT64), A("arg3", UINT64), A("arg4", UINT64), A("arg5", UINT64)})})
+	RegisterCompat(&SArgs{173, PA("rt_sigreturn", []PArg{A("mask", INT)})})
+	RegisterCompat(&SArgs{174, PA("rt_sigaction", []PArg{A("signum", INT), A("act", SIGACTION32), A("oldact", SIGACTION32)})})
+	RegisterCompat(&SArgs{175, PA("rt_sigprocmask", []PArg{A("how", INT), A("set", UINT64), A("oldset", UINT64), A("sigsetsize", INT)})})
+	RegisterCompat(&SArgs{176, PA("rt_sigpending", []PArg{A("uset", POINTER), A("sigsetsize", INT)})})
+	RegisterCompat(&SArgs{177, PA("rt_sigtimedwait", []PArg{A("uthese", POINTER), A("uinfo", POINTER), A("uts", TIMESPEC32), A("sigsetsize", INT)})})
+	RegisterCompat(&SArgs{178, PA("rt_sigqueueinfo", []PArg{A("pid", INT), A("sig", INT), A("uinfo", POINTER)})})
+	RegisterCompat(&SArgs{179, PA("rt_sigsuspend", []PArg{A("mask", SIGSET)})})
+	RegisterCompat(&SArgs{180, PA("pread64", []PArg{A("fd", INT), B("buf", READ_BUFFER_T), A("count", INT), A("pad", INT), A("pos_l", UINT32), A("pos_h", UINT32)})})
+	RegisterCompat(&SArgs{181, PA("pwrite64", []PArg{A("fd", INT), A("buf", WRITE_BUFFER_T), A("count", INT), A("pad", INT), A("pos_l", UINT32), A("pos_h", UINT32)})})
+	RegisterCompat(&SArgs{182, PA("chown", []PArg{A("pathname", STRING), A("owner", INT), A("group", INT)})})
+	RegisterCompat(&SArgs{183, PA("getcwd", []PArg{B("buf", STRING), A("size", UINT64)})})
+	RegisterCompat(&SArgs{184, PA("capget", []PArg{A("header", POINTER), A("dataptr", POINTER)})})
+	RegisterCompat(&SArgs{185, PA("capset", []PArg{A("header", POINTER), A("data", POINTER)})})
+	RegisterCompat(&SArgs{186, PA("sigaltstack", []PArg{A("ss", STACK_T32), A("old_ss", STACK_T32)})})
+	RegisterCompat(&SArgs{187, PA("sendfile", []PArg{A("out_fd", INT), A("in_fd", INT), A("offset", INT), A("count", INT)})})
+	RegisterCompat(&SArgs{190, PA("vfork", []PArg{})})
+	RegisterCompat(&SArgs{191, PA("ugetrlimit", []PArg{A("resource", INT), B("rlim", POINTER)})})
+	RegisterCompat(&SArgs{192, PA("mmap2", []PArg{B("addr", POINTER), A("length", INT), A("prot", INT), A("flags", INT), A("fd", INT), A("pgoff", UINT32)})})
+	RegisterCompat(&SArgs{193, PA("truncate64", []PArg{A("path", STRING), A("pad", INT), A("length_l", UINT32), A("length_h", UINT32)})})
+	RegisterCompat(&SArgs{194, PA("ftruncate64", []PArg{A("fd", INT), A("pad", INT), A("length_l", UINT32), A("length_h", UINT32)})})
+	RegisterCompat(&SArgs{195, PA("stat64", []PArg{A("pathname", STRING), B("statbuf", STAT64)})})
+	RegisterCompat(&SArgs{196, PA("lstat64", []PArg{A("pathname", STRING), B("statbuf", STAT64)})})
+	RegisterCompat(&SArgs{197, PA("fstat64", []PArg{A("fd", INT), B("statbuf", STAT64)})})
+	RegisterCompat(&SArgs{198, PA("lchown32", []PArg{A("pathname", STRING), A("owner", INT), A("group", INT)})})
+	RegisterCompat(&SArgs{199, PA("getuid32", []PArg{})})
+	RegisterCompat(&SArgs{200, PA("getgid32", []PArg{})})
+	RegisterCompat(&SArgs{201, PA("geteuid32", []PArg{})})
+	RegisterCompat(&SArgs{202, PA("getegid32", []PArg{})})
+	RegisterCompat(&SArgs{203, PA("setreuid32", []PArg{A("ruid", INT), A("euid", INT)})})
+	RegisterCompat(&SArgs{204, PA("setregid32", []PArg{A("rgid", INT), A("egid", INT)})})
+	RegisterCompat(&SArgs{205, PA("getgroups32", []PArg{A("gidsetsize", INT), A("grouplist", INT)})})
+	RegisterCompat(&SArgs{206, PA("setgroups32", []PArg{A("gidsetsize", INT), A("grouplist", INT)})})
+	RegisterCompat(&SArgs{207, PA("fchown32", []PArg{A("fd", INT), A("owner", INT), A("group", INT)})})
+	RegisterCompat(&SArgs{208, PA("setresuid32", []PArg{A("ruid", INT), A("euid", INT), A("suid", INT)})})
+	RegisterCompat(&SArgs{209, PA("getresuid32", []PArg{A("ruidp", INT), A("euidp", INT), A("suidp", INT)})})
+	RegisterCompat(&SArgs{210, PA("setresgid32", []PArg{A("rgid", INT), A("egid", INT), A("sgid", INT)})})
+	RegisterCompat(&SArgs{211, PA("getresgid32", []PArg{A("rgidp", INT), A("egidp", INT), A("sgidp", INT)})})
+	RegisterCompat(&SArgs{212, PA("chown32", []PArg{A("pathname", STRING), A("owner", INT), A("group", INT)})})
+	RegisterCompat(&SArgs{213, PA("setuid32", []PArg{A("uid", INT)})})
+	RegisterCompat(&SArgs{214, PA("setgid32", []PArg{A("gid", INT)})})
+	RegisterCompat(&SArgs{215, PA("setfsuid32", []PArg{A("uid", INT)})})
+	RegisterCompat(&SArgs{216, PA("setfsgid32", []PArg{A("gid", INT)})})
+	RegisterCompat(&SArgs{217, PA("getdents64", []PArg{A("fd", INT), B("dirp", POINTER), A("count", INT)})})
+	RegisterCompat(&SArgs{218, PA("pivot_root", []PArg{A("new_root", STRING), A("put_old", STRING)})})
+	RegisterCompat(&SArgs{219, PA("mincore", []PArg{A("start", INT), A("len", INT), A("vec", STRING)})})
+	RegisterCompat(&SArgs{220, PA("madvise", []PArg{A("addr", POINTER), A("len", INT), A("advice", INT)})})
+	RegisterCompat(&SArgs{221, PA("fcntl64", []PArg{A("fd", INT), A("cmd", INT), A("arg", INT)})})
+	RegisterCompat(&SArgs{224, PA("gettid", []PArg{})})
+	RegisterCompat(&SArgs{225, PA("readahead", []PArg{A("fd", INT), A("pad", INT), A("offset_l", UINT32), A("offset_h", UINT32), A("count", INT)})})
+	RegisterCompat(&SArgs{226, PA("setxattr", []PArg{A("pathname", STRING), A("name", STRING), A("value", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{227, PA("lsetxattr", []PArg{A("pathname", STRING), A("name", STRING), A("value", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{228, PA("fsetxattr", []PArg{A("fd", INT), A("name", STRING), A("value", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{229, PA("getxattr", []PArg{A("path", STRING), A("name", STRING), A("value", POINTER), A("size", INT)})})
+	RegisterCompat(&SArgs{230, PA("lgetxattr", []PArg{A("path", STRING), A("name", STRING), A("value", POINTER), A("size", INT)})})
+	RegisterCompat(&SArgs{231, PA("fgetxattr", []PArg{A("fd", INT), A("name", STRING), A("value", POINTER), A("size", INT)})})
+	RegisterCompat(&SArgs{232, PA("listxattr", []PArg{A("pathname", STRING), A("list", STRING), A("size", INT)})})
+	RegisterCompat(&SArgs{233, PA("llistxattr", []PArg{A("pathname", STRING), A("list", STRING), A("size", INT)})})
+	RegisterCompat(&SArgs{234, PA("flistxattr", []PArg{A("fd", INT), A("list", STRING), A("size", INT)})})
+	RegisterCompat(&SArgs{235, PA("removexattr", []PArg{A("pathname", STRING), A("name", STRING)})})
+	RegisterCompat(&SArgs{236, PA("lremovexattr", []PArg{A("pathname", STRING), A("name", STRING)})})
+	RegisterCompat(&SArgs{237, PA("fremovexattr", []PArg{A("fd", INT), A("name", STRING)})})
+	RegisterCompat(&SArgs{238, PA("tkill", []PArg{A("tid", INT), A("sig", INT)})})
+	RegisterCompat(&SArgs{239, PA("sendfile64", []PArg{A("out_fd", INT), A("in_fd", INT), A("offset", INT), A("count", INT)})})
+	RegisterCompat(&SArgs{240, PA("futex", []PArg{A("uaddr", INT), A("futex_op", INT), A("val", INT), A("timeout", TIMESPEC32)})})
+	RegisterCompat(&SArgs{241, PA("sched_setaffinity", []PArg{A("pid", INT), A("cpusetsize", INT), A("mask", POINTER)})})
+	RegisterCompat(&SArgs{242, PA("sched_getaffinity", []PArg{A("pid", INT), A("cpusetsize", INT), B("mask", POINTER)})})
+	RegisterCompat(&SArgs{243, PA("io_setup", []PArg{A("nr_events", UINT), A("ctx_idp", POINTER)})})
+	RegisterCompat(&SArgs{244, PA("io_destroy", []PArg{A("ctx", POINTER)})})
+	RegisterCompat(&SArgs{245, PA("io_getevents", []PArg{A("ctx_id", POINTER), A("min_nr", UINT64), A("nr", UINT64), A("events", POINTER), A("timeout", TIMESPEC32)})})
+	RegisterCompat(&SArgs{246, PA("io_submit", []PArg{A("ctx_id", POINTER), A("nr", UINT64), A("iocbpp", POINTER)})})
+	RegisterCompat(&SArgs{247, PA("io_cancel", []PArg{A("ctx_id", POINTER), A("iocb", POINTER), A("result", POINTER)})})
+	RegisterCompat(&SArgs{248, PA("exit_group", []PArg{A("status", INT)})})
+	RegisterCompat(&SArgs{249, PA("lookup_dcookie", []PArg{A("cookie", INT), B("buffer", STRING), A("len", INT)})})
+	RegisterCompat(&SArgs{250, PA("epoll_create", []PArg{A("size", INT)})})
+	RegisterCompat(&SArgs{251, PA("epoll_ctl", []PArg{A("epfd", INT), A("op", INT), A("fd", INT), A("event", EPOLLEVENT)})})
+	RegisterCompat(&SArgs{252, PA("epoll_wait", []PArg{A("epfd", INT), A("events", POINTER), A("maxevents", INT), A("timeout", INT)})})
+	RegisterCompat(&SArgs{253, PA("remap_file_pages", []PArg{A("start", INT), A("size", INT), A("prot", INT), A("pgoff", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{256, PA("set_tid_address", []PArg{A("tidptr", POINTER)})})
+	RegisterCompat(&SArgs{257, PA("timer_create", []PArg{A("which_clock", INT), A("timer_event_spec", POINTER), A("created_timer_id", INT)})})
+	RegisterCompat(&SArgs{258, PA("timer_settime", []PArg{A("timer_id", INT), A("flags", INT), A("new_setting", POINTER), A("old_setting", POINTER)})})
+	RegisterCompat(&SArgs{259, PA("timer_gettime", []PArg{A("timer_id", INT), A("setting", POINTER)})})
+	RegisterCompat(&SArgs{260, PA("timer_getoverrun", []PArg{A("timer_id", INT)})})
+	RegisterCompat(&SArgs{261, PA("timer_delete", []PArg{A("timer_id", INT)})})
+	RegisterCompat(&SArgs{262, PA("clock_settime", []PArg{A("clockid", INT), A("tp", TIMESPEC32)})})
+	RegisterCompat(&SArgs{263, PA("clock_gettime", []PArg{A("clockid", INT), B("tp", TIMESPEC32)})})
+	RegisterCompat(&SArgs{264, PA("clock_getres", []PArg{A("clockid", INT), B("res", TIMESPEC32)})})
+	RegisterCompat(&SArgs{265, PA("clock_nanosleep", []PArg{A("clockid", INT), A("flags", INT), A("request", TIMESPEC32), B("remain", TIMESPEC32)})})
+	RegisterCompat(&SArgs{266, PA("statfs64", []PArg{A("path", STRING), A("sz", INT), B("buf", STATFS64)})})
+	RegisterCompat(&SArgs{267, PA("fstatfs64", []PArg{A("fd", INT), A("sz", INT), B("buf", STATFS64)})})
+	RegisterCompat(&SArgs{268, PA("tgkill", []PArg{A("tgid", INT), A("tid", INT), A("sig", INT)})})
+	RegisterCompat(&SArgs{269, PA("utimes", []PArg{A("filename", STRING), A("times", POINTER)})})
+	RegisterCompat(&SArgs{270, PA("arm_fadvise64_64", []PArg{A("fd", INT), A("advice", INT), A("offset_l", UINT32), A("offset_h", UINT32), A("len_l", UINT32), A("len_h", UINT32)})})
+	RegisterCompat(&SArgs{274, PA("mq_open", []PArg{A("u_name", STRING), A("oflag", INT), A("mode", INT), A("u_attr", POINTER)})})
+	RegisterCompat(&SArgs{275, PA("mq_unlink", []PArg{A("u_name", STRING)})})
+	RegisterCompat(&SArgs{276, PA("mq_timedsend", []PArg{A("mqdes", INT), A("u_msg_ptr", STRING), A("msg_len", INT), A("msg_prio", INT), A("u_abs_timeout", TIMESPEC32)})})
+	RegisterCompat(&SArgs{277, PA("mq_timedreceive", []PArg{A("mqdes", INT), A("u_msg_ptr", STRING), A("msg_len", INT), A("u_msg_prio", INT), A("u_abs_timeout", TIMESPEC32)})})
+	RegisterCompat(&SArgs{278, PA("mq_notify", []PArg{A("mqdes", INT), A("u_notification", POINTER)})})
+	RegisterCompat(&SArgs{279, PA("mq_getsetattr", []PArg{A("mqdes", INT), A("u_mqstat", POINTER), A("u_omqstat", POINTER)})})
+	RegisterCompat(&SArgs{280, PA("waitid", []PArg{A("which", INT), A("upid", INT), A("infop", POINTER), A("options", INT), A("ru", POINTER)})})
+	RegisterCompat(&SArgs{281, PA("socket", []PArg{A("domain", INT), A("type", INT), A("protocol", INT)})})
+	RegisterCompat(&SArgs{282, PA("bind", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterCompat(&SArgs{283, PA("connect", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterCompat(&SArgs{284, PA("listen", []PArg{A("sockfd", INT), A("backlog", INT)})})
+	RegisterCompat(&SArgs{285, PA("accept", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterCompat(&SArgs{286, PA("getsockname", []PArg{A("sockfd", INT), B("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterCompat(&SArgs{287, PA("getpeername", []PArg{A("sockfd", INT), B("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterCompat(&SArgs{288, PA("socketpair", []PArg{A("domain", INT), A("type", INT), A("protocol", INT), A("sv", POINTER)})})
+	RegisterCompat(&SArgs{289, PA("send", []PArg{A("sockfd", INT), A("buf", READ_BUFFER_T), A("len", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{290, PA("sendto", []PArg{A("sockfd", INT), A("buf", READ_BUFFER_T), A("len", INT), A("flags", INT), A("dest_addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterCompat(&SArgs{291, PA("recv", []PArg{A("sockfd", INT), B("buf", WRITE_BUFFER_T), A("len", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{292, PA("recvfrom", []PArg{A("sockfd", INT), B("buf", WRITE_BUFFER_T), A("len", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{293, PA("shutdown", []PArg{A("sockfd", INT), A("how", INT)})})
+	RegisterCompat(&SArgs{294, PA("setsockopt", []PArg{A("sockfd", INT), A("level", INT), A("optname", INT), A("optval", INT), A("optlen", INT)})})
+	RegisterCompat(&SArgs{295, PA("getsockopt", []PArg{A("sockfd", INT), A("level", INT), A("optname", INT), B("optval", INT), A("optlen", POINTER)})})
+	RegisterCompat(&SArgs{296, PA("sendmsg", []PArg{A("sockfd", INT), A("msg", MSGHDR32), A("flags", INT)})})
+	RegisterCompat(&SArgs{297, PA("recvmsg", []PArg{A("sockfd", INT), B("msg", MSGHDR32), A("flags", INT)})})
+	RegisterCompat(&SArgs{298, PA("semop", []PArg{A("semid", INT), A("tsops", POINTER), A("nsops", INT)})})
+	RegisterCompat(&SArgs{299, PA("semget", []PArg{A("key", INT), A("nsems", INT), A("semflg", INT)})})
+	RegisterCompat(&SArgs{300, PA("semctl", []PArg{A("semid", INT), A("semnum", INT), A("cmd", INT), A("arg", INT)})})
+	RegisterCompat(&SArgs{301, PA("msgsnd", []PArg{A("msqid", INT), A("msgp", POINTER), A("msgsz", INT), A("msgflg", INT)})})
+	RegisterCompat(&SArgs{302, PA("msgrcv", []PArg{A("msqid", INT), A("msgp", POINTER), A("msgsz", INT), A("msgtyp", UINT64), A("msgflg", INT)})})
+	RegisterCompat(&SArgs{303, PA("msgget", []PArg{A("key", INT), A("msgflg", INT)})})
+	RegisterCompat(&SArgs{304, PA("msgctl", []PArg{A("msqid", INT), A("cmd", INT), A("buf", POINTER)})})
+	RegisterCompat(&SArgs{305, PA("shmat", []PArg{A("shmid", INT), A("shmaddr", POINTER), A("shmflg", INT)})})
+	RegisterCompat(&SArgs{306, PA("shmdt", []PArg{A("shmaddr", POINTER)})})
+	RegisterCompat(&SArgs{307, PA("shmget", []PArg{A("key", INT), A("size", INT), A("shmflg", INT)})})
+	RegisterCompat(&SArgs{308, PA("shmctl", []PArg{A("shmid", INT), A("cmd", INT), A("buf", POINTER)})})
+	RegisterCompat(&SArgs{309, PA("add_key", []PArg{A("_type", STRING), A("_description", STRING), A("_payload", POINTER), A("plen", INT), A("ringid", INT)})})
+	RegisterCompat(&SArgs{310, PA("request_key", []PArg{A("_type", STRING), A("_description", STRING), A("_callout_info", STRING), A("destringid", INT)})})
+	RegisterCompat(&SArgs{311, PA("keyctl", []PArg{A("option", INT), A("arg2", INT), A("arg3", INT), A("arg4", INT), A("arg5", INT)})})
+	RegisterCompat(&SArgs{312, PA("semtimedop", []PArg{A("semid", INT), A("tsops", POINTER), A("nsops", INT), A("timeout", TIMESPEC32)})})
+	RegisterCompat(&SArgs{314, PA("ioprio_set", []PArg{A("which", INT), A("who", INT), A("ioprio", INT)})})
+	RegisterCompat(&SArgs{315, PA("ioprio_get", []PArg{A("which", INT), A("who", INT)})})
+	RegisterCompat(&SArgs{316, PA("inotify_init", []PArg{})})
+	RegisterCompat(&SArgs{317, PA("inotify_add_watch", []PArg{A("fd", INT), A("pathname", STRING), A("mask", INT)})})
+	RegisterCompat(&SArgs{318, PA("inotify_rm_watch", []PArg{A("fd", INT), A("wd", INT)})})
+	RegisterCompat(&SArgs{319, PA("mbind", []PArg{A("start", INT), A("len", INT), A("mode", INT), A("nmask", INT), A("maxnode", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{320, PA("get_mempolicy", []PArg{A("policy", INT), A("nmask", INT), A("maxnode", INT), A("addr", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{321, PA("set_mempolicy", []PArg{A("mode", INT), A("nmask", INT), A("maxnode", INT)})})
+	RegisterCompat(&SArgs{322, PA("openat", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterCompat(&SArgs{323, PA("mkdirat", []PArg{A("dirfd", INT), A("pathname", STRING), A("mode", INT)})})
+	RegisterCompat(&SArgs{324, PA("mknodat", []PArg{A("dfd", INT), A("filename", STRING), A("mode", INT), A("dev", INT)})})
+	RegisterCompat(&SArgs{325, PA("fchownat", []PArg{A("dirfd", INT), A("pathname", STRING), A("owner", INT), A("group", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{326, PA("futimesat", []PArg{A("dirfd", INT), A("pathname", STRING), A("times", POINTER)})})
+	RegisterCompat(&SArgs{327, PA("fstatat64", []PArg{A("dirfd", INT), A("pathname", STRING), B("statbuf", STAT64), A("flags", INT)})})
+	RegisterCompat(&SArgs{328, PA("unlinkat", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{329, PA("renameat", []PArg{A("olddirfd", INT), A("oldpath", STRING), A("newdirfd", INT), A("newpath", STRING)})})
+	RegisterCompat(&SArgs{330, PA("linkat", []PArg{A("olddirfd", INT), A("oldpath", STRING), A("newdirfd", INT), A("newpath", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{331, PA("symlinkat", []PArg{A("target", STRING), A("newdirfd", INT), A("linkpath", STRING)})})
+	RegisterCompat(&SArgs{332, PA("readlinkat", []PArg{A("dirfd", INT), A("pathname", STRING), B("buf", STRING), A("bufsiz", INT)})})
+	RegisterCompat(&SArgs{333, PA("fchmodat", []PArg{A("dirfd", INT), A("pathname", STRING), A("mode", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{334, PA("faccessat", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterCompat(&SArgs{335, PA("pselect6", []PArg{A("n", INT), A("inp", POINTER), A("outp", POINTER), A("exp", POINTER), A("tsp", TIMESPEC32), A("sig", POINTER)})})
+	RegisterCompat(&SArgs{336, PA("ppoll", []PArg{A("fds", INT), A("nfds", INT), A("tmo_p", TIMESPEC32), A("sigmask", INT)})})
+	RegisterCompat(&SArgs{337, PA("unshare", []PArg{A("unshare_flags", INT)})})
+	RegisterCompat(&SArgs{338, PA("set_robust_list", []PArg{A("head", POINTER), A("len", INT)})})
+	RegisterCompat(&SArgs{339, PA("get_robust_list", []PArg{A("pid", INT), A("head_ptr", POINTER), A("len_ptr", INT)})})
+	RegisterCompat(&SArgs{340, PA("splice", []PArg{A("fd_in", INT), A("off_in", INT), A("fd_out", INT), A("off_out", INT), A("len", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{341, PA("arm_sync_file_range", []PArg{A("fd", INT), A("flags", INT), A("offset_l", UINT32), A("offset_h", UINT32), A("nbytes_l", UINT32), A("nbytes_h", UINT32)})})
+	RegisterCompat(&SArgs{342, PA("tee", []PArg{A("fdin", INT), A("fdout", INT), A("len", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{343, PA("vmsplice", []PArg{A("fd", INT), A("uiov", POINTER), A("nr_segs", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{344, PA("move_pages", []PArg{A("pid", INT), A("nr_pages", INT), A("pages", POINTER), A("nodes", INT), A("status", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{345, PA("getcpu", []PArg{A("cpup", INT), A("nodep", INT), A("unused", POINTER)})})
+	RegisterCompat(&SArgs{346, PA("epoll_pwait", []PArg{A("epfd", INT), A("events", POINTER), A("maxevents", INT), A("timeout", INT), A("sigmask", SIGSET)})})
+	RegisterCompat(&SArgs{347, PA("kexec_load", []PArg{A("entry", INT), A("nr_segments", INT), A("segments", POINTER), A("flags", INT)})})
+	RegisterCompat(&SArgs{348, PA("utimensat", []PArg{A("dirfd", INT), A("pathname", STRING), A("times", ITIMERSPEC32), A("flags", INT)})})
+	RegisterCompat(&SArgs{349, PA("signalfd", []PArg{A("fd", INT), A("mask", POINTER), A("sizemask", INT)})})
+	RegisterCompat(&SArgs{350, PA("timerfd_create", []PArg{A("clockid", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{351, PA("eventfd", []PArg{A("initval", INT)})})
+	RegisterCompat(&SArgs{352, PA("fallocate", []PArg{A("fd", INT), A("mode", INT), A("offset_l", UINT32), A("offset_h", UINT32), A("len_l", UINT32), A("len_h", UINT32)})})
+	RegisterCompat(&SArgs{353, PA("timerfd_settime", []PArg{A("fd", INT), A("flags", INT), A("new_value", ITIMERSPEC32), A("old_value", ITIMERSPEC32)})})
+	RegisterCompat(&SArgs{354, PA("timerfd_gettime", []PArg{A("fd", INT), B("curr_value", ITIMERSPEC32)})})
+	RegisterCompat(&SArgs{355, PA("signalfd4", []PArg{A("ufd", INT), A("user_mask", POINTER), A("sizemask", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{356, PA("eventfd2", []PArg{A("initval", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{357, PA("epoll_create1", []PArg{A("flags", INT)})})
+	RegisterCompat(&SArgs{358, PA("dup3", []PArg{A("oldfd", INT), A("newfd", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{359, PA("pipe2", []PArg{B("pipefd", PIPEFD), A("flags", INT)})})
+	RegisterCompat(&SArgs{360, PA("inotify_init1", []PArg{A("flags", INT)})})
+	RegisterCompat(&SArgs{361, PA("preadv", []PArg{A("fd", INT), B("iov", POINTER), A("iovcnt", INT), A("pos_l", UINT32), A("pos_h", UINT32)})})
+	RegisterCompat(&SArgs{362, PA("pwritev", []PArg{A("fd", INT), A("iov", POINTER), A("iovcnt", INT), A("pos_l", UINT32), A("pos_h", UINT32)})})
+	RegisterCompat(&SArgs{363, PA("rt_tgsigqueueinfo", []PArg{A("tgid", INT), A("tid", INT), A("sig", INT), A("siginfo", POINTER)})})
+	RegisterCompat(&SArgs{364, PA("perf_event_open", []PArg{A("attr_uptr", POINTER), A("pid", INT), A("cpu", INT), A("group_fd", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{365, PA("recvmmsg", []PArg{A("fd", INT), A("mmsg", POINTER), A("vlen", INT), A("flags", INT), A("timeout", TIMESPEC32)})})
+	RegisterCompat(&SArgs{366, PA("accept4", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{367, PA("fanotify_init", []PArg{A("flags", INT), A("event_f_flags", INT)})})
+	RegisterCompat(&SArgs{368, PA("fanotify_mark", []PArg{A("fanotify_fd", INT), A("flags", INT), A("mask", UINT64), A("dfd", INT), A("pathname", STRING)})})
+	RegisterCompat(&SArgs{369, PA("prlimit64", []PArg{A("pid", INT), A("resource", INT), A("new_rlim", POINTER), A("old_rlim", POINTER)})})
+	RegisterCompat(&SArgs{370, PA("name_to_handle_at", []PArg{A("dfd", INT), A("name", STRING), A("handle", POINTER), A("mnt_id", INT), A("flag", INT)})})
+	RegisterCompat(&SArgs{371, PA("open_by_handle_at", []PArg{A("mountdirfd", INT), A("handle", POINTER), A("flags", INT)})})
+	RegisterCompat(&SArgs{372, PA("clock_adjtime", []PArg{A("which_clock", INT), A("utx", POINTER)})})
+	RegisterCompat(&SArgs{373, PA("syncfs", []PArg{A("fd", INT)})})
+	RegisterCompat(&SArgs{374, PA("sendmmsg", []PArg{A("fd", INT), A("mmsg", POINTER), A("vlen", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{375, PA("setns", []PArg{A("fd", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{376, PA("process_vm_readv", []PArg{A("pid", INT), B("local_iov", POINTER), A("liovcnt", INT), B("remote_iov", POINTER), A("riovcnt", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{377, PA("process_vm_writev", []PArg{A("pid", INT), A("local_iov", POINTER), A("liovcnt", INT), A("remote_iov", POINTER), A("riovcnt", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{378, PA("kcmp", []PArg{A("pid1", INT), A("pid2", INT), A("type", INT), A("idx1", INT), A("idx2", INT)})})
+	RegisterCompat(&SArgs{379, PA("finit_module", []PArg{A("fd", INT), A("uargs", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{380, PA("sched_setattr", []PArg{A("pid", INT), A("uattr", POINTER), A("flags", INT)})})
+	RegisterCompat(&SArgs{381, PA("sched_getattr", []PArg{A("pid", INT), A("uattr", POINTER), A("usize", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{382, PA("renameat2", []PArg{A("olddirfd", INT), A("oldpath", STRING), A("newdirfd", INT), A("newpath", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{383, PA("seccomp", []PArg{A("operation", INT), A("flags", INT), A("args", POINTER)})})
+	RegisterCompat(&SArgs{384, PA("getrandom", []PArg{B("buf", POINTER), A("buflen", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{385, PA("memfd_create", []PArg{A("name", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{386, PA("bpf", []PArg{A("cmd", INT), A("attr", POINTER), A("size", INT)})})
+	RegisterCompat(&SArgs{387, PA("execveat", []PArg{A("dirfd", INT), A("pathname", STRING), A("argv", STRING_ARR), A("envp", STRING_ARR), A("flags", INT)})})
+	RegisterCompat(&SArgs{388, PA("userfaultfd", []PArg{A("flags", INT)})})
+	RegisterCompat(&SArgs{389, PA("membarrier", []PArg{A("cmd", INT), A("flags", POINTER), A("cpu_id", INT)})})
+	RegisterCompat(&SArgs{390, PA("mlock2", []PArg{A("start", INT), A("len", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{391, PA("copy_file_range", []PArg{A("fd_in", INT), A("off_in", INT), A("fd_out", INT), A("off_out", INT), A("len", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{392, PA("preadv2", []PArg{A("fd", INT), A("vec", POINTER), A("vlen", INT), A("pos_l", INT), A("pos_h", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{393, PA("pwritev2", []PArg{A("fd", INT), A("vec", POINTER), A("vlen", INT), A("pos_l", INT), A("pos_h", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{394, PA("pkey_mprotect", []PArg{B("addr", POINTER), A("length", INT), A("prot", INT), A("pkey", INT)})})
+	RegisterCompat(&SArgs{395, PA("pkey_alloc", []PArg{A("flags", INT), A("init_val", INT)})})
+	RegisterCompat(&SArgs{396, PA("pkey_free", []PArg{A("pkey", INT)})})
+	RegisterCompat(&SArgs{397, PA("statx", []PArg{A("dfd", INT), A("filename", STRING), A("flags", INT), A("mask", INT), A("buffer", POINTER)})})
+	RegisterCompat(&SArgs{398, PA("rseq", []PArg{A("rseq", POINTER), A("rseq_len", INT), A("flags", INT), A("sig", INT)})})
+	RegisterCompat(&SArgs{399, PA("io_pgetevents", []PArg{A("ctx_id", POINTER), A("min_nr", UINT64), A("nr", UINT64), A("events", POINTER), A("timeout", TIMESPEC32), A("usig", POINTER)})})
+	RegisterCompat(&SArgs{400, PA("migrate_pages", []PArg{A("pid", INT), A("maxnode", INT), A("old_nodes", INT), A("new_nodes", INT)})})
+	RegisterCompat(&SArgs{401, PA("kexec_file_load", []PArg{A("kernel_fd", INT), A("initrd_fd", INT), A("cmdline_len", INT), A("cmdline_ptr", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{403, PA("clock_gettime64", []PArg{A("clockid", INT), B("tp", TIMESPEC)})})
+	RegisterCompat(&SArgs{404, PA("clock_settime64", []PArg{A("clockid", INT), A("tp", TIMESPEC)})})
+	RegisterCompat(&SArgs{405, PA("clock_adjtime64", []PArg{A("which_clock", INT), A("utx", POINTER)})})
+	RegisterCompat(&SArgs{406, PA("clock_getres_time64", []PArg{A("clockid", INT), B("res", TIMESPEC)})})
+	RegisterCompat(&SArgs{407, PA("clock_nanosleep_time64", []PArg{A("clockid", INT), A("flags", INT), A("request", TIMESPEC), B("remain", TIMESPEC)})})
+	RegisterCompat(&SArgs{408, PA("timer_gettime64", []PArg{A("timer_id", INT), A("setting", POINTER)})})
+	RegisterCompat(&SArgs{409, PA("timer_settime64", []PArg{A("timer_id", INT), A("flags", INT), A("new_setting", POINTER), A("old_setting", POINTER)})})
+	RegisterCompat(&SArgs{410, PA("timerfd_gettime64", []PArg{A("fd", INT), B("curr_value", ITIMERSPEC)})})
+	RegisterCompat(&SArgs{411, PA("timerfd_settime64", []PArg{A("fd", INT), A("flags", INT), A("new_value", ITIMERSPEC), A("old_value", ITIMERSPEC)})})
+	RegisterCompat(&SArgs{412, PA("utimensat_time64", []PArg{A("dirfd", INT), A("pathname", STRING), A("times", ITIMERSPEC), A("flags", INT)})})
+	RegisterCompat(&SArgs{413, PA("pselect6_time64", []PArg{A("n", INT), A("inp", POINTER), A("outp", POINTER), A("exp", POINTER), A("tsp", TIMESPEC), A("sig", POINTER)})})
+	RegisterCompat(&SArgs{414, PA("ppoll_time64", []PArg{A("fds", INT), A("nfds", INT), A("tmo_p", TIMESPEC), A("sigmask", INT)})})
+	RegisterCompat(&SArgs{416, PA("io_pgetevents_time64", []PArg{A("ctx_id", POINTER), A("min_nr", UINT64), A("nr", UINT64), A("events", POINTER), A("timeout", TIMESPEC), A("usig", POINTER)})})
+	RegisterCompat(&SArgs{417, PA("recvmmsg_time64", []PArg{A("fd", INT), A("mmsg", POINTER), A("vlen", INT), A("flags", INT), A("timeout", TIMESPEC)})})
+	RegisterCompat(&SArgs{418, PA("mq_timedsend_time64", []PArg{A("mqdes", INT), A("u_msg_ptr", STRING), A("msg_len", INT), A("msg_prio", INT), A("u_abs_timeout", TIMESPEC)})})
+	RegisterCompat(&SArgs{419, PA("mq_timedreceive_time64", []PArg{A("mqdes", INT), A("u_msg_ptr", STRING), A("msg_len", INT), A("u_msg_prio", INT), A("u_abs_timeout", TIMESPEC)})})
+	RegisterCompat(&SArgs{420, PA("semtimedop_time64", []PArg{A("semid", INT), A("tsops", POINTER), A("nsops", INT), A("timeout", TIMESPEC)})})
+	RegisterCompat(&SArgs{421, PA("rt_sigtimedwait_time64", []PArg{A("uthese", POINTER), A("uinfo", POINTER), A("uts", TIMESPEC), A("sigsetsize", INT)})})
+	RegisterCompat(&SArgs{422, PA("futex_time64", []PArg{A("uaddr", INT), A("futex_op", INT), A("val", INT), A("timeout", TIMESPEC)})})
+	RegisterCompat(&SArgs{423, PA("sched_rr_get_interval_time64", []PArg{A("pid", INT), A("interval", TIMESPEC)})})
+	RegisterCompat(&SArgs{424, PA("pidfd_send_signal", []PArg{A("pidfd", INT), A("sig", INT), A("info", POINTER), A("flags", INT)})})
+	RegisterCompat(&SArgs{425, PA("io_uring_setup", []PArg{A("entries", INT), A("params", POINTER)})})
+	RegisterCompat(&SArgs{426, PA("io_uring_enter", []PArg{A("fd", INT), A("to_submit", INT), A("min_complete", INT), A("flags", INT), A("argp", POINTER), A("argsz", INT)})})
+	RegisterCompat(&SArgs{427, PA("io_uring_register", []PArg{A("fd", INT), A("opcode", INT), A("arg", POINTER), A("nr_args", INT)})})
+	RegisterCompat(&SArgs{428, PA("open_tree", []PArg{A("dfd", INT), A("filename", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{429, PA("move_mount", []PArg{A("from_dfd", INT), A("from_pathname", STRING), A("to_dfd", INT), A("to_pathname", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{430, PA("fsopen", []PArg{A("_fs_name", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{431, PA("fsconfig", []PArg{A("fd", INT), A("cmd", INT), A("_key", STRING), A("_value", POINTER), A("aux", INT)})})
+	RegisterCompat(&SArgs{432, PA("fsmount", []PArg{A("fs_fd", INT), A("flags", INT), A("attr_flags", INT)})})
+	RegisterCompat(&SArgs{433, PA("fspick", []PArg{A("dfd", INT), A("path", STRING), A("flags", INT)})})
+	RegisterCompat(&SArgs{434, PA("pidfd_open", []PArg{A("pid", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{435, PA("clone3", []PArg{A("uargs", POINTER), A("size", INT)})})
+	RegisterCompat(&SArgs{436, PA("close_range", []PArg{A("fd", INT), A("max_fd", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{437, PA("openat2", []PArg{A("dfd", INT), A("filename", STRING), A("how", POINTER), A("usize", INT)})})
+	RegisterCompat(&SArgs{438, PA("pidfd_getfd", []PArg{A("pidfd", INT), A("fd", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{439, PA("faccessat2", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterCompat(&SArgs{440, PA("process_madvise", []PArg{A("pidfd", INT), A("vec", POINTER), A("vlen", INT), A("behavior", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{441, PA("epoll_pwait2", []PArg{A("epfd", INT), A("events", POINTER), A("maxevents", INT), A("timeout", TIMESPEC), A("sigmask", POINTER), A("sigsetsize", INT)})})
+	RegisterCompat(&SArgs{442, PA("mount_setattr", []PArg{A("dfd", INT), A("path", STRING), A("flags", INT), A("uattr", POINTER), A("usize", INT)})})
+	RegisterCompat(&SArgs{443, PA("quotactl_fd", []PArg{A("fd", INT), A("cmd", INT), A("id", INT), A("addr", POINTER)})})
+	RegisterCompat(&SArgs{444, PA("landlock_create_ruleset", []PArg{A("attr", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{445, PA("landlock_add_rule", []PArg{A("ruleset_fd", INT), A("rule_type", INT), A("rule_attr", POINTER), A("flags", INT)})})
+	RegisterCompat(&SArgs{446, PA("landlock_restrict_self", []PArg{A("ruleset_fd", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{447, PA("memfd_secret", []PArg{A("flags", INT)})})
+	RegisterCompat(&SArgs{448, PA("process_mrelease", []PArg{A("pidfd", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{449, PA("futex_waitv", []PArg{A("waiters", POINTER), A("nr_futexes", INT), A("flags", INT), A("timeout", TIMESPEC), A("clockid", INT)})})
+	RegisterCompat(&SArgs{450, PA("set_mempolicy_home_node", []PArg{A("start", INT), A("len", INT), A("home_node", INT), A("flags", INT)})})
+	RegisterCompat(&SArgs{0x0f0002, PA("cacheflush", []PArg{A("start", POINTER), A("end", POINTER), A("flags", INT)})})
+	RegisterCompat(&SArgs{0x0f0005, PA("set_tls", []PArg{A("tls", POINTER)})})
+}
@@ -0,0 +1,391 @@
+package config
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+// x86_64 的 syscall 调用号和 arm64 不同 保留了 open stat 这类老的 syscall
+// 参数含义和 arm64 同名的 syscall 一致 布局不同的结构体单独定义 clone 的后两个参数顺序与 arm64 相反
+// 参数寄存器依次是 rdi rsi rdx r10 r8 r9 由 eBPF 负责读取
+
+type Stat_X86_64 struct {
+	Dev       uint64
+	Ino       uint64
+	Nlink     uint64
+	Mode      uint32
+	Uid       uint32
+	Gid       uint32
+	X__pad0   int32
+	Rdev      uint64
+	Size      int64
+	Blksize   int64
+	Blocks    int64
+	Atim      syscall.Timespec
+	Mtim      syscall.Timespec
+	Ctim      syscall.Timespec
+	X__unused [3]int64
+}
+
+// x86_64 下 epoll_event 是 packed 的
+type EpollEvent_X86_64 struct {
+	Events uint32
+	Fd     int32
+	Pad    int32
+}
+
+var STAT_X86_64 = AT(TYPE_STAT_X86_64, TYPE_STRUCT, uint32(binary.Size(Stat_X86_64{})))
+var EPOLLEVENT_X86_64 = AT(TYPE_EPOLLEVENT_X86_64, TYPE_STRUCT, uint32(binary.Size(EpollEvent_X86_64{})))
+
+func init() {
+	RegisterX86_64(&SArgs{0, PA("read", []PArg{A("fd", INT), B("buf", READ_BUFFER_T), A("count", INT)})})
+	RegisterX86_64(&SArgs{1, PA("write", []PArg{A("fd", INT), A("buf", WRITE_BUFFER_T), A("count", INT)})})
+	RegisterX86_64(&SArgs{2, PA("open", []PArg{A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterX86_64(&SArgs{3, PA("close", []PArg{A("fd", INT)})})
+	RegisterX86_64(&SArgs{4, PA("stat", []PArg{A("pathname", STRING), B("statbuf", STAT_X86_64)})})
+	RegisterX86_64(&SArgs{5, PA("fstat", []PArg{A("fd", INT), B("statbuf", STAT_X86_64)})})
+	RegisterX86_64(&SArgs{6, PA("lstat", []PArg{A("pathname", STRING), B("statbuf", STAT_X86_64)})})
+	RegisterX86_64(&SArgs{7, PA("poll", []PArg{A("fds", POLLFD), A("nfds", INT), A("timeout", INT)})})
+	RegisterX86_64(&SArgs{8, PA("lseek", []PArg{A("fd", INT), A("offset", INT), A("whence", INT)})})
+	RegisterX86_64(&SArgs{9, PA("mmap", []PArg{B("addr", POINTER), A("length", INT), A("prot", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{10, PA("mprotect", []PArg{A("addr", POINTER), A("length", INT), A("prot", INT)})})
+	RegisterX86_64(&SArgs{11, PA("munmap", []PArg{A("addr", INT), A("length", INT)})})
+	RegisterX86_64(&SArgs{12, PA("brk", []PArg{A("brk", INT)})})
+	RegisterX86_64(&SArgs{13, PA("rt_sigaction", []PArg{A("signum", INT), A("act", SIGACTION), A("oldact", SIGACTION)})})
+	RegisterX86_64(&SArgs{14, PA("rt_sigprocmask", []PArg{A("how", INT), A("set", UINT64), A("oldset", UINT64), A("sigsetsize", INT)})})
+	RegisterX86_64(&SArgs{15, PA("rt_sigreturn", []PArg{A("mask", INT)})})
+	RegisterX86_64(&SArgs{16, PA("ioctl", []PArg{A("fd", INT), A("request", UINT64), A("arg0", INT), A("arg1", INT), A("arg2", INT), A("arg3", INT)})})
+	RegisterX86_64(&SArgs{17, PA("pread64", []PArg{A("fd", INT), B("buf", READ_BUFFER_T), A("count", INT), A("offset", INT)})})
+	RegisterX86_64(&SArgs{18, PA("pwrite64", []PArg{A("fd", INT), A("buf", WRITE_BUFFER_T), A("count", INT), A("offset", INT)})})
+	RegisterX86_64(&SArgs{19, PA("readv", []PArg{A("fd", INT), B("iov", POINTER), A("iovcnt", INT)})})
+	RegisterX86_64(&SArgs{20, PA("writev", []PArg{A("fd", INT), A("iov", POINTER), A("iovcnt", INT)})})
+	RegisterX86_64(&SArgs{21, PA("access", []PArg{A("pathname", STRING), A("mode", INT)})})
+	RegisterX86_64(&SArgs{22, PA("pipe", []PArg{B("pipefd", PIPEFD)})})
+	RegisterX86_64(&SArgs{23, PA("select", []PArg{A("nfds", INT), A("readfds", POINTER), A("writefds", POINTER), A("exceptfds", POINTER), A("timeout", TIMEVAL)})})
+	RegisterX86_64(&SArgs{24, PA("sched_yield", []PArg{})})
+	RegisterX86_64(&SArgs{25, PA("mremap", []PArg{A("old_address", POINTER), A("old_size", INT), A("new_size", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{26, PA("msync", []PArg{A("addr", POINTER), A("length", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{27, PA("mincore", []PArg{A("start", INT), A("len", INT), A("vec", STRING)})})
+	RegisterX86_64(&SArgs{28, PA("madvise", []PArg{A("addr", POINTER), A("len", INT), A("advice", INT)})})
+	RegisterX86_64(&SArgs{29, PA("shmget", []PArg{A("key", INT), A("size", INT), A("shmflg", INT)})})
+	RegisterX86_64(&SArgs{30, PA("shmat", []PArg{A("shmid", INT), A("shmaddr", POINTER), A("shmflg", INT)})})
+	RegisterX86_64(&SArgs{31, PA("shmctl", []PArg{A("shmid", INT), A("cmd", INT), A("buf", POINTER)})})
+	RegisterX86_64(&SArgs{32, PA("dup", []PArg{A("oldfd", INT)})})
+	RegisterX86_64(&SArgs{33, PA("dup2", []PArg{A("oldfd", INT), A("newfd", INT)})})
+	RegisterX86_64(&SArgs{34, PA("pause", []PArg{})})
+	RegisterX86_64(&SArgs{35, PA("nanosleep", []PArg{A("req", TIMESPEC), A("rem", TIMESPEC)})})
+	RegisterX86_64(&SArgs{36, PA("getitimer", []PArg{A("which", INT), A("value", POINTER)})})
+	RegisterX86_64(&SArgs{37, PA("alarm", []PArg{A("seconds", UINT32)})})
+	RegisterX86_64(&SArgs{38, PA("setitimer", []PArg{A("which", INT), A("value", POINTER), A("ovalue", POINTER)})})
+	RegisterX86_64(&SArgs{39, PA("getpid", []PArg{})})
+	RegisterX86_64(&SArgs{40, PA("sendfile", []PArg{A("out_fd", INT), A("in_fd", INT), A("offset", INT), A("count", INT)})})
+	RegisterX86_64(&SArgs{41, PA("socket", []PArg{A("domain", INT), A("type", INT), A("protocol", INT)})})
+	RegisterX86_64(&SArgs{42, PA("connect", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterX86_64(&SArgs{43, PA("accept", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterX86_64(&SArgs{44, PA("sendto", []PArg{A("sockfd", INT), A("buf", READ_BUFFER_T), A("len", INT), A("flags", INT), A("dest_addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterX86_64(&SArgs{45, PA("recvfrom", []PArg{A("sockfd", INT), B("buf", WRITE_BUFFER_T), A("len", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{46, PA("sendmsg", []PArg{A("sockfd", INT), A("msg", MSGHDR), A("flags", INT)})})
+	RegisterX86_64(&SArgs{47, PA("recvmsg", []PArg{A("sockfd", INT), B("msg", MSGHDR), A("flags", INT)})})
+	RegisterX86_64(&SArgs{48, PA("shutdown", []PArg{A("sockfd", INT), A("how", INT)})})
+	RegisterX86_64(&SArgs{49, PA("bind", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterX86_64(&SArgs{50, PA("listen", []PArg{A("sockfd", INT), A("backlog", INT)})})
+	RegisterX86_64(&SArgs{51, PA("getsockname", []PArg{A("sockfd", INT), B("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterX86_64(&SArgs{52, PA("getpeername", []PArg{A("sockfd", INT), B("addr", SOCKADDR), A("addrlen", INT)})})
+	RegisterX86_64(&SArgs{53, PA("socketpair", []PArg{A("domain", INT), A("type", INT), A("protocol", INT), A("sv", POINTER)})})
+	RegisterX86_64(&SArgs{54, PA("setsockopt", []PArg{A("sockfd", INT), A("level", INT), A("optname", INT), A("optval", INT), A("optlen", INT)})})
+	RegisterX86_64(&SArgs{55, PA("getsockopt", []PArg{A("sockfd", INT), A("level", INT), A("optname", INT), B("optval", INT), A("optlen", POINTER)})})
+	RegisterX86_64(&SArgs{56, PA("clone", []PArg{A("flags", UINT64), A("stack", POINTER), A("parent_tid", POINTER), A("child_tid", POINTER), A("tls", POINTER)})})
+	RegisterX86_64(&SArgs{57, PA("fork", []PArg{})})
+	RegisterX86_64(&SArgs{58, PA("vfork", []PArg{})})
+	RegisterX86_64(&SArgs{59, PA("execve", []PArg{A("pathname", STRING), A("argv", STRING_ARR), A("envp", STRING_ARR)})})
+	RegisterX86_64(&SArgs{60, PArgs{"exit", B("ret", NONE), []PArg{A("status", INT)}}})
+	RegisterX86_64(&SArgs{61, PA("wait4", []PArg{A("pid", INT), A("wstatus", POINTER), A("options", INT), B("rusage", RUSAGE)})})
+	RegisterX86_64(&SArgs{62, PA("kill", []PArg{A("pid", INT), A("sig", INT)})})
+	RegisterX86_64(&SArgs{63, PA("uname", []PArg{B("buf", UTSNAME)})})
+	RegisterX86_64(&SArgs{64, PA("semget", []PArg{A("key", INT), A("nsems", INT), A("semflg", INT)})})
+	RegisterX86_64(&SArgs{65, PA("semop", []PArg{A("semid", INT), A("tsops", POINTER), A("nsops", INT)})})
+	RegisterX86_64(&SArgs{66, PA("semctl", []PArg{A("semid", INT), A("semnum", INT), A("cmd", INT), A("arg", INT)})})
+	RegisterX86_64(&SArgs{67, PA("shmdt", []PArg{A("shmaddr", POINTER)})})
+	RegisterX86_64(&SArgs{68, PA("msgget", []PArg{A("key", INT), A("msgflg", INT)})})
+	RegisterX86_64(&SArgs{69, PA("msgsnd", []PArg{A("msqid", INT), A("msgp", POINTER), A("msgsz", INT), A("msgflg", INT)})})
+	RegisterX86_64(&SArgs{70, PA("msgrcv", []PArg{A("msqid", INT), A("msgp", POINTER), A("msgsz", INT), A("msgtyp", UINT64), A("msgflg", INT)})})
+	RegisterX86_64(&SArgs{71, PA("msgctl", []PArg{A("msqid", INT), A("cmd", INT), A("buf", POINTER)})})
+	RegisterX86_64(&SArgs{72, PA("fcntl", []PArg{A("fd", INT), A("cmd", INT), A("arg", INT)})})
+	RegisterX86_64(&SArgs{73, PA("flock", []PArg{A("fd", INT), A("operation", INT)})})
+	RegisterX86_64(&SArgs{74, PA("fsync", []PArg{A("fd", INT)})})
+	RegisterX86_64(&SArgs{75, PA("fdatasync", []PArg{A("fd", INT)})})
+	RegisterX86_64(&SArgs{76, PA("truncate", []PArg{A("path", STRING), A("length", INT)})})
+	RegisterX86_64(&SArgs{77, PA("ftruncate", []PArg{A("fd", INT), A("length", INT)})})
+	RegisterX86_64(&SArgs{78, PA("getdents", []PArg{A("fd", INT), B("dirp", POINTER), A("count", INT)})})
+	RegisterX86_64(&SArgs{79, PA("getcwd", []PArg{B("buf", STRING), A("size", UINT64)})})
+	RegisterX86_64(&SArgs{80, PA("chdir", []PArg{A("path", STRING)})})
+	RegisterX86_64(&SArgs{81, PA("fchdir", []PArg{A("fd", INT)})})
+	RegisterX86_64(&SArgs{82, PA("rename", []PArg{A("oldpath", STRING), A("newpath", STRING)})})
+	RegisterX86_64(&SArgs{83, PA("mkdir", []PArg{A("pathname", STRING), A("mode", UINT32)})})
+	RegisterX86_64(&SArgs{84, PA("rmdir", []PArg{A("pathname", STRING)})})
+	RegisterX86_64(&SArgs{85, PA("creat", []PArg{A("pathname", STRING), A("mode", UINT32)})})
+	RegisterX86_64(&SArgs{86, PA("link", []PArg{A("oldpath", STRING), A("newpath", STRING)})})
+	RegisterX86_64(&SArgs{87, PA("unlink", []PArg{A("pathname", STRING)})})
+	RegisterX86_64(&SArgs{88, PA("symlink", []PArg{A("target", STRING), A("linkpath", STRING)})})
+	RegisterX86_64(&SArgs{89, PA("readlink", []PArg{A("pathname", STRING), B("buf", STRING), A("bufsiz", INT)})})
+	RegisterX86_64(&SArgs{90, PA("chmod", []PArg{A("pathname", STRING), A("mode", UINT32)})})
+	RegisterX86_64(&SArgs{91, PA("fchmod", []PArg{A("fd", INT), A("mode", INT)})})
+	RegisterX86_64(&SArgs{92, PA("chown", []PArg{A("pathname", STRING), A("owner", INT), A("group", INT)})})
+	RegisterX86_64(&SArgs{93, PA("fchown", []PArg{A("fd", INT), A("owner", INT), A("group", INT)})})
+	RegisterX86_64(&SArgs{94, PA("lchown", []PArg{A("pathname", STRING), A("owner", INT), A("group", INT)})})
+	RegisterX86_64(&SArgs{95, PA("umask", []PArg{A("mode", INT)})})
+	RegisterX86_64(&SArgs{96, PA("gettimeofday", []PArg{B("tv", TIMEVAL), B("tz", TIMEZONE)})})
+	RegisterX86_64(&SArgs{97, PA("getrlimit", []PArg{A("resource", INT), B("rlim", POINTER)})})
+	RegisterX86_64(&SArgs{98, PA("getrusage", []PArg{A("who", INT), B("usage", RUSAGE)})})
+	RegisterX86_64(&SArgs{99, PA("sysinfo", []PArg{B("info", SYSINFO)})})
+	RegisterX86_64(&SArgs{100, PA("times", []PArg{A("tbuf", POINTER)})})
+	RegisterX86_64(&SArgs{101, PA("ptrace", []PArg{A("request", INT), A("pid", INT), A("addr", POINTER), A("data", POINTER)})})
+	RegisterX86_64(&SArgs{102, PA("getuid", []PArg{})})
+	RegisterX86_64(&SArgs{103, PA("syslog", []PArg{A("type", INT), A("bufp", STRING), A("len", INT)})})
+	RegisterX86_64(&SArgs{104, PA("getgid", []PArg{})})
+	RegisterX86_64(&SArgs{105, PA("setuid", []PArg{A("uid", INT)})})
+	RegisterX86_64(&SArgs{106, PA("setgid", []PArg{A("gid", INT)})})
+	RegisterX86_64(&SArgs{107, PA("geteuid", []PArg{})})
+	RegisterX86_64(&SArgs{108, PA("getegid", []PArg{})})
+	RegisterX86_64(&SArgs{109, PA("setpgid", []PArg{A("pid", INT), A("pgid", INT)})})
+	RegisterX86_64(&SArgs{110, PA("getppid", []PArg{})})
+	RegisterX86_64(&SArgs{111, PA("getpgrp", []PArg{})})
+	RegisterX86_64(&SArgs{112, PA("setsid", []PArg{})})
+	RegisterX86_64(&SArgs{113, PA("setreuid", []PArg{A("ruid", INT), A("euid", INT)})})
+	RegisterX86_64(&SArgs{114, PA("setregid", []PArg{A("rgid", INT), A("egid", INT)})})
+	RegisterX86_64(&SArgs{115, PA("getgroups", []PArg{A("gidsetsize", INT), A("grouplist", INT)})})
+	RegisterX86_64(&SArgs{116, PA("setgroups", []PArg{A("gidsetsize", INT), A("grouplist", INT)})})
+	RegisterX86_64(&SArgs{117, PA("setresuid", []PArg{A("ruid", INT), A("euid", INT), A("suid", INT)})})
+	RegisterX86_64(&SArgs{118, PA("getresuid", []PArg{A("ruidp", INT), A("euidp", INT), A("suidp", INT)})})
+	RegisterX86_64(&SArgs{119, PA("setresgid", []PArg{A("rgid", INT), A("egid", INT), A("sgid", INT)})})
+	RegisterX86_64(&SArgs{120, PA("getresgid", []PArg{A("rgidp", INT), A("egidp", INT), A("sgidp", INT)})})
+	RegisterX86_64(&SArgs{121, PA("getpgid", []PArg{A("pid", INT)})})
+	RegisterX86_64(&SArgs{122, PA("setfsuid", []PArg{A("uid", INT)})})
+	RegisterX86_64(&SArgs{123, PA("setfsgid", []PArg{A("gid", INT)})})
+	RegisterX86_64(&SArgs{124, PA("getsid", []PArg{A("pid", INT)})})
+	RegisterX86_64(&SArgs{125, PA("capget", []PArg{A("header", POINTER), A("dataptr", POINTER)})})
+	RegisterX86_64(&SArgs{126, PA("capset", []PArg{A("header", POINTER), A("data", POINTER)})})
+	RegisterX86_64(&SArgs{127, PA("rt_sigpending", []PArg{A("uset", POINTER), A("sigsetsize", INT)})})
+	RegisterX86_64(&SArgs{128, PA("rt_sigtimedwait", []PArg{A("uthese", POINTER), A("uinfo", POINTER), A("uts", TIMESPEC), A("sigsetsize", INT)})})
+	RegisterX86_64(&SArgs{129, PA("rt_sigqueueinfo", []PArg{A("pid", INT), A("sig", INT), A("uinfo", POINTER)})})
+	RegisterX86_64(&SArgs{130, PA("rt_sigsuspend", []PArg{A("mask", SIGSET)})})
+	RegisterX86_64(&SArgs{131, PA("sigaltstack", []PArg{A("ss", STACK_T), A("old_ss", STACK_T)})})
+	RegisterX86_64(&SArgs{132, PA("utime", []PArg{A("filename", STRING), A("times", POINTER)})})
+	RegisterX86_64(&SArgs{133, PA("mknod", []PArg{A("pathname", STRING), A("mode", UINT32), A("dev", INT)})})
+	RegisterX86_64(&SArgs{134, PA("uselib", []PArg{A("library", STRING)})})
+	RegisterX86_64(&SArgs{135, PA("personality", []PArg{A("personality", INT)})})
+	RegisterX86_64(&SArgs{136, PA("ustat", []PArg{A("dev", INT), B("ubuf", POINTER)})})
+	RegisterX86_64(&SArgs{137, PA("statfs", []PArg{A("path", STRING), B("buf", STATFS)})})
+	RegisterX86_64(&SArgs{138, PA("fstatfs", []PArg{A("fd", INT), B("buf", STATFS)})})
+	RegisterX86_64(&SArgs{139, PA("sysfs", []PArg{A("option", INT), A("arg1", INT), A("arg2", INT)})})
+	RegisterX86_64(&SArgs{140, PA("getpriority", []PArg{A("which", INT), A("who", INT)})})
+	RegisterX86_64(&SArgs{141, PA("setpriority", []PArg{A("which", INT), A("who", INT), A("prio", INT)})})
+	RegisterX86_64(&SArgs{142, PA("sched_setparam", []PArg{A("pid", INT), A("param", POINTER)})})
+	RegisterX86_64(&SArgs{143, PA("sched_getparam", []PArg{A("pid", INT), B("param", POINTER)})})
+	RegisterX86_64(&SArgs{144, PA("sched_setscheduler", []PArg{A("pid", INT), A("policy", INT), A("param", POINTER)})})
+	RegisterX86_64(&SArgs{145, PA("sched_getscheduler", []PArg{A("pid", INT)})})
+	RegisterX86_64(&SArgs{146, PA("sched_get_priority_max", []PArg{A("policy", INT)})})
+	RegisterX86_64(&SArgs{147, PA("sched_get_priority_min", []PArg{A("policy", INT)})})
+	RegisterX86_64(&SArgs{148, PA("sched_rr_get_interval", []PArg{A("pid", INT), A("interval", TIMESPEC)})})
+	RegisterX86_64(&SArgs{149, PA("mlock", []PArg{A("start", INT), A("len", INT)})})
+	RegisterX86_64(&SArgs{150, PA("munlock", []PArg{A("start", INT), A("len", INT)})})
+	RegisterX86_64(&SArgs{151, PA("mlockall", []PArg{A("flags", INT)})})
+	RegisterX86_64(&SArgs{152, PA("munlockall", []PArg{})})
+	RegisterX86_64(&SArgs{153, PA("vhangup", []PArg{})})
+	RegisterX86_64(&SArgs{154, PA("modify_ldt", []PArg{A("func", INT), A("ptr", POINTER), A("bytecount", INT)})})
+	RegisterX86_64(&SArgs{155, PA("pivot_root", []PArg{A("new_root", STRING), A("put_old", STRING)})})
+	RegisterX86_64(&SArgs{157, PA("prctl", []PArg{A("option", INT), A("arg2", UINT64), A("arg3", UINT64), A("arg4", UINT64), A("arg5", UINT64)})})
+	RegisterX86_64(&SArgs{158, PA("arch_prctl", []PArg{A("code", INT), A("addr", POINTER)})})
+	RegisterX86_64(&SArgs{159, PA("adjtimex", []PArg{A("txc_p", POINTER)})})
+	RegisterX86_64(&SArgs{160, PA("setrlimit", []PArg{A("resource", UTSNAME), A("rlim", POINTER)})})
+	RegisterX86_64(&SArgs{161, PA("chroot", []PArg{A("path", STRING)})})
+	RegisterX86_64(&SArgs{162, PArgs{"sync", B("ret", NONE), []PArg{}}})
+	RegisterX86_64(&SArgs{163, PA("acct", []PArg{A("name", STRING)})})
+	RegisterX86_64(&SArgs{164, PA("settimeofday", []PArg{A("tv", TIMEVAL), A("tz", TIMEZONE)})})
+	RegisterX86_64(&SArgs{165, PA("mount", []PArg{A("source", INT), A("target", STRING), A("filesystemtype", STRING), A("mountflags", INT), A("data", POINTER)})})
+	RegisterX86_64(&SArgs{166, PA("umount2", []PArg{A("target", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{167, PA("swapon", []PArg{A("specialfile", STRING), A("swap_flags", INT)})})
+	RegisterX86_64(&SArgs{168, PA("swapoff", []PArg{A("specialfile", STRING)})})
+	RegisterX86_64(&SArgs{169, PA("reboot", []PArg{A("magic1", INT), A("magic2", INT), A("cmd", INT), A("arg", POINTER)})})
+	RegisterX86_64(&SArgs{170, PA("sethostname", []PArg{A("name", STRING), A("len", INT)})})
+	RegisterX86_64(&SArgs{171, PA("setdomainname", []PArg{A("name", STRING), A("len", INT)})})
+	RegisterX86_64(&SArgs{172, PA("iopl", []PArg{A("level", INT)})})
+	RegisterX86_64(&SArgs{173, PA("ioperm", []PArg{A("from", POINTER), A("num", INT), A("turn_on", INT)})})
+	RegisterX86_64(&SArgs{175, PA("init_module", []PArg{A("umod", POINTER), A("len", INT), A("uargs", STRING)})})
+	RegisterX86_64(&SArgs{176, PA("delete_module", []PArg{A("name_user", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{179, PA("quotactl", []PArg{A("cmd", INT), A("special", STRING), A("id", INT), A("addr", INT)})})
+	RegisterX86_64(&SArgs{180, PA("nfsservctl", []PArg{A("cmd", INT), A("argp", POINTER), A("resp", POINTER)})})
+	RegisterX86_64(&SArgs{186, PA("gettid", []PArg{})})
+	RegisterX86_64(&SArgs{187, PA("readahead", []PArg{A("fd", INT), A("offset", INT), A("count", INT)})})
+	RegisterX86_64(&SArgs{188, PA("setxattr", []PArg{A("pathname", STRING), A("name", STRING), A("value", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{189, PA("lsetxattr", []PArg{A("pathname", STRING), A("name", STRING), A("value", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{190, PA("fsetxattr", []PArg{A("fd", INT), A("name", STRING), A("value", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{191, PA("getxattr", []PArg{A("path", STRING), A("name", STRING), A("value", POINTER), A("size", INT)})})
+	RegisterX86_64(&SArgs{192, PA("lgetxattr", []PArg{A("path", STRING), A("name", STRING), A("value", POINTER), A("size", INT)})})
+	RegisterX86_64(&SArgs{193, PA("fgetxattr", []PArg{A("fd", INT), A("name", STRING), A("value", POINTER), A("size", INT)})})
+	RegisterX86_64(&SArgs{194, PA("listxattr", []PArg{A("pathname", STRING), A("list", STRING), A("size", INT)})})
+	RegisterX86_64(&SArgs{195, PA("llistxattr", []PArg{A("pathname", STRING), A("list", STRING), A("size", INT)})})
+	RegisterX86_64(&SArgs{196, PA("flistxattr", []PArg{A("fd", INT), A("list", STRING), A("size", INT)})})
+	RegisterX86_64(&SArgs{197, PA("removexattr", []PArg{A("pathname", STRING), A("name", STRING)})})
+	RegisterX86_64(&SArgs{198, PA("lremovexattr", []PArg{A("pathname", STRING), A("name", STRING)})})
+	RegisterX86_64(&SArgs{199, PA("fremovexattr", []PArg{A("fd", INT), A("name", STRING)})})
+	RegisterX86_64(&SArgs{200, PA("tkill", []PArg{A("tid", INT), A("sig", INT)})})
+	RegisterX86_64(&SArgs{201, PA("time", []PArg{B("tloc", POINTER)})})
+	RegisterX86_64(&SArgs{202, PA("futex", []PArg{A("uaddr", INT), A("futex_op", INT), A("val", INT), A("timeout", TIMESPEC)})})
+	RegisterX86_64(&SArgs{203, PA("sched_setaffinity", []PArg{A("pid", INT), A("cpusetsize", INT), A("mask", POINTER)})})
+	RegisterX86_64(&SArgs{204, PA("sched_getaffinity", []PArg{A("pid", INT), A("cpusetsize", INT), B("mask", POINTER)})})
+	RegisterX86_64(&SArgs{205, PA("set_thread_area", []PArg{A("u_info", POINTER)})})
+	RegisterX86_64(&SArgs{206, PA("io_setup", []PArg{A("nr_events", UINT), A("ctx_idp", POINTER)})})
+	RegisterX86_64(&SArgs{207, PA("io_destroy", []PArg{A("ctx", POINTER)})})
+	RegisterX86_64(&SArgs{208, PA("io_getevents", []PArg{A("ctx_id", POINTER), A("min_nr", UINT64), A("nr", UINT64), A("events", POINTER), A("timeout", TIMESPEC)})})
+	RegisterX86_64(&SArgs{209, PA("io_submit", []PArg{A("ctx_id", POINTER), A("nr", UINT64), A("iocbpp", POINTER)})})
+	RegisterX86_64(&SArgs{210, PA("io_cancel", []PArg{A("ctx_id", POINTER), A("iocb", POINTER), A("result", POINTER)})})
+	RegisterX86_64(&SArgs{211, PA("get_thread_area", []PArg{A("u_info", POINTER)})})
+	RegisterX86_64(&SArgs{212, PA("lookup_dcookie", []PArg{A("cookie", INT), B("buffer", STRING), A("len", INT)})})
+	RegisterX86_64(&SArgs{213, PA("epoll_create", []PArg{A("size", INT)})})
+	RegisterX86_64(&SArgs{216, PA("remap_file_pages", []PArg{A("start", INT), A("size", INT), A("prot", INT), A("pgoff", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{217, PA("getdents64", []PArg{A("fd", INT), B("dirp", POINTER), A("count", INT)})})
+	RegisterX86_64(&SArgs{218, PA("set_tid_address", []PArg{A("tidptr", POINTER)})})
+	RegisterX86_64(&SArgs{219, PA("restart_syscall", []PArg{})})
+	RegisterX86_64(&SArgs{220, PA("semtimedop", []PArg{A("semid", INT), A("tsops", POINTER), A("nsops", INT), A("timeout", TIMESPEC)})})
+	RegisterX86_64(&SArgs{221, PA("fadvise64", []PArg{A("fd", INT), A("offset", INT), A("len", INT), A("advice", INT)})})
+	RegisterX86_64(&SArgs{222, PA("timer_create", []PArg{A("which_clock", INT), A("timer_event_spec", POINTER), A("created_timer_id", INT)})})
+	RegisterX86_64(&SArgs{223, PA("timer_settime", []PArg{A("timer_id", INT), A("flags", INT), A("new_setting", POINTER), A("old_setting", POINTER)})})
+	RegisterX86_64(&SArgs{224, PA("timer_gettime", []PArg{A("timer_id", INT), A("setting", POINTER)})})
+	RegisterX86_64(&SArgs{225, PA("timer_getoverrun", []PArg{A("timer_id", INT)})})
+	RegisterX86_64(&SArgs{226, PA("timer_delete", []PArg{A("timer_id", INT)})})
+	RegisterX86_64(&SArgs{227, PA("clock_settime", []PArg{A("clockid", INT), A("tp", TIMESPEC)})})
+	RegisterX86_64(&SArgs{228, PA("clock_gettime", []PArg{A("clockid", INT), B("tp", TIMESPEC)})})
+	RegisterX86_64(&SArgs{229, PA("clock_getres", []PArg{A("clockid", INT), B("res", TIMESPEC)})})
+	RegisterX86_64(&SArgs{230, PA("clock_nanosleep", []PArg{A("clockid", INT), A("flags", INT), A("request", TIMESPEC), B("remain", TIMESPEC)})})
+	RegisterX86_64(&SArgs{231, PArgs{"exit_group", B("ret", NONE), []PArg{A("status", INT)}}})
+	RegisterX86_64(&SArgs{232, PA("epoll_wait", []PArg{A("epfd", INT), B("events", POINTER), A("maxevents", INT), A("timeout", INT)})})
+	RegisterX86_64(&SArgs{233, PA("epoll_ctl", []PArg{A("epfd", INT), A("op", INT), A("fd", INT), A("event", EPOLLEVENT_X86_64)})})
+	RegisterX86_64(&SArgs{234, PA("tgkill", []PArg{A("tgid", INT), A("tid", INT), A("sig", INT)})})
+	RegisterX86_64(&SArgs{235, PA("utimes", []PArg{A("filename", STRING), A("times", POINTER)})})
+	RegisterX86_64(&SArgs{237, PA("mbind", []PArg{A("start", INT), A("len", INT), A("mode", INT), A("nmask", INT), A("maxnode", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{238, PA("set_mempolicy", []PArg{A("mode", INT), A("nmask", INT), A("maxnode", INT)})})
+	RegisterX86_64(&SArgs{239, PA("get_mempolicy", []PArg{A("policy", INT), A("nmask", INT), A("maxnode", INT), A("addr", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{240, PA("mq_open", []PArg{A("u_name", STRING), A("oflag", INT), A("mode", INT), A("u_attr", POINTER)})})
+	RegisterX86_64(&SArgs{241, PA("mq_unlink", []PArg{A("u_name", STRING)})})
+	RegisterX86_64(&SArgs{242, PA("mq_timedsend", []PArg{A("mqdes", INT), A("u_msg_ptr", STRING), A("msg_len", INT), A("msg_prio", INT), A("u_abs_timeout", TIMESPEC)})})
+	RegisterX86_64(&SArgs{243, PA("mq_timedreceive", []PArg{A("mqdes", INT), A("u_msg_ptr", STRING), A("msg_len", INT), A("u_msg_prio", INT), A("u_abs_timeout", TIMESPEC)})})
+	RegisterX86_64(&SArgs{244, PA("mq_notify", []PArg{A("mqdes", INT), A("u_notification", POINTER)})})
+	RegisterX86_64(&SArgs{245, PA("mq_getsetattr", []PArg{A("mqdes", INT), A("u_mqstat", POINTER), A("u_omqstat", POINTER)})})
+	RegisterX86_64(&SArgs{246, PA("kexec_load", []PArg{A("entry", INT), A("nr_segments", INT), A("segments", POINTER), A("flags", INT)})})
+	RegisterX86_64(&SArgs{247, PA("waitid", []PArg{A("which", INT), A("upid", INT), A("infop", POINTER), A("options", INT), A("ru", POINTER)})})
+	RegisterX86_64(&SArgs{248, PA("add_key", []PArg{A("_type", STRING), A("_description", STRING), A("_payload", POINTER), A("plen", INT), A("ringid", INT)})})
+	RegisterX86_64(&SArgs{249, PA("request_key", []PArg{A("_type", STRING), A("_description", STRING), A("_callout_info", STRING), A("destringid", INT)})})
+	RegisterX86_64(&SArgs{250, PA("keyctl", []PArg{A("option", INT), A("arg2", INT), A("arg3", INT), A("arg4", INT), A("arg5", INT)})})
+	RegisterX86_64(&SArgs{251, PA("ioprio_set", []PArg{A("which", INT), A("who", INT), A("ioprio", INT)})})
+	RegisterX86_64(&SArgs{252, PA("ioprio_get", []PArg{A("which", INT), A("who", INT)})})
+	RegisterX86_64(&SArgs{253, PA("inotify_init", []PArg{})})
+	RegisterX86_64(&SArgs{254, PA("inotify_add_watch", []PArg{A("fd", INT), A("pathname", STRING), A("mask", INT)})})
+	RegisterX86_64(&SArgs{255, PA("inotify_rm_watch", []PArg{A("fd", INT), A("wd", INT)})})
+	RegisterX86_64(&SArgs{256, PA("migrate_pages", []PArg{A("pid", INT), A("maxnode", INT), A("old_nodes", INT), A("new_nodes", INT)})})
+	RegisterX86_64(&SArgs{257, PA("openat", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterX86_64(&SArgs{258, PA("mkdirat", []PArg{A("dirfd", INT), A("pathname", STRING), A("mode", INT)})})
+	RegisterX86_64(&SArgs{259, PA("mknodat", []PArg{A("dfd", INT), A("filename", STRING), A("mode", INT), A("dev", INT)})})
+	RegisterX86_64(&SArgs{260, PA("fchownat", []PArg{A("dirfd", INT), A("pathname", STRING), A("owner", INT), A("group", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{261, PA("futimesat", []PArg{A("dirfd", INT), A("pathname", STRING), A("times", POINTER)})})
+	RegisterX86_64(&SArgs{262, PA("newfstatat", []PArg{A("dirfd", INT), A("pathname", STRING), B("statbuf", STAT_X86_64), A("flags", INT)})})
+	RegisterX86_64(&SArgs{263, PA("unlinkat", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{264, PA("renameat", []PArg{A("olddirfd", INT), A("oldpath", STRING), A("newdirfd", INT), A("newpath", STRING)})})
+	RegisterX86_64(&SArgs{265, PA("linkat", []PArg{A("olddirfd", INT), A("oldpath", STRING), A("newdirfd", INT), A("newpath", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{266, PA("symlinkat", []PArg{A("target", STRING), A("newdirfd", INT), A("linkpath", STRING)})})
+	RegisterX86_64(&SArgs{267, PA("readlinkat", []PArg{A("dirfd", INT), A("pathname", STRING), B("buf", STRING), A("bufsiz", INT)})})
+	RegisterX86_64(&SArgs{268, PA("fchmodat", []PArg{A("dirfd", INT), A("pathname", STRING), A("mode", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{269, PA("faccessat", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterX86_64(&SArgs{270, PA("pselect6", []PArg{A("n", INT), A("inp", POINTER), A("outp", POINTER), A("exp", POINTER), A("tsp", TIMESPEC), A("sig", POINTER)})})
+	RegisterX86_64(&SArgs{271, PA("ppoll", []PArg{A("fds", INT), A("nfds", INT), A("tmo_p", TIMESPEC), A("sigmask", INT)})})
+	RegisterX86_64(&SArgs{272, PA("unshare", []PArg{A("unshare_flags", INT)})})
+	RegisterX86_64(&SArgs{273, PA("set_robust_list", []PArg{A("head", POINTER), A("len", INT)})})
+	RegisterX86_64(&SArgs{274, PA("get_robust_list", []PArg{A("pid", INT), A("head_ptr", POINTER), A("len_ptr", INT)})})
+	RegisterX86_64(&SArgs{275, PA("splice", []PArg{A("fd_in", INT), A("off_in", INT), A("fd_out", INT), A("off_out", INT), A("len", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{276, PA("tee", []PArg{A("fdin", INT), A("fdout", INT), A("len", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{277, PA("sync_file_range", []PArg{A("fd", INT), A("offset", INT), A("nbytes", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{278, PA("vmsplice", []PArg{A("fd", INT), A("uiov", POINTER), A("nr_segs", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{279, PA("move_pages", []PArg{A("pid", INT), A("nr_pages", INT), A("pages", POINTER), A("nodes", INT), A("status", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{280, PA("utimensat", []PArg{A("dirfd", INT), A("pathname", STRING), A("times", ITIMERSPEC), A("flags", INT)})})
+	RegisterX86_64(&SArgs{281, PA("epoll_pwait", []PArg{A("epfd", INT), A("events", POINTER), A("maxevents", INT), A("timeout", INT), A("sigmask", SIGSET)})})
+	RegisterX86_64(&SArgs{282, PA("signalfd", []PArg{A("fd", INT), A("mask", POINTER), A("sizemask", INT)})})
+	RegisterX86_64(&SArgs{283, PA("timerfd_create", []PArg{A("clockid", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{284, PA("eventfd", []PArg{A("initval", INT)})})
+	RegisterX86_64(&SArgs{285, PA("fallocate", []PArg{A("fd", INT), A("mode", INT), A("offset", INT), A("len", INT)})})
+	RegisterX86_64(&SArgs{286, PA("timerfd_settime", []PArg{A("fd", INT), A("flags", INT), A("new_value", ITIMERSPEC), A("old_value", ITIMERSPEC)})})
+	RegisterX86_64(&SArgs{287, PA("timerfd_gettime", []PArg{A("fd", INT), B("curr_value", ITIMERSPEC)})})
+	RegisterX86_64(&SArgs{288, PA("accept4", []PArg{A("sockfd", INT), A("addr", SOCKADDR), A("addrlen", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{289, PA("signalfd4", []PArg{A("ufd", INT), A("user_mask", POINTER), A("sizemask", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{290, PA("eventfd2", []PArg{A("initval", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{291, PA("epoll_create1", []PArg{A("flags", INT)})})
+	RegisterX86_64(&SArgs{292, PA("dup3", []PArg{A("oldfd", INT), A("newfd", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{293, PA("pipe2", []PArg{B("pipefd", PIPEFD), A("flags", INT)})})
+	RegisterX86_64(&SArgs{294, PA("inotify_init1", []PArg{A("flags", INT)})})
+	RegisterX86_64(&SArgs{295, PA("preadv", []PArg{A("fd", INT), B("iov", POINTER), A("iovcnt", INT), A("offset", INT)})})
+	RegisterX86_64(&SArgs{296, PA("pwritev", []PArg{A("fd", INT), A("iov", POINTER), A("iovcnt", INT), A("offset", INT)})})
+	RegisterX86_64(&SArgs{297, PA("rt_tgsigqueueinfo", []PArg{A("tgid", INT), A("tid", INT), A("sig", INT), A("siginfo", POINTER)})})
+	RegisterX86_64(&SArgs{298, PA("perf_event_open", []PArg{A("attr_uptr", POINTER), A("pid", INT), A("cpu", INT), A("group_fd", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{299, PA("recvmmsg", []PArg{A("fd", INT), A("mmsg", POINTER), A("vlen", INT), A("flags", INT), A("timeout", TIMESPEC)})})
+	RegisterX86_64(&SArgs{300, PA("fanotify_init", []PArg{A("flags", INT), A("event_f_flags", INT)})})
+	RegisterX86_64(&SArgs{301, PA("fanotify_mark", []PArg{A("fanotify_fd", INT), A("flags", INT), A("mask", UINT64), A("dfd", INT), A("pathname", STRING)})})
+	RegisterX86_64(&SArgs{302, PA("prlimit64", []PArg{A("pid", INT), A("resource", INT), A("new_rlim", POINTER), A("old_rlim", POINTER)})})
+	RegisterX86_64(&SArgs{303, PA("name_to_handle_at", []PArg{A("dfd", INT), A("name", STRING), A("handle", POINTER), A("mnt_id", INT), A("flag", INT)})})
+	RegisterX86_64(&SArgs{304, PA("open_by_handle_at", []PArg{A("mountdirfd", INT), A("handle", POINTER), A("flags", INT)})})
+	RegisterX86_64(&SArgs{305, PA("clock_adjtime", []PArg{A("which_clock", INT), A("utx", POINTER)})})
+	RegisterX86_64(&SArgs{306, PA("syncfs", []PArg{A("fd", INT)})})
+	RegisterX86_64(&SArgs{307, PA("sendmmsg", []PArg{A("fd", INT), A("mmsg", POINTER), A("vlen", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{308, PA("setns", []PArg{A("fd", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{309, PA("getcpu", []PArg{A("cpup", INT), A("nodep", INT), A("unused", POINTER)})})
+	RegisterX86_64(&SArgs{310, PA("process_vm_readv", []PArg{A("pid", INT), B("local_iov", POINTER), A("liovcnt", INT), B("remote_iov", POINTER), A("riovcnt", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{311, PA("process_vm_writev", []PArg{A("pid", INT), A("local_iov", POINTER), A("liovcnt", INT), A("remote_iov", POINTER), A("riovcnt", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{312, PA("kcmp", []PArg{A("pid1", INT), A("pid2", INT), A("type", INT), A("idx1", INT), A("idx2", INT)})})
+	RegisterX86_64(&SArgs{313, PA("finit_module", []PArg{A("fd", INT), A("uargs", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{314, PA("sched_setattr", []PArg{A("pid", INT), A("uattr", POINTER), A("flags", INT)})})
+	RegisterX86_64(&SArgs{315, PA("sched_getattr", []PArg{A("pid", INT), A("uattr", POINTER), A("usize", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{316, PA("renameat2", []PArg{A("olddirfd", INT), A("oldpath", STRING), A("newdirfd", INT), A("newpath", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{317, PA("seccomp", []PArg{A("operation", INT), A("flags", INT), A("args", POINTER)})})
+	RegisterX86_64(&SArgs{318, PA("getrandom", []PArg{B("buf", POINTER), A("buflen", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{319, PA("memfd_create", []PArg{A("name", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{320, PA("kexec_file_load", []PArg{A("kernel_fd", INT), A("initrd_fd", INT), A("cmdline_len", INT), A("cmdline_ptr", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{321, PA("bpf", []PArg{A("cmd", INT), A("attr", POINTER), A("size", INT)})})
+	RegisterX86_64(&SArgs{322, PA("execveat", []PArg{A("dirfd", INT), A("pathname", STRING), A("argv", STRING_ARR), A("envp", STRING_ARR), A("flags", INT)})})
+	RegisterX86_64(&SArgs{323, PA("userfaultfd", []PArg{A("flags", INT)})})
+	RegisterX86_64(&SArgs{324, PA("membarrier", []PArg{A("cmd", INT), A("flags", POINTER), A("cpu_id", INT)})})
+	RegisterX86_64(&SArgs{325, PA("mlock2", []PArg{A("start", INT), A("len", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{326, PA("copy_file_range", []PArg{A("fd_in", INT), A("off_in", INT), A("fd_out", INT), A("off_out", INT), A("len", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{327, PA("preadv2", []PArg{A("fd", INT), A("vec", POINTER), A("vlen", INT), A("pos_l", INT), A("pos_h", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{328, PA("pwritev2", []PArg{A("fd", INT), A("vec", POINTER), A("vlen", INT), A("pos_l", INT), A("pos_h", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{329, PA("pkey_mprotect", []PArg{B("addr", POINTER), A("length", INT), A("prot", INT), A("pkey", INT)})})
+	RegisterX86_64(&SArgs{330, PA("pkey_alloc", []PArg{A("flags", INT), A("init_val", INT)})})
+	RegisterX86_64(&SArgs{331, PA("pkey_free", []PArg{A("pkey", INT)})})
+	RegisterX86_64(&SArgs{332, PA("statx", []PArg{A("dfd", INT), A("filename", STRING), A("flags", INT), A("mask", INT), A("buffer", POINTER)})})
+	RegisterX86_64(&SArgs{333, PA("io_pgetevents", []PArg{A("ctx_id", POINTER), A("min_nr", UINT64), A("nr", UINT64), A("events", POINTER), A("timeout", TIMESPEC), A("usig", POINTER)})})
+	RegisterX86_64(&SArgs{334, PA("rseq", []PArg{A("rseq", POINTER), A("rseq_len", INT), A("flags", INT), A("sig", INT)})})
+	RegisterX86_64(&SArgs{424, PA("pidfd_send_signal", []PArg{A("pidfd", INT), A("sig", INT), A("info", POINTER), A("flags", INT)})})
+	RegisterX86_64(&SArgs{425, PA("io_uring_setup", []PArg{A("entries", INT), A("params", POINTER)})})
+	RegisterX86_64(&SArgs{426, PA("io_uring_enter", []PArg{A("fd", INT), A("to_submit", INT), A("min_complete", INT), A("flags", INT), A("argp", POINTER), A("argsz", INT)})})
+	RegisterX86_64(&SArgs{427, PA("io_uring_register", []PArg{A("fd", INT), A("opcode", INT), A("arg", POINTER), A("nr_args", INT)})})
+	RegisterX86_64(&SArgs{428, PA("open_tree", []PArg{A("dfd", INT), A("filename", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{429, PA("move_mount", []PArg{A("from_dfd", INT), A("from_pathname", STRING), A("to_dfd", INT), A("to_pathname", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{430, PA("fsopen", []PArg{A("_fs_name", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{431, PA("fsconfig", []PArg{A("fd", INT), A("cmd", INT), A("_key", STRING), A("_value", POINTER), A("aux", INT)})})
+	RegisterX86_64(&SArgs{432, PA("fsmount", []PArg{A("fs_fd", INT), A("flags", INT), A("attr_flags", INT)})})
+	RegisterX86_64(&SArgs{433, PA("fspick", []PArg{A("dfd", INT), A("path", STRING), A("flags", INT)})})
+	RegisterX86_64(&SArgs{434, PA("pidfd_open", []PArg{A("pid", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{435, PA("clone3", []PArg{A("uargs", POINTER), A("size", INT)})})
+	RegisterX86_64(&SArgs{436, PA("close_range", []PArg{A("fd", INT), A("max_fd", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{437, PA("openat2", []PArg{A("dfd", INT), A("filename", STRING), A("how", POINTER), A("usize", INT)})})
+	RegisterX86_64(&SArgs{438, PA("pidfd_getfd", []PArg{A("pidfd", INT), A("fd", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{439, PA("faccessat2", []PArg{A("dirfd", INT), A("pathname", STRING), A("flags", INT), A("mode", UINT32)})})
+	RegisterX86_64(&SArgs{440, PA("process_madvise", []PArg{A("pidfd", INT), A("vec", POINTER), A("vlen", INT), A("behavior", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{441, PA("epoll_pwait2", []PArg{A("epfd", INT), A("events", POINTER), A("maxevents", INT), A("timeout", TIMESPEC), A("sigmask", POINTER), A("sigsetsize", INT)})})
+	RegisterX86_64(&SArgs{442, PA("mount_setattr", []PArg{A("dfd", INT), A("path", STRING), A("flags", INT), A("uattr", POINTER), A("usize", INT)})})
+	RegisterX86_64(&SArgs{443, PA("quotactl_fd", []PArg{A("fd", INT), A("cmd", INT), A("id", INT), A("addr", POINTER)})})
+	RegisterX86_64(&SArgs{444, PA("landlock_create_ruleset", []PArg{A("attr", POINTER), A("size", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{445, PA("landlock_add_rule", []PArg{A("ruleset_fd", INT), A("rule_type", INT), A("rule_attr", POINTER), A("flags", INT)})})
+	RegisterX86_64(&SArgs{446, PA("landlock_restrict_self", []PArg{A("ruleset_fd", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{447, PA("memfd_secret", []PArg{A("flags", INT)})})
+	RegisterX86_64(&SArgs{448, PA("process_mrelease", []PArg{A("pidfd", INT), A("flags", INT)})})
+	RegisterX86_64(&SArgs{449, PA("futex_waitv", []PArg{A("waiters", POINTER), A("nr_futexes", INT), A("flags", INT), A("timeout", TIMESPEC), A("clockid", INT)})})
+	RegisterX86_64(&SArgs{450, PA("set_mempolicy_home_node", []PArg{A("start", INT), A("len", INT), A("home_node", INT), A("flags", INT)})})
+}
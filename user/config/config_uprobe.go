@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 )
 
 type UprobeArgs struct {
@@ -11,11 +13,16 @@ type UprobeArgs struct {
 	SymOffset uint64
 	Offset    uint64
 	ArgsStr   string
+	// 为 true 时额外挂载 uretprobe 返回时读取输出参数以及返回值
+	RetMode bool
+	// 启动时库还不存在 等到库被加载时再挂载 此时 LibPath 为用户指定的库名
+	Deferred bool
 	PointArgs
 }
 
 type UPointTypes struct {
 	Count    uint32
+	RetMode  uint32
 	ArgTypes [MAX_POINT_ARG_COUNT]FilterArgType
 }
 
@@ -33,6 +40,9 @@ func (this *UprobeArgs) GetConfig() *UPointTypes {
 		Count:    uint32(len(this.Args)),
 		ArgTypes: point_arg_types,
 	}
+	if this.RetMode {
+		config.RetMode = 1
+	}
 	return config
 }
 
@@ -44,4 +54,16 @@ func (this *UprobeArgs) String() string {
 	}
 }
 
+func (this *UprobeArgs) GetLibName() string {
+	return filepath.Base(this.LibPath)
+}
+
+// 判断 mmap 的文件是否为延迟 hook 的库 指定完整路径时要求一致 否则只比较文件名
+func (this *UprobeArgs) MatchLib(path string) bool {
+	if strings.HasPrefix(this.LibPath, "/") {
+		return path == this.LibPath
+	}
+	return filepath.Base(path) == this.GetLibName()
+}
+
 type UArgs = UprobeArgs
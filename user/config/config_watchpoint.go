@@ -18,6 +18,7 @@ const (
 	SYS_ENTER
 	SYS_EXIT
 	UPROBE_ENTER_READ
+	UPROBE_EXIT_READ
 )
 
 type ArgType struct {
@@ -186,31 +187,94 @@ func NewSysCallWatchPoint(name string) IWatchPoint {
 	return point
 }
 
-func Register(p IWatchPoint) {
+// 每个架构一套 syscall 表 查询时使用当前架构的
+type WatchPointTable struct {
+	watchpoints       map[string]IWatchPoint
+	compatwatchpoints map[string]IWatchPoint
+	nrwatchpoints     map[uint32]IWatchPoint
+}
+
+func NewWatchPointTable() *WatchPointTable {
+	table := &WatchPointTable{}
+	table.watchpoints = make(map[string]IWatchPoint)
+	table.compatwatchpoints = make(map[string]IWatchPoint)
+	table.nrwatchpoints = make(map[uint32]IWatchPoint)
+	return table
+}
+
+func (this *WatchPointTable) Register(p IWatchPoint) {
 	if p == nil {
 		panic("Register watchpoint is nil")
 	}
 	name := p.Name()
-	if _, dup := watchpoints[name]; dup {
+	if _, dup := this.watchpoints[name]; dup {
 		panic(fmt.Sprintf("Register called twice for watchpoint %s", name))
 	}
-	watchpoints[name] = p
+	this.watchpoints[name] = p
 	// 给 syscall 单独维护一个 map 这样便于在解析的时候快速获取 point 配置
 	nr_point, ok := (p).(*SysCallArgs)
 	if ok {
-		if _, dup := nrwatchpoints[nr_point.NR]; dup {
+		if _, dup := this.nrwatchpoints[nr_point.NR]; dup {
 			panic(fmt.Sprintf("Register called twice for nrwatchpoints %s", name))
 		}
-		nrwatchpoints[nr_point.NR] = nr_point
+		this.nrwatchpoints[nr_point.NR] = nr_point
 	}
 }
 
+// arm32 的 syscall 名字可能和 64 位的重复 所以单独维护
+// 调用号加上 COMPAT_NR_FLAG 和 eBPF 中保持一致 这样解析时仍然通过 GetWatchPointByNR 获取
+func (this *WatchPointTable) RegisterCompat(p *SysCallArgs) {
+	if p == nil {
+		panic("RegisterCompat syscall is nil")
+	}
+	name := p.Name()
+	if _, dup := this.compatwatchpoints[name]; dup {
+		panic(fmt.Sprintf("RegisterCompat called twice for syscall %s", name))
+	}
+	p.NR |= COMPAT_NR_FLAG
+	if _, dup := this.nrwatchpoints[p.NR]; dup {
+		panic(fmt.Sprintf("RegisterCompat called twice for nrwatchpoints %s", name))
+	}
+	this.compatwatchpoints[name] = p
+	this.nrwatchpoints[p.NR] = p
+}
+
+// arm64 的 syscall 表
+func Register(p IWatchPoint) {
+	archs[ARCH_ARM64].points.Register(p)
+}
+
+func RegisterCompat(p *SysCallArgs) {
+	archs[ARCH_ARM64].points.RegisterCompat(p)
+}
+
+// x86_64 的 syscall 表
+func RegisterX86_64(p *SysCallArgs) {
+	archs[ARCH_X86_64].points.Register(p)
+}
+
 func GetAllWatchPoints() map[string]IWatchPoint {
-	return watchpoints
+	return GetArch().points.watchpoints
+}
+
+func GetAllCompatWatchPoints() map[string]IWatchPoint {
+	return GetArch().points.compatwatchpoints
+}
+
+// 64 位和 32 位中同名的 syscall 都返回 不存在则返回空
+func GetSysCallPointsByName(name string) []*SysCallArgs {
+	var points []*SysCallArgs
+	if nr_point, ok := (GetAllWatchPoints()[name]).(*SysCallArgs); ok {
+		points = append(points, nr_point)
+	}
+	if nr_point, ok := (GetAllCompatWatchPoints()[name]).(*SysCallArgs); ok {
+		points = append(points, nr_point)
+	}
+	return points
 }
 
 func GetWatchPointByNR(nr uint32) IWatchPoint {
-	m, f := nrwatchpoints[nr]
+	m, f := GetArch().points.nrwatchpoints[nr]
 	if f {
 		return m
 	}
@@ -218,12 +282,9 @@ func GetWatchPointByNR(nr uint32) IWatchPoint {
 }
 
 func GetWatchPointByName(pointName string) IWatchPoint {
-	m, f := watchpoints[pointName]
+	m, f := GetAllWatchPoints()[pointName]
 	if f {
 		return m
 	}
 	return nil
 }
-
-var watchpoints = make(map[string]IWatchPoint)
-var nrwatchpoints = make(map[uint32]IWatchPoint)
@@ -9,11 +9,31 @@ import (
 const MAX_COUNT = 20
 const MAX_WATCH_PROC_COUNT = 256
 
+// point_index 即 uprobe_point_args_map 的 key 与 eBPF 中 MAX_UPROBE_POINT_COUNT 一致
+const MAX_UPROBE_POINT_COUNT = 512
+
+// 32 位进程的 syscall 调用号加上这个标志 与 eBPF 中一致
+const COMPAT_NR_FLAG uint32 = 0x80000000
+
+// --spawn 期间登记的父进程 fork 出新进程时的处理方式 与 eBPF 中一致
+const (
+	// 子进程直接追踪
+	SPAWN_CHILD_ANY uint32 = 1
+	// zygote 的子进程 切换到要追踪的 uid 之前同样追踪
+	SPAWN_CHILD_UID uint32 = 2
+)
+
 // stackplz => 737461636b706c7a
 const MAGIC_UID = 0x73746163
 const MAGIC_PID = 0x6b706c7a
 const MAGIC_TID = 0x61636b70
 
+// 事件输出格式
+const (
+	FORMAT_TEXT = "text"
+	FORMAT_JSON = "json"
+)
+
 type IConfig interface {
 	GetSConfig() *SConfig
 	SetDebug(bool)
@@ -21,11 +41,15 @@ type IConfig interface {
 }
 
 type SConfig struct {
-	SelfPid       uint32
-	FilterMode    uint32
-	Uid           uint32
-	Pid           uint32
-	Tid           uint32
+	SelfPid    uint32
+	FilterMode uint32
+	Uid        uint32
+	Pid        uint32
+	Tid        uint32
+	// 可以同时追踪多个 uid/pid Uid/Pid 为其中第一个 Tids 为 tid 白名单
+	Uids          []uint32
+	Pids          []uint32
+	Tids          []uint32
 	TraceIsolated bool
 	HideRoot      bool
 	UprobeSignal  uint32
@@ -37,12 +61,22 @@ type SConfig struct {
 	ExternalBTF   string
 	Debug         bool
 	Is32Bit       bool
-	Buffer        uint32
-	BrkAddr       uint64
-	BrkType       uint32
+	// 采集时的架构 回放时据此选择寄存器和 syscall 表
+	Arch   string
+	Buffer uint32
+	// ringbuf 大小 单位 MB 所有 CPU 共用
+	RingBufSize   uint32
+	Brks          []*BrkConfig
 	Color         bool
 	DumpHex       bool
-	logger        *log.Logger
+	Format        string
+	MergeSyscall  bool
+	Latency       bool
+	SlowThreshold uint64
+	// :ret 模式等待函数返回的最长时间 单位 ns
+	RetTimeout uint64
+	Summary    bool
+	logger     *log.Logger
 }
 
 func (this *SConfig) SetLogger(logger *log.Logger) {
@@ -61,6 +95,11 @@ func (this *SConfig) GetSConfig() *SConfig {
 	return this
 }
 
+// 堆栈和寄存器数据只能由内核在 perf 采样时附带 需要这些数据时仍然使用 perf event array
+func (this *SConfig) UseRingBuf() bool {
+	return !this.UnwindStack && !this.ShowRegs && this.RegName == ""
+}
+
 const MAX_BUF_READ_SIZE uint32 = 4096
 
 const (
@@ -100,44 +139,34 @@ const (
 	REG_ARM64_MAX
 )
 
-var RegsMagicMap map[string]uint32 = map[string]uint32{
-	"x0":  REG_ARM64_X0,
-	"x1":  REG_ARM64_X1,
-	"x2":  REG_ARM64_X2,
-	"x3":  REG_ARM64_X3,
-	"x4":  REG_ARM64_X4,
-	"x5":  REG_ARM64_X5,
-	"x6":  REG_ARM64_X6,
-	"x7":  REG_ARM64_X7,
-	"x8":  REG_ARM64_X8,
-	"x9":  REG_ARM64_X9,
-	"x10": REG_ARM64_X10,
-	"x11": REG_ARM64_X11,
-	"x12": REG_ARM64_X12,
-	"x13": REG_ARM64_X13,
-	"x14": REG_ARM64_X14,
-	"x15": REG_ARM64_X15,
-	"x16": REG_ARM64_X16,
-	"x17": REG_ARM64_X17,
-	"x18": REG_ARM64_X18,
-	"x19": REG_ARM64_X19,
-	"x20": REG_ARM64_X20,
-	"x21": REG_ARM64_X21,
-	"x22": REG_ARM64_X22,
-	"x23": REG_ARM64_X23,
-	"x24": REG_ARM64_X24,
-	"x25": REG_ARM64_X25,
-	"x26": REG_ARM64_X26,
-	"x27": REG_ARM64_X27,
-	"x28": REG_ARM64_X28,
-	"x29": REG_ARM64_X29,
-	"lr":  REG_ARM64_LR,
-	"sp":  REG_ARM64_SP,
-	"pc":  REG_ARM64_PC,
-}
+// x86_64 的寄存器编号 即 perf 采样数据中的顺序
+// 64 位下 ds es fs gs 不能采样 所以 r8-r15 紧跟在 ss 之后
+const (
+	REG_X86_64_AX uint32 = iota
+	REG_X86_64_BX
+	REG_X86_64_CX
+	REG_X86_64_DX
+	REG_X86_64_SI
+	REG_X86_64_DI
+	REG_X86_64_BP
+	REG_X86_64_SP
+	REG_X86_64_IP
+	REG_X86_64_FLAGS
+	REG_X86_64_CS
+	REG_X86_64_SS
+	REG_X86_64_R8
+	REG_X86_64_R9
+	REG_X86_64_R10
+	REG_X86_64_R11
+	REG_X86_64_R12
+	REG_X86_64_R13
+	REG_X86_64_R14
+	REG_X86_64_R15
+	REG_X86_64_MAX
+)
 
 func ParseAsReg(reg string) (uint32, error) {
-	value, ok := RegsMagicMap[reg]
+	value, ok := GetArch().Regs[reg]
 	if ok {
 		return value, nil
 	} else {
@@ -0,0 +1,44 @@
+package event
+
+import (
+    "stackplz/user/config"
+    "testing"
+)
+
+func TestRetFilterNoMerge(t *testing.T) {
+    defer config.SetArch(config.GetArch().Name)
+    if err := config.SetArch(config.ARCH_ARM64); err != nil {
+        t.Fatal(err)
+    }
+    mconf := config.NewModuleConfig()
+    if err := mconf.SysCallConf.SetArgFilters([]string{"ret<0"}); err != nil {
+        t.Fatal(err)
+    }
+    nr_point := config.GetWatchPointByName("openat").(*config.SysCallArgs)
+    newEvent := func(event_id uint32, ret int64, merged bool) *SyscallEvent {
+        e := &SyscallEvent{nr_point: nr_point, ret: uint64(ret), merged: merged}
+        e.mconf = mconf
+        e.EventId = event_id
+        return e
+    }
+
+    // --no-merge 时 sys_enter 全部保留 由 sys_exit 判断返回值
+    mconf.MergeSyscall = false
+    if !newEvent(SYSCALL_ENTER, 0, false).MatchArgFilters() {
+        t.Error("sys_enter should not be dropped by a ret filter without merge")
+    }
+    if !newEvent(SYSCALL_EXIT, -2, false).MatchArgFilters() {
+        t.Error("sys_exit with ret -2 should match ret<0")
+    }
+    if newEvent(SYSCALL_EXIT, 3, false).MatchArgFilters() {
+        t.Error("sys_exit with ret 3 should not match ret<0")
+    }
+
+    mconf.MergeSyscall = true
+    if newEvent(SYSCALL_ENTER, 0, false).MatchArgFilters() {
+        t.Error("unfinished sys_enter should not match ret<0")
+    }
+    if !newEvent(SYSCALL_ENTER, -2, true).MatchArgFilters() {
+        t.Error("merged call with ret -2 should match ret<0")
+    }
+}
@@ -1,8 +1,15 @@
+//go:build cgo
+
 package event
 
 import (
+	"fmt"
 	"os"
 	"path"
+	"regexp"
+	"stackplz/user/util"
+	"strconv"
+	"strings"
 	"unsafe"
 )
 
@@ -12,10 +19,47 @@ import "C"
 
 var LibPath string
 
+const HasLibUnwindStack = true
+
 func ParseStack(map_buffer string, ubuf *UnwindBuf) string {
 	stack_str := C.get_stack(C.CString(LibPath), C.CString(map_buffer), C.ulong(((1 << 33) - 1)), unsafe.Pointer(ubuf.GetLibArg()), unsafe.Pointer(&ubuf.Data[0]))
 	// char* 转到 go 的 string
-	return C.GoString(stack_str)
+	return SymbolizeStack(C.GoString(stack_str))
+}
+
+// libunwindstack 的帧格式 #00 pc 000000000004a7e4  /path/libc.so (read+4)
+var frame_regex = regexp.MustCompile(`^(\s*#\d+ pc )([[:xdigit:]]+)(\s+)(/\S+)(.*)$`)
+var frame_func_regex = regexp.MustCompile(`^ \((\S+?)(\+\d+)?\)`)
+
+// 对 libunwindstack 没有给出函数名的帧用 ELF 符号表补全 并 demangle C++ 符号
+func SymbolizeStack(stack_str string) string {
+	lines := strings.Split(stack_str, "\n")
+	for i, line := range lines {
+		items := frame_regex.FindStringSubmatch(line)
+		if items == nil {
+			continue
+		}
+		rest := items[5]
+		if func_items := frame_func_regex.FindStringSubmatch(rest); func_items != nil {
+			if strings.HasPrefix(func_items[1], "_Z") {
+				rest = fmt.Sprintf(" (%s%s)%s", util.Demangle(func_items[1]), func_items[2], rest[len(func_items[0]):])
+				lines[i] = items[1] + items[2] + items[3] + items[4] + rest
+			}
+			continue
+		}
+		rel_pc, err := strconv.ParseUint(items[2], 16, 64)
+		if err != nil {
+			continue
+		}
+		table := util.LoadSymbolTable(items[4])
+		if table == nil {
+			continue
+		}
+		if sym_info := table.Symbolize(rel_pc); sym_info != "" {
+			lines[i] = items[1] + items[2] + items[3] + items[4] + " (" + sym_info + ")" + rest
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 func init() {
@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package event
+
+// 没有 cgo 时无法使用 libunwindstack 只能使用 Go 实现的回溯
+var LibPath string
+
+const HasLibUnwindStack = false
+
+func ParseStack(map_buffer string, ubuf *UnwindBuf) string {
+	return ""
+}
@@ -2,34 +2,240 @@ package event
 
 import (
     "bytes"
+    "encoding/binary"
+    "encoding/hex"
     "fmt"
-    "stackplz/user/util"
+    "os"
+    "stackplz/user/config"
+    "strings"
 )
 
+const MAX_BRK_READ_SIZE = 4096
+
 type BrkEvent struct {
     ContextEvent
-    UUID string
+    UUID      string
+    Brk       *config.BrkConfig
+    arg_str   string
+    json_args []JsonArg
 }
 
 func (this *BrkEvent) String() (s string) {
     s = fmt.Sprintf("[%s] ", this.GetUUID())
+    if this.Brk != nil {
+        s += this.Brk.String() + this.arg_str
+    }
     s = this.GetStackTrace(s)
     return s
 }
 
+func (this *BrkEvent) JsonString() string {
+    e := this.NewJsonEvent("brk")
+    e.Pid = this.mconf.Pid
+    if this.Brk != nil {
+        e.Name = fmt.Sprintf("brk#%d", this.Brk.Index)
+        e.Lib = this.Brk.Lib
+        e.Args = this.json_args
+    }
+    return e.String()
+}
+
+// 多个断点共用同一个 map 通过 reader 上设置的地址和类型区分是哪个断点触发的
+func (this *BrkEvent) findBrk() *config.BrkConfig {
+    eopt := this.rec.ExtraOptions
+    if eopt == nil {
+        return nil
+    }
+    for _, brk := range this.mconf.Brks {
+        if brk.Addr == eopt.BrkAddr && brk.Type == eopt.BrkType {
+            return brk
+        }
+    }
+    return nil
+}
+
 func (this *BrkEvent) GetUUID() string {
     return fmt.Sprintf("%d", this.mconf.Pid)
 }
 
 func (this *BrkEvent) ParseContext() (err error) {
     this.buf = bytes.NewBuffer(this.rec.RawSample)
+    this.Brk = this.findBrk()
     err = this.ParseContextStack()
     if err != nil {
         panic(fmt.Sprintf("ParseContextStack err:%v", err))
     }
+    this.ParseBrkArgs()
     return nil
 }
 
+// 断点事件只有寄存器和栈数据 参数按 --point 的规则从中读取
+func (this *BrkEvent) ParseBrkArgs() {
+    this.arg_str = ""
+    this.json_args = nil
+    if this.Brk == nil || len(this.Brk.Args) == 0 {
+        return
+    }
+    mem := &BrkMemory{pid: this.Pid}
+    var regs [33]uint64
+    if this.UnwindBuffer != nil {
+        regs = this.UnwindBuffer.Regs
+        mem.stack = NewStackMemory(this.UnwindBuffer)
+    } else if this.rec.ExtraOptions.ShowRegs {
+        regs = this.RegsBuffer.Regs
+    } else {
+        return
+    }
+    arch := config.GetArch()
+    var results []string
+    for i, point_arg := range this.Brk.Args {
+        var value uint64
+        if point_arg.ReadIndex == config.READ_INDEX_REG {
+            // 按调用约定依次取参数寄存器
+            if regno, ok := arch.ArgReg(uint32(i)); ok {
+                value = regs[regno]
+            }
+        } else if point_arg.ReadIndex < uint32(len(arch.RegNames)) {
+            value = regs[point_arg.ReadIndex]
+        }
+        if point_arg.Type == config.TYPE_NUM {
+            point_arg.SetValue(fmt.Sprintf("%s=%s", point_arg.ArgName, config.DecodeNumArg("", &point_arg, value)))
+            results = append(results, point_arg.ArgValue)
+            this.json_args = append(this.json_args, NewJsonArg(&point_arg, value, NumValue(&point_arg, value)))
+            continue
+        }
+        point_arg.SetValue(fmt.Sprintf("%s=0x%x", point_arg.ArgName, value))
+        arg_str, arg_value := this.parseBrkArg(&point_arg, value, &regs, mem)
+        point_arg.AppendValue(arg_str)
+        results = append(results, point_arg.ArgValue)
+        this.json_args = append(this.json_args, NewJsonArg(&point_arg, value, arg_value))
+    }
+    this.arg_str = "(" + strings.Join(results, ", ") + ")"
+}
+
+func (this *BrkEvent) parseBrkArg(point_arg *config.PointArg, value uint64, regs *[33]uint64, mem *BrkMemory) (string, interface{}) {
+    // 偏移可能是负数
+    ptr := value + uint64(int64(int32(point_arg.ReadOffset)))
+    if ptr == 0 {
+        return "(NULL)", nil
+    }
+    if point_arg.Type == config.TYPE_POINTER && point_arg.AliasType != config.TYPE_POINTER && point_arg.AliasType != config.TYPE_BUFFER_T {
+        // 指针指向的内容
+        next_ptr, ok := mem.ReadU64(ptr)
+        if !ok {
+            return "(<unreadable>)", nil
+        }
+        if next_ptr == 0 {
+            return "(0x0)", nil
+        }
+        arg_str, arg_value := this.readBrkArg(point_arg, next_ptr, regs, mem)
+        return fmt.Sprintf("(*0x%x)%s", next_ptr, arg_str), arg_value
+    }
+    arg_str, arg_value := this.readBrkArg(point_arg, ptr, regs, mem)
+    if point_arg.AliasType == config.TYPE_BUFFER_T {
+        arg_str = fmt.Sprintf("(*0x%x)%s", ptr, arg_str)
+    }
+    return arg_str, arg_value
+}
+
+func (this *BrkEvent) readBrkArg(point_arg *config.PointArg, ptr uint64, regs *[33]uint64, mem *BrkMemory) (string, interface{}) {
+    switch point_arg.AliasType {
+    case config.TYPE_STRING:
+        str, ok := mem.ReadString(ptr)
+        if !ok {
+            return "(<unreadable>)", nil
+        }
+        return fmt.Sprintf("(%s)", str), str
+    case config.TYPE_POINTER:
+        next_ptr, ok := mem.ReadU64(ptr)
+        if !ok {
+            return "(<unreadable>)", nil
+        }
+        return fmt.Sprintf("(0x%x)", next_ptr), fmt.Sprintf("0x%x", next_ptr)
+    case config.TYPE_BUFFER_T:
+        read_count := uint64(point_arg.Size)
+        if point_arg.ItemCountIndex != config.READ_INDEX_SKIP {
+            // 以寄存器值作为大小 与 uprobe 一致 arm64 下不包含 fp 之后的寄存器
+            if point_arg.ItemCountIndex < config.GetArch().CountRegMax {
+                read_count = regs[point_arg.ItemCountIndex]
+            }
+        }
+        if read_count == 0 || read_count > MAX_BRK_READ_SIZE {
+            read_count = MAX_BRK_READ_SIZE
+        }
+        payload, ok := mem.Read(ptr, read_count)
+        if !ok {
+            return "(<unreadable>)", nil
+        }
+        arg := Arg_Buffer_t{Payload: payload}
+        if this.mconf.DumpHex {
+            return arg.HexFormat(this.mconf.Color), hex.EncodeToString(payload)
+        }
+        return arg.Format(), hex.EncodeToString(payload)
+    default:
+        payload, ok := mem.Read(ptr, uint64(point_arg.Size))
+        if !ok {
+            return "(<unreadable>)", nil
+        }
+        return fmt.Sprintf("([hex]%x)", payload), hex.EncodeToString(payload)
+    }
+}
+
+// 优先从采集到的栈数据中读取 硬件断点不会让进程停下 其他内存只能事后通过 /proc/pid/mem 读取 内容可能已经变化
+type BrkMemory struct {
+    pid   uint32
+    stack *StackMemory
+}
+
+func (this *BrkMemory) Read(addr uint64, size uint64) ([]byte, bool) {
+    if this.stack != nil {
+        if data, ok := this.stack.Read(addr, size); ok {
+            return data, true
+        }
+    }
+    // 回放时进程已经不存在了
+    if IsReplayMode() {
+        return nil, false
+    }
+    f, err := os.Open(fmt.Sprintf("/proc/%d/mem", this.pid))
+    if err != nil {
+        return nil, false
+    }
+    defer f.Close()
+    data := make([]byte, size)
+    // 跨越未映射的页时只能读到一部分
+    n, _ := f.ReadAt(data, int64(addr))
+    if n == 0 {
+        return nil, false
+    }
+    return data[:n], true
+}
+
+func (this *BrkMemory) ReadU64(addr uint64) (uint64, bool) {
+    data, ok := this.Read(addr, 8)
+    if !ok || len(data) < 8 {
+        return 0, false
+    }
+    return binary.LittleEndian.Uint64(data), true
+}
+
+func (this *BrkMemory) ReadString(addr uint64) (string, bool) {
+    if this.stack != nil && addr >= this.stack.Start && addr < this.stack.Start+uint64(len(this.stack.Data)) {
+        data := this.stack.Data[addr-this.stack.Start:]
+        if index := bytes.IndexByte(data, 0); index >= 0 {
+            return string(data[:index]), true
+        }
+    }
+    data, ok := this.Read(addr, MAX_BRK_READ_SIZE)
+    if !ok {
+        return "", false
+    }
+    if index := bytes.IndexByte(data, 0); index >= 0 {
+        data = data[:index]
+    }
+    return string(data), true
+}
+
 func (this *BrkEvent) Clone() IEventStruct {
     event := new(BrkEvent)
     return event
@@ -40,29 +246,29 @@ func (this *BrkEvent) ParseContextStack() (err error) {
     if this.rec.ExtraOptions.UnwindStack {
         // 读取完整的栈数据和寄存器数据 并解析为 UnwindBuf 结构体
         this.UnwindBuffer = &UnwindBuf{}
-        err = this.UnwindBuffer.ParseContext(this.buf)
+        err = this.UnwindBuffer.ParseContext(this.buf, config.GetArch().GetSampleRegCount(this.mconf.Is32Bit))
         if err != nil {
             panic(fmt.Sprintf("UnwindStack ParseContext failed, err:%v", err))
         }
         // 立刻获取堆栈信息 对于某些hook点前后可能导致maps发生变化的 堆栈可能不准确
-        // 这里后续可以调整为只dlopen一次 拿到要调用函数的handle 不要重复dlopen
-        content, err := util.ReadMapsByPid(this.mconf.Pid)
-        if err != nil {
-            // 直接读取 maps 失败 那么从 mmap2 事件中获取
-            // 根据测试结果 有这样的情况 -> 即 fork 产生的子进程 那么应该查找其父进程 mmap2 事件
-            maps_helper.SetLogger(this.logger)
-            info, err := maps_helper.GetStack(this.mconf.Pid, this.UnwindBuffer)
-            if err != nil {
-                // this.logger.Printf("Error when opening file:%v", err)
-                this.logger.Printf("Error when GetStack:%v", err)
-            } else {
-                this.Stackinfo = info
+        // 默认使用 Go 实现的基于 CFI 的回溯 指定 --unwinder libunwindstack 时才通过 cgo 调用外部库
+        if UseLibUnwindStack() {
+            content, err := maps_helper.GetMapBuffer(this.mconf.Pid, this.Ts)
+            if err == nil {
+                this.Stackinfo = ParseStack(content, this.UnwindBuffer)
+                return nil
             }
-            return nil
         }
-        this.Stackinfo = ParseStack(content, this.UnwindBuffer)
+        // maps 来自 mmap2 以及 fork 记录维护的信息 按事件发生时的版本解析
+        maps_helper.SetLogger(this.logger)
+        info, err := maps_helper.GetStack(this.mconf.Pid, this.Ts, this.UnwindBuffer)
+        if err != nil {
+            this.logger.Printf("Error when GetStack:%v", err)
+        } else {
+            this.Stackinfo = info
+        }
     } else if this.rec.ExtraOptions.ShowRegs {
-        err = this.RegsBuffer.ParseContext(this.buf)
+        err = this.RegsBuffer.ParseContext(this.buf, config.GetArch().GetSampleRegCount(this.mconf.Is32Bit))
         if err != nil {
             panic(fmt.Sprintf("UnwindStack ParseContext failed, err:%v", err))
         }
@@ -3,11 +3,11 @@ package event
 import (
     "bytes"
     "encoding/binary"
+    "encoding/hex"
     "encoding/json"
     "fmt"
     "stackplz/user/config"
     "stackplz/user/util"
-    "strconv"
     "strings"
     "time"
 )
@@ -36,11 +36,12 @@ func (this *UnwindBuf) GetLibArg() *LibArg {
     return arg
 }
 
-func (this *UnwindBuf) ParseContext(buf *bytes.Buffer) (err error) {
+// perf 只输出 sample_regs_user 中指定的寄存器 数量和架构以及进程位数有关
+func (this *UnwindBuf) ParseContext(buf *bytes.Buffer, reg_count int) (err error) {
     if err = binary.Read(buf, binary.LittleEndian, &this.Abi); err != nil {
         return err
     }
-    if err = binary.Read(buf, binary.LittleEndian, &this.Regs); err != nil {
+    if err = binary.Read(buf, binary.LittleEndian, this.Regs[:reg_count]); err != nil {
         return err
     }
     if err = binary.Read(buf, binary.LittleEndian, &this.StackSize); err != nil {
@@ -64,11 +65,11 @@ type RegsBuf struct {
     Regs [33]uint64
 }
 
-func (this *RegsBuf) ParseContext(buf *bytes.Buffer) (err error) {
+func (this *RegsBuf) ParseContext(buf *bytes.Buffer, reg_count int) (err error) {
     if err = binary.Read(buf, binary.LittleEndian, &this.Abi); err != nil {
         return err
     }
-    if err = binary.Read(buf, binary.LittleEndian, &this.Regs); err != nil {
+    if err = binary.Read(buf, binary.LittleEndian, this.Regs[:reg_count]); err != nil {
         return err
     }
     return nil
@@ -95,7 +96,7 @@ type ContextEvent struct {
 }
 
 func (this *ContextEvent) GetOffset(addr uint64) string {
-    return maps_helper.GetOffset(this.Pid, addr)
+    return maps_helper.GetOffset(this.Pid, this.Ts, addr)
 }
 
 func (this *ContextEvent) NewSyscallEvent() IEventStruct {
@@ -141,6 +142,10 @@ func (this *ContextEvent) GetEventId() uint32 {
     return this.EventId
 }
 
+func (this *ContextEvent) GetTs() uint64 {
+    return this.Ts
+}
+
 type Arg_raw_size struct {
     Index       uint8
     PartRawSize uint32
@@ -209,6 +214,7 @@ func (this *ContextEvent) ParseContext() (err error) {
     }
     // 这一类的说明都是要关注的
     maps_helper.UpdatePidList(this.Pid)
+    maps_helper.UpdateTs(this.Ts)
     return nil
 }
 
@@ -217,19 +223,20 @@ func (this *ContextEvent) Clone() IEventStruct {
     return event
 }
 
-func (this *ContextEvent) ParseArgByType(point_arg *config.PointArg, ptr Arg_reg) {
+func (this *ContextEvent) ParseArgByType(point_arg *config.PointArg, ptr Arg_reg) interface{} {
     var err error
     if ptr.Address == 0 {
         point_arg.AppendValue("(NULL)")
-        return
+        return nil
     }
     // 这个函数先处理基础类型
 
     if point_arg.Type == config.TYPE_POINTER {
         // BUFFER 比较特殊 单独处理
         if point_arg.AliasType == config.TYPE_BUFFER_T {
-            point_arg.AppendValue(fmt.Sprintf("(*0x%x)%s", ptr.Address, this.ParseArg(point_arg, ptr)))
-            return
+            arg_str, value := this.ParseArg(point_arg, ptr)
+            point_arg.AppendValue(fmt.Sprintf("(*0x%x)%s", ptr.Address, arg_str))
+            return value
         }
         // 对于指针类型 需要先处理
         var next_ptr Arg_reg
@@ -241,21 +248,28 @@ func (this *ContextEvent) ParseArgByType(point_arg *config.PointArg, ptr Arg_reg
         // }
         if next_ptr.Address == 0 {
             point_arg.AppendValue("(0x0)")
-            return
+            return nil
         }
         if point_arg.AliasType == config.TYPE_POINTER {
             // 这种不再需要进一步解析了
             point_arg.AppendValue(fmt.Sprintf("(0x%x)", next_ptr.Address))
+            return fmt.Sprintf("0x%x", next_ptr.Address)
         } else {
             // pointer + struct
-            point_arg.AppendValue(fmt.Sprintf("(*0x%x)%s", next_ptr.Address, this.ParseArg(point_arg, next_ptr)))
+            arg_str, value := this.ParseArg(point_arg, next_ptr)
+            point_arg.AppendValue(fmt.Sprintf("(*0x%x)%s", next_ptr.Address, arg_str))
+            return value
         }
     } else {
         // 这种一般就是特殊类型 获取结构体了
-        point_arg.AppendValue(this.ParseArg(point_arg, ptr))
+        arg_str, value := this.ParseArg(point_arg, ptr)
+        point_arg.AppendValue(arg_str)
+        return value
     }
 }
-func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) string {
+
+// 返回格式化后的字符串 以及用于 json 输出的值
+func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) (string, interface{}) {
     var err error
     switch point_arg.AliasType {
     case config.TYPE_NONE:
@@ -273,9 +287,9 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
         }
         arg.Payload = payload
         if this.mconf.DumpHex {
-            return arg.HexFormat(this.mconf.Color)
+            return arg.HexFormat(this.mconf.Color), hex.EncodeToString(arg.Payload)
         } else {
-            return arg.Format()
+            return arg.Format(), hex.EncodeToString(arg.Payload)
         }
     case config.TYPE_STRING:
         var arg Arg_str
@@ -286,7 +300,7 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
         if err = binary.Read(this.buf, binary.LittleEndian, &payload); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return fmt.Sprintf("(%s)", util.B2STrim(payload))
+        return fmt.Sprintf("(%s)", util.B2STrim(payload)), util.B2STrim(payload)
     case config.TYPE_STRING_ARR:
         var arg_str_arr Arg_str_arr
         if err = binary.Read(this.buf, binary.LittleEndian, &arg_str_arr); err != nil {
@@ -304,7 +318,7 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
             }
             str_arr = append(str_arr, util.B2STrim(payload))
         }
-        return fmt.Sprintf("[%s]", strings.Join(str_arr, ", "))
+        return fmt.Sprintf("[%s]", strings.Join(str_arr, ", ")), str_arr
     case config.TYPE_POINTER:
         // 先解析参数寄存器本身的值
         var ptr_value Arg_reg
@@ -312,7 +326,7 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
         if err = binary.Read(this.buf, binary.LittleEndian, &ptr_value); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return fmt.Sprintf("(0x%x)", ptr_value.Address)
+        return fmt.Sprintf("(0x%x)", ptr_value.Address), fmt.Sprintf("0x%x", ptr_value.Address)
     case config.TYPE_SIGSET:
         var sigs [8]uint32
         if err = binary.Read(this.buf, binary.LittleEndian, &sigs); err != nil {
@@ -322,43 +336,49 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
         for i := 0; i < len(sigs); i++ {
             fmt_sigs = append(fmt_sigs, fmt.Sprintf("0x%x", sigs[i]))
         }
-        return fmt.Sprintf("(sigs=[%s])", strings.Join(fmt_sigs, ","))
+        return fmt.Sprintf("(sigs=[%s])", strings.Join(fmt_sigs, ",")), sigs
+    case config.TYPE_PIPEFD:
+        var arg Arg_Pipefd
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return fmt.Sprintf("([%d, %d])", arg.Fds[0], arg.Fds[1]), arg.Fds
     case config.TYPE_POLLFD:
         var pollfd Arg_Pollfd
         if err = binary.Read(this.buf, binary.LittleEndian, &pollfd); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return fmt.Sprintf("(fd=%d, events=%d, revents=%d)", pollfd.Fd, pollfd.Events, pollfd.Revents)
+        return fmt.Sprintf("(fd=%d, events=%d, revents=%d)", pollfd.Fd, pollfd.Events, pollfd.Revents), pollfd.Pollfd
     case config.TYPE_STRUCT:
         payload := make([]byte, point_arg.Size)
         if err = binary.Read(this.buf, binary.LittleEndian, &payload); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return fmt.Sprintf("([hex]%x)", payload)
+        return fmt.Sprintf("([hex]%x)", payload), hex.EncodeToString(payload)
     case config.TYPE_TIMEZONE:
         var arg Arg_TimeZone_t
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.TimeZone_t
     case config.TYPE_PTHREAD_ATTR:
         var arg Arg_Pthread_attr_t
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.Pthread_attr_t
     case config.TYPE_TIMEVAL:
         var arg Arg_Timeval
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.Timeval
     case config.TYPE_TIMESPEC:
         var arg Arg_Timespec
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.Timespec
     case config.TYPE_STAT:
         var arg_stat_t Arg_Stat_t
         if err = binary.Read(this.buf, binary.LittleEndian, &arg_stat_t); err != nil {
@@ -366,19 +386,19 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
             time.Sleep(3 * 1000 * time.Millisecond)
             panic(fmt.Sprintf("binary.Read %s err:%v", util.B2STrim(this.Comm[:]), err))
         }
-        return arg_stat_t.Format()
+        return arg_stat_t.Format(), arg_stat_t.Stat_t
     case config.TYPE_STATFS:
         var arg_statfs_t Arg_Statfs_t
         if err = binary.Read(this.buf, binary.LittleEndian, &arg_statfs_t); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg_statfs_t.Format()
+        return arg_statfs_t.Format(), arg_statfs_t.Statfs_t
     case config.TYPE_SIGACTION:
         var arg_sigaction Arg_Sigaction
         if err = binary.Read(this.buf, binary.LittleEndian, &arg_sigaction); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg_sigaction.Format()
+        return arg_sigaction.Format(), arg_sigaction.Sigaction
     case config.TYPE_UTSNAME:
         var arg_name Arg_Utsname
         if err = binary.Read(this.buf, binary.LittleEndian, &arg_name); err != nil {
@@ -390,19 +410,27 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
         version := B2S(arg_name.Version[:])
         machine := B2S(arg_name.Machine[:])
         domainname := B2S(arg_name.Domainname[:])
-        return fmt.Sprintf("{sysname=%s, nodename=%s, release=%s, version=%s, machine=%s, domainname=%s}", sysname, nodename, release, version, machine, domainname)
+        utsname := map[string]string{
+            "sysname":    sysname,
+            "nodename":   nodename,
+            "release":    release,
+            "version":    version,
+            "machine":    machine,
+            "domainname": domainname,
+        }
+        return fmt.Sprintf("{sysname=%s, nodename=%s, release=%s, version=%s, machine=%s, domainname=%s}", sysname, nodename, release, version, machine, domainname), utsname
     case config.TYPE_SOCKADDR:
         var arg Arg_RawSockaddrUnix
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.GetSockaddr()
     case config.TYPE_RUSAGE:
         var arg_rusage Arg_Rusage
         if err = binary.Read(this.buf, binary.LittleEndian, &arg_rusage); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg_rusage.Format()
+        return arg_rusage.Format(), arg_rusage.Rusage
     case config.TYPE_IOVEC:
         // IOVEC 这里本质上是一个数组 还不太一样...
         var arg Arg_Iovec_t
@@ -414,44 +442,122 @@ func (this *ContextEvent) ParseArg(point_arg *config.PointArg, ptr Arg_reg) stri
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
         arg.Payload = payload
-        return arg.Format()
+        return arg.Format(), arg.JsonValue()
     case config.TYPE_EPOLLEVENT:
         var arg_epollevent Arg_EpollEvent
         if err = binary.Read(this.buf, binary.LittleEndian, &arg_epollevent); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg_epollevent.Format()
+        return arg_epollevent.Format(), arg_epollevent.EpollEvent
     case config.TYPE_SYSINFO:
         var arg Arg_Sysinfo_t
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.Sysinfo_t
     case config.TYPE_SIGINFO:
         // 这个读取出来有问题
         var arg Arg_SigInfo
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.SigInfo
     case config.TYPE_MSGHDR:
         var arg Arg_Msghdr
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.Msghdr
     case config.TYPE_ITIMERSPEC:
         var arg Arg_ItTmerspec
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.ItTmerspec
     case config.TYPE_STACK_T:
         var arg Arg_Stack_t
         if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
-        return arg.Format()
+        return arg.Format(), arg.Stack_t
+    case config.TYPE_TIMESPEC32:
+        var arg Arg_Timespec32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Timespec32
+    case config.TYPE_TIMEVAL32:
+        var arg Arg_Timeval32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Timeval32
+    case config.TYPE_STAT64:
+        var arg Arg_Stat64_32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Stat64_32
+    case config.TYPE_STATFS32:
+        var arg Arg_Statfs32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Statfs32
+    case config.TYPE_STATFS64:
+        var arg Arg_Statfs64_32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Statfs64_32
+    case config.TYPE_SIGACTION32:
+        var arg Arg_Sigaction32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Sigaction32
+    case config.TYPE_RUSAGE32:
+        var arg Arg_Rusage32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Rusage32
+    case config.TYPE_SYSINFO32:
+        var arg Arg_Sysinfo32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Sysinfo32
+    case config.TYPE_MSGHDR32:
+        var arg Arg_Msghdr32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Msghdr32
+    case config.TYPE_ITIMERSPEC32:
+        var arg Arg_ItTmerspec32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.ItTmerspec32
+    case config.TYPE_STACK_T32:
+        var arg Arg_Stack_t32
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Stack_t32
+    case config.TYPE_STAT_X86_64:
+        var arg Arg_Stat_X86_64
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.Stat_X86_64
+    case config.TYPE_EPOLLEVENT_X86_64:
+        var arg Arg_EpollEvent_X86_64
+        if err = binary.Read(this.buf, binary.LittleEndian, &arg); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        return arg.Format(), arg.EpollEvent_X86_64
     default:
         panic(fmt.Sprintf("unknown point_arg.AliasType %d", point_arg.AliasType))
     }
@@ -469,21 +575,19 @@ func (this *ContextEvent) GetStackTrace(s string) string {
         }
         has_reg_value := false
         var regvalue uint64
-        if strings.HasPrefix(this.RegName, "x") {
-            parts := strings.SplitN(this.RegName, "x", 2)
-            regno, _ := strconv.ParseUint(parts[1], 10, 32)
-            if regno >= 0 && regno <= uint64(config.REG_ARM64_X29) {
-                // 取到对应的寄存器值
-                regvalue = tmp_regs[regno]
-                has_reg_value = true
-            }
-        } else if this.RegName == "lr" {
-            regvalue = tmp_regs[config.REG_ARM64_LR]
+        // 寄存器名与 --point 中的写法一致 arm64 下为 x0-x29 lr
+        regno, ok := config.GetArch().Regs[this.RegName]
+        if ok && regno < uint32(len(tmp_regs)) {
+            regvalue = tmp_regs[regno]
             has_reg_value = true
         }
         if has_reg_value {
             // 读取maps 获取偏移信息
-            info, err := util.ParseReg(this.Pid, regvalue)
+            content, err := ReadMaps(this.Pid)
+            var info string
+            if err == nil {
+                info, err = util.ParseRegByMaps(content, regvalue)
+            }
             if err != nil {
                 fmt.Printf("ParseReg for %s=0x%x failed", this.RegName, regvalue)
             } else {
@@ -499,12 +603,9 @@ func (this *ContextEvent) GetStackTrace(s string) string {
             tmp_regs = this.RegsBuffer.Regs
         }
         regs := make(map[string]string)
-        for regno := 0; regno <= int(config.REG_ARM64_X29); regno++ {
-            regs[fmt.Sprintf("x%d", regno)] = fmt.Sprintf("0x%x", tmp_regs[regno])
+        for regno, name := range config.GetArch().RegNames {
+            regs[name] = fmt.Sprintf("0x%x", tmp_regs[regno])
         }
-        regs["lr"] = fmt.Sprintf("0x%x", tmp_regs[config.REG_ARM64_LR])
-        regs["sp"] = fmt.Sprintf("0x%x", tmp_regs[config.REG_ARM64_SP])
-        regs["pc"] = fmt.Sprintf("0x%x", tmp_regs[config.REG_ARM64_PC])
         regs_info, err := json.Marshal(regs)
         if err != nil {
             regs_info = make([]byte, 0)
@@ -526,29 +627,29 @@ func (this *ContextEvent) ParseContextStack() (err error) {
     if this.rec.ExtraOptions.UnwindStack {
         // 读取完整的栈数据和寄存器数据 并解析为 UnwindBuf 结构体
         this.UnwindBuffer = &UnwindBuf{}
-        err = this.UnwindBuffer.ParseContext(this.buf)
+        err = this.UnwindBuffer.ParseContext(this.buf, config.GetArch().GetSampleRegCount(this.mconf.Is32Bit))
         if err != nil {
             panic(fmt.Sprintf("UnwindStack ParseContext failed, err:%v", err))
         }
         // 立刻获取堆栈信息 对于某些hook点前后可能导致maps发生变化的 堆栈可能不准确
-        // 这里后续可以调整为只dlopen一次 拿到要调用函数的handle 不要重复dlopen
-        content, err := util.ReadMapsByPid(this.Pid)
-        if err != nil {
-            // 直接读取 maps 失败 那么从 mmap2 事件中获取
-            // 根据测试结果 有这样的情况 -> 即 fork 产生的子进程 那么应该查找其父进程 mmap2 事件
-            maps_helper.SetLogger(this.logger)
-            info, err := maps_helper.GetStack(this.Pid, this.UnwindBuffer)
-            if err != nil {
-                // this.logger.Printf("Error when opening file:%v", err)
-                this.logger.Printf("Error when GetStack:%v", err)
-            } else {
-                this.Stackinfo = info
+        // 默认使用 Go 实现的基于 CFI 的回溯 指定 --unwinder libunwindstack 时才通过 cgo 调用外部库
+        if UseLibUnwindStack() {
+            content, err := maps_helper.GetMapBuffer(this.Pid, this.Ts)
+            if err == nil {
+                this.Stackinfo = ParseStack(content, this.UnwindBuffer)
+                return nil
             }
-            return nil
         }
-        this.Stackinfo = ParseStack(content, this.UnwindBuffer)
+        // maps 来自 mmap2 以及 fork 记录维护的信息 按事件发生时的版本解析
+        maps_helper.SetLogger(this.logger)
+        info, err := maps_helper.GetStack(this.Pid, this.Ts, this.UnwindBuffer)
+        if err != nil {
+            this.logger.Printf("Error when GetStack:%v", err)
+        } else {
+            this.Stackinfo = info
+        }
     } else if this.rec.ExtraOptions.ShowRegs {
-        err = this.RegsBuffer.ParseContext(this.buf)
+        err = this.RegsBuffer.ParseContext(this.buf, config.GetArch().GetSampleRegCount(this.mconf.Is32Bit))
         if err != nil {
             panic(fmt.Sprintf("UnwindStack ParseContext failed, err:%v", err))
         }
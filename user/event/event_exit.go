@@ -57,5 +57,7 @@ func (this *ExitEvent) ParseContext() (err error) {
         s := fmt.Sprintf("[ExitEvent] pid=%d ppid=%d tid=%d ptid=%d time=%d", this.Pid, this.Ppid, this.Tid, this.Ptid, this.Time)
         this.logger.Printf(s)
     }
+    maps_helper.UpdateExitEvent(this)
+    fd_table.OnExit(this.Pid, this.Tid)
     return nil
 }
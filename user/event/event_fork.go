@@ -52,5 +52,6 @@ func (this *ForkEvent) ParseContext() (err error) {
         this.logger.Printf(s)
     }
     maps_helper.UpdateForkEvent(this)
+    fd_table.OnFork(this.Ppid, this.Pid, this.Tid)
     return nil
 }
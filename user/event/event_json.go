@@ -0,0 +1,200 @@
+package event
+
+import (
+    "encoding/json"
+    "fmt"
+    "stackplz/user/config"
+    "stackplz/user/util"
+    "strings"
+)
+
+// --format json 时每个事件输出为一行 json 便于脚本处理
+// 地址类的值统一用 hex 字符串 避免 jq 之类的工具按 double 处理丢失精度
+
+type JsonArg struct {
+    Name    string      `json:"name"`
+    Type    string      `json:"type"`
+    Raw     string      `json:"raw"`
+    Value   interface{} `json:"value"`
+    Decoded string      `json:"decoded,omitempty"`
+    FdDesc  string      `json:"fd_desc,omitempty"`
+    Rev     string      `json:"rev,omitempty"`
+}
+
+type JsonEvent struct {
+    Ts        uint64    `json:"ts"`
+    Event     string    `json:"event"`
+    Pid       uint32    `json:"pid"`
+    Tid       uint32    `json:"tid"`
+    Comm      string    `json:"comm"`
+    Uid       uint32    `json:"uid"`
+    Name      string    `json:"name,omitempty"`
+    Lib       string    `json:"lib,omitempty"`
+    Args      []JsonArg `json:"args,omitempty"`
+    Ret       *int64    `json:"ret,omitempty"`
+    Errno     string    `json:"errno,omitempty"`
+    Duration  *uint64   `json:"duration_ns,omitempty"`
+    Slow      bool      `json:"slow,omitempty"`
+    LR        string    `json:"lr,omitempty"`
+    PC        string    `json:"pc,omitempty"`
+    SP        string    `json:"sp,omitempty"`
+    LROffset  string    `json:"lr_offset,omitempty"`
+    PCOffset  string    `json:"pc_offset,omitempty"`
+    Regs      []string  `json:"regs,omitempty"`
+    Backtrace []string  `json:"backtrace,omitempty"`
+}
+
+func (this *JsonEvent) String() string {
+    data, err := json.Marshal(this)
+    if err != nil {
+        panic(fmt.Sprintf("JsonEvent Marshal err:%v", err))
+    }
+    return string(data)
+}
+
+var type_names = map[uint32]string{
+    config.TYPE_NONE:         "none",
+    config.TYPE_NUM:          "num",
+    config.TYPE_INT:          "int",
+    config.TYPE_UINT:         "uint",
+    config.TYPE_UINT32:       "uint32",
+    config.TYPE_UINT64:       "uint64",
+    config.TYPE_STRING:       "string",
+    config.TYPE_STRING_ARR:   "string_arr",
+    config.TYPE_POINTER:      "pointer",
+    config.TYPE_STRUCT:       "struct",
+    config.TYPE_TIMESPEC:     "timespec",
+    config.TYPE_STAT:         "stat",
+    config.TYPE_STATFS:       "statfs",
+    config.TYPE_SIGACTION:    "sigaction",
+    config.TYPE_UTSNAME:      "utsname",
+    config.TYPE_SOCKADDR:     "sockaddr",
+    config.TYPE_RUSAGE:       "rusage",
+    config.TYPE_IOVEC:        "iovec",
+    config.TYPE_EPOLLEVENT:   "epoll_event",
+    config.TYPE_SIGSET:       "sigset",
+    config.TYPE_POLLFD:       "pollfd",
+    config.TYPE_ARGASSIZE:    "argassize",
+    config.TYPE_SYSINFO:      "sysinfo",
+    config.TYPE_SIGINFO:      "siginfo",
+    config.TYPE_MSGHDR:       "msghdr",
+    config.TYPE_ITIMERSPEC:   "itimerspec",
+    config.TYPE_STACK_T:      "stack_t",
+    config.TYPE_TIMEVAL:      "timeval",
+    config.TYPE_TIMEZONE:     "timezone",
+    config.TYPE_PTHREAD_ATTR: "pthread_attr",
+    config.TYPE_BUFFER_T:     "buffer",
+    config.TYPE_PIPEFD:       "pipefd",
+    config.TYPE_TIMESPEC32:   "timespec",
+    config.TYPE_TIMEVAL32:    "timeval",
+    config.TYPE_STAT64:       "stat64",
+    config.TYPE_STATFS32:     "statfs",
+    config.TYPE_STATFS64:     "statfs64",
+    config.TYPE_SIGACTION32:  "sigaction",
+    config.TYPE_RUSAGE32:     "rusage",
+    config.TYPE_SYSINFO32:    "sysinfo",
+    config.TYPE_MSGHDR32:     "msghdr",
+    config.TYPE_ITIMERSPEC32: "itimerspec",
+    config.TYPE_STACK_T32:    "stack_t",
+    // x86_64
+    config.TYPE_STAT_X86_64:       "stat",
+    config.TYPE_EPOLLEVENT_X86_64: "epoll_event",
+}
+
+func GetTypeName(alias_type uint32) string {
+    name, ok := type_names[alias_type]
+    if ok {
+        return name
+    }
+    return fmt.Sprintf("type_%d", alias_type)
+}
+
+func NewJsonArg(point_arg *config.PointArg, address uint64, value interface{}) JsonArg {
+    return JsonArg{
+        Name:  point_arg.ArgName,
+        Type:  GetTypeName(point_arg.AliasType),
+        Raw:   fmt.Sprintf("0x%x", address),
+        Value: value,
+    }
+}
+
+// 数值类型的参数直接输出为数值
+func NumValue(point_arg *config.PointArg, address uint64) interface{} {
+    if point_arg.AliasType == config.TYPE_INT {
+        return config.IntValue(address)
+    }
+    return address
+}
+
+// 有符号转换的数值参数 额外带上转换后的结果
+func NewJsonNumArg(syscall_name string, point_arg *config.PointArg, address uint64) JsonArg {
+    arg := NewJsonArg(point_arg, address, NumValue(point_arg, address))
+    if config.GetArgDecoder(syscall_name, point_arg.ArgName) != nil {
+        arg.Decoded = config.DecodeNumArg(syscall_name, point_arg, address)
+    }
+    return arg
+}
+
+func GetJsonErrno(ret uint64) string {
+    if errno, ok := config.GetErrno(ret); ok {
+        return config.GetErrnoName(errno)
+    }
+    return ""
+}
+
+func (this *ContextEvent) NewJsonEvent(event_type string) *JsonEvent {
+    e := &JsonEvent{
+        Ts:    this.Ts,
+        Event: event_type,
+        Pid:   this.Pid,
+        Tid:   this.Tid,
+        Comm:  util.B2STrim(this.Comm[:]),
+        Uid:   this.Uid,
+    }
+    e.Regs = this.GetJsonRegs()
+    e.Backtrace = this.GetJsonBacktrace()
+    return e
+}
+
+func (this *ContextEvent) SetJsonLocation(e *JsonEvent, lr, pc, sp uint64) {
+    e.LR = fmt.Sprintf("0x%x", lr)
+    e.PC = fmt.Sprintf("0x%x", pc)
+    e.SP = fmt.Sprintf("0x%x", sp)
+    if this.mconf.GetOff {
+        e.LROffset = this.GetOffset(lr)
+        e.PCOffset = this.GetOffset(pc)
+    }
+}
+
+func (this *ContextEvent) GetJsonRegs() []string {
+    if !this.rec.ExtraOptions.ShowRegs {
+        return nil
+    }
+    var tmp_regs [33]uint64
+    if this.rec.ExtraOptions.UnwindStack {
+        tmp_regs = this.UnwindBuffer.Regs
+    } else {
+        tmp_regs = this.RegsBuffer.Regs
+    }
+    // 顺序与 perf 采样一致 arm64 下为 x0-x29 lr sp pc
+    reg_count := len(config.GetArch().RegNames)
+    regs := make([]string, 0, reg_count)
+    for _, value := range tmp_regs[:reg_count] {
+        regs = append(regs, fmt.Sprintf("0x%x", value))
+    }
+    return regs
+}
+
+func (this *ContextEvent) GetJsonBacktrace() []string {
+    if this.Stackinfo == "" {
+        return nil
+    }
+    var frames []string
+    for _, line := range strings.Split(this.Stackinfo, "\n") {
+        line = strings.TrimSpace(line)
+        if line != "" {
+            frames = append(frames, line)
+        }
+    }
+    return frames
+}
@@ -5,21 +5,25 @@ import (
     "encoding/binary"
     "errors"
     "fmt"
-    "io"
-    "io/ioutil"
     "log"
-    "stackplz/user/config"
+    "sort"
     "stackplz/user/util"
     "strings"
     "sync"
+    "time"
+
+    "golang.org/x/sys/unix"
 )
 
 type LibInfo struct {
     BaseAddr uint64
     Off      uint64
     EndAddr  uint64
+    Perm     string
     LibPath  string
     LibName  string
+    // 目标进程在其他 mount namespace 中时为 /proc/<pid>/root 下的路径 为空时直接使用 LibPath
+    FilePath string
 }
 
 func (this *LibInfo) Clone() LibInfo {
@@ -27,71 +31,255 @@ func (this *LibInfo) Clone() LibInfo {
     info.BaseAddr = this.BaseAddr
     info.Off = this.Off
     info.EndAddr = this.EndAddr
+    info.Perm = this.Perm
     info.LibPath = this.LibPath
     info.LibName = this.LibName
+    info.FilePath = this.FilePath
     return info
 }
 
+// 本进程读取库文件时使用的路径
+func (this *LibInfo) OpenPath() string {
+    if this.FilePath != "" {
+        return this.FilePath
+    }
+    return this.LibPath
+}
+
 func (this *LibInfo) ParseLib() {
     parts := strings.Split(this.LibPath, "/")
     this.LibName = parts[len(parts)-1]
 }
 
+// 通过库的符号表将地址转换为 libfoo.so!Foo::bar(int)+0x24 失败返回空字符串
+// is_ret 表示地址是返回地址 此时按调用指令所在位置查找符号 避免落到下一个函数
+func (this *LibInfo) Symbolize(addr uint64, is_ret bool) string {
+    if !strings.HasPrefix(this.LibPath, "/") {
+        return ""
+    }
+    table := util.LoadSymbolTable(this.OpenPath())
+    if table == nil {
+        return ""
+    }
+    vaddr := table.FileOffsetToVaddr(this.Off + (addr - this.BaseAddr))
+    lookup_addr := vaddr
+    if is_ret && lookup_addr >= 4 {
+        lookup_addr -= 4
+    }
+    sym, ok := table.Lookup(lookup_addr)
+    if !ok {
+        return ""
+    }
+    return fmt.Sprintf("%s!%s+0x%x", this.LibName, util.Demangle(sym.Name), vaddr-sym.Value)
+}
+
 var pid_list []uint32
+var new_pid_hook func(pid uint32)
+var lib_load_hook func(pid uint32, lib_path string)
+var maps_snapshots map[uint32][]mapsSnapshot
+
+// record 模式保存的 maps 快照 ts 为读取时的 CLOCK_MONOTONIC
+type mapsSnapshot struct {
+    ts      uint64
+    content string
+}
 
-type ProcMaps map[string][]LibInfo
+// 进程在某一时刻的 maps 按起始地址排序且互不重叠
+// 创建之后不再修改 变更时生成新的快照 所以可以放心地在多个版本之间共享
+type ProcMaps struct {
+    Ts      uint64
+    regions []LibInfo
+}
 
-func (this *MapsHelper) GetRegion(pid_maps *ProcMaps, addr uint64) *LibInfo {
-    var region LibInfo
-    for _, lib_infos := range *pid_maps {
-        for _, lib_info := range lib_infos {
-            // this.logger.Printf("[lib_info] start:0x%x end:0x%x name:%s\n", lib_info.BaseAddr, lib_info.EndAddr, lib_info.LibName)
-            if addr >= lib_info.BaseAddr && addr < lib_info.EndAddr {
-                region = lib_info
-            }
-        }
+func (this *ProcMaps) Regions() []LibInfo {
+    return this.regions
+}
+
+// 二分查找地址所在的 map 找不到返回 nil
+func (this *ProcMaps) Find(addr uint64) *LibInfo {
+    index := sort.Search(len(this.regions), func(i int) bool {
+        return this.regions[i].EndAddr > addr
+    })
+    if index < len(this.regions) && this.regions[index].BaseAddr <= addr {
+        return &this.regions[index]
     }
-    return &region
+    return nil
 }
 
-func (this *MapsHelper) GetRegionInfo(pid_maps *ProcMaps, addr uint64) string {
-    var info string = fmt.Sprintf("0x%x <unknown>", addr)
-    for _, lib_infos := range *pid_maps {
-        for _, lib_info := range lib_infos {
-            if addr >= lib_info.BaseAddr && addr < lib_info.EndAddr {
-                offset := lib_info.Off + (addr - lib_info.BaseAddr)
-                info = fmt.Sprintf("0x%x <%s + 0x%x>", addr, lib_info.LibName, offset)
+// 与内核的行为一致 新的映射会覆盖掉重叠部分的旧映射
+func (this *ProcMaps) Insert(ts uint64, info LibInfo) *ProcMaps {
+    new_maps := &ProcMaps{Ts: ts, regions: make([]LibInfo, 0, len(this.regions)+2)}
+    inserted := false
+    for _, region := range this.regions {
+        if region.EndAddr <= info.BaseAddr || region.BaseAddr >= info.EndAddr {
+            if !inserted && region.BaseAddr >= info.EndAddr {
+                new_maps.regions = append(new_maps.regions, info)
+                inserted = true
             }
+            new_maps.regions = append(new_maps.regions, region)
+            continue
+        }
+        if region.BaseAddr < info.BaseAddr {
+            left := region
+            left.EndAddr = info.BaseAddr
+            new_maps.regions = append(new_maps.regions, left)
+        }
+        if !inserted {
+            new_maps.regions = append(new_maps.regions, info)
+            inserted = true
+        }
+        if region.EndAddr > info.EndAddr {
+            right := region
+            right.Off += info.EndAddr - region.BaseAddr
+            right.BaseAddr = info.EndAddr
+            new_maps.regions = append(new_maps.regions, right)
         }
     }
-    return info
+    if !inserted {
+        new_maps.regions = append(new_maps.regions, info)
+    }
+    return new_maps
 }
 
 func (this *ProcMaps) Clone() ProcMaps {
-    // maps_lock.Lock()
-    // defer maps_lock.Unlock()
-    maps := ProcMaps{}
-    for key, value := range *this {
-        var infos []LibInfo
-        for _, ori_info := range value {
-            infos = append(infos, ori_info)
+    maps := ProcMaps{Ts: this.Ts}
+    maps.regions = append(maps.regions, this.regions...)
+    return maps
+}
+
+func (this *ProcMaps) ToMapBuffer() string {
+    // 把自身转换成 /proc/{pid}/maps 这样的内容
+    var builder strings.Builder
+    for _, region := range this.regions {
+        perm := region.Perm
+        if perm == "" {
+            perm = "r-xp"
+        }
+        // libunwindstack 直接打开其中的路径
+        path := region.OpenPath()
+        if strings.HasPrefix(path, "UNNAMED_") {
+            path = ""
         }
-        maps[key] = infos
+        builder.WriteString(fmt.Sprintf("%x-%x %s %08x 00:00 0 %s\n", region.BaseAddr, region.EndAddr, perm, region.Off, path))
     }
+    return builder.String()
+}
+
+// 其他 mount namespace 中的进程 文件通过 /proc/<pid>/root 访问
+func (this *ProcMaps) SetRootPrefix(prefix string) {
+    if prefix == "" {
+        return
+    }
+    for i := range this.regions {
+        if strings.HasPrefix(this.regions[i].LibPath, "/") {
+            this.regions[i].FilePath = prefix + this.regions[i].LibPath
+        }
+    }
+}
+
+// 解析 /proc/{pid}/maps 的内容
+func ParseProcMaps(ts uint64, content string) *ProcMaps {
+    var (
+        seg_start  uint64
+        seg_end    uint64
+        permission string
+        seg_offset uint64
+        device     string
+        inode      uint64
+        seg_path   string
+    )
+    maps := &ProcMaps{Ts: ts}
+    for _, line := range strings.Split(content, "\n") {
+        seg_path = ""
+        reader := strings.NewReader(line)
+        n, _ := fmt.Fscanf(reader, "%x-%x %s %x %s %d %s", &seg_start, &seg_end, &permission, &seg_offset, &device, &inode, &seg_path)
+        // 匿名映射没有路径
+        if n < 6 {
+            continue
+        }
+        if seg_path == "" {
+            seg_path = fmt.Sprintf("UNNAMED_0x%x", seg_start)
+        }
+        info := LibInfo{
+            BaseAddr: seg_start,
+            Off:      seg_offset,
+            EndAddr:  seg_end,
+            Perm:     permission,
+            LibPath:  seg_path,
+        }
+        info.ParseLib()
+        maps.regions = append(maps.regions, info)
+    }
+    sort.Slice(maps.regions, func(i, j int) bool {
+        return maps.regions[i].BaseAddr < maps.regions[j].BaseAddr
+    })
     return maps
 }
 
-func (this *ProcMaps) ToMapBuffer(pid uint32, del_old bool) string {
-    // 把自身转换成 /proc/{pid}/maps 这样的内容
+// 单个进程 maps 的全部版本 按时间排序
+// 事件按照其发生时的 maps 进行解析 避免之后的 mmap 影响结果
+type PidMaps struct {
+    versions []*ProcMaps
+    // 上次因为查找失败重新读取 maps 的时间
+    refresh_ts uint64
+    // 丢弃过旧版本之后 早于 versions[0] 的事件已经没有对应的 maps
+    dropped bool
+    // 已经提示过找不到对应版本
+    stale_warned bool
+}
+
+// 旧版本至少保留这么久 事件的处理延迟不会超过这个时间
+const MAPS_VERSION_KEEP = uint64(10 * time.Second)
+
+// 频繁 mmap 的进程 版本数量也不能无限增长
+const MAX_MAPS_VERSIONS = 4096
+
+// 查找失败时重新读取 maps 的最小间隔
+const MAPS_REFRESH_INTERVAL = uint64(time.Second)
 
-    return ""
+func (this *PidMaps) Latest() *ProcMaps {
+    return this.versions[len(this.versions)-1]
+}
+
+// 返回 ts 时刻的 maps ts 为 0 表示最新的版本
+// 对应的版本已经被丢弃时返回最旧的版本 并且 ok 为 false
+func (this *PidMaps) At(ts uint64) (maps *ProcMaps, ok bool) {
+    if ts == 0 {
+        return this.Latest(), true
+    }
+    index := sort.Search(len(this.versions), func(i int) bool {
+        return this.versions[i].Ts > ts
+    })
+    if index == 0 {
+        return this.versions[0], !this.dropped
+    }
+    return this.versions[index-1], true
+}
+
+func (this *PidMaps) Add(maps *ProcMaps) {
+    // 乱序到达的变更 直接作为最新版本 时间取最新版本的时间
+    if len(this.versions) > 0 && maps.Ts < this.Latest().Ts {
+        maps.Ts = this.Latest().Ts
+    }
+    this.versions = append(this.versions, maps)
+    // 下一个版本也已经足够旧了 说明不会再有事件用到最旧的版本
+    for len(this.versions) > 1 && this.versions[1].Ts+MAPS_VERSION_KEEP < maps.Ts {
+        this.versions = this.versions[1:]
+        this.dropped = true
+    }
+    if len(this.versions) > MAX_MAPS_VERSIONS {
+        this.versions = this.versions[len(this.versions)-MAX_MAPS_VERSIONS:]
+        this.dropped = true
+    }
 }
 
-// type MapsHelper map[uint32]ProcMaps
 type MapsHelper struct {
     logger           *log.Logger
-    pid_maps         map[uint32]*ProcMaps
+    pid_maps         map[uint32]*PidMaps
     child_parent_map map[uint32]uint32
+    last_ts          uint64
+    // 进程所在 mount namespace 的根目录前缀
+    root_prefix map[uint32]string
 }
 
 func NewMapsHelper() *MapsHelper {
@@ -105,189 +293,191 @@ func (this *MapsHelper) SetLogger(logger *log.Logger) {
 }
 
 func (this *MapsHelper) InitMap() {
-    this.pid_maps = make(map[uint32]*ProcMaps)
+    this.pid_maps = make(map[uint32]*PidMaps)
     this.child_parent_map = make(map[uint32]uint32)
+    this.root_prefix = make(map[uint32]string)
+}
+
+// 回放时文件都在本机 不需要前缀 调用方需要持有 maps_lock
+func (this *MapsHelper) rootPrefix(pid uint32) string {
+    if IsReplayMode() {
+        return ""
+    }
+    prefix, ok := this.root_prefix[pid]
+    if !ok {
+        prefix = util.ProcRootPrefix(pid)
+        this.root_prefix[pid] = prefix
+    }
+    return prefix
 }
-func (this *MapsHelper) FindLib(pid uint32) (ProcMaps, error) {
+
+// 记录事件的时间 回放时用作 maps 变更的时间
+func (this *MapsHelper) UpdateTs(ts uint64) {
     maps_lock.Lock()
     defer maps_lock.Unlock()
+    if ts > this.last_ts {
+        this.last_ts = ts
+    }
+}
+
+// maps 变更的时间 与 eBPF 中的 bpf_ktime_get_ns 同为 CLOCK_MONOTONIC
+// mmap2 等记录是到达时才处理的 时间会略晚于实际发生的时间 查找时会再用最新版本兜底
+func (this *MapsHelper) now() uint64 {
+    if IsReplayMode() {
+        return this.last_ts
+    }
+    var ts unix.Timespec
+    if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+        return this.last_ts
+    }
+    return uint64(ts.Nano())
+}
+
+// 获取进程的 maps 还没有记录时读取 /proc/{pid}/maps 调用方需要持有 maps_lock
+func (this *MapsHelper) getPidMaps(pid uint32) (*PidMaps, error) {
     pid_maps, ok := this.pid_maps[pid]
-    if !ok {
-        err := this.ParseMaps(pid, false)
-        if err != nil {
-            return nil, err
-        }
-        pid_maps, ok = this.pid_maps[pid]
-        if !ok {
-            return nil, errors.New("get ProcMaps by pid failed")
-        }
+    if ok {
+        return pid_maps, nil
     }
-    return pid_maps.Clone(), nil
+    err := this.ParseMaps(pid)
+    if err != nil {
+        return nil, err
+    }
+    return this.pid_maps[pid], nil
 }
 
-func (this *MapsHelper) UpdateForkEvent(event *ForkEvent) {
-    // 根据日志实际的记录结果 fork 的 pid ppid 存在相同的情况 why
-    parent_pid, ok := this.child_parent_map[event.Pid]
-    if !ok {
-        this.child_parent_map[event.Pid] = event.Ppid
-        // 为了便于后续能够快速查找进程的maps
-        // 出现fork事件的时候 把父进程已经收集到的 maps 信息也复制一份
-        // 最开始想设计为需要计算的时候 查找父进程 再去解析偏移 但是考虑到fork产生的子进程可能会产生新的操作 这样不合理
-        if event.Pid != event.Ppid {
-            this.CloneMaps(event.Pid, event.Ppid)
-        }
-    } else {
-        // 大多数情况下应该是不会到这个分支的 除非是进程id不够用了
-        // 例如 A 产生 B B 产生 C A结束 C 产生 D 那么这个时候 D 被分配的pid可能是之前A的pid（应该是有这个概率的
-        if parent_pid != event.Ppid {
-            // 本次所产生的子进程的父进程 与原本记录的父进程不一致
-            // 那么更新此次的子进程的父进程
-            this.child_parent_map[event.Pid] = event.Ppid
-        }
+// 返回进程最新的 maps
+func (this *MapsHelper) FindLib(pid uint32) (*ProcMaps, error) {
+    maps_lock.Lock()
+    defer maps_lock.Unlock()
+    pid_maps, err := this.getPidMaps(pid)
+    if err != nil {
+        return nil, err
     }
+    return pid_maps.Latest(), nil
 }
 
-func (this *MapsHelper) CloneMaps(pid, parent_pid uint32) (err error) {
-    // 为子进程复制一份maps信息
+// 查找 ts 时刻地址所在的 map 找不到时再用最新的版本查找一次
+func (this *MapsHelper) FindRegion(pid uint32, ts uint64, addr uint64) *LibInfo {
     maps_lock.Lock()
     defer maps_lock.Unlock()
-    pid_maps, ok := this.pid_maps[parent_pid]
-    if !ok {
-        // 在当前维护的maps中不存在父进程的 比如是在进程运行过程中hook的
-        // 那么尝试去读取父进程的maps
-        err = this.ParseMaps(parent_pid, false)
-        if err != nil {
-            // 应该不会走到这个分支
-            return err
-        }
-        pid_maps, ok = this.pid_maps[parent_pid]
-        if !ok {
-            // ParseMaps 成功的情况下应该不会到这个分支
-            return errors.New(fmt.Sprintf("ParseMaps success, but get pid_maps failed by child:%d parent:%d", pid, parent_pid))
-        }
+    pid_maps, err := this.getPidMaps(pid)
+    if err != nil {
+        return nil
     }
-    copied_maps := pid_maps.Clone()
-    this.pid_maps[pid] = &copied_maps
-    return nil
+    maps, ok := pid_maps.At(ts)
+    if !ok && !pid_maps.stale_warned && this.logger != nil {
+        pid_maps.stale_warned = true
+        this.logger.Printf("maps of pid %d at %d already dropped, use the oldest version", pid, ts)
+    }
+    if region := maps.Find(addr); region != nil {
+        return region
+    }
+    if region := pid_maps.Latest().Find(addr); region != nil {
+        return region
+    }
+    // 比如 brk 模式下没有 mmap2 记录 限制频率重新读取一次 maps
+    now := this.now()
+    if IsReplayMode() || now-pid_maps.refresh_ts < MAPS_REFRESH_INTERVAL {
+        return nil
+    }
+    pid_maps.refresh_ts = now
+    if this.ParseMaps(pid) != nil {
+        return nil
+    }
+    return pid_maps.Latest().Find(addr)
 }
 
-func (this *MapsHelper) GetStack(pid uint32, ubuf *UnwindBuf) (info string, err error) {
-    // 当直接读取 maps 文件失败的时候 就采用这个方案获取堆栈
+// 返回 ts 时刻的 maps 内容 用于 libunwindstack 避免每次都读取 /proc/{pid}/maps
+func (this *MapsHelper) GetMapBuffer(pid uint32, ts uint64) (string, error) {
+    maps_lock.Lock()
+    defer maps_lock.Unlock()
+    pid_maps, err := this.getPidMaps(pid)
+    if err != nil {
+        return "", err
+    }
+    maps, _ := pid_maps.At(ts)
+    return maps.ToMapBuffer(), nil
+}
 
-    // 首先尝试获取 pid 对应的 maps 信息
-    pid_maps, ok := this.pid_maps[pid]
+func (this *MapsHelper) UpdateForkEvent(event *ForkEvent) {
+    maps_lock.Lock()
+    defer maps_lock.Unlock()
+    // 线程创建同样会产生 fork 记录 只关心新进程
+    if event.Pid == event.Ppid {
+        return
+    }
+    // pid 被复用的情况 例如 A 产生 B B 产生 C A结束 C 产生 D 那么这个时候 D 被分配的pid可能是之前A的pid
+    // 记录最新的父进程 旧的 maps 直接丢弃
+    this.child_parent_map[event.Pid] = event.Ppid
+    delete(this.pid_maps, event.Pid)
+    delete(this.root_prefix, event.Pid)
+    // 回放时子进程有自己的快照 用到时按快照建立各个版本
+    if _, ok := maps_snapshots[event.Pid]; ok {
+        return
+    }
+    // 子进程继承父进程的 maps 父进程还没有记录时不做处理 用到时再读取子进程的 maps
+    parent_maps, ok := this.pid_maps[event.Ppid]
     if !ok {
-        return "", errors.New(fmt.Sprintf("[GetStack] get pid_maps failed by pid:%d", pid))
+        return
     }
+    latest := parent_maps.Latest().Clone()
+    latest.Ts = 0
+    this.pid_maps[event.Pid] = &PidMaps{versions: []*ProcMaps{&latest}}
+}
 
-    // perf_output_sample_ustack dump获取到的栈空间数据 起始地址就是 sp
-    stack_buf := bytes.NewReader(ubuf.Data[:])
-    fp := ubuf.Regs[config.REG_ARM64_X29]
-    // lr := ubuf.Regs[config.REG_ARM64_LR]
-    sp := ubuf.Regs[config.REG_ARM64_SP]
-    pc := ubuf.Regs[config.REG_ARM64_PC]
-    // 栈解析结果
-    // var stack_arr []uint64
-    var stack_infos []string
-    // stack_arr = append(stack_arr, pc)
-    stack_infos = append(stack_infos, this.GetRegionInfo(pid_maps, pc))
-    // 奇怪 这里竟然没有 sp 所在的map信息
-    // sp_region := this.GetRegion(pid_maps, sp)
-    // this.logger.Printf("start:0x%x end:0x%x name:%s\n", sp_region.BaseAddr, sp_region.EndAddr, sp_region.LibName)
-    // this.logger.Printf("pc:0x%x fp:0x%x sp:0x%x\n", pc, fp, sp)
-    for i := 0; i < 20; i++ {
-        // if fp < sp || fp < sp_region.BaseAddr || fp > sp_region.EndAddr {
-        //     break
-        // }
-        if fp < sp {
-            break
-        }
-        fp_offset := fp - sp
-        stack_buf.Seek(int64(fp_offset), io.SeekStart)
-        var next_fp uint64
-        err = binary.Read(stack_buf, binary.LittleEndian, &next_fp)
-        if err != nil {
-            this.logger.Printf("read next_fp at offset:0x%x failed\n", fp_offset)
-            break
-        }
-        var next_lr uint64
-        err = binary.Read(stack_buf, binary.LittleEndian, &next_lr)
-        if err != nil {
-            this.logger.Printf("read next_lr at offset:0x%x failed\n", fp_offset+uint64(binary.Size(next_fp)))
-            break
-        }
-        fp = next_fp
-        // stack_arr = append(stack_arr, next_lr)
-        if next_lr != 0 {
-            stack_infos = append(stack_infos, this.GetRegionInfo(pid_maps, next_lr))
-        }
+func (this *MapsHelper) UpdateExitEvent(event *ExitEvent) {
+    maps_lock.Lock()
+    defer maps_lock.Unlock()
+    // 只有进程退出才处理 还没处理的事件可能仍然需要 maps 所以只保留最新的版本
+    if event.Pid != event.Tid {
+        return
+    }
+    pid_maps, ok := this.pid_maps[event.Pid]
+    if !ok {
+        return
     }
-    // backtrace := fmt.Sprintf("Backtrace:\n\t%s", strings.Join(stack_infos, "\n\t"))
-    backtrace := fmt.Sprintf("\t%s", strings.Join(stack_infos, "\n\t"))
-    return backtrace, nil
-    // return this.GetOffset(pid, addr), nil
+    pid_maps.versions = []*ProcMaps{pid_maps.Latest()}
 }
 
-func (this *MapsHelper) ParseMaps(pid uint32, del_old bool) error {
-    filename := fmt.Sprintf("/proc/%d/maps", pid)
-    content, err := ioutil.ReadFile(filename)
+func (this *MapsHelper) GetStack(pid uint32, ts uint64, ubuf *UnwindBuf) (info string, err error) {
+    // 基于 .eh_frame/.debug_frame 进行回溯 找不到 CFI 的帧退回基于 fp 的回溯
+    _, err = this.FindLib(pid)
     if err != nil {
-        return fmt.Errorf("Error when opening file:%v", err)
+        return "", errors.New(fmt.Sprintf("[GetStack] get pid_maps failed by pid:%d, err:%v", pid, err))
     }
-    var (
-        seg_start  uint64
-        seg_end    uint64
-        permission string
-        seg_offset uint64
-        device     string
-        inode      uint64
-        seg_path   string
-    )
+    find_region := func(addr uint64) *LibInfo {
+        return this.FindRegion(pid, ts, addr)
+    }
+    // perf_output_sample_ustack dump获取到的栈空间数据 起始地址就是 sp
+    frames := Unwind(ubuf.Regs, NewStackMemory(ubuf), find_region)
+    return FormatFrames(frames), nil
+}
 
-    if del_old {
-        this.pid_maps[pid] = &ProcMaps{}
-    } else {
-        _, ok := this.pid_maps[pid]
-        if !ok {
-            this.pid_maps[pid] = &ProcMaps{}
-        }
+// 读取 /proc/{pid}/maps 作为新的版本 调用方需要持有 maps_lock
+func (this *MapsHelper) ParseMaps(pid uint32) error {
+    content, err := ReadMaps(pid)
+    if err != nil {
+        return fmt.Errorf("Error when opening file:%v", err)
     }
-    pid_maps := this.pid_maps[pid]
-    for _, line := range strings.Split(string(content), "\n") {
-        reader := strings.NewReader(line)
-        n, err := fmt.Fscanf(reader, "%x-%x %s %x %s %d %s", &seg_start, &seg_end, &permission, &seg_offset, &device, &inode, &seg_path)
-        if err == nil && n == 7 {
-            if seg_path == "" {
-                seg_path = fmt.Sprintf("UNNAMED_0x%x", seg_start)
-            }
-            new_info := LibInfo{
-                BaseAddr: seg_start,
-                Off:      seg_offset,
-                EndAddr:  seg_end,
-                LibPath:  seg_path,
-            }
-            new_info.ParseLib()
-
-            base_list, ok := (*pid_maps)[seg_path]
-            if ok {
-                // 注意基址列表去重 做成列表的原因是...
-                has_find := false
-                for _, info := range base_list {
-                    if info.BaseAddr == new_info.BaseAddr {
-                        has_find = true
-                        break
-                    }
-                }
-                if !has_find {
-                    base_list = append(base_list, new_info)
-                    (*pid_maps)[seg_path] = base_list
-                }
-            } else {
-                (*pid_maps)[seg_path] = []LibInfo{new_info}
+    pid_maps, ok := this.pid_maps[pid]
+    if !ok {
+        // 第一次读取的 maps 用于解析之前的全部事件
+        maps := ParseProcMaps(0, content)
+        maps.SetRootPrefix(this.rootPrefix(pid))
+        pid_maps = &PidMaps{versions: []*ProcMaps{maps}}
+        this.pid_maps[pid] = pid_maps
+        // 回放时之后的快照按各自的时间作为新的版本
+        if IsReplayMode() {
+            for _, snapshot := range maps_snapshots[pid][1:] {
+                pid_maps.Add(ParseProcMaps(snapshot.ts, snapshot.content))
             }
         }
+        return nil
     }
-    this.pid_maps[pid] = pid_maps
+    maps := ParseProcMaps(this.now(), content)
+    maps.SetRootPrefix(this.rootPrefix(pid))
+    pid_maps.Add(maps)
     return nil
 }
 
@@ -299,95 +489,99 @@ func (this *MapsHelper) UpdatePidList(pid uint32) {
         }
     }
     pid_list = append(pid_list, pid)
+    if new_pid_hook != nil {
+        new_pid_hook(pid)
+    }
 }
-func (this *MapsHelper) UpdateMaps(event *Mmap2Event) {
+
+func GetPidList() []uint32 {
+    return append([]uint32{}, pid_list...)
+}
+
+// 首次出现新的 pid 时回调 用于 record 模式记录 maps 快照
+func SetNewPidHook(hook func(pid uint32)) {
+    new_pid_hook = hook
+}
+
+// 文件被映射为可执行时回调 用于延迟 hook 启动后才加载的库
+func SetLibLoadHook(hook func(pid uint32, lib_path string)) {
+    lib_load_hook = hook
+}
+
+// 回放 trace 文件时 maps 信息全部来自文件中的快照 不能读取本机的 /proc
+func EnableReplayMode() {
+    if maps_snapshots == nil {
+        maps_snapshots = make(map[uint32][]mapsSnapshot)
+    }
+}
+
+// 快照需要按时间顺序添加 第一个快照用于解析它之前的全部事件
+func AddMapsSnapshot(pid uint32, ts uint64, content string) {
+    EnableReplayMode()
+    maps_snapshots[pid] = append(maps_snapshots[pid], mapsSnapshot{ts: ts, content: content})
     maps_lock.Lock()
     defer maps_lock.Unlock()
-    // 只尝试解析存在于 pid_list 的
-    exists := false
-    for _, v := range pid_list {
-        if v == event.Pid {
-            exists = true
-            break
+    delete(maps_helper.pid_maps, pid)
+    maps_helper.ParseMaps(pid)
+}
+
+func IsReplayMode() bool {
+    return maps_snapshots != nil
+}
+
+func ReadMaps(pid uint32) (string, error) {
+    if maps_snapshots != nil {
+        snapshots, ok := maps_snapshots[pid]
+        if !ok {
+            return "", errors.New(fmt.Sprintf("no maps snapshot for pid:%d", pid))
         }
+        return snapshots[0].content, nil
     }
-    if !exists {
-        return
-    }
-    // 遇到 mmap2 事件的时候都去尝试读取maps信息
-    this.ParseMaps(event.Pid, true)
+    return util.ReadMapsByPid(pid)
+}
+
+// 根据 mmap2 记录增量更新 不再重新读取 /proc/{pid}/maps
+func (this *MapsHelper) UpdateMaps(event *Mmap2Event) {
+    maps_lock.Lock()
+    defer maps_lock.Unlock()
+    // 还没有记录的进程不用处理 第一次用到时读取的 maps 已经包含了这次的映射
     pid_maps, ok := this.pid_maps[event.Pid]
     if !ok {
-        this.pid_maps[event.Pid] = &ProcMaps{}
+        return
     }
-    pid_maps = this.pid_maps[event.Pid]
-
-    if event.Filename == "" {
-        event.Filename = fmt.Sprintf("UNNAMED_0x%x", event.Addr)
+    filename := event.Filename
+    if filename == "" {
+        filename = fmt.Sprintf("UNNAMED_0x%x", event.Addr)
     }
     info := LibInfo{
         BaseAddr: event.Addr,
         Off:      event.Pgoff,
         EndAddr:  event.Addr + event.Len,
-        LibPath:  event.Filename,
+        Perm:     event.PermString(),
+        LibPath:  filename,
     }
     info.ParseLib()
-    base_list, ok := (*pid_maps)[event.Filename]
-    if ok {
-        // 注意基址列表去重 做成列表的原因是...
-        has_find := false
-        for _, info := range base_list {
-            if info.BaseAddr == info.BaseAddr {
-                has_find = true
-                break
-            }
-        }
-        if !has_find {
-            base_list = append(base_list, info)
-            (*pid_maps)[event.Filename] = base_list
-        }
-    } else {
-        (*pid_maps)[event.Filename] = []LibInfo{info}
+    if prefix := this.rootPrefix(event.Pid); prefix != "" && strings.HasPrefix(filename, "/") {
+        info.FilePath = prefix + filename
     }
-    // 这一句应该不用了？
-    this.pid_maps[event.Pid] = pid_maps
-}
-
-func (this *MapsHelper) GetOffset(pid uint32, addr uint64) (info string) {
-    maps_lock.Lock()
-    defer maps_lock.Unlock()
-    pid_maps, ok := this.pid_maps[pid]
+    // 优先使用记录自带的时间 记录到达的时间会晚于 mmap 实际发生的时间
+    ts, ok := event.SampleTime()
     if !ok {
-        // 一般不会进入这个分支
-        err := this.ParseMaps(pid, false)
-        if err != nil {
-            return fmt.Sprintf("UNNKOWN + 0x%x", addr)
-        }
-        pid_maps, ok = this.pid_maps[pid]
-        if !ok {
-            return fmt.Sprintf("UNNKOWN + 0x%x", addr)
-        }
+        ts = this.now()
     }
-    // 这里的计算是以库的每个段都是前后连续为前提的，暂时就这样
-    // 但是实际上确实存在一些奇怪的操作
-    // 1. 不连续的段
-    // 2. 两个或者多个同名/同路径的库存在于maps中
-    // 3. 其他...
-    // 全部遍历是一种低效的写法，但是暂时没有更好的想法，就这样
-    // 一定要优化那么应该在每次 pid_maps 变更的时候就进行排序 并按照基址大小插入
-    var off_list []string = []string{}
-    for _, lib_infos := range *pid_maps {
-        for _, lib_info := range lib_infos {
-            if addr >= lib_info.BaseAddr && addr < lib_info.EndAddr {
-                offset := lib_info.Off + (addr - lib_info.BaseAddr)
-                off_list = append(off_list, fmt.Sprintf("%s + 0x%x", lib_info.LibName, offset))
-            }
-        }
-    }
-    if len(off_list) == 0 {
+    pid_maps.Add(pid_maps.Latest().Insert(ts, info))
+}
+
+func (this *MapsHelper) GetOffset(pid uint32, ts uint64, addr uint64) (info string) {
+    region := this.FindRegion(pid, ts, addr)
+    if region == nil {
         return fmt.Sprintf("NOTFOUND + 0x%x", addr)
     }
-    return strings.Join(off_list[:], ",")
+    if sym_info := region.Symbolize(addr, false); sym_info != "" {
+        return sym_info
+    }
+    offset := region.Off + (addr - region.BaseAddr)
+    return fmt.Sprintf("%s + 0x%x", region.LibName, offset)
 }
 
 var maps_helper = NewMapsHelper()
@@ -416,6 +610,45 @@ func (this *Mmap2Event) String() string {
     return s
 }
 
+// 转换为 maps 中的权限形式 比如 r-xp
+func (this *Mmap2Event) PermString() string {
+    perm := []byte("---p")
+    if this.Prot&unix.PROT_READ != 0 {
+        perm[0] = 'r'
+    }
+    if this.Prot&unix.PROT_WRITE != 0 {
+        perm[1] = 'w'
+    }
+    if this.Prot&unix.PROT_EXEC != 0 {
+        perm[2] = 'x'
+    }
+    if this.Flags&unix.MAP_SHARED != 0 {
+        perm[3] = 's'
+    }
+    return string(perm)
+}
+
+// sample_id 中与 sample_type 对应的项 需要与 perf 读取端的设置保持一致
+const SAMPLE_ID_TYPE = unix.PERF_SAMPLE_TID | unix.PERF_SAMPLE_TIME
+
+// 按内核 perf_event__output_id_sample 的顺序取出 PERF_SAMPLE_TIME
+func ParseSampleIdTime(sample_id []byte, sample_type uint64) (uint64, bool) {
+    offset := 0
+    if sample_type&unix.PERF_SAMPLE_TID != 0 {
+        offset += 8
+    }
+    if sample_type&unix.PERF_SAMPLE_TIME == 0 || len(sample_id) < offset+8 {
+        return 0, false
+    }
+    ts := binary.LittleEndian.Uint64(sample_id[offset : offset+8])
+    return ts, ts != 0
+}
+
+// 记录中没有 sample_id 时返回 false
+func (this *Mmap2Event) SampleTime() (uint64, bool) {
+    return ParseSampleIdTime(this.Sample_id, SAMPLE_ID_TYPE)
+}
+
 func (this *Mmap2Event) GetUUID() string {
     return fmt.Sprintf("%d_%d", this.Pid, this.Tid)
 }
@@ -464,7 +697,21 @@ func (this *Mmap2Event) ParseContext() (err error) {
     if err = binary.Read(this.buf, binary.LittleEndian, &tmp); err != nil {
         return err
     }
-    this.Filename = util.B2STrim(tmp)
+    // 文件名以 0 结尾并按 8 字节对齐 之后是 sample_id_all 附加的 sample_id
+    name_len := bytes.IndexByte(tmp, 0)
+    if name_len < 0 {
+        name_len = len(tmp)
+    }
+    aligned_len := (name_len + 8) &^ 7
+    if aligned_len > len(tmp) {
+        aligned_len = len(tmp)
+    }
+    this.Filename = util.B2STrim(tmp[:name_len])
+    this.Sample_id = tmp[aligned_len:]
+    maps_helper.UpdateMaps(this)
+    if lib_load_hook != nil && this.Prot&unix.PROT_EXEC != 0 && strings.HasPrefix(this.Filename, "/") {
+        lib_load_hook(this.Pid, this.Filename)
+    }
     if this.mconf.Debug {
         s := fmt.Sprintf("[Mmap2Event] pid=%d tid=%d addr=0x%x len=0x%x pgoff=0x%x mag=%d min=%d ino=%d ino_generation=%d prot=0x%x flags=0x%x <%s>", this.Pid, this.Tid, this.Addr, this.Len, this.Pgoff, this.Maj, this.Min, this.Ino, this.Ino_generation, this.Prot, this.Flags, this.Filename)
         this.logger.Printf(s)
@@ -478,16 +725,10 @@ func FindLibInMaps(pid uint32, brk_lib string) (LibInfo, error) {
     if err != nil {
         return info, err
     }
-    for _, lib_infos := range pid_maps {
-        for _, lib_info := range lib_infos {
-            if brk_lib == lib_info.LibPath {
-                info = lib_info
-                break
-            }
-            if brk_lib == lib_info.LibName {
-                info = lib_info
-                break
-            }
+    // 按地址排序 第一个匹配的就是库的基址
+    for _, lib_info := range pid_maps.Regions() {
+        if brk_lib == lib_info.LibPath || brk_lib == lib_info.LibName {
+            return lib_info, nil
         }
     }
     return info, err
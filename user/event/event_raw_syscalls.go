@@ -2,12 +2,14 @@ package event
 
 import (
     "encoding/binary"
+    "encoding/hex"
     "fmt"
     "net"
     "stackplz/user/config"
     "stackplz/user/util"
     "strings"
     "syscall"
+    "time"
     "unsafe"
 )
 
@@ -35,6 +37,12 @@ type SyscallEvent struct {
     ret          uint64
     args         [6]uint64
     arg_str      string
+    arg_values   []string
+    ret_str      string
+    merged       bool
+    unfinished   bool
+    duration     uint64
+    json_args    []JsonArg
 }
 
 type IArg interface {
@@ -139,6 +147,12 @@ type Arg_Sigaction struct {
     Len   uint32
     config.Sigaction
 }
+type Arg_Pipefd struct {
+    Index uint8
+    Len   uint32
+    Fds   [2]int32
+}
+
 type Arg_Pollfd struct {
     Index uint8
     Len   uint32
@@ -147,7 +161,7 @@ type Arg_Pollfd struct {
 type Arg_Stat_t struct {
     Index uint8
     Len   uint32
-    syscall.Stat_t
+    config.Stat_t
 }
 type Arg_Statfs_t struct {
     Index uint8
@@ -219,6 +233,47 @@ type Arg_RawSockaddrUnix struct {
     syscall.RawSockaddrUnix
 }
 
+type SockaddrInfo struct {
+    Family string `json:"family"`
+    Addr   string `json:"addr,omitempty"`
+    Port   uint16 `json:"port,omitempty"`
+    Path   string `json:"path,omitempty"`
+}
+
+// 只用于 json 输出 文本输出中的端口保持原来的网络字节序
+func Ntohs(port uint16) uint16 {
+    return port>>8 | port<<8
+}
+
+func (this *Arg_RawSockaddrUnix) GetSockaddr() SockaddrInfo {
+    var info SockaddrInfo
+    switch this.Family {
+    case syscall.AF_FILE:
+        info.Family = "AF_UNIX"
+        if this.Path[0] == 0 && this.Path[1] != 0 {
+            // 抽象命名空间
+            info.Path = "@" + B2S(this.Path[1:])
+        } else {
+            info.Path = B2S(this.Path[:])
+        }
+    case syscall.AF_INET:
+        info.Family = "AF_INET"
+        sockaddr := (*syscall.RawSockaddrInet4)(unsafe.Pointer(&this.RawSockaddrUnix))
+        info.Addr = net.IP(sockaddr.Addr[:]).String()
+        info.Port = Ntohs(sockaddr.Port)
+    case syscall.AF_INET6:
+        info.Family = "AF_INET6"
+        sockaddr6 := (*syscall.RawSockaddrInet6)(unsafe.Pointer(&this.RawSockaddrUnix))
+        info.Addr = net.IP(sockaddr6.Addr[:]).String()
+        info.Port = Ntohs(sockaddr6.Port)
+    case syscall.AF_UNSPEC:
+        info.Family = "AF_UNSPEC"
+    default:
+        info.Family = fmt.Sprintf("0x%x", this.Family)
+    }
+    return info
+}
+
 func (this *Arg_RawSockaddrUnix) Format() string {
     var fields []string
     if this.Family == syscall.AF_FILE {
@@ -260,6 +315,14 @@ type Arg_Iovec_t struct {
     Payload []byte
 }
 
+func (this *Arg_Iovec_t) JsonValue() map[string]interface{} {
+    return map[string]interface{}{
+        "base": fmt.Sprintf("0x%x", this.Base),
+        "len":  this.BufLen,
+        "buf":  hex.EncodeToString(this.Payload),
+    }
+}
+
 func (this *Arg_Iovec_t) Format() string {
     var fields []string
     fields = append(fields, fmt.Sprintf("base=0x%x", this.Base))
@@ -271,7 +334,7 @@ func (this *Arg_Iovec_t) Format() string {
 type Arg_EpollEvent struct {
     Index uint8
     Len   uint32
-    syscall.EpollEvent
+    config.EpollEvent
 }
 
 func (this *Arg_EpollEvent) Format() string {
@@ -430,38 +493,105 @@ func (this *SyscallEvent) ParseContextSysEnter() (err error) {
     }
     this.nr_point = nr_point
     var results []string
-    for _, point_arg := range this.nr_point.Args {
+    for index, point_arg := range this.nr_point.Args {
         // this.logger.Printf(".... AliasType:%d %d %d", point_arg.AliasType, this.EventId, point_arg.ReadFlag)
         var ptr Arg_reg
         if err = binary.Read(this.buf, binary.LittleEndian, &ptr); err != nil {
             panic(fmt.Sprintf("binary.Read err:%v", err))
         }
+        if index < len(this.args) {
+            this.args[index] = ptr.Address
+        }
         base_arg_str := fmt.Sprintf("%s=0x%x", point_arg.ArgName, ptr.Address)
         point_arg.SetValue(base_arg_str)
         if point_arg.Type == config.TYPE_NUM {
-            // 目前会全部输出为 hex 后续优化改进
-            results = append(results, point_arg.ArgValue)
+            // 转换为 flags 枚举等符号 int 输出为有符号数
+            results = append(results, this.ParseNumArg(&point_arg, ptr))
             continue
         }
         // 这一类参数要等执行结束后读取 这里只获取参数所对应的寄存器值就可以了
         if point_arg.ReadFlag == config.SYS_EXIT {
             results = append(results, point_arg.ArgValue)
+            this.json_args = append(this.json_args, NewJsonArg(&point_arg, ptr.Address, nil))
             continue
         }
-        this.ParseArgByType(&point_arg, ptr)
+        value := this.ParseArgByType(&point_arg, ptr)
+        json_arg := NewJsonArg(&point_arg, ptr.Address, value)
+        // 命中 --hide-root/--rules 规则的参数加上标记
+        if str, ok := value.(string); ok && len(this.mconf.RevRules) > 0 {
+            if rule := this.mconf.MatchRevRule(this.nr_point.NR, index, str); rule != nil {
+                point_arg.AppendValue("<" + rule.Mark() + ">")
+                json_arg.Rev = rule.String()
+            }
+        }
         results = append(results, point_arg.ArgValue)
+        this.json_args = append(this.json_args, json_arg)
     }
-    // if !this.WaitExit {
-    //     var results []string
-    //     for _, point_arg := range this.nr_point.Args {
-    //         results = append(results, point_arg.ArgValue)
-    //     }
-    //     this.arg_str = "(" + strings.Join(results, ", ") + ")"
-    // }
+    this.arg_values = results
     this.arg_str = "(" + strings.Join(results, ", ") + ")"
+    fd_table.OnSysEnter(this)
     return nil
 }
 
+// 数值类型的参数 fd 类参数会带上对应的路径或者 socket 信息
+func (this *SyscallEvent) ParseNumArg(point_arg *config.PointArg, ptr Arg_reg) string {
+    point_arg.SetValue(fmt.Sprintf("%s=%s", point_arg.ArgName, config.DecodeNumArg(this.nr_point.PointName, point_arg, ptr.Address)))
+    json_arg := NewJsonNumArg(this.nr_point.PointName, point_arg, ptr.Address)
+    if IsFdArg(point_arg.ArgName) && !this.mconf.Summary {
+        if desc, ok := fd_table.Lookup(this.Pid, int32(ptr.Address)); ok {
+            point_arg.AppendValue("<" + desc + ">")
+            json_arg.FdDesc = desc
+        }
+    }
+    this.json_args = append(this.json_args, json_arg)
+    return point_arg.ArgValue
+}
+
+func (this *SyscallEvent) GetArgValue(arg_name string) interface{} {
+    for _, arg := range this.json_args {
+        if arg.Name == arg_name {
+            return arg.Value
+        }
+    }
+    return nil
+}
+
+// 检查 --filter 条件 多个条件之间是与的关系
+func (this *SyscallEvent) MatchArgFilters() bool {
+    for _, filter := range this.mconf.SysCallConf.ArgFilters {
+        if !filter.MatchSysCall(this.nr_point.PointName) {
+            continue
+        }
+        if filter.IsRet() {
+            if this.IsEnter() && !this.merged {
+                // 不合并时返回值只在 sys_exit 中判断 sys_enter 不受 ret 条件影响
+                if !this.mconf.MergeSyscall {
+                    continue
+                }
+                // 没有等到 sys_exit 的 sys_enter 不知道返回值 视为不满足
+                return false
+            }
+            if !filter.MatchValue(int64(this.ret)) {
+                return false
+            }
+            continue
+        }
+        // 不存在该参数的调用不受影响
+        if this.nr_point.GetArgIndex(filter.ArgName) < 0 {
+            continue
+        }
+        // 当前事件没有读取该参数 比如不合并时 sys_exit 中的 SYS_ENTER 类参数 不做过滤
+        value := this.GetArgValue(filter.ArgName)
+        if value == nil {
+            continue
+        }
+        if !filter.MatchValue(value) {
+            return false
+        }
+    }
+    return true
+}
+
 func (this *SyscallEvent) ParseContextSysExit() (err error) {
     point := config.GetWatchPointByNR(this.nr.Value)
     nr_point, ok := (point).(*config.SysCallArgs)
@@ -470,36 +600,47 @@ func (this *SyscallEvent) ParseContextSysExit() (err error) {
     }
     this.nr_point = nr_point
     var results []string
-    for _, point_arg := range this.nr_point.Args {
+    for index, point_arg := range this.nr_point.Args {
         var ptr Arg_reg
         if err = binary.Read(this.buf, binary.LittleEndian, &ptr); err != nil {
             this.logger.Printf("SyscallEvent EventId:%d RawSample:\n%s", this.EventId, util.HexDump(this.rec.RawSample, util.COLORRED))
             panic(fmt.Sprintf("binary.Read %d %s err:%v", this.nr.Value, util.B2STrim(this.Comm[:]), err))
         }
+        if index < len(this.args) {
+            this.args[index] = ptr.Address
+        }
         base_arg_str := fmt.Sprintf("%s=0x%x", point_arg.ArgName, ptr.Address)
         point_arg.SetValue(base_arg_str)
         if point_arg.Type == config.TYPE_NUM {
-            results = append(results, point_arg.ArgValue)
+            results = append(results, this.ParseNumArg(&point_arg, ptr))
             continue
         }
         if point_arg.ReadFlag != config.SYS_EXIT {
             results = append(results, point_arg.ArgValue)
+            this.json_args = append(this.json_args, NewJsonArg(&point_arg, ptr.Address, nil))
             continue
         }
-        this.ParseArgByType(&point_arg, ptr)
+        value := this.ParseArgByType(&point_arg, ptr)
         results = append(results, point_arg.ArgValue)
+        this.json_args = append(this.json_args, NewJsonArg(&point_arg, ptr.Address, value))
     }
     // 处理返回参数
     var ptr Arg_reg
     if err = binary.Read(this.buf, binary.LittleEndian, &ptr); err != nil {
         panic(fmt.Sprintf("binary.Read err:%v", err))
     }
+    this.ret = ptr.Address
     point_arg := this.nr_point.Ret
     base_arg_str := fmt.Sprintf("0x%x", ptr.Address)
     point_arg.SetValue(base_arg_str)
-    if point_arg.Type != config.TYPE_NUM {
+    if point_arg.Type == config.TYPE_NUM {
+        point_arg.SetValue(config.DecodeRet(this.nr_point.PointName, ptr.Address))
+    } else {
         this.ParseArgByType(&point_arg, ptr)
     }
+    this.arg_values = results
+    this.ret_str = point_arg.ArgValue
+    fd_table.OnSysExit(this)
     if len(results) == 0 {
         results = append(results, "(void)")
     }
@@ -511,22 +652,70 @@ func (this *SyscallEvent) WaitNextEvent() bool {
     return this.WaitExit
 }
 
-// func (this *SyscallEvent) MergeEvent(exit_event IEventStruct) {
-//     exit_p, ok := (exit_event).(*SyscallEvent)
-//     if !ok {
-//         panic("cast event.SYSCALL_EXIT to event.SyscallEvent failed")
-//     }
-//     var results []string
-//     for index, point_arg := range this.nr_point.Args {
-//         if point_arg.ReadFlag == config.SYS_EXIT {
-//             point_arg = exit_p.nr_point.Args[index]
-//         }
-//         results = append(results, point_arg.ArgValue)
-//     }
-//     results = append(results, exit_p.nr_point.Ret.ArgValue)
-//     this.arg_str = "(" + strings.Join(results, ", ") + ")"
-//     this.WaitExit = false
-// }
+func (this *SyscallEvent) IsEnter() bool {
+    return this.EventId == SYSCALL_ENTER
+}
+
+func (this *SyscallEvent) GetNR() uint32 {
+    return this.nr.Value
+}
+
+func (this *SyscallEvent) GetPointName() string {
+    return this.nr_point.PointName
+}
+
+// 这些调用不会有 sys_exit 事件 不需要等待
+var no_exit_syscalls = []string{"exit", "exit_group"}
+
+func (this *SyscallEvent) NeedWaitExit() bool {
+    if !this.mconf.MergeSyscall {
+        return false
+    }
+    for _, name := range no_exit_syscalls {
+        if this.nr_point.PointName == name {
+            return false
+        }
+    }
+    return true
+}
+
+// 把 sys_exit 事件合并到 sys_enter 事件 SYS_EXIT 类参数以及返回值取 sys_exit 的结果
+func (this *SyscallEvent) MergeEvent(exit_event IEventStruct) {
+    exit_p, ok := (exit_event).(*SyscallEvent)
+    if !ok {
+        panic("cast event.SYSCALL_EXIT to event.SyscallEvent failed")
+    }
+    var results []string
+    for index, point_arg := range this.nr_point.Args {
+        arg_value := this.arg_values[index]
+        if point_arg.ReadFlag == config.SYS_EXIT {
+            arg_value = exit_p.arg_values[index]
+            this.json_args[index] = exit_p.json_args[index]
+        }
+        results = append(results, arg_value)
+    }
+    this.arg_str = "(" + strings.Join(results, ", ") + ")"
+    this.ret = exit_p.ret
+    this.ret_str = exit_p.ret_str
+    this.duration = exit_p.duration
+    this.merged = true
+    this.WaitExit = false
+}
+
+// 单位 ns 由 worker 根据 sys_enter 计算
+func (this *SyscallEvent) SetDuration(duration uint64) {
+    this.duration = duration
+}
+
+func (this *SyscallEvent) IsSlow() bool {
+    return this.duration > 0 && this.mconf.SlowThreshold > 0 && this.duration >= this.mconf.SlowThreshold
+}
+
+// 超时没有等到 sys_exit
+func (this *SyscallEvent) SetUnfinished() {
+    this.unfinished = true
+    this.WaitExit = false
+}
 
 func (this *SyscallEvent) ParseContext() (err error) {
     this.WaitExit = false
@@ -535,11 +724,13 @@ func (this *SyscallEvent) ParseContext() (err error) {
     if err = binary.Read(this.buf, binary.LittleEndian, &this.nr); err != nil {
         panic(fmt.Sprintf("binary.Read err:%v", err))
     }
+    if this.mconf.Summary {
+        return this.ParseContextSummary()
+    }
     if this.EventId == SYSCALL_ENTER {
-        // 是否有不执行 sys_exit 的情况 ?
-        // 有的调用耗时 也有可能 要不还是把执行结果分开输出吧
-        // this.WaitExit = true
+        // 交给 worker 等待对应的 sys_exit 然后合并输出
         this.ParseContextSysEnter()
+        this.WaitExit = this.NeedWaitExit()
     } else if this.EventId == SYSCALL_EXIT {
         this.ParseContextSysExit()
     } else {
@@ -553,6 +744,28 @@ func (this *SyscallEvent) ParseContext() (err error) {
     return nil
 }
 
+// 统计模式只需要调用号和返回值 跳过 sys_enter 的参数以及堆栈的解析
+func (this *SyscallEvent) ParseContextSummary() (err error) {
+    if this.EventId == SYSCALL_EXIT {
+        return this.ParseContextSysExit()
+    }
+    point := config.GetWatchPointByNR(this.nr.Value)
+    nr_point, ok := (point).(*config.SysCallArgs)
+    if !ok {
+        panic(fmt.Sprintf("cast nr[%d] point to SysCallArgs failed", this.nr.Value))
+    }
+    this.nr_point = nr_point
+    return nil
+}
+
+func (this *SyscallEvent) GetRet() uint64 {
+    return this.ret
+}
+
+func (this *SyscallEvent) GetComm() string {
+    return util.B2STrim(this.Comm[:])
+}
+
 func (this *SyscallEvent) GetUUID() string {
     return fmt.Sprintf("%d|%d|%s", this.Pid, this.Tid, util.B2STrim(this.Comm[:]))
 }
@@ -560,6 +773,14 @@ func (this *SyscallEvent) GetUUID() string {
 func (this *SyscallEvent) String() string {
     var base_str string
     base_str = fmt.Sprintf("[%s] nr:%s%s", this.GetUUID(), this.nr_point.PointName, this.arg_str)
+    if this.merged {
+        base_str = fmt.Sprintf("%s = %s", base_str, this.ret_str)
+    } else if this.unfinished {
+        base_str = fmt.Sprintf("%s <unfinished ...>", base_str)
+    }
+    if this.IsSlow() {
+        base_str = fmt.Sprintf("%s <slow %s>", base_str, time.Duration(this.duration))
+    }
     if this.EventId == SYSCALL_ENTER {
         var lr_str string
         var pc_str string
@@ -576,8 +797,35 @@ func (this *SyscallEvent) String() string {
     return base_str
 }
 
+func (this *SyscallEvent) JsonString() string {
+    var e *JsonEvent
+    if this.merged {
+        e = this.NewJsonEvent("syscall")
+        this.SetJsonLocation(e, this.lr.Address, this.pc.Address, this.sp.Address)
+        ret := int64(this.ret)
+        e.Ret = &ret
+        e.Errno = GetJsonErrno(this.ret)
+    } else if this.EventId == SYSCALL_ENTER {
+        e = this.NewJsonEvent("syscall_enter")
+        this.SetJsonLocation(e, this.lr.Address, this.pc.Address, this.sp.Address)
+    } else {
+        e = this.NewJsonEvent("syscall_exit")
+        ret := int64(this.ret)
+        e.Ret = &ret
+        e.Errno = GetJsonErrno(this.ret)
+    }
+    e.Name = this.nr_point.PointName
+    e.Args = this.json_args
+    if this.duration > 0 {
+        duration := this.duration
+        e.Duration = &duration
+        e.Slow = this.IsSlow()
+    }
+    return e.String()
+}
+
 func (this *SyscallEvent) ParseLRV1() (string, error) {
-    return maps_helper.GetOffset(this.Pid, this.lr.Address), nil
+    return maps_helper.GetOffset(this.Pid, this.Ts, this.lr.Address), nil
 }
 
 func (this *SyscallEvent) Clone() IEventStruct {
@@ -0,0 +1,214 @@
+package event
+
+import (
+    "fmt"
+    "stackplz/user/config"
+    "strings"
+)
+
+// arm32 进程的结构体 字段名和 64 位的保持一致 便于对比
+
+type Arg_Timespec32 struct {
+    Index uint8
+    Len   uint32
+    config.Timespec32
+}
+
+func (this *Arg_Timespec32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("sec=%d", this.Sec))
+    fields = append(fields, fmt.Sprintf("nsec=%d", this.Nsec))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Timeval32 struct {
+    Index uint8
+    Len   uint32
+    config.Timeval32
+}
+
+func (this *Arg_Timeval32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("sec=%d", this.Sec))
+    fields = append(fields, fmt.Sprintf("usec=%d", this.Usec))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Stat64_32 struct {
+    Index uint8
+    Len   uint32
+    config.Stat64_32
+}
+
+func (this *Arg_Stat64_32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("dev=%d", this.Dev))
+    fields = append(fields, fmt.Sprintf("ino=%d", this.Ino))
+    fields = append(fields, fmt.Sprintf("nlink=%d", this.Nlink))
+    fields = append(fields, fmt.Sprintf("mode=%d", this.Mode))
+    fields = append(fields, fmt.Sprintf("uid=%d", this.Uid))
+    fields = append(fields, fmt.Sprintf("gid=%d", this.Gid))
+    fields = append(fields, fmt.Sprintf("rdev=%d", this.Rdev))
+    fields = append(fields, fmt.Sprintf("size=%d", this.Size))
+    fields = append(fields, fmt.Sprintf("blksize=%d", this.Blksize))
+    fields = append(fields, fmt.Sprintf("blocks=%d", this.Blocks))
+    fields = append(fields, fmt.Sprintf("atim={tv_sec=%d, tv_nsec=%d}", this.Atim.Sec, this.Atim.Nsec))
+    fields = append(fields, fmt.Sprintf("mtim={tv_sec=%d, tv_nsec=%d}", this.Mtim.Sec, this.Mtim.Nsec))
+    fields = append(fields, fmt.Sprintf("ctim={tv_sec=%d, tv_nsec=%d}", this.Ctim.Sec, this.Ctim.Nsec))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Statfs32 struct {
+    Index uint8
+    Len   uint32
+    config.Statfs32
+}
+
+func (this *Arg_Statfs32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("type=%d", this.Type))
+    fields = append(fields, fmt.Sprintf("bsize=%d", this.Bsize))
+    fields = append(fields, fmt.Sprintf("blocks=%d", this.Blocks))
+    fields = append(fields, fmt.Sprintf("bfree=%d", this.Bfree))
+    fields = append(fields, fmt.Sprintf("bavail=%d", this.Bavail))
+    fields = append(fields, fmt.Sprintf("files=%d", this.Files))
+    fields = append(fields, fmt.Sprintf("ffree=%d", this.Ffree))
+    fields = append(fields, fmt.Sprintf("fsid=0x%x,0x%x", this.Fsid[0], this.Fsid[1]))
+    fields = append(fields, fmt.Sprintf("namelen=%d", this.Namelen))
+    fields = append(fields, fmt.Sprintf("frsize=%d", this.Frsize))
+    fields = append(fields, fmt.Sprintf("flags=%d", this.Flags))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Statfs64_32 struct {
+    Index uint8
+    Len   uint32
+    config.Statfs64_32
+}
+
+func (this *Arg_Statfs64_32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("type=%d", this.Type))
+    fields = append(fields, fmt.Sprintf("bsize=%d", this.Bsize))
+    fields = append(fields, fmt.Sprintf("blocks=%d", this.Blocks))
+    fields = append(fields, fmt.Sprintf("bfree=%d", this.Bfree))
+    fields = append(fields, fmt.Sprintf("bavail=%d", this.Bavail))
+    fields = append(fields, fmt.Sprintf("files=%d", this.Files))
+    fields = append(fields, fmt.Sprintf("ffree=%d", this.Ffree))
+    fields = append(fields, fmt.Sprintf("fsid=0x%x,0x%x", this.Fsid[0], this.Fsid[1]))
+    fields = append(fields, fmt.Sprintf("namelen=%d", this.Namelen))
+    fields = append(fields, fmt.Sprintf("frsize=%d", this.Frsize))
+    fields = append(fields, fmt.Sprintf("flags=%d", this.Flags))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Sigaction32 struct {
+    Index uint8
+    Len   uint32
+    config.Sigaction32
+}
+
+func (this *Arg_Sigaction32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("sa_handler=0x%x", this.Sa_handler))
+    fields = append(fields, fmt.Sprintf("sa_flags=0x%x", this.Sa_flags))
+    fields = append(fields, fmt.Sprintf("sa_restorer=0x%x", this.Sa_restorer))
+    fields = append(fields, fmt.Sprintf("sa_mask=0x%x,0x%x", this.Sa_mask[0], this.Sa_mask[1]))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Rusage32 struct {
+    Index uint8
+    Len   uint32
+    config.Rusage32
+}
+
+func (this *Arg_Rusage32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("utime=timeval{sec=%d, usec=%d}", this.Utime.Sec, this.Utime.Usec))
+    fields = append(fields, fmt.Sprintf("stime=timeval{sec=%d, usec=%d}", this.Stime.Sec, this.Stime.Usec))
+    fields = append(fields, fmt.Sprintf("Maxrss=0x%x", this.Maxrss))
+    fields = append(fields, fmt.Sprintf("Ixrss=0x%x", this.Ixrss))
+    fields = append(fields, fmt.Sprintf("Idrss=0x%x", this.Idrss))
+    fields = append(fields, fmt.Sprintf("Isrss=0x%x", this.Isrss))
+    fields = append(fields, fmt.Sprintf("Minflt=0x%x", this.Minflt))
+    fields = append(fields, fmt.Sprintf("Majflt=0x%x", this.Majflt))
+    fields = append(fields, fmt.Sprintf("Nswap=0x%x", this.Nswap))
+    fields = append(fields, fmt.Sprintf("Inblock=0x%x", this.Inblock))
+    fields = append(fields, fmt.Sprintf("Oublock=0x%x", this.Oublock))
+    fields = append(fields, fmt.Sprintf("Msgsnd=0x%x", this.Msgsnd))
+    fields = append(fields, fmt.Sprintf("Msgrcv=0x%x", this.Msgrcv))
+    fields = append(fields, fmt.Sprintf("Nsignals=0x%x", this.Nsignals))
+    fields = append(fields, fmt.Sprintf("Nvcsw=0x%x", this.Nvcsw))
+    fields = append(fields, fmt.Sprintf("Nivcsw=0x%x", this.Nivcsw))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Sysinfo32 struct {
+    Index uint8
+    Len   uint32
+    config.Sysinfo32
+}
+
+func (this *Arg_Sysinfo32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("uptime=0x%x", this.Uptime))
+    fields = append(fields, fmt.Sprintf("loads=0x%x,0x%x,0x%x", this.Loads[0], this.Loads[1], this.Loads[2]))
+    fields = append(fields, fmt.Sprintf("totalram=0x%x", this.Totalram))
+    fields = append(fields, fmt.Sprintf("freeram=0x%x", this.Freeram))
+    fields = append(fields, fmt.Sprintf("sharedram=0x%x", this.Sharedram))
+    fields = append(fields, fmt.Sprintf("bufferram=0x%x", this.Bufferram))
+    fields = append(fields, fmt.Sprintf("totalswap=0x%x", this.Totalswap))
+    fields = append(fields, fmt.Sprintf("freeswap=0x%x", this.Freeswap))
+    fields = append(fields, fmt.Sprintf("procs=0x%x", this.Procs))
+    fields = append(fields, fmt.Sprintf("pad=0x%x", this.Pad))
+    fields = append(fields, fmt.Sprintf("totalhigh=0x%x", this.Totalhigh))
+    fields = append(fields, fmt.Sprintf("freehigh=0x%x", this.Freehigh))
+    fields = append(fields, fmt.Sprintf("unit=0x%x", this.Unit))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Msghdr32 struct {
+    Index uint8
+    Len   uint32
+    config.Msghdr32
+}
+
+func (this *Arg_Msghdr32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("name=0x%x", this.Name))
+    fields = append(fields, fmt.Sprintf("namelen=0x%x", this.Namelen))
+    fields = append(fields, fmt.Sprintf("iov=0x%x", this.Iov))
+    fields = append(fields, fmt.Sprintf("iovlen=0x%x", this.Iovlen))
+    fields = append(fields, fmt.Sprintf("control=0x%x", this.Control))
+    fields = append(fields, fmt.Sprintf("controllen=0x%x", this.Controllen))
+    fields = append(fields, fmt.Sprintf("flags=0x%x", this.Flags))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_ItTmerspec32 struct {
+    Index uint8
+    Len   uint32
+    config.ItTmerspec32
+}
+
+func (this *Arg_ItTmerspec32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("it_interval={sec=%d, nsec=%d}", this.It_interval.Sec, this.It_interval.Nsec))
+    fields = append(fields, fmt.Sprintf("it_value={sec=%d, nsec=%d}", this.It_value.Sec, this.It_value.Nsec))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_Stack_t32 struct {
+    Index uint8
+    Len   uint32
+    config.Stack_t32
+}
+
+func (this *Arg_Stack_t32) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("ss_sp=0x%x", this.Ss_sp))
+    fields = append(fields, fmt.Sprintf("ss_flags=%d", this.Ss_flags))
+    fields = append(fields, fmt.Sprintf("ss_size=%d", this.Ss_size))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
@@ -0,0 +1,47 @@
+package event
+
+import (
+    "fmt"
+    "stackplz/user/config"
+    "strings"
+)
+
+// x86_64 进程的结构体 字段名和 arm64 的保持一致 便于对比
+
+type Arg_Stat_X86_64 struct {
+    Index uint8
+    Len   uint32
+    config.Stat_X86_64
+}
+
+func (this *Arg_Stat_X86_64) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("dev=%d", this.Dev))
+    fields = append(fields, fmt.Sprintf("ino=%d", this.Ino))
+    fields = append(fields, fmt.Sprintf("nlink=%d", this.Nlink))
+    fields = append(fields, fmt.Sprintf("mode=%d", this.Mode))
+    fields = append(fields, fmt.Sprintf("uid=%d", this.Uid))
+    fields = append(fields, fmt.Sprintf("gid=%d", this.Gid))
+    fields = append(fields, fmt.Sprintf("rdev=%d", this.Rdev))
+    fields = append(fields, fmt.Sprintf("size=%d", this.Size))
+    fields = append(fields, fmt.Sprintf("blksize=%d", this.Blksize))
+    fields = append(fields, fmt.Sprintf("blocks=%d", this.Blocks))
+    fields = append(fields, fmt.Sprintf("atim={tv_sec=%d, tv_nsec=%d}", this.Atim.Sec, this.Atim.Nsec))
+    fields = append(fields, fmt.Sprintf("mtim={tv_sec=%d, tv_nsec=%d}", this.Mtim.Sec, this.Mtim.Nsec))
+    fields = append(fields, fmt.Sprintf("ctim={tv_sec=%d, tv_nsec=%d}", this.Ctim.Sec, this.Ctim.Nsec))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
+
+type Arg_EpollEvent_X86_64 struct {
+    Index uint8
+    Len   uint32
+    config.EpollEvent_X86_64
+}
+
+func (this *Arg_EpollEvent_X86_64) Format() string {
+    var fields []string
+    fields = append(fields, fmt.Sprintf("events=0x%x", this.Events))
+    fields = append(fields, fmt.Sprintf("fd=%d", this.Fd))
+    fields = append(fields, fmt.Sprintf("pad=%d", this.Pad))
+    return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+}
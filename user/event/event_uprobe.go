@@ -10,6 +10,7 @@ import (
     "stackplz/user/config"
     "stackplz/user/util"
     "strings"
+    "time"
 )
 
 type UprobeEvent struct {
@@ -21,27 +22,43 @@ type UprobeEvent struct {
     sp           Arg_reg
     pc           Arg_reg
     arg_str      string
+    arg_values   []string
+    json_args    []JsonArg
+    // :ret 模式下返回时的 x0
+    ret        Arg_reg
+    duration   uint64
+    WaitExit   bool
+    merged     bool
+    unfinished bool
 }
 
 func (this *UprobeEvent) ParseContext() (err error) {
+    this.WaitExit = false
     // this.logger.Printf("UprobeEvent EventId:%d RawSample:\n%s", this.EventId, util.HexDump(this.rec.RawSample, util.COLORRED))
     if err = binary.Read(this.buf, binary.LittleEndian, &this.probe_index); err != nil {
         panic(fmt.Sprintf("binary.Read err:%v", err))
     }
-    if err = binary.Read(this.buf, binary.LittleEndian, &this.lr); err != nil {
-        panic(fmt.Sprintf("binary.Read err:%v", err))
-    }
-    if err = binary.Read(this.buf, binary.LittleEndian, &this.pc); err != nil {
-        panic(fmt.Sprintf("binary.Read err:%v", err))
-    }
-    if err = binary.Read(this.buf, binary.LittleEndian, &this.sp); err != nil {
-        panic(fmt.Sprintf("binary.Read err:%v", err))
+    if this.EventId == UPROBE_ENTER {
+        if err = binary.Read(this.buf, binary.LittleEndian, &this.lr); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        if err = binary.Read(this.buf, binary.LittleEndian, &this.pc); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+        if err = binary.Read(this.buf, binary.LittleEndian, &this.sp); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
     }
     // 根据预设索引解析参数
-    if (this.probe_index.Value + 1) > uint32(len(this.mconf.StackUprobeConf.Points)) {
-        panic(fmt.Sprintf("probe_index %d bigger than points", this.probe_index.Value))
+    this.uprobe_point, err = this.mconf.StackUprobeConf.GetPoint(this.probe_index.Value)
+    if err != nil {
+        panic(err)
+    }
+    // 进入时读取 UPROBE_ENTER_READ 类参数 返回时读取 UPROBE_EXIT_READ 类参数
+    read_flag := config.UPROBE_ENTER_READ
+    if this.EventId == UPROBE_EXIT {
+        read_flag = config.UPROBE_EXIT_READ
     }
-    this.uprobe_point = &this.mconf.StackUprobeConf.Points[this.probe_index.Value]
     var results []string
     for _, point_arg := range this.uprobe_point.Args {
         var ptr Arg_reg
@@ -57,17 +74,29 @@ func (this *UprobeEvent) ParseContext() (err error) {
         //     point_arg.AppendValue(ptr.Format())
         // }
         if point_arg.Type == config.TYPE_NUM {
+            point_arg.SetValue(fmt.Sprintf("%s=%s", point_arg.ArgName, config.DecodeNumArg("", &point_arg, ptr.Address)))
             results = append(results, point_arg.ArgValue)
+            this.json_args = append(this.json_args, NewJsonArg(&point_arg, ptr.Address, NumValue(&point_arg, ptr.Address)))
+            continue
+        }
+        if point_arg.ReadFlag != read_flag {
+            results = append(results, point_arg.ArgValue)
+            this.json_args = append(this.json_args, NewJsonArg(&point_arg, ptr.Address, nil))
             continue
         }
-        // if point_arg.ReadFlag == config.UPROBE_ENTER_READ {
-        //     results = append(results, point_arg.ArgValue)
-        //     continue
-        // }
 
-        this.ParseArgByType(&point_arg, ptr)
+        value := this.ParseArgByType(&point_arg, ptr)
         results = append(results, point_arg.ArgValue)
+        this.json_args = append(this.json_args, NewJsonArg(&point_arg, ptr.Address, value))
     }
+    if this.EventId == UPROBE_EXIT {
+        if err = binary.Read(this.buf, binary.LittleEndian, &this.ret); err != nil {
+            panic(fmt.Sprintf("binary.Read err:%v", err))
+        }
+    } else {
+        this.WaitExit = this.uprobe_point.RetMode
+    }
+    this.arg_values = results
     this.arg_str = "(" + strings.Join(results, ", ") + ")"
     this.ParsePadding()
     err = this.ParseContextStack()
@@ -78,6 +107,45 @@ func (this *UprobeEvent) ParseContext() (err error) {
     return nil
 }
 
+func (this *UprobeEvent) WaitNextEvent() bool {
+    return this.WaitExit
+}
+
+func (this *UprobeEvent) IsEnter() bool {
+    return this.EventId == UPROBE_ENTER
+}
+
+func (this *UprobeEvent) GetPointIndex() uint32 {
+    return this.probe_index.Value
+}
+
+// 把返回事件合并到进入事件 UPROBE_EXIT_READ 类参数以及返回值取返回时的结果
+func (this *UprobeEvent) MergeEvent(exit_event IEventStruct) {
+    exit_p, ok := (exit_event).(*UprobeEvent)
+    if !ok {
+        panic("cast event.UPROBE_EXIT to event.UprobeEvent failed")
+    }
+    for index, point_arg := range this.uprobe_point.Args {
+        if point_arg.ReadFlag == config.UPROBE_EXIT_READ {
+            this.arg_values[index] = exit_p.arg_values[index]
+            this.json_args[index] = exit_p.json_args[index]
+        }
+    }
+    this.arg_str = "(" + strings.Join(this.arg_values, ", ") + ")"
+    this.ret = exit_p.ret
+    if exit_p.Ts >= this.Ts {
+        this.duration = exit_p.Ts - this.Ts
+    }
+    this.merged = true
+    this.WaitExit = false
+}
+
+// 超时没有等到函数返回
+func (this *UprobeEvent) SetUnfinished() {
+    this.unfinished = true
+    this.WaitExit = false
+}
+
 func (this *UprobeEvent) Clone() IEventStruct {
     event := new(UprobeEvent)
     return event
@@ -88,6 +156,17 @@ func (this *UprobeEvent) GetUUID() string {
 }
 
 func (this *UprobeEvent) String() string {
+    var s string
+    s = fmt.Sprintf("[%s] %s:%s%s", this.GetUUID(), this.uprobe_point.GetLibName(), this.uprobe_point.PointName, this.arg_str)
+    if this.merged {
+        s = fmt.Sprintf("%s = 0x%x <%s>", s, this.ret.Address, time.Duration(this.duration))
+    } else if this.unfinished {
+        s = fmt.Sprintf("%s <unfinished ...>", s)
+    } else if this.EventId == UPROBE_EXIT {
+        // 没有对应的进入事件 只有返回时的信息
+        s = fmt.Sprintf("%s = 0x%x", s, this.ret.Address)
+        return this.GetStackTrace(s)
+    }
 
     var lr_str string
     var pc_str string
@@ -99,9 +178,30 @@ func (this *UprobeEvent) String() string {
         pc_str = fmt.Sprintf("PC:0x%x", this.pc.Address)
     }
 
-    var s string
-    s = fmt.Sprintf("[%s] %s%s %s %s SP:0x%x", this.GetUUID(), this.uprobe_point.PointName, this.arg_str, lr_str, pc_str, this.sp.Address)
+    s = fmt.Sprintf("%s %s %s SP:0x%x", s, lr_str, pc_str, this.sp.Address)
     s = this.GetStackTrace(s)
 
     return s
 }
+
+func (this *UprobeEvent) JsonString() string {
+    var e *JsonEvent
+    if this.EventId == UPROBE_EXIT {
+        e = this.NewJsonEvent("uprobe_exit")
+    } else {
+        e = this.NewJsonEvent("uprobe")
+        this.SetJsonLocation(e, this.lr.Address, this.pc.Address, this.sp.Address)
+    }
+    e.Name = this.uprobe_point.PointName
+    e.Lib = this.uprobe_point.GetLibName()
+    e.Args = this.json_args
+    if this.merged || this.EventId == UPROBE_EXIT {
+        ret := int64(this.ret.Address)
+        e.Ret = &ret
+    }
+    if this.merged {
+        duration := this.duration
+        e.Duration = &duration
+    }
+    return e.String()
+}
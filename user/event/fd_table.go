@@ -0,0 +1,539 @@
+package event
+
+import (
+    "encoding/hex"
+    "fmt"
+    "io/ioutil"
+    "net"
+    "os"
+    "path"
+    "stackplz/user/config"
+    "strconv"
+    "strings"
+    "sync"
+    "syscall"
+)
+
+// 按进程记录 fd 对应的文件路径或者 socket 信息 用于标注 fd 类参数
+// 挂载时从 /proc/<pid>/fd 初始化目标进程 之后根据 openat socket connect dup3 pipe2 close 等调用更新
+// fork 时子进程复制父进程的表 execve 时去掉 CLOEXEC 的 fd 进程退出时整个删除
+
+// 这些参数名表示参数是一个 fd
+var fd_arg_names = []string{
+    "fd", "sockfd", "oldfd", "newfd", "epfd",
+    "fd_in", "fd_out", "fdin", "fdout", "in_fd", "out_fd",
+    "dirfd", "olddirfd", "newdirfd", "dfd", "from_dfd", "to_dfd",
+}
+
+func IsFdArg(arg_name string) bool {
+    for _, name := range fd_arg_names {
+        if name == arg_name {
+            return true
+        }
+    }
+    return false
+}
+
+// O_CLOEXEC SOCK_CLOEXEC EFD_CLOEXEC EPOLL_CLOEXEC 在 arm arm64 x86_64 上都是这个值
+const O_CLOEXEC = 0x80000
+
+// MFD_CLOEXEC 和 FD_CLOEXEC
+const FD_CLOEXEC = 1
+
+type fdEntry struct {
+    desc    string
+    cloexec bool
+}
+
+type FdTable struct {
+    sync.Mutex
+    pids map[uint32]map[int32]fdEntry
+    // 每个线程最近一次需要跟踪的 sys_enter 在 sys_exit 时才知道结果
+    pending map[uint32]*SyscallEvent
+}
+
+func NewFdTable() *FdTable {
+    return &FdTable{
+        pids:    make(map[uint32]map[int32]fdEntry),
+        pending: make(map[uint32]*SyscallEvent),
+    }
+}
+
+var fd_table = NewFdTable()
+
+// 挂载时调用 之后的变化通过 syscall 跟踪
+func SeedFdTable(pids []uint32) {
+    if IsReplayMode() {
+        return
+    }
+    for _, pid := range pids {
+        fds := make(map[int32]fdEntry)
+        fd_table.seed(pid, fds)
+        fd_table.Lock()
+        fd_table.pids[pid] = fds
+        fd_table.Unlock()
+    }
+}
+
+// 调用时需要持有锁
+func (this *FdTable) getFds(pid uint32) map[int32]fdEntry {
+    fds, ok := this.pids[pid]
+    if !ok {
+        fds = make(map[int32]fdEntry)
+        this.pids[pid] = fds
+    }
+    return fds
+}
+
+func (this *FdTable) seed(pid uint32, fds map[int32]fdEntry) {
+    fd_dir := fmt.Sprintf("/proc/%d/fd", pid)
+    entries, err := ioutil.ReadDir(fd_dir)
+    if err != nil {
+        return
+    }
+    var sockets map[uint64]string
+    for _, entry := range entries {
+        fd, err := strconv.ParseInt(entry.Name(), 10, 32)
+        if err != nil {
+            continue
+        }
+        link, err := os.Readlink(path.Join(fd_dir, entry.Name()))
+        if err != nil {
+            continue
+        }
+        if strings.HasPrefix(link, "socket:[") {
+            if sockets == nil {
+                sockets = ReadSocketTable(pid)
+            }
+            link = DescribeSocketLink(link, sockets)
+        }
+        fds[int32(fd)] = fdEntry{desc: link, cloexec: readFdCloexec(pid, int32(fd))}
+    }
+}
+
+// /proc/<pid>/fdinfo/<fd> 中的 flags 是八进制
+func readFdCloexec(pid uint32, fd int32) bool {
+    content, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/fdinfo/%d", pid, fd))
+    if err != nil {
+        return false
+    }
+    for _, line := range strings.Split(string(content), "\n") {
+        if strings.HasPrefix(line, "flags:") {
+            flags, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "flags:")), 8, 64)
+            return err == nil && flags&O_CLOEXEC != 0
+        }
+    }
+    return false
+}
+
+// 表中没有的 fd 实时模式下再尝试读取一次
+func (this *FdTable) lookupLive(pid uint32, fd int32) (string, bool) {
+    if IsReplayMode() {
+        return "", false
+    }
+    link, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, fd))
+    if err != nil {
+        return "", false
+    }
+    if strings.HasPrefix(link, "socket:[") {
+        link = DescribeSocketLink(link, ReadSocketTable(pid))
+    }
+    return link, true
+}
+
+func (this *FdTable) Lookup(pid uint32, fd int32) (string, bool) {
+    if fd < 0 {
+        return "", false
+    }
+    this.Lock()
+    defer this.Unlock()
+    fds := this.getFds(pid)
+    entry, ok := fds[fd]
+    if ok {
+        return entry.desc, true
+    }
+    desc, ok := this.lookupLive(pid, fd)
+    if ok {
+        fds[fd] = fdEntry{desc: desc}
+    }
+    return desc, ok
+}
+
+func (this *FdTable) Set(pid uint32, fd int32, desc string, cloexec bool) {
+    this.Lock()
+    defer this.Unlock()
+    this.getFds(pid)[fd] = fdEntry{desc: desc, cloexec: cloexec}
+}
+
+// connect 之后只更新描述
+func (this *FdTable) SetDesc(pid uint32, fd int32, desc string) {
+    this.Lock()
+    defer this.Unlock()
+    fds := this.getFds(pid)
+    entry := fds[fd]
+    entry.desc = desc
+    fds[fd] = entry
+}
+
+// dup 得到的新 fd 不继承 CLOEXEC
+func (this *FdTable) Dup(pid uint32, oldfd int32, newfd int32, cloexec bool) {
+    this.Lock()
+    defer this.Unlock()
+    fds := this.getFds(pid)
+    entry, ok := fds[oldfd]
+    if ok {
+        fds[newfd] = fdEntry{desc: entry.desc, cloexec: cloexec}
+    } else {
+        delete(fds, newfd)
+    }
+}
+
+func (this *FdTable) SetCloexec(pid uint32, fd int32, cloexec bool) {
+    this.Lock()
+    defer this.Unlock()
+    fds := this.getFds(pid)
+    if entry, ok := fds[fd]; ok {
+        entry.cloexec = cloexec
+        fds[fd] = entry
+    }
+}
+
+func (this *FdTable) Close(pid uint32, fd int32) {
+    this.Lock()
+    defer this.Unlock()
+    delete(this.getFds(pid), fd)
+}
+
+// 新进程复制父进程的 fd 表 pid 被复用时旧的记录一并丢弃
+func (this *FdTable) OnFork(ppid uint32, pid uint32, tid uint32) {
+    this.Lock()
+    defer this.Unlock()
+    delete(this.pending, tid)
+    if ppid == pid {
+        return
+    }
+    delete(this.pids, pid)
+    parent, ok := this.pids[ppid]
+    if !ok {
+        return
+    }
+    fds := make(map[int32]fdEntry, len(parent))
+    for fd, entry := range parent {
+        fds[fd] = entry
+    }
+    this.pids[pid] = fds
+}
+
+// 线程退出时丢弃未完成的调用 进程退出时删除整个表
+func (this *FdTable) OnExit(pid uint32, tid uint32) {
+    this.Lock()
+    defer this.Unlock()
+    delete(this.pending, tid)
+    if pid == tid {
+        delete(this.pids, pid)
+    }
+}
+
+// execve 成功后 CLOEXEC 的 fd 都被关闭
+func (this *FdTable) OnExec(pid uint32) {
+    this.Lock()
+    defer this.Unlock()
+    fds := this.pids[pid]
+    for fd, entry := range fds {
+        if entry.cloexec {
+            delete(fds, fd)
+        }
+    }
+}
+
+// 这些 syscall 的结果会改变 fd 表
+var fd_syscalls = []string{
+    "openat", "socket", "connect", "accept", "accept4",
+    "dup", "dup3", "fcntl", "pipe2", "close",
+    "memfd_create", "eventfd2", "epoll_create1",
+    "execve", "execveat",
+    // 以下只在 32 位进程中存在
+    "open", "dup2", "fcntl64", "pipe", "eventfd", "epoll_create",
+}
+
+func (this *FdTable) OnSysEnter(e *SyscallEvent) {
+    for _, name := range fd_syscalls {
+        if name == e.nr_point.PointName {
+            this.Lock()
+            this.pending[e.Tid] = e
+            this.Unlock()
+            return
+        }
+    }
+}
+
+func (this *FdTable) OnSysExit(e *SyscallEvent) {
+    this.Lock()
+    enter, ok := this.pending[e.Tid]
+    if ok {
+        delete(this.pending, e.Tid)
+    }
+    this.Unlock()
+    if !ok || enter.nr.Value != e.nr.Value {
+        return
+    }
+    ret := int64(e.ret)
+    _, is_error := config.GetErrno(e.ret)
+    pid := e.Pid
+    switch e.nr_point.PointName {
+    case "openat":
+        if is_error {
+            return
+        }
+        pathname, _ := enter.GetArgValue("pathname").(string)
+        this.Set(pid, int32(ret), this.resolvePath(pid, int32(enter.args[0]), pathname), enter.args[2]&O_CLOEXEC != 0)
+    case "open":
+        if is_error {
+            return
+        }
+        pathname, _ := enter.GetArgValue("pathname").(string)
+        // 相当于 dirfd 为 AT_FDCWD 的 openat
+        this.Set(pid, int32(ret), this.resolvePath(pid, -100, pathname), enter.args[1]&O_CLOEXEC != 0)
+    case "socket":
+        if is_error {
+            return
+        }
+        this.Set(pid, int32(ret), GetSocketProto(enter.args[0], enter.args[1]), enter.args[1]&O_CLOEXEC != 0)
+    case "connect":
+        // 非阻塞的 socket 会返回 EINPROGRESS
+        if is_error && syscall.Errno(-ret) != syscall.EINPROGRESS {
+            return
+        }
+        sockfd := int32(enter.args[0])
+        addr, ok := enter.GetArgValue("addr").(SockaddrInfo)
+        if !ok {
+            return
+        }
+        proto, _ := this.Lookup(pid, sockfd)
+        proto = strings.SplitN(proto, " ", 2)[0]
+        if proto == "" || strings.HasPrefix(proto, "socket:") {
+            proto = "socket"
+        }
+        this.SetDesc(pid, sockfd, fmt.Sprintf("%s %s", proto, FormatSockaddr(addr)))
+    case "accept", "accept4":
+        if is_error {
+            return
+        }
+        proto, _ := this.Lookup(pid, int32(enter.args[0]))
+        proto = strings.SplitN(proto, " ", 2)[0]
+        if proto == "" {
+            proto = "socket"
+        }
+        this.Set(pid, int32(ret), proto, e.nr_point.PointName == "accept4" && enter.args[3]&O_CLOEXEC != 0)
+    case "dup", "dup2":
+        if is_error {
+            return
+        }
+        this.Dup(pid, int32(enter.args[0]), int32(ret), false)
+    case "dup3":
+        if is_error {
+            return
+        }
+        this.Dup(pid, int32(enter.args[0]), int32(enter.args[1]), enter.args[2]&O_CLOEXEC != 0)
+    case "fcntl", "fcntl64":
+        if is_error {
+            return
+        }
+        switch enter.args[1] {
+        case syscall.F_DUPFD:
+            this.Dup(pid, int32(enter.args[0]), int32(ret), false)
+        case syscall.F_DUPFD_CLOEXEC:
+            this.Dup(pid, int32(enter.args[0]), int32(ret), true)
+        case syscall.F_SETFD:
+            this.SetCloexec(pid, int32(enter.args[0]), enter.args[2]&FD_CLOEXEC != 0)
+        }
+    case "pipe", "pipe2":
+        if is_error {
+            return
+        }
+        // pipefd 在 sys_exit 时读取
+        fds, ok := e.GetArgValue("pipefd").([2]int32)
+        if !ok {
+            return
+        }
+        cloexec := e.nr_point.PointName == "pipe2" && enter.args[1]&O_CLOEXEC != 0
+        this.Set(pid, fds[0], "pipe:[read]", cloexec)
+        this.Set(pid, fds[1], "pipe:[write]", cloexec)
+    case "close":
+        if is_error {
+            return
+        }
+        this.Close(pid, int32(enter.args[0]))
+    case "memfd_create":
+        if is_error {
+            return
+        }
+        name, _ := enter.GetArgValue("name").(string)
+        this.Set(pid, int32(ret), "/memfd:"+name, enter.args[1]&FD_CLOEXEC != 0)
+    case "eventfd", "eventfd2":
+        if !is_error {
+            this.Set(pid, int32(ret), "anon_inode:[eventfd]", e.nr_point.PointName == "eventfd2" && enter.args[1]&O_CLOEXEC != 0)
+        }
+    case "epoll_create", "epoll_create1":
+        if !is_error {
+            this.Set(pid, int32(ret), "anon_inode:[eventpoll]", e.nr_point.PointName == "epoll_create1" && enter.args[0]&O_CLOEXEC != 0)
+        }
+    case "execve", "execveat":
+        if !is_error {
+            this.OnExec(pid)
+        }
+    }
+}
+
+// 相对路径根据 dirfd 或者 cwd 转换为绝对路径 无法转换时保持原样
+func (this *FdTable) resolvePath(pid uint32, dirfd int32, pathname string) string {
+    if pathname == "" || strings.HasPrefix(pathname, "/") {
+        return pathname
+    }
+    var dir string
+    if dirfd == -100 {
+        if IsReplayMode() {
+            return pathname
+        }
+        cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+        if err != nil {
+            return pathname
+        }
+        dir = cwd
+    } else {
+        desc, ok := this.Lookup(pid, dirfd)
+        if !ok || !strings.HasPrefix(desc, "/") {
+            return pathname
+        }
+        dir = desc
+    }
+    return path.Join(dir, pathname)
+}
+
+func GetSocketProto(domain uint64, socket_type uint64) string {
+    socket_type &= 0xf
+    switch domain {
+    case syscall.AF_INET:
+        if socket_type == syscall.SOCK_STREAM {
+            return "TCP"
+        } else if socket_type == syscall.SOCK_DGRAM {
+            return "UDP"
+        }
+    case syscall.AF_INET6:
+        if socket_type == syscall.SOCK_STREAM {
+            return "TCPv6"
+        } else if socket_type == syscall.SOCK_DGRAM {
+            return "UDPv6"
+        }
+    case syscall.AF_UNIX:
+        return "UNIX"
+    case syscall.AF_NETLINK:
+        return "NETLINK"
+    }
+    return "socket"
+}
+
+func FormatSockaddr(addr SockaddrInfo) string {
+    switch addr.Family {
+    case "AF_UNIX":
+        return addr.Path
+    case "AF_INET":
+        return fmt.Sprintf("%s:%d", addr.Addr, addr.Port)
+    case "AF_INET6":
+        return fmt.Sprintf("[%s]:%d", addr.Addr, addr.Port)
+    }
+    return addr.Family
+}
+
+// socket:[12345] 转换为 TCP 10.0.0.2:443 这样的形式
+func DescribeSocketLink(link string, sockets map[uint64]string) string {
+    inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+    if err != nil {
+        return link
+    }
+    desc, ok := sockets[inode]
+    if !ok {
+        return link
+    }
+    return desc
+}
+
+// 读取 /proc/<pid>/net 下的 socket 信息 返回 inode 到描述的映射
+func ReadSocketTable(pid uint32) map[uint64]string {
+    sockets := make(map[uint64]string)
+    for _, item := range []struct {
+        file  string
+        proto string
+    }{
+        {"tcp", "TCP"},
+        {"tcp6", "TCPv6"},
+        {"udp", "UDP"},
+        {"udp6", "UDPv6"},
+    } {
+        content, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/net/%s", pid, item.file))
+        if err != nil {
+            continue
+        }
+        lines := strings.Split(string(content), "\n")
+        for _, line := range lines[1:] {
+            fields := strings.Fields(line)
+            if len(fields) < 10 {
+                continue
+            }
+            inode, err := strconv.ParseUint(fields[9], 10, 64)
+            if err != nil || inode == 0 {
+                continue
+            }
+            // 已连接的显示远端地址 否则显示本地地址
+            addr := ParseProcNetAddr(fields[2])
+            if strings.HasSuffix(addr, ":0") {
+                addr = ParseProcNetAddr(fields[1])
+            }
+            sockets[inode] = fmt.Sprintf("%s %s", item.proto, addr)
+        }
+    }
+    content, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/net/unix", pid))
+    if err == nil {
+        lines := strings.Split(string(content), "\n")
+        for _, line := range lines[1:] {
+            fields := strings.Fields(line)
+            if len(fields) < 7 {
+                continue
+            }
+            inode, err := strconv.ParseUint(fields[6], 10, 64)
+            if err != nil || inode == 0 {
+                continue
+            }
+            if len(fields) > 7 {
+                sockets[inode] = "UNIX " + fields[7]
+            } else {
+                sockets[inode] = "UNIX"
+            }
+        }
+    }
+    return sockets
+}
+
+// 0100007F:1F90 => 127.0.0.1:8080 地址部分是按 32 位小端存储的
+func ParseProcNetAddr(value string) string {
+    items := strings.Split(value, ":")
+    if len(items) != 2 {
+        return value
+    }
+    raw, err := hex.DecodeString(items[0])
+    if err != nil || len(raw)%4 != 0 {
+        return value
+    }
+    for i := 0; i < len(raw); i += 4 {
+        raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+    }
+    port, err := strconv.ParseUint(items[1], 16, 16)
+    if err != nil {
+        return value
+    }
+    ip := net.IP(raw)
+    if len(raw) == 16 && ip.To4() == nil {
+        return fmt.Sprintf("[%s]:%d", ip.String(), port)
+    }
+    return fmt.Sprintf("%s:%d", ip.String(), port)
+}
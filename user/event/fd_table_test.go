@@ -0,0 +1,70 @@
+package event
+
+import (
+    "testing"
+)
+
+func lookupFd(table *FdTable, pid uint32, fd int32) string {
+    table.Lock()
+    defer table.Unlock()
+    return table.pids[pid][fd].desc
+}
+
+func TestFdTableExit(t *testing.T) {
+    table := NewFdTable()
+    table.Set(100, 3, "/data/a", false)
+    table.pending[101] = &SyscallEvent{}
+    // 线程退出只丢弃未完成的调用
+    table.OnExit(100, 101)
+    if _, ok := table.pending[101]; ok {
+        t.Fatal("pending call of the exited thread should be dropped")
+    }
+    if got := lookupFd(table, 100, 3); got != "/data/a" {
+        t.Fatalf("fd 3 = %q after thread exit", got)
+    }
+    table.OnExit(100, 100)
+    if _, ok := table.pids[100]; ok {
+        t.Fatal("fd table of the exited process should be dropped")
+    }
+}
+
+func TestFdTablePidReuse(t *testing.T) {
+    table := NewFdTable()
+    table.Set(100, 3, "/data/parent", false)
+    table.Set(200, 3, "/data/old", false)
+    table.Set(200, 4, "/data/old2", false)
+    // 200 被复用为 100 的子进程 只继承父进程的 fd
+    table.OnFork(100, 200, 200)
+    if got := lookupFd(table, 200, 3); got != "/data/parent" {
+        t.Fatalf("fd 3 = %q, want the parent's", got)
+    }
+    if _, ok := table.pids[200][4]; ok {
+        t.Fatal("fd 4 of the dead process should not be inherited")
+    }
+    // 子进程的修改不影响父进程
+    table.Close(200, 3)
+    if got := lookupFd(table, 100, 3); got != "/data/parent" {
+        t.Fatalf("parent fd 3 = %q after child close", got)
+    }
+    // 新线程不改变 fd 表
+    table.OnFork(100, 100, 101)
+    if got := lookupFd(table, 100, 3); got != "/data/parent" {
+        t.Fatalf("fd 3 = %q after thread creation", got)
+    }
+}
+
+func TestFdTableExec(t *testing.T) {
+    table := NewFdTable()
+    table.Set(100, 3, "/data/keep", false)
+    table.Set(100, 4, "/data/cloexec", true)
+    table.Dup(100, 4, 5, false)
+    table.Set(100, 6, "/data/setfd", false)
+    table.SetCloexec(100, 6, true)
+    table.SetDesc(100, 4, "/data/renamed")
+    table.OnExec(100)
+    for fd, want := range map[int32]string{3: "/data/keep", 4: "", 5: "/data/cloexec", 6: ""} {
+        if got := lookupFd(table, 100, fd); got != want {
+            t.Errorf("fd %d = %q, want %q", fd, got, want)
+        }
+    }
+}
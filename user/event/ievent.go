@@ -2,6 +2,7 @@ package event
 
 import (
     "bytes"
+    "encoding/binary"
     "errors"
     "fmt"
     "log"
@@ -17,14 +18,18 @@ const (
     SYSCALL_ENTER uint32 = iota + 456
     SYSCALL_EXIT
     UPROBE_ENTER
+    UPROBE_EXIT
 )
 
 type IEventStruct interface {
     String() string
+    JsonString() string
     Clone() IEventStruct
     GetUUID() string
     RecordType() uint32
     GetEventId() uint32
+    GetTs() uint64
+    RecordTs() uint64
     ParseEvent() (IEventStruct, error)
     ParseContext() error
     SetLogger(logger *log.Logger)
@@ -43,6 +48,10 @@ func (this *CommonEvent) String() string {
     panic("CommonEvent String")
 }
 
+func (this *CommonEvent) JsonString() string {
+    panic("CommonEvent JsonString")
+}
+
 func (this *CommonEvent) GetUUID() string {
     panic("CommonEvent GetUUID")
 }
@@ -51,6 +60,51 @@ func (this *CommonEvent) GetEventId() uint32 {
     panic("CommonEvent.GetEventId() not implemented yet")
 }
 
+// mmap2 fork 之类的 perf 记录解析后没有 Ts 排序使用的是 RecordTs
+func (this *CommonEvent) GetTs() uint64 {
+    return 0
+}
+
+// 解析之前从原始记录中取出时间 用于按 Ts 排序之后再解析
+// fd 表 maps 版本 comm 之类有状态的解析都要按时间顺序进行 取不到时返回 0
+func (this *CommonEvent) RecordTs() uint64 {
+    raw := this.rec.RawSample
+    switch this.rec.RecordType {
+    case unix.PERF_RECORD_SAMPLE:
+        // SampleSize 之后就是 eBPF 中填写的 ts
+        if len(raw) >= 12 {
+            return binary.LittleEndian.Uint64(raw[4:12])
+        }
+    case unix.PERF_RECORD_FORK, unix.PERF_RECORD_EXIT:
+        // pid ppid tid ptid 之后是 time
+        if len(raw) >= 24 {
+            return binary.LittleEndian.Uint64(raw[16:24])
+        }
+    case unix.PERF_RECORD_MMAP2:
+        return recordSampleIdTs(raw, 64)
+    case unix.PERF_RECORD_COMM:
+        return recordSampleIdTs(raw, 8)
+    }
+    return 0
+}
+
+// 以 0 结尾并按 8 字节对齐的名字之后是 sample_id
+func recordSampleIdTs(raw []byte, name_off int) uint64 {
+    if len(raw) <= name_off {
+        return 0
+    }
+    name_len := bytes.IndexByte(raw[name_off:], 0)
+    if name_len < 0 {
+        return 0
+    }
+    aligned_len := (name_len + 8) &^ 7
+    if name_off+aligned_len > len(raw) {
+        return 0
+    }
+    ts, _ := ParseSampleIdTime(raw[name_off+aligned_len:], SAMPLE_ID_TYPE)
+    return ts
+}
+
 func (this *CommonEvent) Clone() IEventStruct {
     event := new(CommonEvent)
     return event
@@ -182,13 +236,13 @@ func (this *CommonEvent) ParseEvent() (IEventStruct, error) {
                 {
                     event = this.NewSyscallEvent(event)
                 }
-            case UPROBE_ENTER:
+            case UPROBE_ENTER, UPROBE_EXIT:
                 {
                     event = this.NewUprobeEvent(event)
                 }
             default:
                 {
-                    if this.mconf.BrkAddr != 0 {
+                    if len(this.mconf.Brks) != 0 {
                         event = this.NewBrkEvent(event)
                     } else {
 
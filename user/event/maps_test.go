@@ -0,0 +1,115 @@
+package event
+
+import (
+    "encoding/binary"
+    "strings"
+    "testing"
+
+    "golang.org/x/sys/unix"
+)
+
+func TestPidMapsAt(t *testing.T) {
+    pid_maps := &PidMaps{}
+    pid_maps.Add(&ProcMaps{Ts: 100})
+    pid_maps.Add(&ProcMaps{Ts: 200})
+    // 乱序到达的版本取最新版本的时间
+    pid_maps.Add(&ProcMaps{Ts: 150})
+    if ts := pid_maps.Latest().Ts; ts != 200 {
+        t.Fatalf("latest ts = %d, want 200", ts)
+    }
+    maps, ok := pid_maps.At(120)
+    if !ok || maps.Ts != 100 {
+        t.Fatalf("At(120) = %d %v, want 100 true", maps.Ts, ok)
+    }
+    // 没有丢弃过版本 更早的事件仍然使用最旧的版本
+    maps, ok = pid_maps.At(50)
+    if !ok || maps.Ts != 100 {
+        t.Fatalf("At(50) = %d %v, want 100 true", maps.Ts, ok)
+    }
+}
+
+func TestPidMapsDrop(t *testing.T) {
+    pid_maps := &PidMaps{}
+    pid_maps.Add(&ProcMaps{Ts: 1})
+    pid_maps.Add(&ProcMaps{Ts: 2})
+    // 未超过保留时间 不丢弃
+    pid_maps.Add(&ProcMaps{Ts: 2 + MAPS_VERSION_KEEP})
+    if len(pid_maps.versions) != 3 {
+        t.Fatalf("versions = %d, want 3", len(pid_maps.versions))
+    }
+    pid_maps.Add(&ProcMaps{Ts: 3 + MAPS_VERSION_KEEP})
+    if len(pid_maps.versions) != 3 || pid_maps.versions[0].Ts != 2 {
+        t.Fatalf("oldest ts = %d, want 2", pid_maps.versions[0].Ts)
+    }
+    if _, ok := pid_maps.At(1); ok {
+        t.Fatal("At(1) should report the dropped version")
+    }
+    if maps, ok := pid_maps.At(2); !ok || maps.Ts != 2 {
+        t.Fatalf("At(2) = %d %v, want 2 true", maps.Ts, ok)
+    }
+}
+
+func TestParseSampleIdTime(t *testing.T) {
+    sample_id := make([]byte, 16)
+    binary.LittleEndian.PutUint32(sample_id[0:], 1234)
+    binary.LittleEndian.PutUint32(sample_id[4:], 1235)
+    binary.LittleEndian.PutUint64(sample_id[8:], 987654321)
+    ts, ok := ParseSampleIdTime(sample_id, SAMPLE_ID_TYPE)
+    if !ok || ts != 987654321 {
+        t.Fatalf("ts = %d %v, want 987654321 true", ts, ok)
+    }
+    if _, ok := ParseSampleIdTime(sample_id[:8], SAMPLE_ID_TYPE); ok {
+        t.Fatal("short sample_id should have no time")
+    }
+    if _, ok := ParseSampleIdTime(nil, SAMPLE_ID_TYPE); ok {
+        t.Fatal("empty sample_id should have no time")
+    }
+    if ts, _ := ParseSampleIdTime(sample_id[8:], unix.PERF_SAMPLE_TIME); ts != 987654321 {
+        t.Fatalf("ts without tid = %d, want 987654321", ts)
+    }
+}
+
+func TestReplayMapsSnapshots(t *testing.T) {
+    t.Cleanup(func() {
+        maps_snapshots = nil
+        maps_helper.InitMap()
+    })
+    const pid = 4242
+    first := "00001000-00002000 r-xp 00000000 fd:00 1 /system/lib64/liba.so\n"
+    second := first + "00005000-00006000 r-xp 00000000 fd:00 2 /system/lib64/libb.so\n"
+    AddMapsSnapshot(pid, 100, first)
+    AddMapsSnapshot(pid, 200, second)
+    // 第一个快照之前的事件同样使用第一个快照
+    for _, ts := range []uint64{50, 100, 150} {
+        content, err := maps_helper.GetMapBuffer(pid, ts)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if strings.Contains(content, "libb.so") {
+            t.Fatalf("maps at %d should not contain libb.so:\n%s", ts, content)
+        }
+    }
+    content, err := maps_helper.GetMapBuffer(pid, 250)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(content, "libb.so") {
+        t.Fatalf("maps at 250 should contain libb.so:\n%s", content)
+    }
+}
+
+func TestProcMapsRootPrefix(t *testing.T) {
+    content := "7f0000000000-7f0000001000 r-xp 00000000 fd:00 1 /usr/lib/libc.so.6\n" +
+        "7f0000002000-7f0000003000 rw-p 00000000 00:00 0 [heap]\n"
+    maps := ParseProcMaps(0, content)
+    maps.SetRootPrefix("/proc/42/root")
+    libc := maps.Find(0x7f0000000010)
+    if libc == nil || libc.LibPath != "/usr/lib/libc.so.6" || libc.OpenPath() != "/proc/42/root/usr/lib/libc.so.6" {
+        t.Fatalf("libc region = %+v", libc)
+    }
+    // 匿名映射没有对应的文件
+    heap := maps.Find(0x7f0000002010)
+    if heap == nil || heap.OpenPath() != heap.LibPath {
+        t.Fatalf("heap region = %+v", heap)
+    }
+}
@@ -0,0 +1,73 @@
+package event
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "stackplz/user/config"
+    "strings"
+    "testing"
+)
+
+// 样本和库的生成方法见 util/testdata/unwind_x86_64.c
+func loadUnwindSample(t *testing.T) (*UnwindBuf, func(addr uint64) *LibInfo) {
+    if err := config.SetArch(config.ARCH_X86_64); err != nil {
+        t.Fatal(err)
+    }
+    content, err := os.ReadFile("testdata/unwind_x86_64.bin")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ubuf := &UnwindBuf{}
+    err = ubuf.ParseContext(bytes.NewBuffer(content), config.GetArch().GetSampleRegCount(false))
+    if err != nil {
+        t.Fatal(err)
+    }
+    lib_path, err := filepath.Abs("../util/testdata/unwind_x86_64.elf")
+    if err != nil {
+        t.Fatal(err)
+    }
+    // -no-pie 的可执行文件 代码段固定映射在 0x401000
+    region := &LibInfo{BaseAddr: 0x401000, Off: 0x1000, EndAddr: 0x402000, Perm: "r-xp", LibPath: lib_path, LibName: "unwind_x86_64.elf"}
+    find_region := func(addr uint64) *LibInfo {
+        if addr >= region.BaseAddr && addr < region.EndAddr {
+            return region
+        }
+        return nil
+    }
+    return ubuf, find_region
+}
+
+func TestUnwind(t *testing.T) {
+    defer config.SetArch(config.GetArch().Name)
+    ubuf, find_region := loadUnwindSample(t)
+    frames := Unwind(ubuf.Regs, NewStackMemory(ubuf), find_region)
+    want := []string{"inner", "middle", "outer", "main"}
+    if len(frames) < len(want) {
+        t.Fatalf("got %d frames, want at least %d\n%s", len(frames), len(want), FormatFrames(frames))
+    }
+    lines := strings.Split(FormatFrames(frames), "\n")
+    for i, name := range want {
+        if frames[i].Region == nil || !strings.Contains(lines[i], "("+name+"+0x") {
+            t.Errorf("frame %d should be in %s, got %s", i, name, lines[i])
+        }
+    }
+    // 每一帧的 sp 都比上一帧高
+    for i := 1; i < len(want); i++ {
+        if frames[i].Sp <= frames[i-1].Sp {
+            t.Errorf("frame %d sp 0x%x not above 0x%x", i, frames[i].Sp, frames[i-1].Sp)
+        }
+    }
+}
+
+// 栈数据被截断时回溯应当停止 而不是读到越界的数据
+func TestUnwindTruncatedStack(t *testing.T) {
+    defer config.SetArch(config.GetArch().Name)
+    ubuf, find_region := loadUnwindSample(t)
+    stack := NewStackMemory(ubuf)
+    stack.Data = stack.Data[:64]
+    frames := Unwind(ubuf.Regs, stack, find_region)
+    if len(frames) != 1 {
+        t.Fatalf("got %d frames, want only the first one\n%s", len(frames), FormatFrames(frames))
+    }
+}
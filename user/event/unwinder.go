@@ -0,0 +1,248 @@
+package event
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "stackplz/user/config"
+    "stackplz/user/util"
+    "strings"
+)
+
+const (
+    UNWINDER_AUTO           = "auto"
+    UNWINDER_GO             = "go"
+    UNWINDER_LIBUNWINDSTACK = "libunwindstack"
+)
+
+const MAX_UNWIND_FRAMES = 64
+
+var unwinder_name = UNWINDER_AUTO
+
+func SetUnwinder(name string) error {
+    switch name {
+    case UNWINDER_AUTO, UNWINDER_GO:
+    case UNWINDER_LIBUNWINDSTACK:
+        if !HasLibUnwindStack {
+            return errors.New("libunwindstack unwinder requires a cgo build")
+        }
+        if config.GetArch().Name != config.ARCH_ARM64 {
+            return errors.New("libunwindstack unwinder only supports arm64")
+        }
+    default:
+        return fmt.Errorf("unknown unwinder %s, should be auto, go or libunwindstack", name)
+    }
+    unwinder_name = name
+    return nil
+}
+
+// 32 位进程的寄存器布局不同 库中的回溯信息也多是 .ARM.exidx 这里的实现不支持
+// auto 时 64 位进程使用 Go 的实现 32 位进程仍然使用 libunwindstack
+func ResolveUnwinder(is_32bit bool) (string, error) {
+    if !is_32bit {
+        if unwinder_name == UNWINDER_AUTO {
+            unwinder_name = UNWINDER_GO
+        }
+        return unwinder_name, nil
+    }
+    switch unwinder_name {
+    case UNWINDER_GO:
+        return "", errors.New("go unwinder does not support 32-bit process, use --unwinder libunwindstack")
+    case UNWINDER_AUTO:
+        if !HasLibUnwindStack {
+            return "", errors.New("unwinding 32-bit process requires libunwindstack, which needs a cgo build")
+        }
+        unwinder_name = UNWINDER_LIBUNWINDSTACK
+    }
+    return unwinder_name, nil
+}
+
+// 回放时没有 libunwindstack 可用 总是使用 Go 的实现
+func UseLibUnwindStack() bool {
+    return unwinder_name == UNWINDER_LIBUNWINDSTACK && !IsReplayMode()
+}
+
+// perf 采集到的用户栈 起始地址就是 sp
+type StackMemory struct {
+    Start uint64
+    Data  []byte
+}
+
+func NewStackMemory(ubuf *UnwindBuf) *StackMemory {
+    data := ubuf.Data
+    // dyn_size 是实际 dump 下来的大小
+    if ubuf.DynSize > 0 && ubuf.DynSize < uint64(len(data)) {
+        data = data[:ubuf.DynSize]
+    }
+    return &StackMemory{Start: ubuf.Regs[config.GetArch().RegSP], Data: data}
+}
+
+func (this *StackMemory) Read(addr uint64, size uint64) ([]byte, bool) {
+    if addr < this.Start || addr-this.Start+size > uint64(len(this.Data)) {
+        return nil, false
+    }
+    offset := addr - this.Start
+    return this.Data[offset : offset+size], true
+}
+
+func (this *StackMemory) ReadU64(addr uint64) (uint64, bool) {
+    data, ok := this.Read(addr, 8)
+    if !ok {
+        return 0, false
+    }
+    return binary.LittleEndian.Uint64(data), true
+}
+
+type UnwindFrame struct {
+    Pc     uint64
+    Sp     uint64
+    Region *LibInfo
+}
+
+// 去掉 PAC 签名等高位
+func stripPointer(addr uint64) uint64 {
+    return addr & ((1 << 48) - 1)
+}
+
+// 根据寄存器和栈数据回溯 优先使用 .eh_frame/.debug_frame 找不到时退回基于 fp 的回溯
+// find_region 用于查找地址所在的 map 与 maps 的来源无关 便于离线对采集样本进行回溯
+func Unwind(regs [33]uint64, stack *StackMemory, find_region func(addr uint64) *LibInfo) []UnwindFrame {
+    arch := config.GetArch()
+    var frames []UnwindFrame
+    for i := 0; i < MAX_UNWIND_FRAMES; i++ {
+        pc := stripPointer(regs[arch.RegPC])
+        sp := regs[arch.RegSP]
+        if pc == 0 {
+            break
+        }
+        // 除了第一帧 pc 都是返回地址 减去一条指令的长度才是调用所在的位置
+        // x86_64 指令不定长 减 1 就能落在 call 指令中
+        if i > 0 && pc >= arch.PcAdjust {
+            pc -= arch.PcAdjust
+        }
+        region := find_region(pc)
+        frames = append(frames, UnwindFrame{Pc: pc, Sp: sp, Region: region})
+        next_regs, ok := stepCFI(regs, pc, region, stack, i == 0)
+        if !ok {
+            next_regs, ok = stepFP(regs, stack)
+        }
+        if !ok {
+            break
+        }
+        // 栈向高地址回溯 sp 不增长并且 pc 没有变化说明陷入了循环
+        next_sp := next_regs[arch.RegSP]
+        if next_sp < sp || (next_sp == sp && stripPointer(next_regs[arch.RegPC]) == stripPointer(regs[arch.RegPC])) {
+            break
+        }
+        regs = next_regs
+    }
+    return frames
+}
+
+// CFI 中的寄存器是 DWARF 编号 通过 DwarfRegs 换算成 perf 采样数据中的下标
+func stepCFI(regs [33]uint64, pc uint64, region *LibInfo, stack *StackMemory, is_first bool) ([33]uint64, bool) {
+    if region == nil || !strings.HasPrefix(region.LibPath, "/") {
+        return regs, false
+    }
+    table := util.LoadCFITable(region.OpenPath())
+    if table == nil {
+        return regs, false
+    }
+    arch := config.GetArch()
+    dwarf_count := uint64(len(arch.DwarfRegs))
+    vaddr := table.FileOffsetToVaddr(region.Off + (pc - region.BaseAddr))
+    row, err := table.FindRow(vaddr)
+    if err != nil || row.CfaExpr || row.CfaReg >= dwarf_count {
+        return regs, false
+    }
+    cfa := uint64(int64(regs[arch.DwarfRegs[row.CfaReg]]) + row.CfaOffset)
+    next_regs := regs
+    for reg, regno := range arch.DwarfRegs {
+        rule := row.Regs[reg]
+        switch rule.Type {
+        case util.CFI_RULE_OFFSET:
+            value, ok := stack.ReadU64(uint64(int64(cfa) + rule.Offset))
+            if !ok {
+                return regs, false
+            }
+            next_regs[regno] = value
+        case util.CFI_RULE_VAL_OFFSET:
+            next_regs[regno] = uint64(int64(cfa) + rule.Offset)
+        case util.CFI_RULE_REGISTER:
+            if rule.Reg >= dwarf_count {
+                return regs, false
+            }
+            next_regs[regno] = regs[arch.DwarfRegs[rule.Reg]]
+        case util.CFI_RULE_EXPRESSION:
+            return regs, false
+        }
+    }
+    ra_rule := util.CFIRule{Type: util.CFI_RULE_SAME}
+    if row.RaReg < util.CFI_MAX_REG {
+        ra_rule = row.Regs[row.RaReg]
+    }
+    if ra_rule.Type == util.CFI_RULE_UNDEFINED || row.RaReg >= dwarf_count {
+        // 返回地址未定义 说明已经到了最外层
+        next_regs[arch.RegPC] = 0
+    } else {
+        // 返回地址仍在 lr 中只可能出现在第一帧 否则会导致原地打转
+        if ra_rule.Type == util.CFI_RULE_SAME && !is_first {
+            return regs, false
+        }
+        next_regs[arch.RegPC] = next_regs[arch.DwarfRegs[row.RaReg]]
+    }
+    next_regs[arch.RegSP] = cfa
+    return next_regs, true
+}
+
+// 基于 fp 的回溯 要求函数保留了帧指针 [fp] 为上一帧的 fp [fp+8] 为返回地址
+// x86_64 的 rbp 链也是同样的布局
+func stepFP(regs [33]uint64, stack *StackMemory) ([33]uint64, bool) {
+    arch := config.GetArch()
+    fp := regs[arch.RegFP]
+    if fp < regs[arch.RegSP] {
+        return regs, false
+    }
+    next_fp, ok := stack.ReadU64(fp)
+    if !ok {
+        return regs, false
+    }
+    next_lr, ok := stack.ReadU64(fp + 8)
+    if !ok {
+        return regs, false
+    }
+    next_regs := regs
+    next_regs[arch.RegFP] = next_fp
+    if arch.RegLR != config.REG_NONE {
+        next_regs[arch.RegLR] = next_lr
+    }
+    next_regs[arch.RegSP] = fp + 16
+    next_regs[arch.RegPC] = next_lr
+    return next_regs, true
+}
+
+// 输出格式与 libunwindstack 保持一致 #00 pc 000000000004a7e4  /path/libc.so (read+0x4)
+func FormatFrames(frames []UnwindFrame) string {
+    var lines []string
+    for i, frame := range frames {
+        if frame.Region == nil {
+            lines = append(lines, fmt.Sprintf("  #%02d pc %016x  <unknown>", i, frame.Pc))
+            continue
+        }
+        offset := frame.Region.Off + (frame.Pc - frame.Region.BaseAddr)
+        rel_pc := offset
+        var sym_info string
+        if strings.HasPrefix(frame.Region.LibPath, "/") {
+            if table := util.LoadSymbolTable(frame.Region.OpenPath()); table != nil {
+                rel_pc = table.FileOffsetToVaddr(offset)
+                sym_info = table.Symbolize(rel_pc)
+            }
+        }
+        line := fmt.Sprintf("  #%02d pc %016x  %s", i, rel_pc, frame.Region.LibPath)
+        if sym_info != "" {
+            line += fmt.Sprintf(" (%s)", sym_info)
+        }
+        lines = append(lines, line)
+    }
+    return strings.Join(lines, "\n")
+}
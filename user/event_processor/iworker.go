@@ -1,6 +1,7 @@
 package event_processor
 
 import (
+	"stackplz/user/config"
 	"stackplz/user/event"
 	"time"
 
@@ -19,11 +20,20 @@ type IWorker interface {
 }
 
 const (
-	MAX_TICKER_COUNT = 10  // 1 Sencond/(eventWorker.ticker.C) = 10
-	MAX_CHAN_LEN     = 256 // 包队列长度
+	MAX_TICKER_COUNT    = 10  // 1 Sencond/(eventWorker.ticker.C) = 10
+	MAX_CHAN_LEN        = 256 // 包队列长度
+	MAX_WAIT_EXIT_COUNT = 5   // 等待 sys_exit 的最长时间 500ms
 	//MAX_EVENT_LEN    = 16 // 事件数组长度
+	// 没有设置 --ret-timeout 时等待 uprobe 返回的最长时间
+	DEFAULT_RET_TIMEOUT = 5 * time.Second
 )
 
+// 等待返回的 uprobe 事件 各自有超时时间
+type uprobeEnter struct {
+	e        *event.UprobeEvent
+	deadline time.Time
+}
+
 type eventWorker struct {
 	incoming chan event.IEventStruct
 	// last_event event.IEventStruct
@@ -32,12 +42,25 @@ type eventWorker struct {
 	tickerCount uint8
 	UUID        string
 	processor   *EventProcessor
+	// 等待合并的 sys_enter 事件
+	enter_event *event.SyscallEvent
+	wait_count  uint8
+	// 最近一次 sys_enter 用于计算耗时
+	last_enter_nr uint32
+	last_enter_ts uint64
+	has_enter     bool
+	// 等待函数返回的 uprobe 事件 嵌套调用时按栈的方式匹配
+	uprobe_enters []uprobeEnter
+	// 处理器关闭时通知 worker 处理完积压的事件后退出
+	quit chan struct{}
 }
 
 func NewEventWorker(uuid string, processor *EventProcessor) IWorker {
 	eWorker := &eventWorker{}
 	eWorker.init(uuid, processor)
+	processor.workers.Add(1)
 	go func() {
+		defer processor.workers.Done()
 		eWorker.Run()
 	}()
 	return eWorker
@@ -46,6 +69,7 @@ func NewEventWorker(uuid string, processor *EventProcessor) IWorker {
 func (this *eventWorker) init(uuid string, processor *EventProcessor) {
 	this.ticker = time.NewTicker(time.Millisecond * 100)
 	this.incoming = make(chan event.IEventStruct, MAX_CHAN_LEN)
+	this.quit = make(chan struct{})
 	this.UUID = uuid
 	this.processor = processor
 }
@@ -61,12 +85,130 @@ func (this *eventWorker) Write(e event.IEventStruct) error {
 
 // 输出包内容
 func (this *eventWorker) Display() {
+	this.flushEnterEvent()
+	this.flushUprobeEnters(0)
+}
+
+// 没有等到对应的 sys_exit 直接输出 sys_enter
+func (this *eventWorker) flushEnterEvent() {
+	if this.enter_event == nil {
+		return
+	}
+	this.enter_event.SetUnfinished()
+	this.outputSyscallEvent(this.enter_event)
+	this.enter_event = nil
+}
+
+// 同一线程 sys_exit 与对应 sys_enter 的时间差即为耗时
+func (this *eventWorker) updateDuration(e *event.SyscallEvent) {
+	if e.IsEnter() {
+		this.last_enter_nr = e.GetNR()
+		this.last_enter_ts = e.Ts
+		this.has_enter = true
+		return
+	}
+	if !this.has_enter || this.last_enter_nr != e.GetNR() || e.Ts < this.last_enter_ts {
+		return
+	}
+	this.has_enter = false
+	duration := e.Ts - this.last_enter_ts
+	e.SetDuration(duration)
+	if this.processor.GetSConfig().Latency {
+		AddLatency(e.GetPointName(), duration)
+	}
+}
+
+func (this *eventWorker) handleSyscallEvent(e *event.SyscallEvent) {
+	this.updateDuration(e)
+	if this.enter_event != nil {
+		if !e.IsEnter() && e.GetNR() == this.enter_event.GetNR() {
+			this.enter_event.MergeEvent(e)
+			this.outputSyscallEvent(this.enter_event)
+			this.enter_event = nil
+			return
+		}
+		this.flushEnterEvent()
+	}
+	if e.WaitNextEvent() {
+		this.enter_event = e
+		this.wait_count = 0
+		return
+	}
+	this.outputSyscallEvent(e)
+}
+
+// 从栈顶开始把 index 及之后没有等到返回的 uprobe 事件直接输出
+func (this *eventWorker) flushUprobeEnters(index int) {
+	for i := index; i < len(this.uprobe_enters); i++ {
+		this.uprobe_enters[i].e.SetUnfinished()
+		this.outputEvent(this.uprobe_enters[i].e)
+	}
+	this.uprobe_enters = this.uprobe_enters[:index]
+}
+
+// 超时的只会是栈底的一段 外层调用先进入 超时时间也更早
+func (this *eventWorker) flushExpiredUprobeEnters(now time.Time) {
+	expired := 0
+	for expired < len(this.uprobe_enters) && now.After(this.uprobe_enters[expired].deadline) {
+		this.uprobe_enters[expired].e.SetUnfinished()
+		this.outputEvent(this.uprobe_enters[expired].e)
+		expired++
+	}
+	this.uprobe_enters = this.uprobe_enters[expired:]
+}
 
+func (this *eventWorker) retTimeout() time.Duration {
+	timeout := time.Duration(this.processor.GetSConfig().RetTimeout)
+	if timeout == 0 {
+		return DEFAULT_RET_TIMEOUT
+	}
+	return timeout
+}
+
+func (this *eventWorker) handleUprobeEvent(e *event.UprobeEvent) {
+	if e.IsEnter() {
+		if e.WaitNextEvent() {
+			this.uprobe_enters = append(this.uprobe_enters, uprobeEnter{e: e, deadline: time.Now().Add(this.retTimeout())})
+			return
+		}
+		this.outputEvent(e)
+		return
+	}
+	for i := len(this.uprobe_enters) - 1; i >= 0; i-- {
+		enter_event := this.uprobe_enters[i].e
+		if enter_event.GetPointIndex() != e.GetPointIndex() {
+			continue
+		}
+		// 更内层的调用没有返回 比如 longjmp 之类的情况
+		this.flushUprobeEnters(i + 1)
+		enter_event.MergeEvent(e)
+		this.outputEvent(enter_event)
+		this.uprobe_enters = this.uprobe_enters[:i]
+		return
+	}
+	this.outputEvent(e)
+}
+
+// eBPF 中只处理了简单的条件 完整的 --filter 在这里检查
+func (this *eventWorker) outputSyscallEvent(e *event.SyscallEvent) {
+	if !e.MatchArgFilters() {
+		return
+	}
+	this.outputEvent(e)
+}
+
+func (this *eventWorker) outputEvent(e event.IEventStruct) {
+	logger := this.processor.GetLogger()
+	if this.processor.GetSConfig().Format == config.FORMAT_JSON {
+		// json 内容可能包含 % 不能作为 format 使用
+		logger.Print(e.JsonString())
+	} else {
+		logger.Printf(e.String())
+	}
 }
 
 // 解析类型，输出
 func (this *eventWorker) parserEvent(e event.IEventStruct) {
-	logger := this.processor.GetLogger()
 	switch e.RecordType() {
 	case unix.PERF_RECORD_COMM:
 	case unix.PERF_RECORD_MMAP2:
@@ -78,7 +220,13 @@ func (this *eventWorker) parserEvent(e event.IEventStruct) {
 		}
 	default:
 		{
-			logger.Printf(e.String())
+			if syscall_event, ok := e.(*event.SyscallEvent); ok {
+				this.handleSyscallEvent(syscall_event)
+			} else if uprobe_event, ok := e.(*event.UprobeEvent); ok {
+				this.handleUprobeEvent(uprobe_event)
+			} else {
+				this.outputEvent(e)
+			}
 		}
 	}
 
@@ -88,7 +236,16 @@ func (this *eventWorker) Run() {
 	for {
 		select {
 		case _ = <-this.ticker.C:
-			if this.tickerCount > MAX_TICKER_COUNT {
+			if this.enter_event != nil {
+				this.wait_count++
+				if this.wait_count > MAX_WAIT_EXIT_COUNT {
+					this.flushEnterEvent()
+				}
+			}
+			// 超过 --ret-timeout 没有返回的 uprobe 事件直接输出
+			this.flushExpiredUprobeEnters(time.Now())
+			// 还有没处理完的事件或者还在等待返回的调用就先不退出
+			if this.tickerCount > MAX_TICKER_COUNT && len(this.incoming) == 0 && len(this.uprobe_enters) == 0 {
 				this.Close()
 				return
 			}
@@ -97,6 +254,22 @@ func (this *eventWorker) Run() {
 			// reset tickerCount
 			this.tickerCount = 0
 			this.parserEvent(e)
+		case _ = <-this.quit:
+			this.drain()
+			this.Close()
+			return
+		}
+	}
+}
+
+// 处理完 incoming 中剩余的事件
+func (this *eventWorker) drain() {
+	for {
+		select {
+		case e := <-this.incoming:
+			this.parserEvent(e)
+		default:
+			return
 		}
 	}
 }
@@ -104,6 +277,7 @@ func (this *eventWorker) Run() {
 func (this *eventWorker) Close() {
 	// 即将关闭， 必须输出结果
 	this.Display()
+	this.ticker.Stop()
 	this.tickerCount = 0
 	this.processor.delWorkerByUUID(this)
 }
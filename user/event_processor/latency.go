@@ -0,0 +1,191 @@
+package event_processor
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --latency 模式下统计每个 syscall 的耗时 也就是同一线程 sys_exit 与 sys_enter 的 Ts 之差
+
+type SyscallLatency struct {
+	Name      string
+	Count     uint64
+	Total     uint64
+	Min       uint64
+	Max       uint64
+	// 第 i 个桶表示耗时在 [2^(i-1), 2^i) ns 之间 分位数也由它估算 内存占用固定
+	hist [65]uint64
+}
+
+func (this *SyscallLatency) Add(duration uint64) {
+	if this.Count == 0 || duration < this.Min {
+		this.Min = duration
+	}
+	if duration > this.Max {
+		this.Max = duration
+	}
+	this.Count++
+	this.Total += duration
+	this.hist[bits.Len64(duration)]++
+}
+
+func (this *SyscallLatency) Avg() uint64 {
+	if this.Count == 0 {
+		return 0
+	}
+	return this.Total / this.Count
+}
+
+// 找到分位所在的桶 在桶内按线性分布插值 误差不超过桶的宽度
+func (this *SyscallLatency) Percentile(p float64) uint64 {
+	if this.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(float64(this.Count) * p / 100))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, count := range this.hist {
+		if count == 0 || seen+count < rank {
+			seen += count
+			continue
+		}
+		var low, high uint64
+		if i > 0 {
+			low = uint64(1) << (i - 1)
+			high = (uint64(1) << i) - 1
+		}
+		// 第一个桶和最后一个桶的实际范围由 min max 限定
+		if low < this.Min {
+			low = this.Min
+		}
+		if high > this.Max {
+			high = this.Max
+		}
+		return low + uint64(float64(high-low)*float64(rank-seen)/float64(count))
+	}
+	return this.Max
+}
+
+func (this *SyscallLatency) HistString() string {
+	first, last := -1, -1
+	var max_count uint64
+	for i, count := range this.hist {
+		if count == 0 {
+			continue
+		}
+		if first == -1 {
+			first = i
+		}
+		last = i
+		if count > max_count {
+			max_count = count
+		}
+	}
+	if first == -1 {
+		return ""
+	}
+	const width = 40
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%24s : %-8s %s", "nsecs", "count", "distribution"))
+	for i := first; i <= last; i++ {
+		var low, high uint64
+		if i > 0 {
+			low = uint64(1) << (i - 1)
+			high = (uint64(1) << i) - 1
+		}
+		stars := int(this.hist[i] * width / max_count)
+		lines = append(lines, fmt.Sprintf("%10d -> %-10d : %-8d |%-40s|", low, high, this.hist[i], strings.Repeat("*", stars)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type LatencyStats struct {
+	sync.Mutex
+	stats map[string]*SyscallLatency
+}
+
+func NewLatencyStats() *LatencyStats {
+	return &LatencyStats{stats: make(map[string]*SyscallLatency)}
+}
+
+func (this *LatencyStats) Add(name string, duration uint64) {
+	this.Lock()
+	defer this.Unlock()
+	item, ok := this.stats[name]
+	if !ok {
+		item = &SyscallLatency{Name: name}
+		this.stats[name] = item
+	}
+	item.Add(duration)
+}
+
+func FormatDuration(duration uint64) string {
+	return time.Duration(duration).String()
+}
+
+func (this *LatencyStats) Report() string {
+	this.Lock()
+	defer this.Unlock()
+	if len(this.stats) == 0 {
+		return ""
+	}
+	var items []*SyscallLatency
+	for _, item := range this.stats {
+		items = append(items, item)
+	}
+	// 总耗时最多的排在前面
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Total > items[j].Total
+	})
+	var lines []string
+	lines = append(lines, "syscall latency report:")
+	lines = append(lines, fmt.Sprintf("%-20s %10s %12s %12s %12s %12s %12s", "syscall", "count", "min", "avg", "p50", "p99", "max"))
+	for _, item := range items {
+		lines = append(lines, fmt.Sprintf("%-20s %10d %12s %12s %12s %12s %12s",
+			item.Name,
+			item.Count,
+			FormatDuration(item.Min),
+			FormatDuration(item.Avg()),
+			FormatDuration(item.Percentile(50)),
+			FormatDuration(item.Percentile(99)),
+			FormatDuration(item.Max),
+		))
+	}
+	for _, item := range items {
+		lines = append(lines, "")
+		lines = append(lines, fmt.Sprintf("%s latency histogram:", item.Name))
+		lines = append(lines, item.HistString())
+	}
+	return strings.Join(lines, "\n")
+}
+
+var latency_stats = NewLatencyStats()
+
+func AddLatency(name string, duration uint64) {
+	latency_stats.Add(name, duration)
+}
+
+func LatencyReport() string {
+	return latency_stats.Report()
+}
+
+// 按间隔定时输出统计结果 统计数据是累计的
+func StartLatencyReport(logger *log.Logger, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		for range ticker.C {
+			report := LatencyReport()
+			if report != "" {
+				logger.Print(report)
+			}
+		}
+	}()
+}
@@ -0,0 +1,43 @@
+package event_processor
+
+import (
+	"testing"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	item := &SyscallLatency{Name: "read"}
+	for i := uint64(1); i <= 1000; i++ {
+		item.Add(i * 1000)
+	}
+	tests := []struct {
+		p    float64
+		want uint64
+	}{
+		{50, 500000},
+		{99, 990000},
+	}
+	for _, tt := range tests {
+		got := item.Percentile(tt.p)
+		// 估算值和真实值在同一个 log2 桶内
+		if got < tt.want/2 || got > tt.want*2 {
+			t.Errorf("p%v = %d, want about %d", tt.p, got, tt.want)
+		}
+	}
+	if got := item.Percentile(100); got != item.Max {
+		t.Errorf("p100 = %d, want max %d", got, item.Max)
+	}
+}
+
+func TestLatencyPercentileSingle(t *testing.T) {
+	item := &SyscallLatency{Name: "getpid"}
+	item.Add(1234)
+	for _, p := range []float64{0, 50, 99} {
+		if got := item.Percentile(p); got != 1234 {
+			t.Errorf("p%v = %d, want 1234", p, got)
+		}
+	}
+	empty := &SyscallLatency{}
+	if got := empty.Percentile(50); got != 0 {
+		t.Errorf("empty p50 = %d", got)
+	}
+}
@@ -3,6 +3,7 @@ package event_processor
 import (
 	"fmt"
 	"log"
+	"stackplz/user/config"
 	"stackplz/user/event"
 	"sync"
 	"time"
@@ -11,6 +12,8 @@ import (
 const (
 	MAX_INCOMING_CHAN_LEN = 1024
 	MAX_PARSER_QUEUE_LEN  = 1024
+	// 等待 worker 输出完剩余事件的最长时间
+	MAX_CLOSE_WAIT = 5 * time.Second
 )
 
 type EventProcessor struct {
@@ -20,14 +23,25 @@ type EventProcessor struct {
 
 	// key为 PID+UID+COMMON等确定唯一的信息
 	workerQueue map[string]IWorker
+	// 正在运行的 worker 数量 Close 时等待它们全部退出
+	workers sync.WaitGroup
+
+	// 分发之前按 Ts 排序
+	orderLock sync.Mutex
+	reorder   reorderBuffer
 
 	logger *log.Logger
+	sconf  *config.SConfig
 }
 
 func (this *EventProcessor) GetLogger() *log.Logger {
 	return this.logger
 }
 
+func (this *EventProcessor) GetSConfig() *config.SConfig {
+	return this.sconf
+}
+
 func (this *EventProcessor) init() {
 	this.incoming = make(chan event.IEventStruct, MAX_INCOMING_CHAN_LEN)
 	this.workerQueue = make(map[string]IWorker, MAX_PARSER_QUEUE_LEN)
@@ -35,16 +49,43 @@ func (this *EventProcessor) init() {
 
 // Write event 处理器读取事件
 func (this *EventProcessor) Serve() {
+	ticker := time.NewTicker(REORDER_TICK)
 	for {
 		select {
 		case e := <-this.incoming:
 			this.dispatch(e)
+		case _ = <-ticker.C:
+			this.flushReorder(false)
 		}
 	}
 }
 
+// 原始记录按 Ts 排序之后再解析 fd 表 maps 版本之类有状态的解析才会按时间顺序进行
 func (this *EventProcessor) dispatch(e event.IEventStruct) {
+	this.orderLock.Lock()
+	defer this.orderLock.Unlock()
+	ts := e.RecordTs()
+	if ts == 0 {
+		this.route(e)
+		return
+	}
+	now := time.Now()
+	this.reorder.Push(e, ts, now)
+	for _, e := range this.reorder.Pop(now, false) {
+		this.route(e)
+	}
+}
+
+// 把排序缓冲区中等够时间的事件交给 worker force 为 true 时全部交出
+func (this *EventProcessor) flushReorder(force bool) {
+	this.orderLock.Lock()
+	defer this.orderLock.Unlock()
+	for _, e := range this.reorder.Pop(time.Now(), force) {
+		this.route(e)
+	}
+}
 
+func (this *EventProcessor) route(e event.IEventStruct) {
 	// 做初步解析之后 转换为更明确的 event
 	e, err := e.ParseEvent()
 	if err != nil {
@@ -57,12 +98,22 @@ func (this *EventProcessor) dispatch(e event.IEventStruct) {
 		// 比如是自己的 mmap2 事件 直接忽略调
 		return
 	}
+	// 统计模式不输出每个事件 直接汇总
+	if this.sconf.Summary {
+		if syscall_event, ok := e.(*event.SyscallEvent); ok {
+			duration, ok := AddSummary(syscall_event)
+			if ok && this.sconf.Latency {
+				AddLatency(syscall_event.GetPointName(), duration)
+			}
+			return
+		}
+	}
 	// 单就输出日志来说 下面这样做反而给人一种输出有延迟的感觉 如果没有必要就去掉这部分吧
-	var uuid string = e.GetUUID()
+	var uuid string = workerKey(e)
 	found, eWorker := this.getWorkerByUUID(uuid)
 	if !found {
 		// ADD a new eventWorker into queue
-		eWorker = NewEventWorker(e.GetUUID(), this)
+		eWorker = NewEventWorker(uuid, this)
 		this.addWorkerByUUID(eWorker)
 	}
 	err = eWorker.Write(e)
@@ -72,6 +123,23 @@ func (this *EventProcessor) dispatch(e event.IEventStruct) {
 	}
 }
 
+// 同一线程的事件交给同一个 worker 配对
+// 不能带上 comm 否则 prctl 或 execve 改名后 enter 和 exit 会分到两个 worker
+func workerKey(e event.IEventStruct) string {
+	switch ev := e.(type) {
+	case *event.SyscallEvent:
+		return ev.ContextEvent.GetUUID()
+	case *event.UprobeEvent:
+		return ev.ContextEvent.GetUUID()
+	}
+	return e.GetUUID()
+}
+
+// 回放 trace 文件时直接同步分发 排序缓冲区中剩余的事件在 Close 时交给 worker
+func (this *EventProcessor) DispatchSync(e event.IEventStruct) {
+	this.dispatch(e)
+}
+
 //func (this *EventProcessor) Incoming() chan user.IEventStruct {
 //	return this.incoming
 //}
@@ -110,28 +178,34 @@ func (this *EventProcessor) Write(e event.IEventStruct) {
 	}
 }
 
+// 排序缓冲区中的事件全部交给 worker 然后通知 worker 处理完积压的事件后退出
 func (this *EventProcessor) Close() error {
-	// 关闭模块的时候 变更 tickerCount 大小 让它自己退出
+	this.flushReorder(true)
+	this.Lock()
 	for _, worker := range this.workerQueue {
-		worker.(*eventWorker).tickerCount = MAX_TICKER_COUNT + 1
+		close(worker.(*eventWorker).quit)
 	}
-	// 等待 1s 因为输出可能没有那么快结束
-	// 或者考虑在接收到结束信号后 把日志改成只输出到文件
-	time.Sleep(1 * 500 * time.Millisecond)
-	if len(this.workerQueue) > 0 {
-		this.logger.Printf("EventProcessor.Close(): workerQueue is not empty:%d, wait 3s", len(this.workerQueue))
-		time.Sleep(3 * 500 * time.Millisecond)
-	}
-	if len(this.workerQueue) > 0 {
+	this.Unlock()
+	done := make(chan struct{})
+	go func() {
+		this.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(MAX_CLOSE_WAIT):
+		this.Lock()
+		defer this.Unlock()
 		return fmt.Errorf("EventProcessor.Close(): workerQueue is not empty:%d", len(this.workerQueue))
 	}
-	return nil
 }
 
-func NewEventProcessor(logger *log.Logger) *EventProcessor {
+func NewEventProcessor(logger *log.Logger, conf config.IConfig) *EventProcessor {
 	var ep *EventProcessor
 	ep = &EventProcessor{}
 	ep.logger = logger
+	ep.sconf = conf.GetSConfig()
 	ep.init()
 	return ep
 }
@@ -0,0 +1,62 @@
+package event_processor
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"stackplz/user/config"
+	"stackplz/user/event"
+	"testing"
+)
+
+// 记录 ParseEvent 的调用顺序 解析结果为 nil 不再交给 worker
+type orderedRecord struct {
+	event.CommonEvent
+	ts     uint64
+	parsed *[]uint64
+}
+
+func (this *orderedRecord) RecordTs() uint64 {
+	return this.ts
+}
+
+func (this *orderedRecord) ParseEvent() (event.IEventStruct, error) {
+	*this.parsed = append(*this.parsed, this.ts)
+	return nil, nil
+}
+
+func TestDispatchParsesInTsOrder(t *testing.T) {
+	processor := NewEventProcessor(log.New(ioutil.Discard, "", 0), config.NewModuleConfig())
+	var parsed []uint64
+	// 例如 close 的记录先于对应的 read 到达
+	for _, ts := range []uint64{300, 100, 200} {
+		processor.DispatchSync(&orderedRecord{ts: ts, parsed: &parsed})
+	}
+	if len(parsed) != 0 {
+		t.Fatalf("records parsed before reorder: %v", parsed)
+	}
+	if err := processor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{100, 200, 300}; !reflect.DeepEqual(parsed, want) {
+		t.Fatalf("parse order = %v, want %v", parsed, want)
+	}
+}
+
+func TestWorkerKeyIgnoresComm(t *testing.T) {
+	enter := &event.SyscallEvent{}
+	enter.Pid, enter.Tid = 100, 101
+	copy(enter.Comm[:], "main")
+	exit := &event.SyscallEvent{}
+	exit.Pid, exit.Tid = 100, 101
+	// 例如 prctl(PR_SET_NAME) 的 sys_exit 已经是新名字
+	copy(exit.Comm[:], "worker")
+	if workerKey(enter) != workerKey(exit) {
+		t.Fatalf("enter key %q != exit key %q", workerKey(enter), workerKey(exit))
+	}
+	other := &event.UprobeEvent{}
+	other.Pid, other.Tid = 100, 102
+	if workerKey(other) == workerKey(enter) {
+		t.Fatal("different threads should not share a worker")
+	}
+}
@@ -0,0 +1,81 @@
+package event_processor
+
+import (
+	"container/heap"
+	"stackplz/user/event"
+	"time"
+)
+
+const (
+	// perf 各个 CPU 的缓冲区之间 以及 ringbuf 的提交顺序 与 Ts 都不完全一致
+	// 事件先停留这么久 期间按 Ts 排序 超过这个时间才到达的事件不再等待
+	REORDER_WINDOW = 100 * time.Millisecond
+	REORDER_TICK   = 10 * time.Millisecond
+)
+
+type reorderItem struct {
+	e       event.IEventStruct
+	ts      uint64
+	seq     uint64
+	arrival time.Time
+}
+
+// Ts 相同时按到达顺序
+type reorderHeap []*reorderItem
+
+func (h reorderHeap) Len() int {
+	return len(h)
+}
+
+func (h reorderHeap) Less(i, j int) bool {
+	if h[i].ts != h[j].ts {
+		return h[i].ts < h[j].ts
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h reorderHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *reorderHeap) Push(x interface{}) {
+	*h = append(*h, x.(*reorderItem))
+}
+
+func (h *reorderHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+type reorderBuffer struct {
+	items reorderHeap
+	seq   uint64
+	// 已到达事件中最大的 Ts 回放时没有真实的到达时间 靠它判断
+	max_ts uint64
+}
+
+func (this *reorderBuffer) Push(e event.IEventStruct, ts uint64, now time.Time) {
+	this.seq++
+	heap.Push(&this.items, &reorderItem{e: e, ts: ts, seq: this.seq, arrival: now})
+	if ts > this.max_ts {
+		this.max_ts = ts
+	}
+}
+
+// 按 Ts 从小到大取出已经等够的事件 force 为 true 时全部取出
+func (this *reorderBuffer) Pop(now time.Time, force bool) []event.IEventStruct {
+	var events []event.IEventStruct
+	window := uint64(REORDER_WINDOW)
+	for this.items.Len() > 0 {
+		item := this.items[0]
+		if !force && now.Sub(item.arrival) < REORDER_WINDOW && item.ts+window > this.max_ts {
+			break
+		}
+		heap.Pop(&this.items)
+		events = append(events, item.e)
+	}
+	return events
+}
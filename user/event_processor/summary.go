@@ -0,0 +1,141 @@
+package event_processor
+
+import (
+	"fmt"
+	"sort"
+	"stackplz/user/config"
+	"stackplz/user/event"
+	"strings"
+	"sync"
+)
+
+// --summary 模式下只做计数 类似 strace -c
+// 调用次数在 sys_enter 时统计 错误在 sys_exit 时统计
+// 事件不经过 worker 耗时也在这里按线程配对计算
+
+type summaryEnter struct {
+	nr uint32
+	ts uint64
+}
+
+type SyscallSummary struct {
+	sync.Mutex
+	total  uint64
+	calls  map[string]uint64
+	errors map[string]uint64
+	errnos map[string]uint64
+	comms  map[string]uint64
+	pids   map[string]uint64
+	// 每个线程最近一次 sys_enter
+	enters map[string]summaryEnter
+}
+
+func NewSyscallSummary() *SyscallSummary {
+	return &SyscallSummary{
+		calls:  make(map[string]uint64),
+		errors: make(map[string]uint64),
+		errnos: make(map[string]uint64),
+		comms:  make(map[string]uint64),
+		pids:   make(map[string]uint64),
+		enters: make(map[string]summaryEnter),
+	}
+}
+
+// sys_exit 能和同一线程的 sys_enter 配对时返回耗时
+func (this *SyscallSummary) Add(e *event.SyscallEvent) (uint64, bool) {
+	this.Lock()
+	defer this.Unlock()
+	name := e.GetPointName()
+	key := workerKey(e)
+	if e.IsEnter() {
+		this.total++
+		this.calls[name]++
+		this.comms[e.GetComm()]++
+		this.pids[fmt.Sprintf("%d", e.Pid)]++
+		this.enters[key] = summaryEnter{e.GetNR(), e.Ts}
+		return 0, false
+	}
+	errno, ok := config.GetErrno(e.GetRet())
+	if ok {
+		this.errors[name]++
+		this.errnos[config.GetErrnoName(errno)]++
+	}
+	enter, ok := this.enters[key]
+	if !ok {
+		return 0, false
+	}
+	delete(this.enters, key)
+	if enter.nr != e.GetNR() || e.Ts < enter.ts {
+		return 0, false
+	}
+	return e.Ts - enter.ts, true
+}
+
+type summaryItem struct {
+	key   string
+	count uint64
+}
+
+func sortByCount(counts map[string]uint64) []summaryItem {
+	var items []summaryItem
+	for key, count := range counts {
+		items = append(items, summaryItem{key, count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].count == items[j].count {
+			return items[i].key < items[j].key
+		}
+		return items[i].count > items[j].count
+	})
+	return items
+}
+
+func (this *SyscallSummary) percent(count uint64) float64 {
+	if this.total == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(this.total)
+}
+
+func (this *SyscallSummary) Report() string {
+	this.Lock()
+	defer this.Unlock()
+	var lines []string
+	lines = append(lines, "syscall summary:")
+	lines = append(lines, fmt.Sprintf("%8s %10s %10s %s", "% calls", "calls", "errors", "syscall"))
+	var total_errors uint64
+	for _, item := range sortByCount(this.calls) {
+		total_errors += this.errors[item.key]
+		lines = append(lines, fmt.Sprintf("%8.2f %10d %10d %s", this.percent(item.count), item.count, this.errors[item.key], item.key))
+	}
+	lines = append(lines, fmt.Sprintf("%8.2f %10d %10d %s", 100.0, this.total, total_errors, "total"))
+
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("%10s %s", "errors", "errno"))
+	for _, item := range sortByCount(this.errnos) {
+		lines = append(lines, fmt.Sprintf("%10d %s", item.count, item.key))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("%8s %10s %s", "% calls", "calls", "thread name"))
+	for _, item := range sortByCount(this.comms) {
+		lines = append(lines, fmt.Sprintf("%8.2f %10d %s", this.percent(item.count), item.count, item.key))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("%8s %10s %s", "% calls", "calls", "pid"))
+	for _, item := range sortByCount(this.pids) {
+		lines = append(lines, fmt.Sprintf("%8.2f %10d %s", this.percent(item.count), item.count, item.key))
+	}
+	return strings.Join(lines, "\n")
+}
+
+var syscall_summary = NewSyscallSummary()
+
+func AddSummary(e *event.SyscallEvent) (uint64, bool) {
+	return syscall_summary.Add(e)
+}
+
+func SummaryReport() string {
+	return syscall_summary.Report()
+}
@@ -121,6 +121,7 @@ func (this *PerfBRK) start() error {
 		return err
 	}
 	this.setupManagerOptions()
+	this.bpfManagerOptions.MapSpecEditors = this.getRingBufEditors(false)
 
 	var bpfFileName = filepath.Join("user/assets", this.hookBpfFile)
 	byteBuf, err := assets.Asset(bpfFileName)
@@ -73,3 +73,32 @@ const (
     PERF_REG_ARM64_PC
     PERF_REG_ARM64_MAX
 )
+
+// https://elixir.bootlin.com/linux/v5.10/source/arch/x86/include/uapi/asm/perf_regs.h
+const (
+    PERF_REG_X86_AX uint32 = iota
+    PERF_REG_X86_BX
+    PERF_REG_X86_CX
+    PERF_REG_X86_DX
+    PERF_REG_X86_SI
+    PERF_REG_X86_DI
+    PERF_REG_X86_BP
+    PERF_REG_X86_SP
+    PERF_REG_X86_IP
+    PERF_REG_X86_FLAGS
+    PERF_REG_X86_CS
+    PERF_REG_X86_SS
+    PERF_REG_X86_DS
+    PERF_REG_X86_ES
+    PERF_REG_X86_FS
+    PERF_REG_X86_GS
+    PERF_REG_X86_R8
+    PERF_REG_X86_R9
+    PERF_REG_X86_R10
+    PERF_REG_X86_R11
+    PERF_REG_X86_R12
+    PERF_REG_X86_R13
+    PERF_REG_X86_R14
+    PERF_REG_X86_R15
+    PERF_REG_X86_64_MAX
+)
@@ -2,6 +2,7 @@ package module
 
 import (
     "context"
+    "encoding/binary"
     "errors"
     "fmt"
     "log"
@@ -10,9 +11,13 @@ import (
     "stackplz/user/config"
     "stackplz/user/event"
     "stackplz/user/event_processor"
+    "sync/atomic"
 
     "github.com/cilium/ebpf"
     "github.com/cilium/ebpf/perf"
+    "github.com/cilium/ebpf/ringbuf"
+    manager "github.com/ehids/ebpfmanager"
+    "golang.org/x/sys/unix"
 )
 
 type IModule interface {
@@ -66,6 +71,7 @@ type Module struct {
 
     processor *event_processor.EventProcessor
 
+    // perf 读取和 ringbuf 丢失检查都会累加 通过 atomic 访问
     TotalLost uint64
 }
 
@@ -74,7 +80,7 @@ func (this *Module) Init(ctx context.Context, logger *log.Logger, conf config.IC
     this.ctx = ctx
     this.logger = logger
     this.sconf = conf.GetSConfig()
-    this.processor = event_processor.NewEventProcessor(logger)
+    this.processor = event_processor.NewEventProcessor(logger, conf)
 
 }
 
@@ -164,8 +170,27 @@ func (this *Module) readEvents() error {
     // 读取之前从eBPF程序中解析预设的map的事件数据
     for _, ebpfMap := range this.child.Events() {
         switch {
+        case ebpfMap.Type() == ebpf.PerfEventArray && this.mType == PROBE_TYPE_BREAKPOINT:
+            // 每个断点/观察点单独一个 perf event
+            for _, brk := range this.sconf.Brks {
+                err := this.perfEventReader(errChan, ebpfMap, this.getBrkExtraOptions(ebpfMap, brk))
+                if errors.Is(err, unix.ENOSPC) {
+                    return fmt.Errorf("%s\tno free hardware slot for %s, the cpu only has a few breakpoint/watchpoint registers", this.child.Name(), brk.String())
+                }
+                if err != nil {
+                    return fmt.Errorf("%s\tset %s failed, err:%v", this.child.Name(), brk.String(), err)
+                }
+            }
         case ebpfMap.Type() == ebpf.PerfEventArray:
-            this.perfEventReader(errChan, ebpfMap)
+            err := this.perfEventReader(errChan, ebpfMap, this.getExtraOptions(ebpfMap))
+            if err != nil {
+                errChan <- err
+            }
+        case ebpfMap.Type() == ebpf.RingBuf:
+            err := this.ringBufReader(errChan, ebpfMap, this.getExtraOptions(ebpfMap))
+            if err != nil {
+                errChan <- err
+            }
         default:
             return fmt.Errorf("%s\tNot support mapType:%s , mapinfo:%s", this.child.Name(), ebpfMap.Type().String(), ebpfMap.String())
         }
@@ -182,7 +207,11 @@ func (this *Module) getExtraOptions(em *ebpf.Map) perf.ExtraPerfOptions {
 
     // http://aospxref.com/android-11.0.0_r21/xref/system/extras/simpleperf/perf_regs.cpp#82
     var RegMask uint64
-    if this.sconf.Is32Bit {
+    if config.GetArch().Name == config.ARCH_X86_64 {
+        // 64 位进程不能采样 ds es fs gs 指定了会导致 perf_event_open 失败
+        RegMask = (1 << PERF_REG_X86_64_MAX) - 1
+        RegMask &^= 1<<PERF_REG_X86_DS | 1<<PERF_REG_X86_ES | 1<<PERF_REG_X86_FS | 1<<PERF_REG_X86_GS
+    } else if this.sconf.Is32Bit {
         RegMask = (1 << PERF_REG_ARM_MAX) - 1
     } else {
         RegMask = (1 << PERF_REG_ARM64_MAX) - 1
@@ -198,31 +227,48 @@ func (this *Module) getExtraOptions(em *ebpf.Map) perf.ExtraPerfOptions {
         UnwindStack:       this.sconf.UnwindStack,
         ShowRegs:          ShowRegs,
         PerfMmap:          IsMmapEvent,
-        BrkAddr:           this.sconf.BrkAddr,
-        BrkType:           this.sconf.BrkType,
         Sample_regs_user:  RegMask,
         Sample_stack_user: this.sconf.StackSize,
     }
 }
 
+func (this *Module) getBrkExtraOptions(em *ebpf.Map, brk *config.BrkConfig) perf.ExtraPerfOptions {
+    eopt := this.getExtraOptions(em)
+    eopt.BrkAddr = brk.Addr
+    eopt.BrkType = brk.Type
+    eopt.BrkLen = brk.Len
+    return eopt
+}
+
 func (this *Module) getPerCPUBuffer() int {
     return os.Getpagesize() * (int(this.sconf.Buffer) * 1024 / 4)
 }
 
-func (this *Module) perfEventReader(errChan chan error, em *ebpf.Map) {
+// 每个 eBPF 程序中都有 ring_events 不使用 ringbuf 时缩小到一页 避免白白占用内存
+func (this *Module) getRingBufEditors(use_ringbuf bool) map[string]manager.MapSpecEditor {
+    size := uint32(os.Getpagesize())
+    if use_ringbuf {
+        size = this.sconf.RingBufSize * 1024 * 1024
+    }
+    return map[string]manager.MapSpecEditor{
+        "ring_events": {
+            MaxEntries: size,
+            EditorFlag: manager.EditMaxEntries,
+        },
+    }
+}
+
+func (this *Module) perfEventReader(errChan chan error, em *ebpf.Map, eopt perf.ExtraPerfOptions) error {
     // 这里对原ebpf包代码做了修改 以此控制是否让内核发生栈空间数据和寄存器数据
     // 用于进行堆栈回溯 以后可以细分栈数据与寄存器数据
     // 每个 模块都是 Clone 得到的 map 虽然名字相同 但是 fd不同 所以可以正常区分
 
-    eopt := this.getExtraOptions(em)
-
     var rd *perf.Reader
     var err error
 
     rd, err = perf.NewReaderWithOptions(em, this.getPerCPUBuffer(), perf.ReaderOptions{}, eopt)
     if err != nil {
-        errChan <- fmt.Errorf("creating %s reader dns: %s", em.String(), err)
-        return
+        return fmt.Errorf("creating %s reader dns: %w", em.String(), err)
     }
     // 可能存在多种类型的reader 添加到reader列表 异常时便于一起安全关闭
     this.reader = append(this.reader, rd)
@@ -247,23 +293,74 @@ func (this *Module) perfEventReader(errChan chan error, em *ebpf.Map) {
             }
 
             if record.LostSamples != 0 {
-                this.TotalLost += record.LostSamples
+                atomic.AddUint64(&this.TotalLost, record.LostSamples)
                 this.logger.Printf("%s\tperf event ring buffer full, dropped %d samples, record_type:%d", this.child.Name(), record.LostSamples, record.RecordType)
                 continue
             }
 
-            // 只做简单的准备 数据解析不要在这个部分做
-            var e event.IEventStruct
-            e, err = this.child.PrePare(em, record)
+            this.handleRecord(em, &eopt, record)
+        }
+    }()
+    return nil
+}
+
+func (this *Module) ringBufReader(errChan chan error, em *ebpf.Map, eopt perf.ExtraPerfOptions) error {
+    // 所有 CPU 共用一个缓冲区 读取顺序即提交顺序 缓冲区满时在 eBPF 中提交失败
+    // 读取到的记录里没有丢失的信息 丢失数量由模块定期从 ringbuf_lost 中检查
+    rd, err := ringbuf.NewReader(em)
+    if err != nil {
+        return fmt.Errorf("creating %s reader: %w", em.String(), err)
+    }
+    this.reader = append(this.reader, rd)
+    go func() {
+        for {
+            select {
+            case _ = <-this.ctx.Done():
+                this.logger.Printf("%s\tringBufReader received close signal from context.Done().", this.child.Name())
+                return
+            default:
+            }
+
+            rb_record, err := rd.Read()
             if err != nil {
-                this.logger.Printf("%s\tthis.child.decode error:%v", this.child.Name(), err)
-                continue
+                if errors.Is(err, ringbuf.ErrClosed) {
+                    return
+                }
+                errChan <- fmt.Errorf("%s\treading from ringbuf reader: %s", this.child.Name(), err)
+                return
+            }
+
+            // 补上 PERF_SAMPLE_RAW 的 size 与 perf 读到的 RawSample 格式一致 之后的解析和 trace 文件都不用区分
+            record := perf.Record{
+                RecordType:   unix.PERF_RECORD_SAMPLE,
+                RawSample:    make([]byte, 4+len(rb_record.RawSample)),
+                ExtraOptions: &eopt,
             }
-            // 准备完成将数据交给 processor 处理
-            // 从而加快读取环形缓冲区的数据 减缓数据丢失的概率
-            this.processor.Write(e)
+            binary.LittleEndian.PutUint32(record.RawSample, uint32(len(rb_record.RawSample)))
+            copy(record.RawSample[4:], rb_record.RawSample)
+            this.handleRecord(em, &eopt, record)
         }
     }()
+    return nil
+}
+
+func (this *Module) handleRecord(em *ebpf.Map, eopt *perf.ExtraPerfOptions, record perf.Record) {
+    if trace_writer != nil {
+        err := trace_writer.WriteRecord(eopt, &record)
+        if err != nil {
+            this.logger.Printf("%s\twrite trace record failed, err:%v", this.child.Name(), err)
+        }
+    }
+
+    // 只做简单的准备 数据解析不要在这个部分做
+    e, err := this.child.PrePare(em, record)
+    if err != nil {
+        this.logger.Printf("%s\tthis.child.decode error:%v", this.child.Name(), err)
+        return
+    }
+    // 准备完成将数据交给 processor 处理
+    // 从而加快读取环形缓冲区的数据 减缓数据丢失的概率
+    this.processor.Write(e)
 }
 
 func (this *Module) PrePare(em *ebpf.Map, rec perf.Record) (event event.IEventStruct, err error) {
@@ -281,7 +378,7 @@ func (this *Module) PrePare(em *ebpf.Map, rec perf.Record) (event event.IEventSt
 }
 
 func (this *Module) Close() error {
-    this.logger.Printf("TotalLost => %d\n", this.TotalLost)
+    this.logger.Printf("TotalLost => %d\n", atomic.LoadUint64(&this.TotalLost))
     if this.sconf.Debug {
         this.logger.Printf("%s\tClose", this.child.Name())
     }
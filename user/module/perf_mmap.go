@@ -95,6 +95,7 @@ func (this *PerfMMAP) start() error {
 		return err
 	}
 	this.setupManagerOptions()
+	this.bpfManagerOptions.MapSpecEditors = this.getRingBufEditors(false)
 
 	var bpfFileName = filepath.Join("user/assets", this.hookBpfFile)
 	byteBuf, err := assets.Asset(bpfFileName)
@@ -12,14 +12,25 @@ import (
     "stackplz/user/config"
     "stackplz/user/event"
     "stackplz/user/util"
+    "sync"
+    "sync/atomic"
+    "time"
     "unsafe"
 
     "github.com/cilium/ebpf"
+    "github.com/cilium/ebpf/asm"
     "github.com/cilium/ebpf/btf"
+    "github.com/cilium/ebpf/features"
+    "github.com/cilium/ebpf/link"
     manager "github.com/ehids/ebpfmanager"
     "golang.org/x/sys/unix"
 )
 
+type libLoad struct {
+    pid      uint32
+    lib_path string
+}
+
 type MStack struct {
     Module
     mconf             *config.ModuleConfig
@@ -29,6 +40,17 @@ type MStack struct {
     eventMaps         []*ebpf.Map
 
     hookBpfFile string
+    // 库加载的通知先放进队列 由单独的 goroutine 挂载 不阻塞事件的读取
+    lazyLock   sync.Mutex
+    lazyQueue  []libLoad
+    lazyNotify chan struct{}
+    // 已经提示过的 ringbuf 丢失数量
+    lostLock    sync.Mutex
+    ringbufLost uint64
+    // 支持 attach cookie 时 hook 点不经过 bpfManager 直接挂载
+    attachCookie bool
+    linkLock     sync.Mutex
+    uprobeLinks  []link.Link
 }
 
 func (this *MStack) Init(ctx context.Context, logger *log.Logger, conf config.IConfig) error {
@@ -67,36 +89,6 @@ func (this *MStack) setupManager() error {
     }
     probes = append(probes, fork_probe)
 
-    for i, uprobe_point := range this.mconf.StackUprobeConf.Points {
-        // stack hook 配置
-        sym := uprobe_point.Symbol
-        var stack_probe *manager.Probe
-        if sym == "" {
-            sym = util.RandStringBytes(8)
-            stack_probe = &manager.Probe{
-                Section:          fmt.Sprintf("uprobe/stack_%d", i),
-                EbpfFuncName:     fmt.Sprintf("probe_stack_%d", i),
-                AttachToFuncName: sym,
-                BinaryPath:       uprobe_point.LibPath,
-                // 这个是相对于库文件基址的偏移
-                UAddress: uprobe_point.Offset,
-            }
-        } else {
-            stack_probe = &manager.Probe{
-                Section:          fmt.Sprintf("uprobe/stack_%d", i),
-                EbpfFuncName:     fmt.Sprintf("probe_stack_%d", i),
-                AttachToFuncName: sym,
-                BinaryPath:       uprobe_point.LibPath,
-                // 这个是相对于符号的偏移
-                UprobeOffset: uprobe_point.Offset,
-            }
-        }
-        if this.mconf.Debug {
-            this.logger.Printf("uprobe uprobe_index:%d hook %s", i, uprobe_point.String())
-        }
-        probes = append(probes, stack_probe)
-    }
-
     if this.mconf.SysCallConf.IsEnable() {
         if this.mconf.Debug {
             this.logger.Printf("Syscall:%s", this.mconf.SysCallConf.Info())
@@ -112,6 +104,16 @@ func (this *MStack) setupManager() error {
         }
         probes = append(probes, sys_enter_probe)
         probes = append(probes, sys_exit_probe)
+        // 规则中的 errno 动作通过 kprobe 修改返回值
+        for _, func_name := range this.mconf.GetRevErrnoFuncs() {
+            rev_probe := &manager.Probe{
+                Section:          "kprobe/rev_override_return",
+                EbpfFuncName:     "rev_override_return",
+                AttachToFuncName: func_name,
+                UID:              func_name,
+            }
+            probes = append(probes, rev_probe)
+        }
     }
 
     this.bpfManager = &manager.Manager{
@@ -121,6 +123,214 @@ func (this *MStack) setupManager() error {
     return nil
 }
 
+func (this *MStack) newUprobe(index int, uprobe_point config.UprobeArgs) *manager.Probe {
+    // stack hook 配置
+    sym := uprobe_point.Symbol
+    var stack_probe *manager.Probe
+    if sym == "" {
+        sym = util.RandStringBytes(8)
+        stack_probe = &manager.Probe{
+            Section:          "uprobe/stack",
+            EbpfFuncName:     "probe_stack",
+            AttachToFuncName: sym,
+            BinaryPath:       uprobe_point.LibPath,
+            // 这个是相对于库文件基址的偏移
+            UAddress: uprobe_point.Offset,
+        }
+    } else {
+        stack_probe = &manager.Probe{
+            Section:          "uprobe/stack",
+            EbpfFuncName:     "probe_stack",
+            AttachToFuncName: sym,
+            BinaryPath:       uprobe_point.LibPath,
+            // 这个是相对于符号的偏移
+            UprobeOffset: uprobe_point.Offset,
+        }
+    }
+    stack_probe.UID = fmt.Sprintf("stack_%d", index)
+    if this.mconf.Debug {
+        this.logger.Printf("uprobe uprobe_index:%d hook %s", index, uprobe_point.String())
+    }
+    return stack_probe
+}
+
+// 支持 attach cookie 时所有 hook 点挂载同一个程序 否则为每个 hook 点复制模板程序 并改写其中的 point_index
+// :ret 模式的 hook 点再复制一份 uretprobe 程序 模板本身不对应任何 hook 点 也不挂载
+// 延迟 hook 的点在库被加载时才挂载
+func (this *MStack) attachUprobes() error {
+    for i, uprobe_point := range this.mconf.StackUprobeConf.GetPoints() {
+        if uprobe_point.Deferred {
+            continue
+        }
+        err := this.attachUprobe(i, uprobe_point)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (this *MStack) attachUprobe(i int, uprobe_point config.UprobeArgs) error {
+    if this.attachCookie {
+        return this.attachUprobeCookie(i, uprobe_point)
+    }
+    editors := []manager.ConstantEditor{
+        {
+            Name:          "point_index",
+            Value:         uint64(i),
+            FailOnMissing: true,
+        },
+    }
+    stack_probe := this.newUprobe(i, uprobe_point)
+    err := this.bpfManager.CloneProgram("", stack_probe, editors, nil)
+    if err != nil {
+        return fmt.Errorf("attach uprobe %s failed, err:%v", uprobe_point.String(), err)
+    }
+    if !uprobe_point.RetMode {
+        return nil
+    }
+    ret_probe := this.newUprobe(i, uprobe_point)
+    ret_probe.Section = "uretprobe/stack"
+    ret_probe.EbpfFuncName = "probe_stack_ret"
+    ret_probe.UID = fmt.Sprintf("stack_ret_%d", i)
+    err = this.bpfManager.CloneProgram("", ret_probe, editors, nil)
+    if err != nil {
+        return fmt.Errorf("attach uretprobe %s failed, err:%v", uprobe_point.String(), err)
+    }
+    return nil
+}
+
+// point_index 作为 cookie 在 eBPF 中通过 bpf_get_attach_cookie 获取
+func (this *MStack) attachUprobeCookie(i int, uprobe_point config.UprobeArgs) error {
+    ex, err := link.OpenExecutable(uprobe_point.LibPath)
+    if err != nil {
+        return fmt.Errorf("attach uprobe %s failed, err:%v", uprobe_point.String(), err)
+    }
+    opts := &link.UprobeOptions{Cookie: uint64(i)}
+    if uprobe_point.Symbol == "" {
+        // 这个是相对于库文件基址的偏移
+        opts.Address = uprobe_point.Offset
+    } else {
+        // 这个是相对于符号的偏移
+        opts.Offset = uprobe_point.Offset
+    }
+    if this.mconf.Debug {
+        this.logger.Printf("uprobe uprobe_index:%d hook %s", i, uprobe_point.String())
+    }
+    prog, err := this.FindProgram("probe_stack_cookie")
+    if err != nil {
+        return err
+    }
+    uprobe_link, err := ex.Uprobe(uprobe_point.Symbol, prog, opts)
+    if err != nil {
+        return fmt.Errorf("attach uprobe %s failed, err:%v", uprobe_point.String(), err)
+    }
+    this.addUprobeLink(uprobe_link)
+    if !uprobe_point.RetMode {
+        return nil
+    }
+    prog, err = this.FindProgram("probe_stack_ret_cookie")
+    if err != nil {
+        return err
+    }
+    uprobe_link, err = ex.Uretprobe(uprobe_point.Symbol, prog, opts)
+    if err != nil {
+        return fmt.Errorf("attach uretprobe %s failed, err:%v", uprobe_point.String(), err)
+    }
+    this.addUprobeLink(uprobe_link)
+    return nil
+}
+
+func (this *MStack) addUprobeLink(uprobe_link link.Link) {
+    this.linkLock.Lock()
+    defer this.linkLock.Unlock()
+    this.uprobeLinks = append(this.uprobeLinks, uprobe_link)
+}
+
+func (this *MStack) closeUprobeLinks() {
+    this.linkLock.Lock()
+    defer this.linkLock.Unlock()
+    for _, uprobe_link := range this.uprobeLinks {
+        uprobe_link.Close()
+    }
+    this.uprobeLinks = nil
+}
+
+// 延迟 hook 的库被 mmap 时按实际路径挂载 这里在读取事件的路径上 只做入队
+func (this *MStack) onLibLoad(pid uint32, lib_path string) {
+    this.lazyLock.Lock()
+    this.lazyQueue = append(this.lazyQueue, libLoad{pid, lib_path})
+    this.lazyLock.Unlock()
+    select {
+    case this.lazyNotify <- struct{}{}:
+    default:
+    }
+}
+
+func (this *MStack) lazyAttachLoop() {
+    for {
+        select {
+        case _ = <-this.ctx.Done():
+            return
+        case _ = <-this.lazyNotify:
+        }
+        this.lazyLock.Lock()
+        queue := this.lazyQueue
+        this.lazyQueue = nil
+        this.lazyLock.Unlock()
+        for _, item := range queue {
+            this.attachDeferred(item.pid, item.lib_path)
+        }
+    }
+}
+
+// 只在 lazyAttachLoop 中调用 修改后的 hook 点整体替换发布
+func (this *MStack) attachDeferred(pid uint32, lib_path string) {
+    for i, uprobe_point := range this.mconf.StackUprobeConf.GetPoints() {
+        if !uprobe_point.Deferred || !uprobe_point.MatchLib(lib_path) {
+            continue
+        }
+        // 挂载失败也不再重试 避免每次 mmap 都刷屏
+        uprobe_point.Deferred = false
+        uprobe_point.LibPath = lib_path
+        this.mconf.StackUprobeConf.UpdatePoint(i, uprobe_point)
+        err := this.attachUprobe(i, uprobe_point)
+        if err != nil {
+            this.logger.Printf("%s\t%s loaded by pid %d, but %v", this.Name(), lib_path, pid, err)
+            continue
+        }
+        this.logger.Printf("%s\t%s loaded by pid %d, attach %s", this.Name(), lib_path, pid, uprobe_point.String())
+    }
+}
+
+func (this *MStack) hasDeferredUprobes() bool {
+    for _, uprobe_point := range this.mconf.StackUprobeConf.GetPoints() {
+        if uprobe_point.Deferred {
+            return true
+        }
+    }
+    return false
+}
+
+func (this *MStack) watchDeferredUprobes() {
+    this.lazyNotify = make(chan struct{}, 1)
+    go this.lazyAttachLoop()
+    event.SetLibLoadHook(this.onLibLoad)
+    if this.mconf.Pid == config.MAGIC_PID {
+        return
+    }
+    // 启动前就已经加载了的 直接按 maps 中的路径挂载
+    for _, uprobe_point := range this.mconf.StackUprobeConf.GetPoints() {
+        if !uprobe_point.Deferred {
+            continue
+        }
+        lib_info, err := event.FindLibInMaps(this.mconf.Pid, uprobe_point.LibPath)
+        if err == nil && lib_info.LibPath != "" {
+            this.onLibLoad(this.mconf.Pid, lib_info.LibPath)
+        }
+    }
+}
+
 func (this *MStack) setupManagerOptions() {
     // 对于没有开启 CONFIG_DEBUG_INFO_BTF 的加载额外的 btf.Spec
     if this.mconf.ExternalBTF != "" {
@@ -178,12 +388,28 @@ func (this *MStack) start() error {
         return errors.New("hook nothing")
     }
 
+    // 5.15+ 支持 bpf_get_attach_cookie 所有 hook 点共用一个程序 不需要每个 hook 点复制一份
+    if this.mconf.StackUprobeConf.IsEnable() {
+        this.attachCookie = features.HaveProgramHelper(ebpf.Kprobe, asm.FnGetAttachCookie) == nil
+    }
+
     // 初始化uprobe相关设置
     err := this.setupManager()
     if err != nil {
         return err
     }
     this.setupManagerOptions()
+    this.bpfManagerOptions.MapSpecEditors = this.getRingBufEditors(this.mconf.UseRingBuf())
+    // bpf_override_return 依赖 CONFIG_BPF_KPROBE_OVERRIDE 没有 errno 规则时不加载
+    if this.mconf.SysCallConf.IsEnable() && len(this.mconf.GetRevErrnoFuncs()) == 0 {
+        this.bpfManagerOptions.ExcludedEbpfFuncs = append(this.bpfManagerOptions.ExcludedEbpfFuncs, "rev_override_return")
+    }
+    // 另一组程序不加载 旧内核上 bpf_get_attach_cookie 无法通过校验
+    if this.attachCookie {
+        this.bpfManagerOptions.ExcludedEbpfFuncs = append(this.bpfManagerOptions.ExcludedEbpfFuncs, "probe_stack", "probe_stack_ret")
+    } else {
+        this.bpfManagerOptions.ExcludedEbpfFuncs = append(this.bpfManagerOptions.ExcludedEbpfFuncs, "probe_stack_cookie", "probe_stack_ret_cookie")
+    }
 
     // 从assets中获取eBPF程序的二进制数据
     var bpfFileName = filepath.Join("user/assets", this.hookBpfFile)
@@ -203,17 +429,32 @@ func (this *MStack) start() error {
         return fmt.Errorf("couldn't start bootstrap manager %v .", err)
     }
 
+    err = this.attachUprobes()
+    if err != nil {
+        return err
+    }
+
     // 通过更新 BPF_MAP_TYPE_HASH 类型的 map 实现过滤设定的同步
     err = this.updateFilter()
     if err != nil {
         return err
     }
+    if this.mconf.SysCallConf.IsEnable() {
+        this.seedFdTable()
+    }
+    // hook 点的参数配置已经就绪 之后才能挂载延迟的 hook 点
+    if this.hasDeferredUprobes() {
+        this.watchDeferredUprobes()
+    }
 
     // 加载map信息，设置eventFuncMaps，给不同的事件指定处理事件数据的函数
     err = this.initDecodeFun()
     if err != nil {
         return err
     }
+    if this.mconf.UseRingBuf() {
+        go this.watchRingBufLost()
+    }
 
     return nil
 }
@@ -247,15 +488,37 @@ func (this *MStack) updateFilter() (err error) {
     if this.sconf.Debug {
         this.logger.Printf("update common_filter success")
     }
-    if this.mconf.Pid != config.MAGIC_PID {
-        // 更新 child_parent_map
+    // 更新 uid/pid/tid 集合
+    watch_uid_map, err := this.FindMap("watch_uid_map")
+    if err != nil {
+        return err
+    }
+    watch_proc_map, err := this.FindMap("watch_proc_map")
+    if err != nil {
+        return err
+    }
+    watch_tid_map, err := this.FindMap("watch_tid_map")
+    if err != nil {
+        return err
+    }
+    err = this.mconf.UpdateWatchFilter(watch_uid_map, watch_proc_map, watch_tid_map)
+    if err != nil {
+        return err
+    }
+    if this.sconf.Debug {
+        this.logger.Printf("update watch filter success")
+    }
+    if len(this.mconf.Pids) > 0 {
+        // 更新 child_parent_map 指定的 pid 产生的子进程同样追踪
         child_parent_map, err := this.FindMap("child_parent_map")
         if err != nil {
             return err
         }
-        err = child_parent_map.Update(unsafe.Pointer(&this.mconf.Pid), unsafe.Pointer(&this.mconf.Pid), ebpf.UpdateAny)
-        if err != nil {
-            return err
+        for _, pid := range this.mconf.Pids {
+            err = child_parent_map.Update(unsafe.Pointer(&pid), unsafe.Pointer(&pid), ebpf.UpdateAny)
+            if err != nil {
+                return err
+            }
         }
         if this.sconf.Debug {
             this.logger.Printf("update child_parent_map success")
@@ -276,7 +539,11 @@ func (this *MStack) updateFilter() (err error) {
     if err != nil {
         return err
     }
-    err = this.mconf.UpdateRevFilter(rev_filter)
+    rev_action, err := this.FindMap("rev_action")
+    if err != nil {
+        return err
+    }
+    err = this.mconf.UpdateRevFilter(rev_filter, rev_action)
     if err != nil {
         return err
     }
@@ -314,6 +581,7 @@ func (this *MStack) updateFilter() (err error) {
             return err
         }
         filter := this.mconf.SysCallConf.GetSyscallFilter()
+        filter.SetRevMode(len(this.mconf.RevRules) > 0)
         err = syscall_filter.Update(unsafe.Pointer(&filter_key), unsafe.Pointer(&filter), ebpf.UpdateAny)
         if err != nil {
             return err
@@ -338,6 +606,14 @@ func (this *MStack) updateFilter() (err error) {
         if err != nil {
             return err
         }
+        sys_arg_filter, err := this.FindMap("sys_arg_filter")
+        if err != nil {
+            return err
+        }
+        err = this.mconf.SysCallConf.UpdateArgFilterMap(sys_arg_filter)
+        if err != nil {
+            return err
+        }
 
     }
     return nil
@@ -345,20 +621,20 @@ func (this *MStack) updateFilter() (err error) {
 
 func (this *MStack) initDecodeFun() error {
 
-    CommonEventsMap, err := this.FindMap("events")
-    if err != nil {
-        return err
+    // 不需要堆栈和寄存器数据时 事件通过 ringbuf 提交
+    events_name := "events"
+    if this.mconf.UseRingBuf() {
+        events_name = "ring_events"
     }
-    this.eventMaps = append(this.eventMaps, CommonEventsMap)
-    commonEvent := &event.CommonEvent{}
-    commonEvent.SetConf(this.mconf)
-    this.eventFuncMaps[CommonEventsMap] = commonEvent
-
-    EventsMap, err := this.FindMap("events")
+    EventsMap, err := this.FindMap(events_name)
     if err != nil {
         return err
     }
+    // 同一个 map 只能有一个 reader
     this.eventMaps = append(this.eventMaps, EventsMap)
+    commonEvent := &event.CommonEvent{}
+    commonEvent.SetConf(this.mconf)
+    this.eventFuncMaps[EventsMap] = commonEvent
     // 根据设置添加 map 不然即使不使用的map也会创建缓冲区
     if this.mconf.StackUprobeConf.IsEnable() {
         uprobestackEvent := &event.UprobeEvent{}
@@ -384,6 +660,119 @@ func (this *MStack) FindMap(map_name string) (*ebpf.Map, error) {
     return em, err
 }
 
+func (this *MStack) FindProgram(prog_name string) (*ebpf.Program, error) {
+    progs, found, err := this.bpfManager.GetProgram(manager.ProbeIdentificationPair{EbpfFuncName: prog_name})
+    if err != nil {
+        return nil, err
+    }
+    if !found || len(progs) == 0 || progs[0] == nil {
+        return nil, errors.New(fmt.Sprintf("cannot find program:%s", prog_name))
+    }
+    return progs[0], nil
+}
+
+// --spawn 期间 pid fork 出的新进程从 fork 开始追踪 mode 为 config.SPAWN_CHILD_*
+func (this *MStack) TrackSpawnParent(pid uint32, mode uint32) error {
+    spawn_parent_map, err := this.FindMap("spawn_parent_map")
+    if err != nil {
+        return err
+    }
+    return spawn_parent_map.Update(unsafe.Pointer(&pid), unsafe.Pointer(&mode), ebpf.UpdateAny)
+}
+
+func (this *MStack) UntrackSpawnParent(pid uint32) error {
+    spawn_parent_map, err := this.FindMap("spawn_parent_map")
+    if err != nil {
+        return err
+    }
+    return spawn_parent_map.Delete(unsafe.Pointer(&pid))
+}
+
+// 过滤规则生效之后从 /proc 初始化目标进程的 fd 表 之后的变化通过 syscall 跟踪
+// uid 模式下是这些 uid 已经在运行的进程 之后启动的进程从 fork 时复制父进程的表
+func (this *MStack) seedFdTable() {
+    pids := append([]uint32{}, this.mconf.Pids...)
+    for pid := range util.ListPidsByUids(this.mconf.Uids) {
+        pids = append(pids, pid)
+    }
+    event.SeedFdTable(pids)
+}
+
+// 运行期间新匹配到的目标进程
+func (this *MStack) WatchPid(pid uint32) error {
+    watch_proc_map, err := this.FindMap("watch_proc_map")
+    if err != nil {
+        return err
+    }
+    var flag uint32 = 1
+    err = watch_proc_map.Update(unsafe.Pointer(&pid), unsafe.Pointer(&flag), ebpf.UpdateAny)
+    if err != nil {
+        return err
+    }
+    if this.mconf.SysCallConf.IsEnable() {
+        event.SeedFdTable([]uint32{pid})
+    }
+    return nil
+}
+
+func (this *MStack) UnwatchPid(pid uint32) error {
+    watch_proc_map, err := this.FindMap("watch_proc_map")
+    if err != nil {
+        return err
+    }
+    return watch_proc_map.Delete(unsafe.Pointer(&pid))
+}
+
+const RINGBUF_LOST_CHECK_INTERVAL = time.Second
+
+// ringbuf 满了的时候是在 eBPF 中提交失败 丢失的数量记录在 ringbuf_lost 中
+// 和 perf 的 LostSamples 一样 出现新的丢失就提示
+func (this *MStack) checkRingBufLost() {
+    this.lostLock.Lock()
+    defer this.lostLock.Unlock()
+    ringbuf_lost, err := this.FindMap("ringbuf_lost")
+    if err != nil {
+        return
+    }
+    var key uint32 = 0
+    var values []uint64
+    err = ringbuf_lost.Lookup(&key, &values)
+    if err != nil {
+        return
+    }
+    var lost uint64
+    for _, value := range values {
+        lost += value
+    }
+    if lost <= this.ringbufLost {
+        return
+    }
+    atomic.AddUint64(&this.TotalLost, lost-this.ringbufLost)
+    this.logger.Printf("%s\tringbuf full, dropped %d samples", this.Name(), lost-this.ringbufLost)
+    this.ringbufLost = lost
+}
+
+func (this *MStack) watchRingBufLost() {
+    ticker := time.NewTicker(RINGBUF_LOST_CHECK_INTERVAL)
+    defer ticker.Stop()
+    for {
+        select {
+        case _ = <-this.ctx.Done():
+            return
+        case _ = <-ticker.C:
+            this.checkRingBufLost()
+        }
+    }
+}
+
+func (this *MStack) Close() error {
+    if this.mconf.UseRingBuf() && this.bpfManager != nil {
+        this.checkRingBufLost()
+    }
+    this.closeUprobeLinks()
+    return this.Module.Close()
+}
+
 func (this *MStack) Events() []*ebpf.Map {
     return this.eventMaps
 }
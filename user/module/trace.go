@@ -0,0 +1,285 @@
+package module
+
+import (
+    "bufio"
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "stackplz/user/config"
+    "stackplz/user/event"
+    "stackplz/user/util"
+    "sync"
+
+    "github.com/cilium/ebpf/perf"
+    "golang.org/x/sys/unix"
+)
+
+// trace 文件格式
+// magic(8) | version(4) | config_len(4) | config(json)
+// 之后是若干条记录 每条记录以 1 字节类型开头
+// TRACE_ENTRY_RECORD: flags(1) | cpu(4) | record_type(4) | raw_len(4) | raw
+// TRACE_ENTRY_MAPS:   pid(4) | ts(8) | maps_len(4) | maps
+// maps 快照的 ts 与事件的 Ts 同为 CLOCK_MONOTONIC 回放时按时间选择对应的版本
+
+const TRACE_MAGIC = "STACKPLZ"
+const TRACE_VERSION uint32 = 2
+
+const (
+    TRACE_ENTRY_RECORD uint8 = iota + 1
+    TRACE_ENTRY_MAPS
+)
+
+const (
+    TRACE_FLAG_UNWIND_STACK uint8 = 1 << iota
+    TRACE_FLAG_SHOW_REGS
+    TRACE_FLAG_PERF_MMAP
+)
+
+type TraceEntry struct {
+    Kind   uint8
+    Record perf.Record
+    Pid    uint32
+    Ts     uint64
+    Maps   string
+}
+
+type TraceWriter struct {
+    sync.Mutex
+    f *os.File
+    w *bufio.Writer
+    // 第一次写入失败的错误 之后的写入直接丢弃 Close 时再次返回
+    err error
+}
+
+func NewTraceWriter(path string, conf *config.ModuleConfig) (*TraceWriter, error) {
+    conf_data, err := json.Marshal(conf)
+    if err != nil {
+        return nil, err
+    }
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    writer := &TraceWriter{f: f, w: bufio.NewWriterSize(f, 1024*1024)}
+    err = writer.write(TRACE_MAGIC, TRACE_VERSION, uint32(len(conf_data)), conf_data)
+    if err != nil {
+        f.Close()
+        return nil, err
+    }
+    return writer, nil
+}
+
+// 依次写入各个字段 任意一个失败都返回错误 避免磁盘写满时得到截断的 trace 文件
+func (this *TraceWriter) write(fields ...interface{}) (err error) {
+    if this.err != nil {
+        return nil
+    }
+    defer func() {
+        this.err = err
+    }()
+    for _, field := range fields {
+        switch v := field.(type) {
+        case string:
+            _, err = this.w.WriteString(v)
+        case []byte:
+            _, err = this.w.Write(v)
+        default:
+            err = binary.Write(this.w, binary.LittleEndian, v)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (this *TraceWriter) WriteRecord(eopt *perf.ExtraPerfOptions, rec *perf.Record) error {
+    var flags uint8
+    if eopt.UnwindStack {
+        flags |= TRACE_FLAG_UNWIND_STACK
+    }
+    if eopt.ShowRegs {
+        flags |= TRACE_FLAG_SHOW_REGS
+    }
+    if eopt.PerfMmap {
+        flags |= TRACE_FLAG_PERF_MMAP
+    }
+    this.Lock()
+    defer this.Unlock()
+    return this.write(TRACE_ENTRY_RECORD, flags, uint32(rec.CPU), rec.RecordType, uint32(len(rec.RawSample)), rec.RawSample)
+}
+
+func (this *TraceWriter) WriteMaps(pid uint32, ts uint64, content string) error {
+    this.Lock()
+    defer this.Unlock()
+    return this.write(TRACE_ENTRY_MAPS, pid, ts, uint32(len(content)), content)
+}
+
+func (this *TraceWriter) SnapshotMaps(pid uint32) error {
+    var ts unix.Timespec
+    if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+        return err
+    }
+    content, err := util.ReadMapsByPid(pid)
+    if err != nil {
+        // 进程可能已经退出了
+        return err
+    }
+    return this.WriteMaps(pid, uint64(ts.Nano()), content)
+}
+
+func (this *TraceWriter) Close() error {
+    this.Lock()
+    defer this.Unlock()
+    if this.err == nil {
+        this.err = this.w.Flush()
+    }
+    if err := this.f.Close(); err != nil && this.err == nil {
+        this.err = err
+    }
+    return this.err
+}
+
+type TraceReader struct {
+    f       *os.File
+    r       *bufio.Reader
+    Version uint32
+    Conf    *config.ModuleConfig
+}
+
+func OpenTraceReader(path string) (*TraceReader, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    reader := &TraceReader{f: f}
+    err = reader.Rewind()
+    if err != nil {
+        f.Close()
+        return nil, err
+    }
+    return reader, nil
+}
+
+// 回到第一条记录 同时重新解析文件头
+func (this *TraceReader) Rewind() (err error) {
+    if _, err = this.f.Seek(0, io.SeekStart); err != nil {
+        return err
+    }
+    this.r = bufio.NewReaderSize(this.f, 1024*1024)
+    magic := make([]byte, len(TRACE_MAGIC))
+    if _, err = io.ReadFull(this.r, magic); err != nil {
+        return err
+    }
+    if string(magic) != TRACE_MAGIC {
+        return errors.New("not a stackplz trace file")
+    }
+    if err = binary.Read(this.r, binary.LittleEndian, &this.Version); err != nil {
+        return err
+    }
+    if this.Version != TRACE_VERSION {
+        return errors.New(fmt.Sprintf("unsupported trace version %d, current version is %d", this.Version, TRACE_VERSION))
+    }
+    var conf_len uint32
+    if err = binary.Read(this.r, binary.LittleEndian, &conf_len); err != nil {
+        return err
+    }
+    conf_data := make([]byte, conf_len)
+    if _, err = io.ReadFull(this.r, conf_data); err != nil {
+        return err
+    }
+    this.Conf = config.NewModuleConfig()
+    return json.Unmarshal(conf_data, this.Conf)
+}
+
+// 读取到结尾时返回 io.EOF
+func (this *TraceReader) Next() (*TraceEntry, error) {
+    kind, err := this.r.ReadByte()
+    if err != nil {
+        return nil, err
+    }
+    entry := &TraceEntry{Kind: kind}
+    switch kind {
+    case TRACE_ENTRY_RECORD:
+        var flags uint8
+        var cpu uint32
+        var raw_len uint32
+        if flags, err = this.r.ReadByte(); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        if err = binary.Read(this.r, binary.LittleEndian, &cpu); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        if err = binary.Read(this.r, binary.LittleEndian, &entry.Record.RecordType); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        if err = binary.Read(this.r, binary.LittleEndian, &raw_len); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        entry.Record.CPU = int(cpu)
+        entry.Record.RawSample = make([]byte, raw_len)
+        if _, err = io.ReadFull(this.r, entry.Record.RawSample); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        entry.Record.ExtraOptions = &perf.ExtraPerfOptions{
+            UnwindStack: flags&TRACE_FLAG_UNWIND_STACK != 0,
+            ShowRegs:    flags&TRACE_FLAG_SHOW_REGS != 0,
+            PerfMmap:    flags&TRACE_FLAG_PERF_MMAP != 0,
+        }
+    case TRACE_ENTRY_MAPS:
+        var maps_len uint32
+        if err = binary.Read(this.r, binary.LittleEndian, &entry.Pid); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        if err = binary.Read(this.r, binary.LittleEndian, &entry.Ts); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        if err = binary.Read(this.r, binary.LittleEndian, &maps_len); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        content := make([]byte, maps_len)
+        if _, err = io.ReadFull(this.r, content); err != nil {
+            return nil, io.ErrUnexpectedEOF
+        }
+        entry.Maps = string(content)
+    default:
+        return nil, errors.New(fmt.Sprintf("unknown trace entry kind:%d", kind))
+    }
+    return entry, nil
+}
+
+func (this *TraceReader) Close() error {
+    return this.f.Close()
+}
+
+var trace_writer *TraceWriter
+
+// 开启 record 模式 原始 record 和 maps 快照都写入 trace 文件
+func StartRecord(path string, conf *config.ModuleConfig) (err error) {
+    trace_writer, err = NewTraceWriter(path, conf)
+    if err != nil {
+        return err
+    }
+    writer := trace_writer
+    event.SetNewPidHook(func(pid uint32) {
+        writer.SnapshotMaps(pid)
+    })
+    return nil
+}
+
+func StopRecord() error {
+    if trace_writer == nil {
+        return nil
+    }
+    event.SetNewPidHook(nil)
+    // 结束前再记录一次 这样回放时可以拿到运行过程中新加载的库
+    for _, pid := range event.GetPidList() {
+        trace_writer.SnapshotMaps(pid)
+    }
+    err := trace_writer.Close()
+    trace_writer = nil
+    return err
+}
@@ -0,0 +1,176 @@
+package module
+
+import (
+    "bytes"
+    "encoding/binary"
+    "io"
+    "path/filepath"
+    "stackplz/user/config"
+    "stackplz/user/event"
+    "testing"
+
+    "github.com/cilium/ebpf/perf"
+    "golang.org/x/sys/unix"
+)
+
+// PERF_RECORD_MMAP2 的 RawSample 文件名之后带上 sample_id(tid, time)
+func newMmap2Raw(pid uint32, addr uint64, filename string, ts uint64) []byte {
+    var buf bytes.Buffer
+    binary.Write(&buf, binary.LittleEndian, pid)
+    binary.Write(&buf, binary.LittleEndian, pid)
+    binary.Write(&buf, binary.LittleEndian, addr)
+    binary.Write(&buf, binary.LittleEndian, uint64(0x1000))
+    binary.Write(&buf, binary.LittleEndian, uint64(0))
+    binary.Write(&buf, binary.LittleEndian, uint32(0xfd))
+    binary.Write(&buf, binary.LittleEndian, uint32(0))
+    binary.Write(&buf, binary.LittleEndian, uint64(3))
+    binary.Write(&buf, binary.LittleEndian, uint64(0))
+    binary.Write(&buf, binary.LittleEndian, uint32(unix.PROT_READ|unix.PROT_EXEC))
+    binary.Write(&buf, binary.LittleEndian, uint32(unix.MAP_PRIVATE))
+    name := make([]byte, (len(filename)+8)&^7)
+    copy(name, filename)
+    buf.Write(name)
+    binary.Write(&buf, binary.LittleEndian, pid)
+    binary.Write(&buf, binary.LittleEndian, pid)
+    binary.Write(&buf, binary.LittleEndian, ts)
+    return buf.Bytes()
+}
+
+func TestTraceRoundTrip(t *testing.T) {
+    const pid = 4242
+    path := filepath.Join(t.TempDir(), "tmp.trace")
+    conf := config.NewModuleConfig()
+    conf.Arch = config.ARCH_ARM64
+    conf.Pid = pid
+    writer, err := NewTraceWriter(path, conf)
+    if err != nil {
+        t.Fatal(err)
+    }
+    first := "00001000-00002000 r-xp 00000000 fd:00 1 /system/lib64/liba.so\n"
+    second := first + "00005000-00006000 r-xp 00000000 fd:00 2 /system/lib64/libb.so\n"
+    eopt := &perf.ExtraPerfOptions{PerfMmap: true}
+    rec := &perf.Record{CPU: 3, RecordType: unix.PERF_RECORD_MMAP2, RawSample: newMmap2Raw(pid, 0x9000, "/system/lib64/libc.so", 300)}
+    if err = writer.WriteMaps(pid, 100, first); err != nil {
+        t.Fatal(err)
+    }
+    if err = writer.WriteRecord(eopt, rec); err != nil {
+        t.Fatal(err)
+    }
+    if err = writer.WriteMaps(pid, 200, second); err != nil {
+        t.Fatal(err)
+    }
+    if err = writer.Close(); err != nil {
+        t.Fatal(err)
+    }
+
+    reader, err := OpenTraceReader(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer reader.Close()
+    if reader.Conf.Arch != config.ARCH_ARM64 || reader.Conf.Pid != pid {
+        t.Fatalf("conf = %s %d, want %s %d", reader.Conf.Arch, reader.Conf.Pid, config.ARCH_ARM64, pid)
+    }
+    var entries []*TraceEntry
+    for {
+        entry, err := reader.Next()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            t.Fatal(err)
+        }
+        entries = append(entries, entry)
+    }
+    if len(entries) != 3 {
+        t.Fatalf("entries = %d, want 3", len(entries))
+    }
+    if entries[0].Kind != TRACE_ENTRY_MAPS || entries[0].Ts != 100 || entries[0].Maps != first {
+        t.Fatalf("unexpected first entry %+v", entries[0])
+    }
+    if entries[2].Kind != TRACE_ENTRY_MAPS || entries[2].Ts != 200 || entries[2].Maps != second {
+        t.Fatalf("unexpected last entry %+v", entries[2])
+    }
+    replayed := entries[1]
+    if replayed.Kind != TRACE_ENTRY_RECORD || replayed.Record.CPU != rec.CPU || replayed.Record.RecordType != rec.RecordType {
+        t.Fatalf("unexpected record entry %+v", replayed)
+    }
+    if !bytes.Equal(replayed.Record.RawSample, rec.RawSample) || !replayed.Record.ExtraOptions.PerfMmap {
+        t.Fatal("record changed after round trip")
+    }
+
+    // 与 replay 命令相同 先加载快照再解析记录
+    for _, entry := range entries {
+        if entry.Kind == TRACE_ENTRY_MAPS {
+            event.AddMapsSnapshot(entry.Pid, entry.Ts, entry.Maps)
+        }
+    }
+    var e event.IEventStruct = &event.CommonEvent{}
+    e.SetConf(reader.Conf)
+    e.SetRecord(replayed.Record)
+    parsed, err := e.ParseEvent()
+    if err != nil {
+        t.Fatal(err)
+    }
+    mmap2_event, ok := parsed.(*event.Mmap2Event)
+    if !ok {
+        t.Fatalf("parsed event is %T, want *event.Mmap2Event", parsed)
+    }
+    if mmap2_event.Filename != "/system/lib64/libc.so" || mmap2_event.Addr != 0x9000 {
+        t.Fatalf("mmap2 = %s 0x%x", mmap2_event.Filename, mmap2_event.Addr)
+    }
+    if ts, ok := mmap2_event.SampleTime(); !ok || ts != 300 {
+        t.Fatalf("sample time = %d %v, want 300 true", ts, ok)
+    }
+    for lib, addr := range map[string]uint64{"liba.so": 0x1000, "libb.so": 0x5000, "libc.so": 0x9000} {
+        info, err := event.FindLibInMaps(pid, lib)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if info.BaseAddr != addr {
+            t.Fatalf("%s base = 0x%x, want 0x%x", lib, info.BaseAddr, addr)
+        }
+    }
+}
+
+func TestTraceWriterError(t *testing.T) {
+    writer, err := NewTraceWriter(filepath.Join(t.TempDir(), "tmp.trace"), config.NewModuleConfig())
+    if err != nil {
+        t.Fatal(err)
+    }
+    // 模拟磁盘写满 缓冲区写满之后的写入会失败
+    writer.f.Close()
+    rec := &perf.Record{RecordType: unix.PERF_RECORD_SAMPLE, RawSample: make([]byte, 2*1024*1024)}
+    if err = writer.WriteRecord(&perf.ExtraPerfOptions{}, rec); err == nil {
+        t.Fatal("write to closed file should fail")
+    }
+    if err = writer.Close(); err == nil {
+        t.Fatal("close should report the write error")
+    }
+}
+
+// 排序在解析之前进行 原始记录中的时间要能直接取出
+func TestRecordTs(t *testing.T) {
+    fork_raw := make([]byte, 40)
+    binary.LittleEndian.PutUint64(fork_raw[16:], 700)
+    sample_raw := make([]byte, 32)
+    binary.LittleEndian.PutUint64(sample_raw[4:], 800)
+    tests := []struct {
+        record_type uint32
+        raw         []byte
+        want        uint64
+    }{
+        {unix.PERF_RECORD_MMAP2, newMmap2Raw(1, 0x9000, "/system/lib64/libc.so", 600), 600},
+        {unix.PERF_RECORD_FORK, fork_raw, 700},
+        {unix.PERF_RECORD_EXIT, fork_raw, 700},
+        {unix.PERF_RECORD_SAMPLE, sample_raw, 800},
+        {unix.PERF_RECORD_SAMPLE, sample_raw[:8], 0},
+    }
+    for _, tt := range tests {
+        e := &event.CommonEvent{}
+        e.SetRecord(perf.Record{RecordType: tt.record_type, RawSample: tt.raw})
+        if got := e.RecordTs(); got != tt.want {
+            t.Errorf("RecordTs(type %d) = %d, want %d", tt.record_type, got, tt.want)
+        }
+    }
+}
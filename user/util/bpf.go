@@ -2,6 +2,9 @@ package util
 
 import (
     "fmt"
+    "io/ioutil"
+    "strings"
+    "sync"
 
     "golang.org/x/sys/unix"
 )
@@ -82,3 +85,34 @@ func CheckKernelConfig() error {
     }
     return nil
 }
+
+var kallsyms map[string]bool
+var kallsyms_once sync.Once
+
+// 只读取一次 /proc/kallsyms 读取失败时所有符号都视为不存在
+func HasKallsymsSymbol(symbol string) bool {
+    kallsyms_once.Do(func() {
+        kallsyms = make(map[string]bool)
+        content, err := ioutil.ReadFile("/proc/kallsyms")
+        if err != nil {
+            return
+        }
+        for _, line := range strings.Split(string(content), "\n") {
+            // ffffffc0080f2d60 T __arm64_sys_openat
+            fields := strings.Fields(line)
+            if len(fields) >= 3 {
+                kallsyms[fields[2]] = true
+            }
+        }
+    })
+    return kallsyms[symbol]
+}
+
+// bpf_override_return 依赖 CONFIG_BPF_KPROBE_OVERRIDE 读不到内核配置时看 kallsyms 中有没有这个 helper
+func HasKprobeOverride() bool {
+    config, err := GetSystemConfig()
+    if err == nil {
+        return config["CONFIG_BPF_KPROBE_OVERRIDE"] == "y"
+    }
+    return HasKallsymsSymbol("bpf_override_return")
+}
@@ -0,0 +1,608 @@
+package util
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// 寄存器恢复规则
+const (
+	CFI_RULE_SAME = iota
+	CFI_RULE_UNDEFINED
+	// 保存在 CFA+N 处
+	CFI_RULE_OFFSET
+	// 值就是 CFA+N
+	CFI_RULE_VAL_OFFSET
+	// 保存在另一个寄存器中
+	CFI_RULE_REGISTER
+	// DWARF 表达式 暂不支持
+	CFI_RULE_EXPRESSION
+)
+
+// arm64 的 v8-v15 编号为 72-79 超出的寄存器直接忽略
+const CFI_MAX_REG = 128
+
+type CFIRule struct {
+	Type   int
+	Offset int64
+	Reg    uint64
+}
+
+type CFIRow struct {
+	CfaReg    uint64
+	CfaOffset int64
+	// CFA 由表达式给出 无法计算
+	CfaExpr bool
+	RaReg   uint64
+	Regs    [CFI_MAX_REG]CFIRule
+}
+
+type cfiCie struct {
+	code_align uint64
+	data_align int64
+	ra_reg     uint64
+	fde_enc    byte
+	has_aug    bool
+	order      binary.ByteOrder
+	initial    []byte
+}
+
+type cfiFde struct {
+	cie   *cfiCie
+	start uint64
+	end   uint64
+	insts []byte
+}
+
+// 一个库的全部 FDE 按起始地址排序
+type CFITable struct {
+	Path  string
+	fdes  []cfiFde
+	loads []elf.ProgHeader
+}
+
+var cfi_tables = make(map[string]*CFITable)
+var cfi_tables_lock sync.Mutex
+
+// 加载并缓存库的 CFI 同一个库只解析一次 失败同样缓存
+func LoadCFITable(lib_path string) *CFITable {
+	cfi_tables_lock.Lock()
+	defer cfi_tables_lock.Unlock()
+	if table, ok := cfi_tables[lib_path]; ok {
+		return table
+	}
+	table, err := ParseCFITable(lib_path)
+	if err != nil {
+		table = nil
+	}
+	cfi_tables[lib_path] = table
+	return table
+}
+
+// 解析 .eh_frame 和 .debug_frame 两者都存在时都保留 查找时优先 .eh_frame
+func ParseCFITable(lib_path string) (*CFITable, error) {
+	f, err := elf.Open(lib_path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	table := &CFITable{Path: lib_path}
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_LOAD {
+			table.loads = append(table.loads, prog.ProgHeader)
+		}
+	}
+	for _, name := range []string{".eh_frame", ".debug_frame"} {
+		section := f.Section(name)
+		if section == nil || section.Type == elf.SHT_NOBITS {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			continue
+		}
+		parser := cfiParser{order: f.ByteOrder, data: data, addr: section.Addr, is_eh: name == ".eh_frame"}
+		fdes, err := parser.parse()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s of %s failed, err:%v", name, lib_path, err)
+		}
+		table.fdes = append(table.fdes, fdes...)
+	}
+	if len(table.fdes) == 0 {
+		return nil, fmt.Errorf("no CFI found in %s", lib_path)
+	}
+	sort.SliceStable(table.fdes, func(i, j int) bool {
+		return table.fdes[i].start < table.fdes[j].start
+	})
+	return table, nil
+}
+
+// 文件偏移转换为虚拟地址 不在任何 PT_LOAD 段内则原样返回
+func (this *CFITable) FileOffsetToVaddr(offset uint64) uint64 {
+	for _, load := range this.loads {
+		if offset >= load.Off && offset < load.Off+load.Filesz {
+			return offset - load.Off + load.Vaddr
+		}
+	}
+	return offset
+}
+
+func (this *CFITable) findFde(pc uint64) *cfiFde {
+	index := sort.Search(len(this.fdes), func(i int) bool {
+		return this.fdes[i].start > pc
+	})
+	// 可能有重叠的 FDE 往前多看几个即可
+	for i := index - 1; i >= 0 && i >= index-4; i-- {
+		if pc < this.fdes[i].end {
+			return &this.fdes[i]
+		}
+	}
+	return nil
+}
+
+// 计算 pc 处的规则 pc 为库内的虚拟地址
+func (this *CFITable) FindRow(pc uint64) (*CFIRow, error) {
+	fde := this.findFde(pc)
+	if fde == nil {
+		return nil, fmt.Errorf("no FDE for pc:0x%x", pc)
+	}
+	row := &CFIRow{RaReg: fde.cie.ra_reg}
+	vm := cfiVM{cie: fde.cie, row: row, loc: fde.start, pc: pc}
+	if err := vm.execute(fde.cie.initial); err != nil {
+		return nil, err
+	}
+	vm.initial = *row
+	if err := vm.execute(fde.insts); err != nil && err != errCfiStop {
+		return nil, err
+	}
+	return row, nil
+}
+
+var errCfiStop = errors.New("cfi stop")
+
+type cfiReader struct {
+	order binary.ByteOrder
+	data  []byte
+	pos   int
+}
+
+func (this *cfiReader) eof() bool {
+	return this.pos >= len(this.data)
+}
+
+func (this *cfiReader) bytes(n int) []byte {
+	if n < 0 || this.pos+n > len(this.data) {
+		panic(errors.New("cfi data truncated"))
+	}
+	b := this.data[this.pos : this.pos+n]
+	this.pos += n
+	return b
+}
+
+func (this *cfiReader) u8() byte {
+	return this.bytes(1)[0]
+}
+
+func (this *cfiReader) u16() uint16 {
+	return this.order.Uint16(this.bytes(2))
+}
+
+func (this *cfiReader) u32() uint32 {
+	return this.order.Uint32(this.bytes(4))
+}
+
+func (this *cfiReader) u64() uint64 {
+	return this.order.Uint64(this.bytes(8))
+}
+
+func (this *cfiReader) uleb() uint64 {
+	var result uint64
+	var shift uint
+	for {
+		b := this.u8()
+		if shift < 64 {
+			result |= uint64(b&0x7f) << shift
+		}
+		shift += 7
+		if b&0x80 == 0 {
+			return result
+		}
+	}
+}
+
+func (this *cfiReader) sleb() int64 {
+	var result int64
+	var shift uint
+	var b byte
+	for {
+		b = this.u8()
+		if shift < 64 {
+			result |= int64(b&0x7f) << shift
+		}
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if shift < 64 && b&0x40 != 0 {
+		result |= -1 << shift
+	}
+	return result
+}
+
+func (this *cfiReader) cstr() string {
+	index := bytes.IndexByte(this.data[this.pos:], 0)
+	if index < 0 {
+		panic(errors.New("cfi string truncated"))
+	}
+	s := string(this.data[this.pos : this.pos+index])
+	this.pos += index + 1
+	return s
+}
+
+// 按 DW_EH_PE_* 编码读取指针 base 是当前数据在内存中的地址 用于 pcrel
+func (this *cfiReader) pointer(enc byte, base uint64) uint64 {
+	if enc == 0xff {
+		return 0
+	}
+	field_addr := base + uint64(this.pos)
+	var value uint64
+	switch enc & 0x0f {
+	case 0x00:
+		value = this.u64()
+	case 0x01:
+		value = this.uleb()
+	case 0x02:
+		value = uint64(this.u16())
+	case 0x03:
+		value = uint64(this.u32())
+	case 0x04:
+		value = this.u64()
+	case 0x09:
+		value = uint64(this.sleb())
+	case 0x0a:
+		value = uint64(int64(int16(this.u16())))
+	case 0x0b:
+		value = uint64(int64(int32(this.u32())))
+	case 0x0c:
+		value = this.u64()
+	default:
+		panic(fmt.Errorf("unsupported pointer encoding 0x%x", enc))
+	}
+	if enc&0x70 == 0x10 {
+		value += field_addr
+	}
+	return value
+}
+
+type cfiParser struct {
+	order binary.ByteOrder
+	data  []byte
+	addr  uint64
+	is_eh bool
+	cies  map[int]*cfiCie
+}
+
+func (this *cfiParser) parse() (fdes []cfiFde, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	this.cies = make(map[int]*cfiCie)
+	reader := &cfiReader{order: this.order, data: this.data}
+	for !reader.eof() {
+		entry_start := reader.pos
+		length := uint64(reader.u32())
+		if length == 0 {
+			// .eh_frame 以长度为 0 的项结尾
+			if this.is_eh {
+				break
+			}
+			continue
+		}
+		is_64 := length == 0xffffffff
+		if is_64 {
+			length = reader.u64()
+		}
+		id_pos := reader.pos
+		entry_end := reader.pos + int(length)
+		if length > uint64(len(this.data)) || entry_end > len(this.data) {
+			return nil, fmt.Errorf("entry at 0x%x out of range", entry_start)
+		}
+		var id uint64
+		if is_64 {
+			id = reader.u64()
+		} else {
+			id = uint64(reader.u32())
+		}
+		entry := &cfiReader{order: this.order, data: this.data[:entry_end], pos: reader.pos}
+		if this.isCie(id, is_64) {
+			this.cies[entry_start] = this.parseCie(entry)
+		} else {
+			// .eh_frame 中是相对当前位置的偏移 .debug_frame 中是相对节开始的偏移
+			cie_pos := int(id)
+			if this.is_eh {
+				cie_pos = id_pos - int(id)
+			}
+			cie, ok := this.cies[cie_pos]
+			if !ok {
+				cie = this.parseCieAt(cie_pos)
+			}
+			if cie != nil {
+				if fde, ok := this.parseFde(entry, cie); ok {
+					fdes = append(fdes, fde)
+				}
+			}
+		}
+		reader.pos = entry_end
+	}
+	return fdes, nil
+}
+
+func (this *cfiParser) isCie(id uint64, is_64 bool) bool {
+	if this.is_eh {
+		return id == 0
+	}
+	if is_64 {
+		return id == 0xffffffffffffffff
+	}
+	return id == 0xffffffff
+}
+
+// CIE 一般在 FDE 之前 这里处理少见的 CIE 在后的情况
+func (this *cfiParser) parseCieAt(pos int) *cfiCie {
+	if pos < 0 || pos+4 > len(this.data) {
+		return nil
+	}
+	reader := &cfiReader{order: this.order, data: this.data, pos: pos}
+	length := uint64(reader.u32())
+	is_64 := length == 0xffffffff
+	if is_64 {
+		length = reader.u64()
+	}
+	entry_end := reader.pos + int(length)
+	if entry_end > len(this.data) {
+		return nil
+	}
+	var id uint64
+	if is_64 {
+		id = reader.u64()
+	} else {
+		id = uint64(reader.u32())
+	}
+	if !this.isCie(id, is_64) {
+		return nil
+	}
+	cie := this.parseCie(&cfiReader{order: this.order, data: this.data[:entry_end], pos: reader.pos})
+	this.cies[pos] = cie
+	return cie
+}
+
+func (this *cfiParser) parseCie(reader *cfiReader) *cfiCie {
+	cie := &cfiCie{fde_enc: 0x00, order: this.order}
+	version := reader.u8()
+	augmentation := reader.cstr()
+	if augmentation == "eh" {
+		reader.u64()
+	}
+	if version >= 4 {
+		// address_size segment_size
+		reader.u8()
+		reader.u8()
+	}
+	cie.code_align = reader.uleb()
+	cie.data_align = reader.sleb()
+	if version == 1 {
+		cie.ra_reg = uint64(reader.u8())
+	} else {
+		cie.ra_reg = reader.uleb()
+	}
+	if len(augmentation) > 0 && augmentation[0] == 'z' {
+		cie.has_aug = true
+		aug_len := int(reader.uleb())
+		aug_end := reader.pos + aug_len
+		for _, c := range augmentation[1:] {
+			switch c {
+			case 'R':
+				cie.fde_enc = reader.u8()
+			case 'P':
+				enc := reader.u8()
+				reader.pointer(enc&0x7f, this.addr)
+			case 'L':
+				reader.u8()
+			}
+			// S B G 等没有额外数据
+		}
+		reader.pos = aug_end
+	}
+	cie.initial = reader.data[reader.pos:]
+	return cie
+}
+
+func (this *cfiParser) parseFde(reader *cfiReader, cie *cfiCie) (fde cfiFde, ok bool) {
+	fde.cie = cie
+	fde.start = reader.pointer(cie.fde_enc, this.addr)
+	fde.end = fde.start + reader.pointer(cie.fde_enc&0x0f, this.addr)
+	// CIE 使用 z 增强时 FDE 同样带有增强数据
+	if cie.has_aug {
+		aug_len := int(reader.uleb())
+		reader.pos += aug_len
+	}
+	if reader.pos > len(reader.data) {
+		return fde, false
+	}
+	fde.insts = reader.data[reader.pos:]
+	// 被丢弃的函数 起始地址为 0
+	return fde, fde.start != 0 && fde.end > fde.start
+}
+
+type cfiVM struct {
+	cie     *cfiCie
+	row     *CFIRow
+	initial CFIRow
+	stack   []CFIRow
+	loc     uint64
+	pc      uint64
+}
+
+func (this *cfiVM) setRule(reg uint64, rule CFIRule) {
+	if reg < CFI_MAX_REG {
+		this.row.Regs[reg] = rule
+	}
+}
+
+func (this *cfiVM) restoreRule(reg uint64) {
+	if reg < CFI_MAX_REG {
+		this.row.Regs[reg] = this.initial.Regs[reg]
+	}
+}
+
+// loc 前进后超过 pc 说明当前行已经是 pc 处的规则 返回 errCfiStop
+func (this *cfiVM) advance(delta uint64) error {
+	this.loc += delta * this.cie.code_align
+	if this.loc > this.pc {
+		return errCfiStop
+	}
+	return nil
+}
+
+func (this *cfiVM) execute(insts []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	reader := &cfiReader{order: this.cie.order, data: insts}
+	data_align := this.cie.data_align
+	for !reader.eof() {
+		op := reader.u8()
+		switch op & 0xc0 {
+		case 0x40:
+			// DW_CFA_advance_loc
+			if err := this.advance(uint64(op & 0x3f)); err != nil {
+				return err
+			}
+			continue
+		case 0x80:
+			// DW_CFA_offset
+			this.setRule(uint64(op&0x3f), CFIRule{Type: CFI_RULE_OFFSET, Offset: int64(reader.uleb()) * data_align})
+			continue
+		case 0xc0:
+			// DW_CFA_restore
+			this.restoreRule(uint64(op & 0x3f))
+			continue
+		}
+		switch op {
+		case 0x00:
+			// DW_CFA_nop
+		case 0x01:
+			// DW_CFA_set_loc
+			this.loc = reader.pointer(this.cie.fde_enc&0x0f, 0)
+			if this.loc > this.pc {
+				return errCfiStop
+			}
+		case 0x02:
+			if err := this.advance(uint64(reader.u8())); err != nil {
+				return err
+			}
+		case 0x03:
+			if err := this.advance(uint64(reader.u16())); err != nil {
+				return err
+			}
+		case 0x04:
+			if err := this.advance(uint64(reader.u32())); err != nil {
+				return err
+			}
+		case 0x05:
+			// DW_CFA_offset_extended
+			reg := reader.uleb()
+			this.setRule(reg, CFIRule{Type: CFI_RULE_OFFSET, Offset: int64(reader.uleb()) * data_align})
+		case 0x06:
+			// DW_CFA_restore_extended
+			this.restoreRule(reader.uleb())
+		case 0x07:
+			// DW_CFA_undefined
+			this.setRule(reader.uleb(), CFIRule{Type: CFI_RULE_UNDEFINED})
+		case 0x08:
+			// DW_CFA_same_value
+			this.setRule(reader.uleb(), CFIRule{Type: CFI_RULE_SAME})
+		case 0x09:
+			// DW_CFA_register
+			reg := reader.uleb()
+			this.setRule(reg, CFIRule{Type: CFI_RULE_REGISTER, Reg: reader.uleb()})
+		case 0x0a:
+			// DW_CFA_remember_state
+			this.stack = append(this.stack, *this.row)
+		case 0x0b:
+			// DW_CFA_restore_state
+			if len(this.stack) == 0 {
+				return errors.New("restore_state without remember_state")
+			}
+			state := this.stack[len(this.stack)-1]
+			this.stack = this.stack[:len(this.stack)-1]
+			this.row.Regs = state.Regs
+			this.row.CfaReg = state.CfaReg
+			this.row.CfaOffset = state.CfaOffset
+			this.row.CfaExpr = state.CfaExpr
+		case 0x0c:
+			// DW_CFA_def_cfa
+			this.row.CfaReg = reader.uleb()
+			this.row.CfaOffset = int64(reader.uleb())
+			this.row.CfaExpr = false
+		case 0x0d:
+			// DW_CFA_def_cfa_register
+			this.row.CfaReg = reader.uleb()
+			this.row.CfaExpr = false
+		case 0x0e:
+			// DW_CFA_def_cfa_offset
+			this.row.CfaOffset = int64(reader.uleb())
+		case 0x0f:
+			// DW_CFA_def_cfa_expression
+			reader.bytes(int(reader.uleb()))
+			this.row.CfaExpr = true
+		case 0x10, 0x16:
+			// DW_CFA_expression DW_CFA_val_expression
+			reg := reader.uleb()
+			reader.bytes(int(reader.uleb()))
+			this.setRule(reg, CFIRule{Type: CFI_RULE_EXPRESSION})
+		case 0x11:
+			// DW_CFA_offset_extended_sf
+			reg := reader.uleb()
+			this.setRule(reg, CFIRule{Type: CFI_RULE_OFFSET, Offset: reader.sleb() * data_align})
+		case 0x12:
+			// DW_CFA_def_cfa_sf
+			this.row.CfaReg = reader.uleb()
+			this.row.CfaOffset = reader.sleb() * data_align
+			this.row.CfaExpr = false
+		case 0x13:
+			// DW_CFA_def_cfa_offset_sf
+			this.row.CfaOffset = reader.sleb() * data_align
+		case 0x14:
+			// DW_CFA_val_offset
+			reg := reader.uleb()
+			this.setRule(reg, CFIRule{Type: CFI_RULE_VAL_OFFSET, Offset: int64(reader.uleb()) * data_align})
+		case 0x15:
+			// DW_CFA_val_offset_sf
+			reg := reader.uleb()
+			this.setRule(reg, CFIRule{Type: CFI_RULE_VAL_OFFSET, Offset: reader.sleb() * data_align})
+		case 0x2d:
+			// DW_CFA_AARCH64_negate_ra_state 返回地址带有 PAC 签名 回溯时统一去掉高位即可
+		case 0x2e:
+			// DW_CFA_GNU_args_size
+			reader.uleb()
+		case 0x2f:
+			// DW_CFA_GNU_negative_offset_extended
+			reg := reader.uleb()
+			this.setRule(reg, CFIRule{Type: CFI_RULE_OFFSET, Offset: -int64(reader.uleb()) * data_align})
+		default:
+			return fmt.Errorf("unsupported CFA op 0x%x", op)
+		}
+	}
+	return nil
+}
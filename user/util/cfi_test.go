@@ -0,0 +1,91 @@
+package util
+
+import (
+	"testing"
+)
+
+// x86_64 的 DWARF 寄存器编号
+const (
+	dwarfRbx = 3
+	dwarfRbp = 6
+	dwarfRsp = 7
+	dwarfR12 = 12
+	dwarfRa  = 16
+)
+
+func TestFindRow(t *testing.T) {
+	table, err := ParseCFITable("testdata/unwind_x86_64.elf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 期望值来自 readelf --debug-dump=frames-interp
+	tests := []struct {
+		name       string
+		pc         uint64
+		cfa_offset int64
+		saved      map[uint64]int64
+	}{
+		{"inner entry", 0x401240, 8, map[uint64]int64{dwarfRa: -8}},
+		{"inner after push rbx", 0x401241, 16, map[uint64]int64{dwarfRbx: -16, dwarfRa: -8}},
+		{"inner body", 0x401260, 176, map[uint64]int64{dwarfRbx: -16, dwarfRa: -8}},
+		{"inner epilogue", 0x4012a2, 8, map[uint64]int64{dwarfRbx: -16, dwarfRa: -8}},
+		{"dump body", 0x401192, 48, map[uint64]int64{dwarfRbx: -32, dwarfRbp: -24, dwarfR12: -16, dwarfRa: -8}},
+		{"middle body", 0x4012c0, 80, map[uint64]int64{dwarfRa: -8}},
+		{"outer body", 0x4012d8, 16, map[uint64]int64{dwarfRa: -8}},
+	}
+	for _, tt := range tests {
+		row, err := table.FindRow(tt.pc)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if row.CfaExpr || row.CfaReg != dwarfRsp || row.CfaOffset != tt.cfa_offset {
+			t.Errorf("%s: cfa reg:%d offset:%d expr:%v, want rsp+%d", tt.name, row.CfaReg, row.CfaOffset, row.CfaExpr, tt.cfa_offset)
+		}
+		if row.RaReg != dwarfRa {
+			t.Errorf("%s: ra reg %d", tt.name, row.RaReg)
+		}
+		for reg := uint64(0); reg <= dwarfRa; reg++ {
+			offset, ok := tt.saved[reg]
+			rule := row.Regs[reg]
+			if !ok {
+				if rule.Type == CFI_RULE_OFFSET {
+					t.Errorf("%s: reg %d unexpectedly saved at c%+d", tt.name, reg, rule.Offset)
+				}
+				continue
+			}
+			if rule.Type != CFI_RULE_OFFSET || rule.Offset != offset {
+				t.Errorf("%s: reg %d rule %+v, want c%+d", tt.name, reg, rule, offset)
+			}
+		}
+	}
+}
+
+func TestFindRowUnsupported(t *testing.T) {
+	table, err := ParseCFITable("testdata/unwind_x86_64.elf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// .plt 的 CFA 是表达式
+	row, err := table.FindRow(0x401030)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !row.CfaExpr {
+		t.Errorf("plt row should use cfa expression, got %+v", row.CfaReg)
+	}
+	// _init 没有 FDE
+	if _, err := table.FindRow(0x401000); err == nil {
+		t.Errorf("expected no FDE for 0x401000")
+	}
+}
+
+func TestFileOffsetToVaddr(t *testing.T) {
+	table, err := ParseCFITable("testdata/unwind_x86_64.elf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// -no-pie 代码段 offset 0x1000 映射到 0x401000
+	if vaddr := table.FileOffsetToVaddr(0x1240); vaddr != 0x401240 {
+		t.Errorf("FileOffsetToVaddr(0x1240) = 0x%x", vaddr)
+	}
+}
@@ -0,0 +1,748 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Itanium C++ ABI 符号还原 覆盖常见的函数 类型 模板以及 lambda
+// 表达式之类的少见写法不支持 失败时返回原始符号
+// 函数模板的返回值不输出 便于在堆栈中阅读
+
+type demangleError struct{}
+
+type dtype struct {
+	s string
+	// 函数类型单独记录 用于输出函数指针
+	is_func bool
+	ret     string
+	params  string
+	// 数组同理 用于输出数组的指针和引用
+	is_array bool
+	elem     string
+	dims     string
+	// 模板参数包 Dp 展开时逐个输出
+	pack []string
+}
+
+type demangler struct {
+	s    string
+	pos  int
+	subs []dtype
+	tmpl []dtype
+	// 最近一个 source-name 用于构造和析构函数
+	last_name string
+	// nested-name 中的 cv 修饰 作用于成员函数
+	method_cv string
+	// 大于 0 表示正在解析类型 此时的模板参数不影响 T_
+	in_type int
+	// 正在展开 Dp 时 参数包取第 pack_index 个元素 pack_len 为参数包的长度
+	expanding  bool
+	pack_index int
+	pack_len   int
+}
+
+func Demangle(name string) string {
+	if !strings.HasPrefix(name, "_Z") {
+		return name
+	}
+	result, ok := demangle(name)
+	if !ok {
+		return name
+	}
+	return result
+}
+
+func demangle(name string) (result string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, is_err := r.(demangleError); !is_err {
+				panic(r)
+			}
+			result = ""
+			ok = false
+		}
+	}()
+	// 编译器生成的 .cold .constprop.0 之类的后缀
+	suffix := ""
+	if index := strings.Index(name, "."); index > 0 {
+		suffix = name[index:]
+		name = name[:index]
+	}
+	d := &demangler{s: name, pos: 2}
+	result = d.encoding(true)
+	if d.pos != len(d.s) {
+		return "", false
+	}
+	if suffix != "" {
+		result += " [clone " + suffix + "]"
+	}
+	return result, true
+}
+
+func (this *demangler) fail() {
+	panic(demangleError{})
+}
+
+func (this *demangler) peek() byte {
+	if this.pos >= len(this.s) {
+		return 0
+	}
+	return this.s[this.pos]
+}
+
+func (this *demangler) peekAt(offset int) byte {
+	if this.pos+offset >= len(this.s) {
+		return 0
+	}
+	return this.s[this.pos+offset]
+}
+
+func (this *demangler) consume(prefix string) bool {
+	if strings.HasPrefix(this.s[this.pos:], prefix) {
+		this.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (this *demangler) expect(prefix string) {
+	if !this.consume(prefix) {
+		this.fail()
+	}
+}
+
+func (this *demangler) number() int {
+	start := this.pos
+	if this.peek() == 'n' {
+		this.pos++
+	}
+	for this.peek() >= '0' && this.peek() <= '9' {
+		this.pos++
+	}
+	value, err := strconv.Atoi(strings.Replace(this.s[start:this.pos], "n", "-", 1))
+	if err != nil {
+		this.fail()
+	}
+	return value
+}
+
+// <seq-id> 是 36 进制 S_ 为 0 S0_ 为 1
+func (this *demangler) seqId(end byte) int {
+	if this.peek() == end {
+		this.pos++
+		return 0
+	}
+	value := 0
+	for {
+		c := this.peek()
+		this.pos++
+		switch {
+		case c >= '0' && c <= '9':
+			value = value*36 + int(c-'0')
+		case c >= 'A' && c <= 'Z':
+			value = value*36 + int(c-'A') + 10
+		case c == end:
+			return value + 1
+		default:
+			this.fail()
+		}
+	}
+}
+
+func (this *demangler) addSub(t dtype) {
+	this.subs = append(this.subs, t)
+}
+
+func (this *demangler) encoding(top bool) string {
+	if this.peek() == 'T' || this.peek() == 'G' {
+		return this.specialName()
+	}
+	this.method_cv = ""
+	name, has_tmpl, is_cdtor := this.name()
+	if this.pos >= len(this.s) || this.peek() == 'E' {
+		// 全局变量之类的 没有参数
+		return name
+	}
+	cv := this.method_cv
+	if has_tmpl && !is_cdtor {
+		// 函数模板先编码返回值
+		this.typ()
+	}
+	return name + this.bareFunctionType() + cv
+}
+
+func (this *demangler) specialName() string {
+	switch {
+	case this.consume("TV"):
+		return "vtable for " + this.typ().s
+	case this.consume("TT"):
+		return "VTT for " + this.typ().s
+	case this.consume("TI"):
+		return "typeinfo for " + this.typ().s
+	case this.consume("TS"):
+		return "typeinfo name for " + this.typ().s
+	case this.consume("Th"):
+		this.number()
+		this.expect("_")
+		return "non-virtual thunk to " + this.encoding(false)
+	case this.consume("Tv"):
+		this.number()
+		this.expect("_")
+		this.number()
+		this.expect("_")
+		return "virtual thunk to " + this.encoding(false)
+	case this.consume("GTt"):
+		return "transaction clone for " + this.encoding(false)
+	case this.consume("GV"):
+		name, _, _ := this.name()
+		return "guard variable for " + name
+	}
+	this.fail()
+	return ""
+}
+
+func (this *demangler) bareFunctionType() string {
+	var params []string
+	for this.pos < len(this.s) && this.peek() != 'E' && this.peek() != '.' {
+		params = append(params, this.typ().s)
+	}
+	if len(params) == 1 && params[0] == "void" {
+		params = nil
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// 返回名字 是否带模板参数 是否为构造或析构函数
+func (this *demangler) name() (string, bool, bool) {
+	switch this.peek() {
+	case 'N':
+		return this.nestedName()
+	case 'Z':
+		return this.localName(), false, false
+	case 'S':
+		if this.peekAt(1) != 't' {
+			sub := this.substitution()
+			if this.peek() != 'I' {
+				this.fail()
+			}
+			args := this.templateArgs()
+			return sub.s + args, true, false
+		}
+	}
+	prefix := ""
+	if this.consume("St") {
+		prefix = "std::"
+	}
+	uname, is_cdtor := this.unqualifiedName()
+	uname = prefix + uname
+	if this.peek() == 'I' {
+		this.addSub(dtype{s: uname})
+		return withArgs(uname, this.templateArgs()), true, is_cdtor
+	}
+	return uname, false, is_cdtor
+}
+
+func (this *demangler) nestedName() (string, bool, bool) {
+	this.expect("N")
+	cv := this.cvQualifiers()
+	if this.consume("R") {
+		cv += " &"
+	} else if this.consume("O") {
+		cv += " &&"
+	}
+	if this.in_type == 0 {
+		this.method_cv = cv
+	}
+	var parts string
+	has_tmpl := false
+	is_cdtor := false
+	for this.peek() != 'E' {
+		var part string
+		switch {
+		case this.peek() == 'S' && this.peekAt(1) != 't':
+			part = this.substitution().s
+			parts = part
+			has_tmpl = false
+			is_cdtor = false
+			continue
+		case this.peek() == 'I':
+			if parts == "" {
+				this.fail()
+			}
+			parts = withArgs(parts, this.templateArgs())
+			has_tmpl = true
+		case this.peek() == 'T':
+			parts = this.templateParam().s
+			has_tmpl = false
+		default:
+			if this.consume("St") {
+				part = "std::"
+			}
+			uname, cdtor := this.unqualifiedName()
+			part += uname
+			if parts != "" {
+				parts += "::"
+			}
+			parts += part
+			has_tmpl = false
+			is_cdtor = cdtor
+		}
+		if this.peek() != 'E' {
+			this.addSub(dtype{s: parts})
+		}
+	}
+	this.pos++
+	return parts, has_tmpl, is_cdtor
+}
+
+func (this *demangler) localName() string {
+	this.expect("Z")
+	saved_cv := this.method_cv
+	encoding := this.encoding(false)
+	this.method_cv = saved_cv
+	this.expect("E")
+	if this.consume("s") {
+		this.discriminator()
+		return encoding + "::string literal"
+	}
+	name, _, _ := this.name()
+	this.discriminator()
+	return encoding + "::" + name
+}
+
+func (this *demangler) discriminator() {
+	if this.consume("__") {
+		this.number()
+		this.expect("_")
+	} else if this.consume("_") {
+		this.number()
+	}
+}
+
+func (this *demangler) unqualifiedName() (string, bool) {
+	c := this.peek()
+	var name string
+	is_cdtor := false
+	switch {
+	case c >= '0' && c <= '9':
+		name = this.sourceName()
+		this.last_name = name
+	case c == 'C' && this.peekAt(1) != 'v':
+		this.pos++
+		this.consume("I")
+		if this.peek() < '1' || this.peek() > '5' {
+			this.fail()
+		}
+		this.pos++
+		if this.s[this.pos-2] == 'I' {
+			this.typ()
+		}
+		name = this.last_name
+		is_cdtor = true
+	case c == 'D' && this.peekAt(1) >= '0' && this.peekAt(1) <= '5':
+		this.pos += 2
+		name = "~" + this.last_name
+		is_cdtor = true
+	case c == 'U' && this.peekAt(1) == 't':
+		this.pos += 2
+		index := 1
+		if this.peek() != '_' {
+			index = this.number() + 2
+		}
+		this.expect("_")
+		name = fmt.Sprintf("{unnamed type#%d}", index)
+	case c == 'U' && this.peekAt(1) == 'l':
+		this.pos += 2
+		params := this.bareFunctionType()
+		this.expect("E")
+		index := 1
+		if this.peek() != '_' {
+			index = this.number() + 2
+		}
+		this.expect("_")
+		name = fmt.Sprintf("{lambda%s#%d}", params, index)
+	case c == 'L':
+		// 内部链接的名字
+		this.pos++
+		return this.unqualifiedName()
+	default:
+		name = this.operatorName()
+	}
+	for this.consume("B") {
+		name += "[abi:" + this.sourceName() + "]"
+	}
+	return name, is_cdtor
+}
+
+func (this *demangler) sourceName() string {
+	length := this.number()
+	if length <= 0 || this.pos+length > len(this.s) {
+		this.fail()
+	}
+	name := this.s[this.pos : this.pos+length]
+	this.pos += length
+	if strings.HasPrefix(name, "_GLOBAL__N") {
+		return "(anonymous namespace)"
+	}
+	return name
+}
+
+var demangle_operators = map[string]string{
+	"nw": "new", "na": "new[]", "dl": "delete", "da": "delete[]",
+	"ps": "+", "ng": "-", "ad": "&", "de": "*", "co": "~",
+	"pl": "+", "mi": "-", "ml": "*", "dv": "/", "rm": "%",
+	"an": "&", "or": "|", "eo": "^", "aS": "=",
+	"pL": "+=", "mI": "-=", "mL": "*=", "dV": "/=", "rM": "%=",
+	"aN": "&=", "oR": "|=", "eO": "^=",
+	"ls": "<<", "rs": ">>", "lS": "<<=", "rS": ">>=",
+	"eq": "==", "ne": "!=", "lt": "<", "gt": ">", "le": "<=", "ge": ">=", "ss": "<=>",
+	"nt": "!", "aa": "&&", "oo": "||", "pp": "++", "mm": "--",
+	"cm": ",", "pm": "->*", "pt": "->", "cl": "()", "ix": "[]", "qu": "?",
+}
+
+func (this *demangler) operatorName() string {
+	if this.pos+2 > len(this.s) {
+		this.fail()
+	}
+	code := this.s[this.pos : this.pos+2]
+	this.pos += 2
+	if op, ok := demangle_operators[code]; ok {
+		if op[0] >= 'a' && op[0] <= 'z' {
+			return "operator " + op
+		}
+		return "operator" + op
+	}
+	switch code {
+	case "cv":
+		return "operator " + this.typ().s
+	case "li":
+		return "operator\"\" " + this.sourceName()
+	}
+	this.fail()
+	return ""
+}
+
+// 符号中的顺序是 rVK 输出时与 c++filt 一致为 const volatile restrict
+func (this *demangler) cvQualifiers() string {
+	restrict := this.consume("r")
+	volatile := this.consume("V")
+	cv := ""
+	if this.consume("K") {
+		cv += " const"
+	}
+	if volatile {
+		cv += " volatile"
+	}
+	if restrict {
+		cv += " restrict"
+	}
+	return cv
+}
+
+var demangle_std_subs = map[byte][2]string{
+	'a': {"std::allocator", "allocator"},
+	'b': {"std::basic_string", "basic_string"},
+	's': {"std::string", "basic_string"},
+	'i': {"std::istream", "basic_istream"},
+	'o': {"std::ostream", "basic_ostream"},
+	'd': {"std::iostream", "basic_iostream"},
+}
+
+func (this *demangler) substitution() dtype {
+	this.expect("S")
+	if sub, ok := demangle_std_subs[this.peek()]; ok {
+		this.pos++
+		this.last_name = sub[1]
+		return dtype{s: sub[0]}
+	}
+	index := this.seqId('_')
+	if index >= len(this.subs) {
+		this.fail()
+	}
+	sub := this.subs[index]
+	this.last_name = baseName(sub.s)
+	return sub
+}
+
+// operator< 之类的名字后面跟模板参数时需要空格隔开
+func withArgs(name string, args string) string {
+	if strings.HasSuffix(name, "<") {
+		return name + " " + args
+	}
+	return name + args
+}
+
+// 去掉模板参数以及作用域 用于构造和析构函数的名字
+func baseName(name string) string {
+	if strings.HasSuffix(name, ">") {
+		depth := 0
+		for i := len(name) - 1; i >= 0; i-- {
+			if name[i] == '>' {
+				depth++
+			} else if name[i] == '<' {
+				depth--
+				if depth == 0 {
+					name = name[:i]
+					break
+				}
+			}
+		}
+	}
+	if index := strings.LastIndex(name, "::"); index >= 0 {
+		name = name[index+2:]
+	}
+	return name
+}
+
+func (this *demangler) templateParam() dtype {
+	this.expect("T")
+	index := this.seqId('_')
+	if index >= len(this.tmpl) {
+		this.fail()
+	}
+	t := this.tmpl[index]
+	if t.pack == nil {
+		return t
+	}
+	if !this.expanding {
+		return dtype{s: t.s}
+	}
+	this.pack_len = len(t.pack)
+	if this.pack_index >= len(t.pack) {
+		return dtype{}
+	}
+	return dtype{s: t.pack[this.pack_index]}
+}
+
+// Dp 之后的类型按参数包的每个元素各输出一次 如 DpRT_ 为 int&, double&
+func (this *demangler) packExpansion() string {
+	expanding, pack_index, pack_len := this.expanding, this.pack_index, this.pack_len
+	defer func() {
+		this.expanding, this.pack_index, this.pack_len = expanding, pack_index, pack_len
+	}()
+	this.expanding = true
+	this.pack_index = 0
+	this.pack_len = -1
+	start := this.pos
+	first := this.typ().s
+	if this.pack_len < 0 {
+		// 不是模板参数包
+		return first + "..."
+	}
+	if this.pack_len == 0 {
+		return ""
+	}
+	end := this.pos
+	subs_len := len(this.subs)
+	names := []string{first}
+	for this.pack_index = 1; this.pack_index < this.pack_len; this.pack_index++ {
+		this.pos = start
+		names = append(names, this.typ().s)
+		// 替换表只记录第一次解析的结果
+		this.subs = this.subs[:subs_len]
+	}
+	this.pos = end
+	return strings.Join(names, ", ")
+}
+
+func (this *demangler) templateArgs() string {
+	this.expect("I")
+	last_name := this.last_name
+	var args []dtype
+	for !this.consume("E") {
+		args = append(args, this.templateArg())
+	}
+	this.last_name = last_name
+	if this.in_type == 0 {
+		this.tmpl = args
+	}
+	var names []string
+	for _, arg := range args {
+		names = append(names, arg.s)
+	}
+	s := "<" + strings.Join(names, ", ")
+	if strings.HasSuffix(s, ">") {
+		s += " "
+	}
+	return s + ">"
+}
+
+func (this *demangler) templateArg() dtype {
+	switch this.peek() {
+	case 'L':
+		return dtype{s: this.literal()}
+	case 'J':
+		this.pos++
+		names := []string{}
+		for !this.consume("E") {
+			names = append(names, this.templateArg().s)
+		}
+		return dtype{s: strings.Join(names, ", "), pack: names}
+	case 'X':
+		// 表达式不支持
+		this.fail()
+	}
+	return this.typ()
+}
+
+func (this *demangler) literal() string {
+	this.expect("L")
+	if this.consume("_Z") {
+		name := this.encoding(false)
+		this.expect("E")
+		return name
+	}
+	t := this.typ()
+	value := this.s[this.pos:]
+	end := strings.IndexByte(value, 'E')
+	if end < 0 {
+		this.fail()
+	}
+	value = value[:end]
+	this.pos += end + 1
+	if strings.HasPrefix(value, "n") {
+		value = "-" + value[1:]
+	}
+	switch t.s {
+	case "bool":
+		if value == "0" {
+			return "false"
+		}
+		return "true"
+	case "int":
+		return value
+	case "unsigned int":
+		return value + "u"
+	case "long":
+		return value + "l"
+	case "unsigned long":
+		return value + "ul"
+	}
+	return "(" + t.s + ")" + value
+}
+
+var demangle_builtins = map[byte]string{
+	'v': "void", 'w': "wchar_t", 'b': "bool", 'c': "char", 'a': "signed char",
+	'h': "unsigned char", 's': "short", 't': "unsigned short", 'i': "int",
+	'j': "unsigned int", 'l': "long", 'm': "unsigned long", 'x': "long long",
+	'y': "unsigned long long", 'n': "__int128", 'o': "unsigned __int128",
+	'f': "float", 'd': "double", 'e': "long double", 'g': "__float128", 'z': "...",
+}
+
+var demangle_d_builtins = map[byte]string{
+	'd': "decimal64", 'e': "decimal128", 'f': "decimal32", 'h': "half",
+	'i': "char32_t", 's': "char16_t", 'u': "char8_t", 'a': "auto",
+	'c': "decltype(auto)", 'n': "decltype(nullptr)",
+}
+
+func (this *demangler) typ() dtype {
+	this.in_type++
+	defer func() { this.in_type-- }()
+	c := this.peek()
+	if name, ok := demangle_builtins[c]; ok {
+		this.pos++
+		return dtype{s: name}
+	}
+	var t dtype
+	switch c {
+	case 'r', 'V', 'K':
+		cv := this.cvQualifiers()
+		inner := this.typ()
+		if inner.is_func {
+			t = inner
+			t.s = inner.s + cv
+		} else {
+			t = dtype{s: inner.s + cv}
+		}
+	case 'P':
+		this.pos++
+		t = this.pointerTo(this.typ(), "*")
+	case 'R':
+		this.pos++
+		t = this.pointerTo(this.typ(), "&")
+	case 'O':
+		this.pos++
+		t = this.pointerTo(this.typ(), "&&")
+	case 'F':
+		this.pos++
+		this.consume("Y")
+		ret := this.typ()
+		params := this.bareFunctionType()
+		this.consume("R")
+		this.consume("O")
+		this.expect("E")
+		t = dtype{s: ret.s + " " + params, is_func: true, ret: ret.s, params: params}
+	case 'A':
+		this.pos++
+		size := ""
+		if this.peek() != '_' {
+			size = strconv.Itoa(this.number())
+		}
+		this.expect("_")
+		inner := this.typ()
+		dims := "[" + size + "]"
+		if inner.is_array {
+			inner.s = inner.elem
+			dims += inner.dims
+		}
+		t = dtype{s: inner.s + " " + dims, is_array: true, elem: inner.s, dims: dims}
+	case 'M':
+		this.pos++
+		class := this.typ()
+		member := this.typ()
+		if member.is_func {
+			t = dtype{s: member.ret + " (" + class.s + "::*)" + member.params}
+		} else {
+			t = dtype{s: member.s + " " + class.s + "::*"}
+		}
+	case 'T':
+		t = this.templateParam()
+		if this.peek() == 'I' {
+			this.addSub(t)
+			t = dtype{s: t.s + this.templateArgs()}
+		}
+	case 'S':
+		if this.peekAt(1) == 't' {
+			name, _, _ := this.name()
+			t = dtype{s: name}
+			break
+		}
+		t = this.substitution()
+		if this.peek() != 'I' {
+			// 替换本身不再加入替换表
+			return t
+		}
+		t = dtype{s: t.s + this.templateArgs()}
+	case 'D':
+		if name, ok := demangle_d_builtins[this.peekAt(1)]; ok {
+			this.pos += 2
+			return dtype{s: name}
+		}
+		if this.peekAt(1) == 'p' {
+			this.pos += 2
+			t = dtype{s: this.packExpansion()}
+			break
+		}
+		this.fail()
+	case 'u':
+		this.pos++
+		return dtype{s: this.sourceName()}
+	default:
+		name, _, _ := this.name()
+		t = dtype{s: name}
+	}
+	this.addSub(t)
+	return t
+}
+
+func (this *demangler) pointerTo(inner dtype, op string) dtype {
+	if inner.is_func {
+		return dtype{s: inner.ret + " (" + op + ")" + inner.params}
+	}
+	if inner.is_array {
+		return dtype{s: inner.elem + " (" + op + ") " + inner.dims}
+	}
+	return dtype{s: inner.s + op}
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"testing"
+)
+
+// 期望值来自 c++filt 区别在于函数模板不输出返回值 以及 Ss 输出为 std::string
+func TestDemangle(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		// 不是 C++ 符号或者不支持的写法 原样返回
+		{"open", "open"},
+		{"_Z", "_Z"},
+		{"_Z3fooX", "_Z3fooX"},
+		{"_Z1fU8__vectori", "_Z1fU8__vectori"},
+
+		{"_Z3foo", "foo"},
+		{"_Z3foov", "foo()"},
+		{"_Z3fooi", "foo(int)"},
+		{"_Z1fz", "f(...)"},
+		{"_Z1fPKcz", "f(char const*, ...)"},
+		{"_Z1fDn", "f(decltype(nullptr))"},
+		{"_ZN7android6Parcel10writeInt32Ei", "android::Parcel::writeInt32(int)"},
+		{"_ZNK7android6Parcel9readInt32Ev", "android::Parcel::readInt32() const"},
+		{"_ZNVK1A1gEv", "A::g() const volatile"},
+		{"_Z1fPrVKi", "f(int const volatile restrict*)"},
+		{"_ZN1A1hEOS_", "A::h(A&&)"},
+		{"_ZN7android7String8C2EPKDsm", "android::String8::String8(char16_t const*, unsigned long)"},
+		{"_ZN3art9ArtMethod6InvokeEPNS_6ThreadEPjjPNS_6JValueEPKc", "art::ArtMethod::Invoke(art::Thread*, unsigned int*, unsigned int, art::JValue*, char const*)"},
+		{"_ZN3art12_GLOBAL__N_18ResolverD2Ev", "art::(anonymous namespace)::Resolver::~Resolver()"},
+		{"_ZN12_GLOBAL__N_13barEv", "(anonymous namespace)::bar()"},
+		{"_ZN1AD0Ev", "A::~A()"},
+
+		// 运算符
+		{"_ZplRK1AS1_", "operator+(A const&, A const&)"},
+		{"_ZN1AcvbEv", "A::operator bool()"},
+		{"_ZN1AixEi", "A::operator[](int)"},
+		{"_ZdlPv", "operator delete(void*)"},
+		{"_Znwm", "operator new(unsigned long)"},
+
+		// 函数指针 数组 成员指针
+		{"_Z1fPFviE", "f(void (*)(int))"},
+		{"_Z1fA10_i", "f(int [10])"},
+		{"_Z1fRA4_i", "f(int (&) [4])"},
+		{"_Z1fM1AFvvE", "f(void (A::*)())"},
+
+		// 特殊名字以及局部实体
+		{"_ZTV1A", "vtable for A"},
+		{"_ZTI1A", "typeinfo for A"},
+		{"_ZTS1A", "typeinfo name for A"},
+		{"_ZThn8_N1A1fEv", "non-virtual thunk to A::f()"},
+		{"_ZGVZ4mainE1x", "guard variable for main::x"},
+		{"_ZZ4mainE1x", "main::x"},
+		{"_ZZN1A3fooEvE1s_0", "A::foo()::s"},
+
+		// 标准库以及替换
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_ZNSsC1EPKcRKSaIcE", "std::string::basic_string(char const*, std::allocator<char> const&)"},
+		{"_ZNSt7__cxx1112basic_stringIcSt11char_traitsIcESaIcEEC1EPKcRKS3_", "std::__cxx11::basic_string<char, std::char_traits<char>, std::allocator<char> >::basic_string(char const*, std::allocator<char> const&)"},
+		{"_ZNKSt3__112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEE4findEPKcmm", "std::__1::basic_string<char, std::__1::char_traits<char>, std::__1::allocator<char> >::find(char const*, unsigned long, unsigned long) const"},
+		{"_ZNSt3__16vectorINS_4pairIiiEENS_9allocatorIS2_EEE6resizeEm", "std::__1::vector<std::__1::pair<int, int>, std::__1::allocator<std::__1::pair<int, int> > >::resize(unsigned long)"},
+		{"_ZNSt3__110unique_ptrI1ANS_14default_deleteIS1_EEED2Ev", "std::__1::unique_ptr<A, std::__1::default_delete<A> >::~unique_ptr()"},
+		{"_ZNSt3__18functionIFvRKiEEC2ERKS4_", "std::__1::function<void (int const&)>::function(std::__1::function<void (int const&)> const&)"},
+
+		// 函数模板 返回值不输出
+		{"_Z1fIiEvT_", "f<int>(int)"},
+		{"_Z1fILi3EEvv", "f<3>()"},
+		{"_Z3maxIiET_S0_S0_", "max<int>(int, int)"},
+		{"_ZN1N1fIiEEvT_", "N::f<int>(int)"},
+		{"_ZN4base4BindIFvvEEEvPT_", "base::Bind<void ()>(void (*)())"},
+		{"_ZStlsISt11char_traitsIcEERSt13basic_ostreamIcT_ES5_h", "std::operator<< <std::char_traits<char> >(std::basic_ostream<char, std::char_traits<char> >&, unsigned char)"},
+
+		// 参数包
+		{"_Z5printIJidEEvDpT_", "print<int, double>(int, double)"},
+		{"_Z5printIJidEEvDpRKT_", "print<int, double>(int const&, double const&)"},
+		{"_Z5printIJEEvDpT_", "print<>()"},
+		{"_Z1fIJicEJdEEvDpT_DpT0_", "f<int, char, double>(int, char, double)"},
+
+		// 编译器生成的后缀
+		{"_Z3fooi.cold", "foo(int) [clone .cold]"},
+	}
+	for _, test := range tests {
+		if got := Demangle(test.name); got != test.want {
+			t.Errorf("Demangle(%s)\n got  %s\n want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package util
+
+import (
+	"debug/elf"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type ElfSymbol struct {
+	Name  string
+	Value uint64
+	Size  uint64
+}
+
+// 读取库中已定义的函数符号 合并 .dynsym 和 .symtab 按名字去重
+func ReadFuncSymbols(lib_path string) ([]ElfSymbol, error) {
+	f, err := elf.Open(lib_path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var all_syms []elf.Symbol
+	// 被 strip 的库没有 .symtab 这种情况忽略错误
+	if syms, err := f.DynamicSymbols(); err == nil {
+		all_syms = append(all_syms, syms...)
+	}
+	if syms, err := f.Symbols(); err == nil {
+		all_syms = append(all_syms, syms...)
+	}
+	names := make(map[string]bool)
+	var results []ElfSymbol
+	for _, sym := range all_syms {
+		if elf.ST_TYPE(sym.Info) != elf.STT_FUNC || sym.Section == elf.SHN_UNDEF || sym.Value == 0 {
+			continue
+		}
+		if sym.Name == "" || names[sym.Name] {
+			continue
+		}
+		names[sym.Name] = true
+		results = append(results, ElfSymbol{sym.Name, sym.Value, sym.Size})
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no func symbol found in %s", lib_path)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// 按名字精确查找函数符号
+func FindFuncSymbol(lib_path string, name string) (ElfSymbol, error) {
+	syms, err := ReadFuncSymbols(lib_path)
+	if err != nil {
+		return ElfSymbol{}, err
+	}
+	for _, sym := range syms {
+		if sym.Name == name {
+			return sym, nil
+		}
+	}
+	return ElfSymbol{}, fmt.Errorf("can not find func symbol %s in %s", name, lib_path)
+}
+
+// 以 / 包裹的认为是正则 含有 * 或 ? 的认为是 glob
+func IsSymbolPattern(pattern string) bool {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return true
+	}
+	return strings.ContainsAny(pattern, "*?")
+}
+
+func NewSymbolMatcher(pattern string) (func(string) bool, error) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		// 正则需要完整匹配符号
+		reg, err := regexp.Compile("^(?:" + pattern[1:len(pattern)-1] + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid symbol regex %s, err:%v", pattern, err)
+		}
+		return reg.MatchString, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid symbol glob %s, err:%v", pattern, err)
+	}
+	return func(name string) bool {
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// 返回库中与 pattern 匹配的函数符号 pattern 为空表示全部
+func MatchFuncSymbols(lib_path string, pattern string) ([]ElfSymbol, error) {
+	syms, err := ReadFuncSymbols(lib_path)
+	if err != nil {
+		return nil, err
+	}
+	if pattern == "" {
+		return syms, nil
+	}
+	match, err := NewSymbolMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var results []ElfSymbol
+	for _, sym := range syms {
+		if match(sym.Name) {
+			results = append(results, sym)
+		}
+	}
+	return results, nil
+}
+
+// 用于符号化的符号表 按地址升序
+type SymbolTable struct {
+	Path    string
+	BuildId string
+	syms    []ElfSymbol
+	loads   []elf.ProgHeader
+}
+
+var symbol_tables = make(map[string]*SymbolTable)
+var symbol_tables_by_id = make(map[string]*SymbolTable)
+var symbol_tables_lock sync.Mutex
+
+func readBuildId(f *elf.File) string {
+	section := f.Section(".note.gnu.build-id")
+	if section == nil {
+		return ""
+	}
+	data, err := section.Data()
+	if err != nil || len(data) < 16 {
+		return ""
+	}
+	// namesz descsz type name(GNU\0) desc
+	namesz := f.ByteOrder.Uint32(data[0:4])
+	descsz := f.ByteOrder.Uint32(data[4:8])
+	start := 12 + (namesz+3)&^3
+	if uint64(start)+uint64(descsz) > uint64(len(data)) {
+		return ""
+	}
+	return hex.EncodeToString(data[start : start+descsz])
+}
+
+// 加载并缓存库的符号表 同一个库只解析一次 失败同样缓存
+// 路径不同但 build-id 相同的库 比如 apex 下的重复库 复用同一份符号表
+func LoadSymbolTable(lib_path string) *SymbolTable {
+	symbol_tables_lock.Lock()
+	defer symbol_tables_lock.Unlock()
+	if table, ok := symbol_tables[lib_path]; ok {
+		return table
+	}
+	table := loadSymbolTable(lib_path)
+	symbol_tables[lib_path] = table
+	return table
+}
+
+func loadSymbolTable(lib_path string) *SymbolTable {
+	f, err := elf.Open(lib_path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	build_id := readBuildId(f)
+	if build_id != "" {
+		if table, ok := symbol_tables_by_id[build_id]; ok {
+			return table
+		}
+	}
+	table := &SymbolTable{Path: lib_path, BuildId: build_id}
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_LOAD {
+			table.loads = append(table.loads, prog.ProgHeader)
+		}
+	}
+	var all_syms []elf.Symbol
+	if syms, err := f.DynamicSymbols(); err == nil {
+		all_syms = append(all_syms, syms...)
+	}
+	if syms, err := f.Symbols(); err == nil {
+		all_syms = append(all_syms, syms...)
+	}
+	for _, sym := range all_syms {
+		if elf.ST_TYPE(sym.Info) != elf.STT_FUNC || sym.Section == elf.SHN_UNDEF || sym.Value == 0 {
+			continue
+		}
+		// thumb 函数地址最低位为 1
+		table.syms = append(table.syms, ElfSymbol{sym.Name, sym.Value &^ 1, sym.Size})
+	}
+	sort.SliceStable(table.syms, func(i, j int) bool {
+		return table.syms[i].Value < table.syms[j].Value
+	})
+	if build_id != "" {
+		symbol_tables_by_id[build_id] = table
+	}
+	return table
+}
+
+// 文件偏移转换为虚拟地址 不在任何 PT_LOAD 段内则原样返回
+func (this *SymbolTable) FileOffsetToVaddr(offset uint64) uint64 {
+	for _, load := range this.loads {
+		if offset >= load.Off && offset < load.Off+load.Filesz {
+			return offset - load.Off + load.Vaddr
+		}
+	}
+	return offset
+}
+
+// 查找包含 vaddr 的函数符号 没有 size 的符号只在紧邻时认为命中
+func (this *SymbolTable) Lookup(vaddr uint64) (ElfSymbol, bool) {
+	index := sort.Search(len(this.syms), func(i int) bool {
+		return this.syms[i].Value > vaddr
+	})
+	// 同一地址可能有多个别名 往前多看几个即可
+	for i := index - 1; i >= 0 && i >= index-8; i-- {
+		sym := this.syms[i]
+		if sym.Size == 0 && sym.Value == this.syms[index-1].Value {
+			return sym, true
+		}
+		if vaddr < sym.Value+sym.Size {
+			return sym, true
+		}
+	}
+	return ElfSymbol{}, false
+}
+
+// 返回 Foo::bar(int)+0x24 这样的形式 查找失败返回空字符串
+func (this *SymbolTable) Symbolize(vaddr uint64) string {
+	sym, ok := this.Lookup(vaddr)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s+0x%x", Demangle(sym.Name), vaddr-sym.Value)
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSymbolMatcher(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		is_pattern bool
+		name       string
+		want       bool
+	}{
+		{"open", false, "open", true},
+		{"open*", true, "openat", true},
+		{"open*", true, "fopen", false},
+		{"*open*", true, "fopen64", true},
+		{"read?", true, "readv", true},
+		{"read?", true, "read", false},
+		{"Java_*_init", true, "Java_com_example_Native_init", true},
+		// 正则需要完整匹配
+		{"/open(at)?/", true, "openat", true},
+		{"/open(at)?/", true, "openat2", false},
+		{"/_ZN7android6Parcel.*/", true, "_ZN7android6Parcel10writeInt32Ei", true},
+		{"/open|close/", true, "fclose", false},
+		// 太短的不算正则
+		{"//", false, "//", true},
+	}
+	for _, test := range tests {
+		if got := IsSymbolPattern(test.pattern); got != test.is_pattern {
+			t.Errorf("IsSymbolPattern(%s) = %v, want %v", test.pattern, got, test.is_pattern)
+		}
+		match, err := NewSymbolMatcher(test.pattern)
+		if err != nil {
+			t.Errorf("%s: %v", test.pattern, err)
+			continue
+		}
+		if got := match(test.name); got != test.want {
+			t.Errorf("%s match %s = %v, want %v", test.pattern, test.name, got, test.want)
+		}
+	}
+	for _, pattern := range []string{"/open(/", "open[", "[a-"} {
+		if _, err := NewSymbolMatcher(pattern); err == nil {
+			t.Errorf("%s should be rejected", pattern)
+		}
+	}
+}
+
+func TestMatchFuncSymbols(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"/(inner|middle|outer)/", "inner middle outer"},
+		{"*er", "inner outer"},
+		{"?ain", "main"},
+		{"/mid.*/", "middle"},
+		{"nosuch*", ""},
+	}
+	for _, test := range tests {
+		syms, err := MatchFuncSymbols("testdata/unwind_x86_64.elf", test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, sym := range syms {
+			names = append(names, sym.Name)
+		}
+		sort.Strings(names)
+		if got := strings.Join(names, " "); got != test.want {
+			t.Errorf("%s matched %q, want %q", test.pattern, got, test.want)
+		}
+	}
+	if _, err := MatchFuncSymbols("testdata/unwind_x86_64.elf", "/(/"); err == nil {
+		t.Error("invalid regex should be rejected")
+	}
+}
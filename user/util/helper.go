@@ -147,12 +147,17 @@ func ParseSignal(signal string) (uint32, error) {
 }
 
 func ParseReg(pid uint32, value uint64) (string, error) {
-	info := "UNKNOWN"
 	// 直接读取maps信息 计算value在什么地方 用于定位跳转目的地
 	content, err := ReadMapsByPid(pid)
 	if err != nil {
-		return info, fmt.Errorf("Error when opening file:%v", err)
+		return "UNKNOWN", fmt.Errorf("Error when opening file:%v", err)
 	}
+	return ParseRegByMaps(content, value)
+}
+
+func ParseRegByMaps(content string, value uint64) (string, error) {
+	info := "UNKNOWN"
+	var err error
 	var (
 		seg_start  uint64
 		seg_end    uint64
@@ -0,0 +1,277 @@
+package util
+
+import (
+	"debug/elf"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 容器运行时在 cgroup 路径中使用 64 位十六进制的容器 ID
+// docker: /docker/<id> 或 /system.slice/docker-<id>.scope
+// containerd: cri-containerd-<id>.scope podman: libpod-<id>.scope
+var containerIdRegex = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// 普通 Linux 下 libc 的名字 如 libc.so.6 libc-2.31.so
+var libcRegex = regexp.MustCompile(`^libc(\.so(\.\d+)*|-[\d.]+\.so)$`)
+
+type ProcInfo struct {
+	Pid  uint32
+	Uid  uint32
+	Comm string
+	Exe  string
+	// 每个层级的 cgroup 路径 cgroup v2 下只有一个
+	Cgroups []string
+}
+
+// 直接读取 /proc 获取进程信息 不依赖 ps/id 等命令
+func ReadProcInfo(pid uint32) (*ProcInfo, error) {
+	info := &ProcInfo{Pid: pid}
+	uid, err := ReadProcUid(pid)
+	if err != nil {
+		return nil, err
+	}
+	info.Uid = uid
+	content, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return nil, err
+	}
+	info.Comm = strings.TrimSpace(string(content))
+	// 内核线程没有 exe 忽略错误
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err == nil {
+		info.Exe = strings.TrimSuffix(exe, " (deleted)")
+	}
+	content, err = ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err == nil {
+		// 0::/system.slice/docker-<id>.scope
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			parts := strings.SplitN(line, ":", 3)
+			if len(parts) == 3 {
+				info.Cgroups = append(info.Cgroups, parts[2])
+			}
+		}
+	}
+	return info, nil
+}
+
+func ReadProcUid(pid uint32) (uint32, error) {
+	content, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		// Uid:	10245	10245	10245	10245
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "Uid:" {
+			continue
+		}
+		uid, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		return uint32(uid), nil
+	}
+	return 0, fmt.Errorf("can not find uid of pid=%d", pid)
+}
+
+// 返回属于这些 uid 的进程 pid 到 uid 的映射
+func ListPidsByUids(uids []uint32) map[uint32]uint32 {
+	pids := make(map[uint32]uint32)
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return pids
+	}
+	for _, entry := range entries {
+		pid, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		proc_uid, err := ReadProcUid(uint32(pid))
+		if err != nil {
+			continue
+		}
+		for _, uid := range uids {
+			if proc_uid == uid {
+				pids[uint32(pid)] = uid
+			}
+		}
+	}
+	return pids
+}
+
+// 按可执行文件的 ELF 头判断进程是否为 32 位
+func IsProc32Bit(pid uint32) (bool, error) {
+	f, err := elf.Open(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return f.Class == elf.ELFCLASS32, nil
+}
+
+// 目标进程在其他 mount namespace 中时 maps 里的路径要通过 /proc/<pid>/root 访问
+func ProcRootPath(pid uint32, lib_path string) string {
+	return ProcRootPrefix(pid) + lib_path
+}
+
+// 与本进程在同一个 mount namespace 时返回空字符串
+func ProcRootPrefix(pid uint32) string {
+	self_ns, err1 := os.Readlink("/proc/self/ns/mnt")
+	proc_ns, err2 := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", pid))
+	if err1 != nil || err2 != nil || self_ns == proc_ns {
+		return ""
+	}
+	return fmt.Sprintf("/proc/%d/root", pid)
+}
+
+// 从目标进程自身的 maps 中找到 libc 返回本进程可以访问的路径
+func FindLibcInMaps(pid uint32) (string, error) {
+	content, err := ReadMapsByPid(pid)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(content, "\n") {
+		// 7f2c8e600000-7f2c8e628000 r--p 00000000 08:01 1835101 /usr/lib/x86_64-linux-gnu/libc.so.6
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.HasPrefix(fields[5], "/") {
+			continue
+		}
+		lib_path := fields[5]
+		if libcRegex.MatchString(filepath.Base(lib_path)) {
+			return ProcRootPath(pid, lib_path), nil
+		}
+	}
+	return "", fmt.Errorf("can not find libc in maps of pid=%d", pid)
+}
+
+// 按可执行文件路径 comm 正则 cgroup 路径 容器 ID 选择进程
+// 同一类条件满足任意一个即可 设置了多类条件时需要同时满足
+type TargetSelector struct {
+	Exes       []string
+	Comms      []*regexp.Regexp
+	Cgroups    []string
+	Containers []string
+}
+
+func NewTargetSelector(exes, comms, cgroups, containers []string) (*TargetSelector, error) {
+	selector := &TargetSelector{}
+	selector.Exes = exes
+	for _, comm := range comms {
+		re, err := regexp.Compile(comm)
+		if err != nil {
+			return nil, fmt.Errorf("invalid comm regex %s, err:%v", comm, err)
+		}
+		selector.Comms = append(selector.Comms, re)
+	}
+	for _, cgroup := range cgroups {
+		// 统一为 / 开头 不带结尾的 /
+		cgroup = "/" + strings.Trim(cgroup, "/")
+		selector.Cgroups = append(selector.Cgroups, cgroup)
+	}
+	for _, container := range containers {
+		container = strings.ToLower(container)
+		if len(container) < 4 || len(container) > 64 || strings.Trim(container, "0123456789abcdef") != "" {
+			return nil, fmt.Errorf("invalid container id %s", container)
+		}
+		selector.Containers = append(selector.Containers, container)
+	}
+	return selector, nil
+}
+
+func (this *TargetSelector) IsEmpty() bool {
+	return len(this.Exes) == 0 && len(this.Comms) == 0 && len(this.Cgroups) == 0 && len(this.Containers) == 0
+}
+
+func (this *TargetSelector) matchExe(info *ProcInfo) bool {
+	for _, exe := range this.Exes {
+		// 不含 / 的只比较文件名
+		if info.Exe == exe || (!strings.Contains(exe, "/") && filepath.Base(info.Exe) == exe) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *TargetSelector) matchComm(info *ProcInfo) bool {
+	for _, re := range this.Comms {
+		if re.MatchString(info.Comm) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *TargetSelector) matchCgroup(info *ProcInfo) bool {
+	for _, cgroup := range this.Cgroups {
+		for _, proc_cgroup := range info.Cgroups {
+			// 子 cgroup 中的进程同样算在内
+			if proc_cgroup == cgroup || strings.HasPrefix(proc_cgroup, cgroup+"/") || cgroup == "/" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (this *TargetSelector) matchContainer(info *ProcInfo) bool {
+	for _, proc_cgroup := range info.Cgroups {
+		for _, id := range containerIdRegex.FindAllString(proc_cgroup, -1) {
+			for _, container := range this.Containers {
+				if strings.HasPrefix(id, container) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (this *TargetSelector) Match(info *ProcInfo) bool {
+	if len(this.Exes) > 0 && !this.matchExe(info) {
+		return false
+	}
+	if len(this.Comms) > 0 && !this.matchComm(info) {
+		return false
+	}
+	if len(this.Cgroups) > 0 && !this.matchCgroup(info) {
+		return false
+	}
+	if len(this.Containers) > 0 && !this.matchContainer(info) {
+		return false
+	}
+	return true
+}
+
+// 遍历 /proc 得到满足条件的进程 排除自身和内核线程
+func (this *TargetSelector) Resolve() ([]*ProcInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	self_pid := uint32(os.Getpid())
+	var results []*ProcInfo
+	for _, entry := range entries {
+		value, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		pid := uint32(value)
+		if pid == self_pid {
+			continue
+		}
+		// 进程可能已经退出 忽略错误
+		info, err := ReadProcInfo(pid)
+		if err != nil || info.Exe == "" {
+			continue
+		}
+		if this.Match(info) {
+			results = append(results, info)
+		}
+	}
+	return results, nil
+}
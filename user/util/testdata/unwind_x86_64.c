@@ -0,0 +1,64 @@
+// cfi_test.go 和 event/unwind_test.go 使用的库以及栈样本
+// gcc -O2 -fomit-frame-pointer -no-pie -o unwind_x86_64.elf unwind_x86_64.c
+// ./unwind_x86_64.elf ../../event/testdata/unwind_x86_64.bin
+// 样本格式与 perf 采集的 UnwindBuf 相同 abi regs[20] size data dyn_size
+#include <stdint.h>
+#include <stdio.h>
+#include <string.h>
+
+#define REG_COUNT 20
+#define STACK_SIZE 4096
+
+static const char *out_path;
+
+__attribute__((noinline)) static void dump(uint64_t *regs) {
+    uint64_t abi = 2;
+    uint64_t size = STACK_SIZE;
+    FILE *fp = fopen(out_path, "wb");
+    fwrite(&abi, 8, 1, fp);
+    fwrite(regs, 8, REG_COUNT, fp);
+    fwrite(&size, 8, 1, fp);
+    fwrite((void *)regs[7], 1, STACK_SIZE, fp);
+    fwrite(&size, 8, 1, fp);
+    fclose(fp);
+}
+
+__attribute__((noinline)) int inner(int n) {
+    uint64_t regs[REG_COUNT];
+    memset(regs, 0, sizeof(regs));
+    // 同一条 asm 中取 sp 和 pc 保证两者对应
+    __asm__ volatile(
+        "mov %%rbx, 8(%0)\n"
+        "mov %%rbp, 48(%0)\n"
+        "mov %%rsp, 56(%0)\n"
+        "lea 0(%%rip), %%rax\n"
+        "mov %%rax, 64(%0)\n"
+        "mov %%r12, 128(%0)\n"
+        "mov %%r13, 136(%0)\n"
+        "mov %%r14, 144(%0)\n"
+        "mov %%r15, 152(%0)\n"
+        :
+        : "r"(regs)
+        : "rax", "memory");
+    // 之后的调用只会覆盖 sp 以下的内容 sp 以上保存的返回地址不变
+    dump(regs);
+    return n + (int)regs[0];
+}
+
+__attribute__((noinline)) int middle(int n) {
+    volatile int buf[16];
+    buf[n & 15] = n;
+    return inner(buf[n & 15] + 1) + 1;
+}
+
+__attribute__((noinline)) int outer(int n) {
+    return middle(n * 2) * 3;
+}
+
+int main(int argc, char **argv) {
+    if (argc < 2) {
+        return 1;
+    }
+    out_path = argv[1];
+    return outer(argc) & 1;
+}